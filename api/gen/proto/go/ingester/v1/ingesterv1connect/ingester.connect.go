@@ -50,6 +50,9 @@ const (
 	IngesterServiceSeriesProcedure = "/ingester.v1.IngesterService/Series"
 	// IngesterServiceFlushProcedure is the fully-qualified name of the IngesterService's Flush RPC.
 	IngesterServiceFlushProcedure = "/ingester.v1.IngesterService/Flush"
+	// IngesterServiceIngestedBytesProcedure is the fully-qualified name of the IngesterService's
+	// IngestedBytes RPC.
+	IngesterServiceIngestedBytesProcedure = "/ingester.v1.IngesterService/IngestedBytes"
 	// IngesterServiceMergeProfilesStacktracesProcedure is the fully-qualified name of the
 	// IngesterService's MergeProfilesStacktraces RPC.
 	IngesterServiceMergeProfilesStacktracesProcedure = "/ingester.v1.IngesterService/MergeProfilesStacktraces"
@@ -62,6 +65,9 @@ const (
 	// IngesterServiceMergeSpanProfileProcedure is the fully-qualified name of the IngesterService's
 	// MergeSpanProfile RPC.
 	IngesterServiceMergeSpanProfileProcedure = "/ingester.v1.IngesterService/MergeSpanProfile"
+	// IngesterServiceTransferProcedure is the fully-qualified name of the IngesterService's Transfer
+	// RPC.
+	IngesterServiceTransferProcedure = "/ingester.v1.IngesterService/Transfer"
 )
 
 // IngesterServiceClient is a client for the ingester.v1.IngesterService service.
@@ -72,10 +78,12 @@ type IngesterServiceClient interface {
 	ProfileTypes(context.Context, *connect_go.Request[v12.ProfileTypesRequest]) (*connect_go.Response[v12.ProfileTypesResponse], error)
 	Series(context.Context, *connect_go.Request[v12.SeriesRequest]) (*connect_go.Response[v12.SeriesResponse], error)
 	Flush(context.Context, *connect_go.Request[v12.FlushRequest]) (*connect_go.Response[v12.FlushResponse], error)
+	IngestedBytes(context.Context, *connect_go.Request[v12.IngestedBytesRequest]) (*connect_go.Response[v12.IngestedBytesResponse], error)
 	MergeProfilesStacktraces(context.Context) *connect_go.BidiStreamForClient[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse]
 	MergeProfilesLabels(context.Context) *connect_go.BidiStreamForClient[v12.MergeProfilesLabelsRequest, v12.MergeProfilesLabelsResponse]
 	MergeProfilesPprof(context.Context) *connect_go.BidiStreamForClient[v12.MergeProfilesPprofRequest, v12.MergeProfilesPprofResponse]
 	MergeSpanProfile(context.Context) *connect_go.BidiStreamForClient[v12.MergeSpanProfileRequest, v12.MergeSpanProfileResponse]
+	Transfer(context.Context) *connect_go.ClientStreamForClient[v12.TransferRequest, v12.TransferResponse]
 }
 
 // NewIngesterServiceClient constructs a client for the ingester.v1.IngesterService service. By
@@ -118,6 +126,11 @@ func NewIngesterServiceClient(httpClient connect_go.HTTPClient, baseURL string,
 			baseURL+IngesterServiceFlushProcedure,
 			opts...,
 		),
+		ingestedBytes: connect_go.NewClient[v12.IngestedBytesRequest, v12.IngestedBytesResponse](
+			httpClient,
+			baseURL+IngesterServiceIngestedBytesProcedure,
+			opts...,
+		),
 		mergeProfilesStacktraces: connect_go.NewClient[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse](
 			httpClient,
 			baseURL+IngesterServiceMergeProfilesStacktracesProcedure,
@@ -138,6 +151,11 @@ func NewIngesterServiceClient(httpClient connect_go.HTTPClient, baseURL string,
 			baseURL+IngesterServiceMergeSpanProfileProcedure,
 			opts...,
 		),
+		transfer: connect_go.NewClient[v12.TransferRequest, v12.TransferResponse](
+			httpClient,
+			baseURL+IngesterServiceTransferProcedure,
+			opts...,
+		),
 	}
 }
 
@@ -149,10 +167,12 @@ type ingesterServiceClient struct {
 	profileTypes             *connect_go.Client[v12.ProfileTypesRequest, v12.ProfileTypesResponse]
 	series                   *connect_go.Client[v12.SeriesRequest, v12.SeriesResponse]
 	flush                    *connect_go.Client[v12.FlushRequest, v12.FlushResponse]
+	ingestedBytes            *connect_go.Client[v12.IngestedBytesRequest, v12.IngestedBytesResponse]
 	mergeProfilesStacktraces *connect_go.Client[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse]
 	mergeProfilesLabels      *connect_go.Client[v12.MergeProfilesLabelsRequest, v12.MergeProfilesLabelsResponse]
 	mergeProfilesPprof       *connect_go.Client[v12.MergeProfilesPprofRequest, v12.MergeProfilesPprofResponse]
 	mergeSpanProfile         *connect_go.Client[v12.MergeSpanProfileRequest, v12.MergeSpanProfileResponse]
+	transfer                 *connect_go.Client[v12.TransferRequest, v12.TransferResponse]
 }
 
 // Push calls ingester.v1.IngesterService.Push.
@@ -185,6 +205,11 @@ func (c *ingesterServiceClient) Flush(ctx context.Context, req *connect_go.Reque
 	return c.flush.CallUnary(ctx, req)
 }
 
+// IngestedBytes calls ingester.v1.IngesterService.IngestedBytes.
+func (c *ingesterServiceClient) IngestedBytes(ctx context.Context, req *connect_go.Request[v12.IngestedBytesRequest]) (*connect_go.Response[v12.IngestedBytesResponse], error) {
+	return c.ingestedBytes.CallUnary(ctx, req)
+}
+
 // MergeProfilesStacktraces calls ingester.v1.IngesterService.MergeProfilesStacktraces.
 func (c *ingesterServiceClient) MergeProfilesStacktraces(ctx context.Context) *connect_go.BidiStreamForClient[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse] {
 	return c.mergeProfilesStacktraces.CallBidiStream(ctx)
@@ -205,6 +230,11 @@ func (c *ingesterServiceClient) MergeSpanProfile(ctx context.Context) *connect_g
 	return c.mergeSpanProfile.CallBidiStream(ctx)
 }
 
+// Transfer calls ingester.v1.IngesterService.Transfer.
+func (c *ingesterServiceClient) Transfer(ctx context.Context) *connect_go.ClientStreamForClient[v12.TransferRequest, v12.TransferResponse] {
+	return c.transfer.CallClientStream(ctx)
+}
+
 // IngesterServiceHandler is an implementation of the ingester.v1.IngesterService service.
 type IngesterServiceHandler interface {
 	Push(context.Context, *connect_go.Request[v1.PushRequest]) (*connect_go.Response[v1.PushResponse], error)
@@ -213,10 +243,12 @@ type IngesterServiceHandler interface {
 	ProfileTypes(context.Context, *connect_go.Request[v12.ProfileTypesRequest]) (*connect_go.Response[v12.ProfileTypesResponse], error)
 	Series(context.Context, *connect_go.Request[v12.SeriesRequest]) (*connect_go.Response[v12.SeriesResponse], error)
 	Flush(context.Context, *connect_go.Request[v12.FlushRequest]) (*connect_go.Response[v12.FlushResponse], error)
+	IngestedBytes(context.Context, *connect_go.Request[v12.IngestedBytesRequest]) (*connect_go.Response[v12.IngestedBytesResponse], error)
 	MergeProfilesStacktraces(context.Context, *connect_go.BidiStream[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse]) error
 	MergeProfilesLabels(context.Context, *connect_go.BidiStream[v12.MergeProfilesLabelsRequest, v12.MergeProfilesLabelsResponse]) error
 	MergeProfilesPprof(context.Context, *connect_go.BidiStream[v12.MergeProfilesPprofRequest, v12.MergeProfilesPprofResponse]) error
 	MergeSpanProfile(context.Context, *connect_go.BidiStream[v12.MergeSpanProfileRequest, v12.MergeSpanProfileResponse]) error
+	Transfer(context.Context, *connect_go.ClientStream[v12.TransferRequest]) (*connect_go.Response[v12.TransferResponse], error)
 }
 
 // NewIngesterServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -255,6 +287,11 @@ func NewIngesterServiceHandler(svc IngesterServiceHandler, opts ...connect_go.Ha
 		svc.Flush,
 		opts...,
 	)
+	ingesterServiceIngestedBytesHandler := connect_go.NewUnaryHandler(
+		IngesterServiceIngestedBytesProcedure,
+		svc.IngestedBytes,
+		opts...,
+	)
 	ingesterServiceMergeProfilesStacktracesHandler := connect_go.NewBidiStreamHandler(
 		IngesterServiceMergeProfilesStacktracesProcedure,
 		svc.MergeProfilesStacktraces,
@@ -275,6 +312,11 @@ func NewIngesterServiceHandler(svc IngesterServiceHandler, opts ...connect_go.Ha
 		svc.MergeSpanProfile,
 		opts...,
 	)
+	ingesterServiceTransferHandler := connect_go.NewClientStreamHandler(
+		IngesterServiceTransferProcedure,
+		svc.Transfer,
+		opts...,
+	)
 	return "/ingester.v1.IngesterService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case IngesterServicePushProcedure:
@@ -289,6 +331,8 @@ func NewIngesterServiceHandler(svc IngesterServiceHandler, opts ...connect_go.Ha
 			ingesterServiceSeriesHandler.ServeHTTP(w, r)
 		case IngesterServiceFlushProcedure:
 			ingesterServiceFlushHandler.ServeHTTP(w, r)
+		case IngesterServiceIngestedBytesProcedure:
+			ingesterServiceIngestedBytesHandler.ServeHTTP(w, r)
 		case IngesterServiceMergeProfilesStacktracesProcedure:
 			ingesterServiceMergeProfilesStacktracesHandler.ServeHTTP(w, r)
 		case IngesterServiceMergeProfilesLabelsProcedure:
@@ -297,6 +341,8 @@ func NewIngesterServiceHandler(svc IngesterServiceHandler, opts ...connect_go.Ha
 			ingesterServiceMergeProfilesPprofHandler.ServeHTTP(w, r)
 		case IngesterServiceMergeSpanProfileProcedure:
 			ingesterServiceMergeSpanProfileHandler.ServeHTTP(w, r)
+		case IngesterServiceTransferProcedure:
+			ingesterServiceTransferHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -330,6 +376,10 @@ func (UnimplementedIngesterServiceHandler) Flush(context.Context, *connect_go.Re
 	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ingester.v1.IngesterService.Flush is not implemented"))
 }
 
+func (UnimplementedIngesterServiceHandler) IngestedBytes(context.Context, *connect_go.Request[v12.IngestedBytesRequest]) (*connect_go.Response[v12.IngestedBytesResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ingester.v1.IngesterService.IngestedBytes is not implemented"))
+}
+
 func (UnimplementedIngesterServiceHandler) MergeProfilesStacktraces(context.Context, *connect_go.BidiStream[v12.MergeProfilesStacktracesRequest, v12.MergeProfilesStacktracesResponse]) error {
 	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ingester.v1.IngesterService.MergeProfilesStacktraces is not implemented"))
 }
@@ -345,3 +395,7 @@ func (UnimplementedIngesterServiceHandler) MergeProfilesPprof(context.Context, *
 func (UnimplementedIngesterServiceHandler) MergeSpanProfile(context.Context, *connect_go.BidiStream[v12.MergeSpanProfileRequest, v12.MergeSpanProfileResponse]) error {
 	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ingester.v1.IngesterService.MergeSpanProfile is not implemented"))
 }
+
+func (UnimplementedIngesterServiceHandler) Transfer(context.Context, *connect_go.ClientStream[v12.TransferRequest]) (*connect_go.Response[v12.TransferResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ingester.v1.IngesterService.Transfer is not implemented"))
+}
@@ -49,6 +49,11 @@ func RegisterIngesterServiceHandler(mux *mux.Router, svc IngesterServiceHandler,
 		svc.Flush,
 		opts...,
 	))
+	mux.Handle("/ingester.v1.IngesterService/IngestedBytes", connect_go.NewUnaryHandler(
+		"/ingester.v1.IngesterService/IngestedBytes",
+		svc.IngestedBytes,
+		opts...,
+	))
 	mux.Handle("/ingester.v1.IngesterService/MergeProfilesStacktraces", connect_go.NewBidiStreamHandler(
 		"/ingester.v1.IngesterService/MergeProfilesStacktraces",
 		svc.MergeProfilesStacktraces,
@@ -69,4 +74,9 @@ func RegisterIngesterServiceHandler(mux *mux.Router, svc IngesterServiceHandler,
 		svc.MergeSpanProfile,
 		opts...,
 	))
+	mux.Handle("/ingester.v1.IngesterService/Transfer", connect_go.NewClientStreamHandler(
+		"/ingester.v1.IngesterService/Transfer",
+		svc.Transfer,
+		opts...,
+	))
 }
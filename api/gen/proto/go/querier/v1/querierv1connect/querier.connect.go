@@ -58,6 +58,9 @@ const (
 	// QuerierServiceSelectSeriesProcedure is the fully-qualified name of the QuerierService's
 	// SelectSeries RPC.
 	QuerierServiceSelectSeriesProcedure = "/querier.v1.QuerierService/SelectSeries"
+	// QuerierServiceCardinalityProcedure is the fully-qualified name of the QuerierService's
+	// Cardinality RPC.
+	QuerierServiceCardinalityProcedure = "/querier.v1.QuerierService/Cardinality"
 	// QuerierServiceDiffProcedure is the fully-qualified name of the QuerierService's Diff RPC.
 	QuerierServiceDiffProcedure = "/querier.v1.QuerierService/Diff"
 )
@@ -80,6 +83,8 @@ type QuerierServiceClient interface {
 	SelectMergeProfile(context.Context, *connect_go.Request[v1.SelectMergeProfileRequest]) (*connect_go.Response[v12.Profile], error)
 	// SelectSeries returns a time series for the total sum of the requested profiles.
 	SelectSeries(context.Context, *connect_go.Request[v1.SelectSeriesRequest]) (*connect_go.Response[v1.SelectSeriesResponse], error)
+	// Cardinality returns the number of series per label name, the top values of each label and the bytes ingested per service.
+	Cardinality(context.Context, *connect_go.Request[v1.CardinalityRequest]) (*connect_go.Response[v1.CardinalityResponse], error)
 	Diff(context.Context, *connect_go.Request[v1.DiffRequest]) (*connect_go.Response[v1.DiffResponse], error)
 }
 
@@ -133,6 +138,11 @@ func NewQuerierServiceClient(httpClient connect_go.HTTPClient, baseURL string, o
 			baseURL+QuerierServiceSelectSeriesProcedure,
 			opts...,
 		),
+		cardinality: connect_go.NewClient[v1.CardinalityRequest, v1.CardinalityResponse](
+			httpClient,
+			baseURL+QuerierServiceCardinalityProcedure,
+			opts...,
+		),
 		diff: connect_go.NewClient[v1.DiffRequest, v1.DiffResponse](
 			httpClient,
 			baseURL+QuerierServiceDiffProcedure,
@@ -151,6 +161,7 @@ type querierServiceClient struct {
 	selectMergeSpanProfile *connect_go.Client[v1.SelectMergeSpanProfileRequest, v1.SelectMergeSpanProfileResponse]
 	selectMergeProfile     *connect_go.Client[v1.SelectMergeProfileRequest, v12.Profile]
 	selectSeries           *connect_go.Client[v1.SelectSeriesRequest, v1.SelectSeriesResponse]
+	cardinality            *connect_go.Client[v1.CardinalityRequest, v1.CardinalityResponse]
 	diff                   *connect_go.Client[v1.DiffRequest, v1.DiffResponse]
 }
 
@@ -194,6 +205,11 @@ func (c *querierServiceClient) SelectSeries(ctx context.Context, req *connect_go
 	return c.selectSeries.CallUnary(ctx, req)
 }
 
+// Cardinality calls querier.v1.QuerierService.Cardinality.
+func (c *querierServiceClient) Cardinality(ctx context.Context, req *connect_go.Request[v1.CardinalityRequest]) (*connect_go.Response[v1.CardinalityResponse], error) {
+	return c.cardinality.CallUnary(ctx, req)
+}
+
 // Diff calls querier.v1.QuerierService.Diff.
 func (c *querierServiceClient) Diff(ctx context.Context, req *connect_go.Request[v1.DiffRequest]) (*connect_go.Response[v1.DiffResponse], error) {
 	return c.diff.CallUnary(ctx, req)
@@ -217,6 +233,8 @@ type QuerierServiceHandler interface {
 	SelectMergeProfile(context.Context, *connect_go.Request[v1.SelectMergeProfileRequest]) (*connect_go.Response[v12.Profile], error)
 	// SelectSeries returns a time series for the total sum of the requested profiles.
 	SelectSeries(context.Context, *connect_go.Request[v1.SelectSeriesRequest]) (*connect_go.Response[v1.SelectSeriesResponse], error)
+	// Cardinality returns the number of series per label name, the top values of each label and the bytes ingested per service.
+	Cardinality(context.Context, *connect_go.Request[v1.CardinalityRequest]) (*connect_go.Response[v1.CardinalityResponse], error)
 	Diff(context.Context, *connect_go.Request[v1.DiffRequest]) (*connect_go.Response[v1.DiffResponse], error)
 }
 
@@ -266,6 +284,11 @@ func NewQuerierServiceHandler(svc QuerierServiceHandler, opts ...connect_go.Hand
 		svc.SelectSeries,
 		opts...,
 	)
+	querierServiceCardinalityHandler := connect_go.NewUnaryHandler(
+		QuerierServiceCardinalityProcedure,
+		svc.Cardinality,
+		opts...,
+	)
 	querierServiceDiffHandler := connect_go.NewUnaryHandler(
 		QuerierServiceDiffProcedure,
 		svc.Diff,
@@ -289,6 +312,8 @@ func NewQuerierServiceHandler(svc QuerierServiceHandler, opts ...connect_go.Hand
 			querierServiceSelectMergeProfileHandler.ServeHTTP(w, r)
 		case QuerierServiceSelectSeriesProcedure:
 			querierServiceSelectSeriesHandler.ServeHTTP(w, r)
+		case QuerierServiceCardinalityProcedure:
+			querierServiceCardinalityHandler.ServeHTTP(w, r)
 		case QuerierServiceDiffProcedure:
 			querierServiceDiffHandler.ServeHTTP(w, r)
 		default:
@@ -332,6 +357,10 @@ func (UnimplementedQuerierServiceHandler) SelectSeries(context.Context, *connect
 	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("querier.v1.QuerierService.SelectSeries is not implemented"))
 }
 
+func (UnimplementedQuerierServiceHandler) Cardinality(context.Context, *connect_go.Request[v1.CardinalityRequest]) (*connect_go.Response[v1.CardinalityResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("querier.v1.QuerierService.Cardinality is not implemented"))
+}
+
 func (UnimplementedQuerierServiceHandler) Diff(context.Context, *connect_go.Request[v1.DiffRequest]) (*connect_go.Response[v1.DiffResponse], error) {
 	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("querier.v1.QuerierService.Diff is not implemented"))
 }
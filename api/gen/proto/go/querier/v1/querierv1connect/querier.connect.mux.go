@@ -59,6 +59,11 @@ func RegisterQuerierServiceHandler(mux *mux.Router, svc QuerierServiceHandler, o
 		svc.SelectSeries,
 		opts...,
 	))
+	mux.Handle("/querier.v1.QuerierService/Cardinality", connect_go.NewUnaryHandler(
+		"/querier.v1.QuerierService/Cardinality",
+		svc.Cardinality,
+		opts...,
+	))
 	mux.Handle("/querier.v1.QuerierService/Diff", connect_go.NewUnaryHandler(
 		"/querier.v1.QuerierService/Diff",
 		svc.Diff,
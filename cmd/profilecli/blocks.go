@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -74,3 +75,82 @@ func blocksList(ctx context.Context) error {
 
 	return nil
 }
+
+// maxReportedRows is the maximum number of corrupted
+// rows reported per block.
+const maxReportedRows = 10
+
+func blocksVerify(ctx context.Context) error {
+	blockIDs := cfg.blocks.verify.blockIDs
+	if len(blockIDs) == 0 {
+		entries, err := os.ReadDir(cfg.blocks.path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, ok := block.IsBlockDir(e.Name()); ok && e.IsDir() {
+				blockIDs = append(blockIDs, e.Name())
+			}
+		}
+	}
+
+	out := output(ctx)
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Block ID", "Rows", "Corrupted Rows", "Issues", "Repaired Block ID"})
+	var corrupted int
+	for _, id := range blockIDs {
+		var (
+			dir      = filepath.Join(cfg.blocks.path, id)
+			result   *phlaredb.BlockVerification
+			repaired *block.Meta
+			err      error
+		)
+		if cfg.blocks.verify.repair {
+			result, repaired, err = phlaredb.RepairLocalBlock(ctx, dir, cfg.blocks.verify.outputPath)
+		} else {
+			result, err = phlaredb.VerifyLocalBlock(ctx, dir)
+		}
+		if result == nil {
+			return fmt.Errorf("verifying block %s: %w", id, err)
+		}
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to verify block", "block", id, "err", err)
+		}
+
+		var repairedID string
+		if repaired != nil {
+			repairedID = repaired.ULID.String()
+		} else if !result.OK() {
+			corrupted++
+		}
+		table.Append([]string{
+			id,
+			fmt.Sprintf("%d", result.TotalRows),
+			fmt.Sprintf("%d", len(result.CorruptedRows)),
+			fmt.Sprintf("%d", len(result.Issues)),
+			repairedID,
+		})
+
+		for _, issue := range result.Issues {
+			level.Warn(logger).Log("msg", "block issue", "block", id, "issue", issue)
+		}
+		rows := make([]int64, 0, len(result.CorruptedRows))
+		for row := range result.CorruptedRows {
+			rows = append(rows, row)
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i] < rows[j] })
+		for i, row := range rows {
+			if i == maxReportedRows {
+				level.Warn(logger).Log("msg", "more corrupted rows omitted", "block", id, "count", len(rows)-i)
+				break
+			}
+			level.Warn(logger).Log("msg", "corrupted row", "block", id, "row", row, "reason", result.CorruptedRows[row])
+		}
+	}
+	table.Render()
+
+	if corrupted > 0 {
+		return fmt.Errorf("%d of %d blocks are corrupted", corrupted, len(blockIDs))
+	}
+	return nil
+}
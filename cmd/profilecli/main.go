@@ -21,6 +21,11 @@ var cfg struct {
 	blocks  struct {
 		path               string
 		restoreMissingMeta bool
+		verify             struct {
+			blockIDs   []string
+			repair     bool
+			outputPath string
+		}
 	}
 }
 
@@ -44,6 +49,11 @@ func main() {
 	blocksListCmd := blocksCmd.Command("list", "List blocks.")
 	blocksListCmd.Flag("restore-missing-meta", "").Default("false").BoolVar(&cfg.blocks.restoreMissingMeta)
 
+	blocksVerifyCmd := blocksCmd.Command("verify", "Verify the consistency of blocks.")
+	blocksVerifyCmd.Arg("block-id", "IDs of the blocks to verify, all blocks are verified if none given.").StringsVar(&cfg.blocks.verify.blockIDs)
+	blocksVerifyCmd.Flag("repair", "Write a copy of each corrupted block without the corrupted rows to the output path.").Default("false").BoolVar(&cfg.blocks.verify.repair)
+	blocksVerifyCmd.Flag("output-path", "Path to write repaired blocks to.").Default("./data/repaired").StringVar(&cfg.blocks.verify.outputPath)
+
 	parquetCmd := app.Command("parquet", "Operate on a Parquet file.")
 	parquetInspectCmd := parquetCmd.Command("inspect", "Inspect a parquet file's structure.")
 	parquetInspectFiles := parquetInspectCmd.Arg("file", "parquet file path").Required().ExistingFiles()
@@ -54,6 +64,8 @@ func main() {
 	queryMergeParams := addQueryMergeParams(queryMergeCmd)
 	querySeriesCmd := queryCmd.Command("series", "Request series labels.")
 	querySeriesParams := addQuerySeriesParams(querySeriesCmd)
+	queryCardinalityCmd := queryCmd.Command("cardinality", "Request series cardinality and ingested bytes per service.")
+	queryCardinalityParams := addQueryCardinalityParams(queryCardinalityCmd)
 
 	uploadCmd := app.Command("upload", "Upload profile(s).")
 	uploadParams := addUploadParams(uploadCmd)
@@ -72,6 +84,8 @@ func main() {
 	switch parsedCmd {
 	case blocksListCmd.FullCommand():
 		os.Exit(checkError(blocksList(ctx)))
+	case blocksVerifyCmd.FullCommand():
+		os.Exit(checkError(blocksVerify(ctx)))
 	case parquetInspectCmd.FullCommand():
 		for _, file := range *parquetInspectFiles {
 			if err := parquetInspect(ctx, file); err != nil {
@@ -86,6 +100,10 @@ func main() {
 		if err := querySeries(ctx, querySeriesParams); err != nil {
 			os.Exit(checkError(err))
 		}
+	case queryCardinalityCmd.FullCommand():
+		if err := queryCardinality(ctx, queryCardinalityParams); err != nil {
+			os.Exit(checkError(err))
+		}
 	case uploadCmd.FullCommand():
 		if err := upload(ctx, uploadParams); err != nil {
 			os.Exit(checkError(err))
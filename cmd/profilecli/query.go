@@ -12,12 +12,14 @@ import (
 	"time"
 
 	"github.com/bufbuild/connect-go"
+	"github.com/dustin/go-humanize"
 	"github.com/go-kit/log/level"
 	gprofile "github.com/google/pprof/profile"
 	"github.com/grafana/dskit/runutil"
 	"github.com/k0kubun/pp/v3"
 	"github.com/klauspost/compress/gzip"
 	"github.com/mattn/go-isatty"
+	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 
@@ -314,3 +316,61 @@ func querySeries(ctx context.Context, params *querySeriesParams) (err error) {
 	return nil
 
 }
+
+type queryCardinalityParams struct {
+	*queryParams
+	Limit int64
+}
+
+func addQueryCardinalityParams(queryCmd commander) *queryCardinalityParams {
+	params := new(queryCardinalityParams)
+	params.queryParams = addQueryParams(queryCmd)
+	queryCmd.Flag("limit", "The maximum number of values returned for each label name.").Default("10").Int64Var(&params.Limit)
+	return params
+}
+
+func queryCardinality(ctx context.Context, params *queryCardinalityParams) (err error) {
+	from, to, err := params.parseFromTo()
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "query cardinality", "url", params.URL, "from", from, "to", to, "query", params.Query)
+
+	qc := params.phlareClient.queryClient()
+	resp, err := qc.Cardinality(ctx, connect.NewRequest(&querierv1.CardinalityRequest{
+		Start:    from.UnixMilli(),
+		End:      to.UnixMilli(),
+		Matchers: []string{params.Query},
+		Limit:    params.Limit,
+	}))
+	if err != nil {
+		return errors.Wrap(err, "failed to query")
+	}
+
+	out := output(ctx)
+	fmt.Fprintf(out, "Series: %d\n", resp.Msg.SeriesCount)
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Label", "Series", "Values", "Top Values"})
+	for _, l := range resp.Msg.Labels {
+		values := make([]string, 0, len(l.TopValues))
+		for _, v := range l.TopValues {
+			values = append(values, fmt.Sprintf("%s (%d)", v.Value, v.SeriesCount))
+		}
+		table.Append([]string{
+			l.Name,
+			strconv.FormatInt(l.SeriesCount, 10),
+			strconv.FormatInt(l.ValuesCount, 10),
+			strings.Join(values, "\n"),
+		})
+	}
+	table.Render()
+
+	table = tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Service", "Ingested Bytes"})
+	for _, s := range resp.Msg.Services {
+		table.Append([]string{s.ServiceName, humanize.Bytes(uint64(s.Bytes))})
+	}
+	table.Render()
+	return nil
+}
@@ -2,7 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/go-kit/log/level"
@@ -24,6 +30,7 @@ func (c *phlareClient) pusherClient() pushv1connect.PusherServiceClient {
 type uploadParams struct {
 	*phlareClient
 	paths       []string
+	dir         string
 	extraLabels map[string]string
 }
 
@@ -35,12 +42,24 @@ func addUploadParams(cmd commander) *uploadParams {
 	)
 	params.phlareClient = addPhlareClient(cmd)
 
-	cmd.Arg("path", "Path(s) to profile(s) to upload").Required().ExistingFilesVar(&params.paths)
+	cmd.Arg("path", "Path(s) to profile(s) to upload").ExistingFilesVar(&params.paths)
+	cmd.Flag("dir", "Directory of profiles recorded by the eBPF recorder to upload, with their recorded labels and timestamps.").ExistingDirVar(&params.dir)
 	cmd.Flag("extra-labels", "Add additional labels to the profile(s)").Default("job=profilecli-upload").StringMapVar(&params.extraLabels)
 	return params
 }
 
 func upload(ctx context.Context, params *uploadParams) (err error) {
+	if len(params.paths) == 0 && params.dir == "" {
+		return errors.New("either path(s) or --dir must be specified")
+	}
+	if params.dir != "" {
+		if err = uploadDir(ctx, params); err != nil {
+			return err
+		}
+	}
+	if len(params.paths) == 0 {
+		return nil
+	}
 	pc := params.phlareClient.pusherClient()
 
 	lblStrings := make([]string, 0, len(params.extraLabels)*2)
@@ -108,3 +127,84 @@ func upload(ctx context.Context, params *uploadParams) (err error) {
 
 	return nil
 }
+
+// The layout of the directories written by the eBPF recorder, see ebpf/pprof DirWriter.
+const (
+	recordedLabelsFile    = "labels.json"
+	recordedProfileSuffix = ".pb.gz"
+)
+
+// uploadDir pushes the profiles recorded by the eBPF recorder. Every target sub directory holds the
+// target labels and a profile file per collection. The profiles keep their TimeNanos, so they are
+// ingested at the time they were recorded. The extra labels are added unless recorded.
+func uploadDir(ctx context.Context, params *uploadParams) error {
+	pc := params.phlareClient.pusherClient()
+
+	targets, err := os.ReadDir(params.dir)
+	if err != nil {
+		return err
+	}
+	uploaded := 0
+	for _, target := range targets {
+		if !target.IsDir() {
+			continue
+		}
+		dir := filepath.Join(params.dir, target.Name())
+		data, err := os.ReadFile(filepath.Join(dir, recordedLabelsFile))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		recorded := map[string]string{}
+		if err = json.Unmarshal(data, &recorded); err != nil {
+			return fmt.Errorf("decoding %s: %w", filepath.Join(dir, recordedLabelsFile), err)
+		}
+		lblBuilder := model.NewLabelsBuilder(nil)
+		for key, value := range params.extraLabels {
+			lblBuilder.Set(key, value)
+		}
+		for key, value := range recorded {
+			lblBuilder.Set(key, value)
+		}
+		lbls := lblBuilder.Labels()
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(files))
+		for _, f := range files {
+			if !f.IsDir() && strings.HasSuffix(f.Name(), recordedProfileSuffix) {
+				names = append(names, f.Name())
+			}
+		}
+		// oldest first, the files are named by the profile timestamp
+		sort.Strings(names)
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			id := uuid.New().String()
+			_, err = pc.Push(ctx, connect.NewRequest(&pushv1.PushRequest{
+				Series: []*pushv1.RawProfileSeries{{
+					Labels: lbls,
+					Samples: []*pushv1.RawSample{{
+						ID:         id,
+						RawProfile: data,
+					}},
+				}},
+			}))
+			if err != nil {
+				return fmt.Errorf("uploading %s: %w", path, err)
+			}
+			level.Debug(logger).Log("msg", "successfully uploaded profile", "id", id, "labels", model.Labels(lbls).ToPrometheusLabels().String(), "path", path)
+			uploaded++
+		}
+	}
+	level.Info(logger).Log("msg", "successfully uploaded recorded profiles", "dir", params.dir, "count", uploaded)
+	return nil
+}
@@ -0,0 +1,106 @@
+#ifndef PYROSCOPE_INTERP_H
+#define PYROSCOPE_INTERP_H
+
+// shared by the interpreter unwinders rbperf.bpf.c and v8perf.bpf.c
+// the layout of interp_event and interp_symbol is mirrored by the interp go package
+
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+
+#include "stacks.h"
+
+#define INTERP_STACK_FRAMES_PER_PROG 32
+#define INTERP_STACK_PROG_CNT 3
+#define INTERP_STACK_MAX_LEN (INTERP_STACK_FRAMES_PER_PROG * INTERP_STACK_PROG_CNT)
+#define INTERP_NAME_LEN 64
+#define INTERP_FILE_LEN 128
+
+// should be enough
+#define INTERP_NUM_CPU 512
+
+enum {
+    INTERP_STACK_STATUS_COMPLETE = 0,
+    INTERP_STACK_STATUS_ERROR = 1,
+    INTERP_STACK_STATUS_TRUNCATED = 2,
+};
+
+typedef struct {
+    char name[INTERP_NAME_LEN];
+    char file[INTERP_FILE_LEN];
+} interp_symbol;
+
+typedef struct {
+    uint8_t stack_status;
+    uint8_t err;
+    uint8_t reserved2;
+    uint8_t reserved3;
+    uint32_t pid;
+    int64_t kern_stack;
+    uint32_t stack_len;
+    uint32_t stack[INTERP_STACK_MAX_LEN];
+} interp_event;
+
+typedef uint32_t interp_symbol_id;
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, interp_symbol);
+    __type(value, interp_symbol_id);
+    __uint(max_entries, 16384);
+} interp_symbols SEC(".maps");
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+    __uint(key_size, sizeof(u32));
+    __uint(value_size, sizeof(u32));
+} interp_events SEC(".maps");
+
+static __always_inline int interp_submit_sample(void *ctx, interp_event *event) {
+    bpf_perf_event_output(ctx, &interp_events, BPF_F_CURRENT_CPU, event, sizeof(interp_event));
+    return 0;
+}
+
+static __always_inline int interp_submit_error_sample(void *ctx, interp_event *event, uint8_t err) {
+    event->stack_status = INTERP_STACK_STATUS_ERROR;
+    event->err = err;
+    bpf_perf_event_output(ctx, &interp_events, BPF_F_CURRENT_CPU, event,
+                          offsetof(interp_event, kern_stack) + sizeof(event->kern_stack));
+    return -1;
+}
+
+// To avoid duplicate ids, every CPU uses different ids when inserting into the symbols map,
+// the same way as pyperf get_symbol_id does.
+static __always_inline int interp_get_symbol_id(
+        int64_t *symbol_counter,
+        uint32_t cur_cpu,
+        interp_symbol *sym,
+        interp_symbol_id *out_symbol_id) {
+    interp_symbol_id *symbol_id_ptr = bpf_map_lookup_elem(&interp_symbols, sym);
+    if (symbol_id_ptr) {
+        *out_symbol_id = *symbol_id_ptr;
+        return 0;
+    }
+    (*symbol_counter)++;
+    interp_symbol_id symbol_id = *symbol_counter * INTERP_NUM_CPU + cur_cpu;
+    if (bpf_map_update_elem(&interp_symbols, sym, &symbol_id, BPF_NOEXIST) == 0) {
+        *out_symbol_id = symbol_id;
+        return 0;
+    }
+    symbol_id_ptr = bpf_map_lookup_elem(&interp_symbols, sym);
+    if (symbol_id_ptr) {
+        *out_symbol_id = *symbol_id_ptr;
+        return 0;
+    }
+    *out_symbol_id = 0;
+    return -1;
+}
+
+static __always_inline void interp_push_symbol(interp_event *event, interp_symbol_id symbol_id) {
+    uint32_t cur_len = event->stack_len;
+    if (cur_len < INTERP_STACK_MAX_LEN) {
+        event->stack[cur_len] = symbol_id;
+        event->stack_len++;
+    }
+}
+
+#endif // PYROSCOPE_INTERP_H
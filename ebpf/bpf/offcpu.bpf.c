@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: GPL-2.0-only
+
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+#include "offcpu.bpf.h"
+#include "pid.h"
+
+// The stacks are collected when a thread is switched out and accounted when it is switched back in,
+// so the time spent blocked on locks, IO and waiting in the run queue is attributed to the blocking stack.
+SEC("tracepoint/sched/sched_switch")
+int offcpu_sched_switch(struct trace_event_raw_sched_switch *ctx) {
+    u64 now = bpf_ktime_get_ns();
+    u32 prev_tid = ctx->prev_pid;
+    u32 next_tid = ctx->next_pid;
+
+    if (prev_tid != 0) {
+        u32 tgid = 0;
+        current_pid(&tgid);
+        struct pid_config *config = bpf_map_lookup_elem(&pids, &tgid);
+        if (config != NULL && config->type == PROFILING_TYPE_FRAMEPOINTERS) {
+            struct offcpu_start start = {
+                    .ts = now,
+                    .key = {
+                            .pid = tgid,
+                            .flags = 0,
+                            .kern_stack = -1,
+                            .user_stack = -1,
+                    },
+            };
+            if (config->collect_kernel) {
+                start.key.kern_stack = bpf_get_stackid(ctx, &stacks, KERN_STACKID_FLAGS);
+            }
+            if (config->collect_user) {
+                start.key.user_stack = bpf_get_stackid(ctx, &stacks, USER_STACKID_FLAGS);
+            }
+            // the switched out thread is still the current task
+            sample_key_collect_thread(&start.key);
+            bpf_map_update_elem(&offcpu_starts, &prev_tid, &start, BPF_ANY);
+        }
+    }
+
+    struct offcpu_start *start = bpf_map_lookup_elem(&offcpu_starts, &next_tid);
+    if (start == NULL) {
+        return 0;
+    }
+    u64 delta = now - start->ts;
+    struct sample_key key = start->key;
+    bpf_map_delete_elem(&offcpu_starts, &next_tid);
+
+    u32 zero = 0;
+    struct offcpu_config *config = bpf_map_lookup_elem(&offcpu_settings, &zero);
+    if (config == NULL || delta < config->min_block_ns) {
+        return 0;
+    }
+    u64 *val = bpf_map_lookup_elem(&offcpu_counts, &key);
+    if (val) {
+        __sync_fetch_and_add(val, delta);
+    } else {
+        bpf_map_update_elem(&offcpu_counts, &key, &delta, BPF_NOEXIST);
+    }
+    return 0;
+}
+
+char _license[] SEC("license") = "GPL";
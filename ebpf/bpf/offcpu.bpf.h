@@ -0,0 +1,40 @@
+#ifndef OFFCPU_BPF_H
+#define OFFCPU_BPF_H
+
+#include "sample.h"
+#include "stacks.h"
+
+struct offcpu_config {
+    __u64 min_block_ns;
+};
+
+// the stack of a thread at the moment it was switched out
+struct offcpu_start {
+    __u64 ts;
+    struct sample_key key;
+};
+
+struct {
+    __uint(type, BPF_MAP_TYPE_ARRAY);
+    __type(key, u32);
+    __type(value, struct offcpu_config);
+    __uint(max_entries, 1);
+} offcpu_settings SEC(".maps");
+
+// keyed by thread id, threads which exit while switched out are never deleted
+struct {
+    __uint(type, BPF_MAP_TYPE_LRU_HASH);
+    __type(key, u32);
+    __type(value, struct offcpu_start);
+    __uint(max_entries, PROFILE_MAPS_SIZE);
+} offcpu_starts SEC(".maps");
+
+// blocked nanoseconds per stack
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, struct sample_key);
+    __type(value, u64);
+    __uint(max_entries, PROFILE_MAPS_SIZE);
+} offcpu_counts SEC(".maps");
+
+#endif // OFFCPU_BPF_H
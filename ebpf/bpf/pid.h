@@ -35,12 +35,31 @@ static __always_inline void current_pid(u32 *pid) {
 //    *pid_ns_id = ns.inum;
 }
 
+static __always_inline void current_tid(u32 *tid) {
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    if (task == 0) {
+        return;
+    }
+    struct upid upid;
+
+    unsigned int level = BPF_CORE_READ(task, nsproxy, pid_ns_for_children, level);
+    struct pid *ns_pid = (struct pid *)BPF_CORE_READ(task, thread_pid);
+    bpf_probe_read_kernel(&upid, sizeof(upid), &ns_pid->numbers[level]);
+
+    *tid = (u32)upid.nr;
+}
+
 #else // PYROSCOPE_PID_NAMESPACED
 
 static __always_inline void current_pid(u32 *pid) {
   u64 pid_tgid = bpf_get_current_pid_tgid();
   *pid = (u32)(pid_tgid >> 32);
 }
+
+static __always_inline void current_tid(u32 *tid) {
+  u64 pid_tgid = bpf_get_current_pid_tgid();
+  *tid = (u32)pid_tgid;
+}
 #endif // PYROSCOPE_PID_NAMESPACED
 
 
@@ -43,10 +43,21 @@ int do_perf_event(struct bpf_perf_event_data *ctx) {
         return 0;
     }
 
+    if (config->type == PROFILING_TYPE_RUBY) {
+        bpf_tail_call(ctx, &progs, PROG_IDX_RUBY);
+        return 0;
+    }
+
+    if (config->type == PROFILING_TYPE_NODEJS) {
+        bpf_tail_call(ctx, &progs, PROG_IDX_NODEJS);
+        return 0;
+    }
+
     if (config->type == PROFILING_TYPE_FRAMEPOINTERS) {
         key.pid = tgid;
         key.kern_stack = -1;
         key.user_stack = -1;
+        sample_key_collect_thread(&key);
 
         if (config->collect_kernel) {
             key.kern_stack = bpf_get_stackid(ctx, &stacks, KERN_STACKID_FLAGS);
@@ -4,24 +4,7 @@
 
 
 
-struct sample_key {
-    __u32 pid;
-    __u32 flags;
-    __s64 kern_stack;
-    __s64 user_stack;
-};
-
-#define PROFILING_TYPE_UNKNOWN 1
-#define PROFILING_TYPE_FRAMEPOINTERS 2
-#define PROFILING_TYPE_PYTHON 3
-#define PROFILING_TYPE_ERROR 4
-
-struct pid_config {
-    uint8_t type;
-    uint8_t collect_user;
-    uint8_t collect_kernel;
-    uint8_t padding_;
-};
+#include "sample.h"
 
 #define OP_REQUEST_UNKNOWN_PROCESS_INFO 1
 #define OP_PID_DEAD 2
@@ -38,14 +21,6 @@ struct pid_event e__;
 
 
 
-struct {
-    __uint(type, BPF_MAP_TYPE_HASH);
-    __type(key, u32);
-    __type(value, struct pid_config);
-    __uint(max_entries, 1024);
-} pids SEC(".maps");
-
-
 struct {
     __uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
     __uint(key_size, sizeof(u32));
@@ -55,12 +30,14 @@ struct {
 
 struct {
     __uint(type, BPF_MAP_TYPE_PROG_ARRAY);
-    __uint(max_entries, 1);
+    __uint(max_entries, 3);
     __type(key, int);
     __array(values, int (void *));
 } progs SEC(".maps");
 
 #define PROG_IDX_PYTHON 0
+#define PROG_IDX_RUBY 1
+#define PROG_IDX_NODEJS 2
 
 #include "stacks.h"
 
@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: GPL-2.0-only
+
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+
+#include "pid.h"
+#include "interp.h"
+
+enum {
+    RB_ERROR_GENERIC = 1,
+    RB_ERROR_VM = 2,
+    RB_ERROR_EC = 3,
+    RB_ERROR_EC_NULL = 4,
+    RB_ERROR_CFP = 5,
+    RB_ERROR_ISEQ = 6,
+    RB_ERROR_SYMBOL = 7,
+    RB_ERROR_LABEL = 8,
+    RB_ERROR_PATH = 9,
+};
+
+// MRI 3.x constants, see include/ruby/internal/value_type.h, fl_type.h and internal/imemo.h
+#define RB_T_MASK 0x1f
+#define RB_T_STRING 0x05
+#define RB_T_ARRAY 0x07
+#define RB_T_IMEMO 0x1a
+#define RB_FL_USHIFT 12
+#define RB_IMEMO_MASK 0x0f
+#define RB_IMEMO_ISEQ 7
+#define RB_FL_USER1 (1 << (RB_FL_USHIFT + 1))
+#define RB_RSTRING_NOEMBED RB_FL_USER1
+#define RB_RARRAY_EMBED_FLAG RB_FL_USER1
+
+typedef struct {
+    int16_t Vm_ractor_main_ractor;
+    int16_t Ractor_threads_running_ec;
+    int16_t ExecutionContext_vm_stack;
+    int16_t ExecutionContext_vm_stack_size;
+    int16_t ExecutionContext_cfp;
+    int16_t ControlFrame_iseq;
+    int16_t ControlFrameSize;
+    int16_t Iseq_body;
+    int16_t IseqBody_location_pathobj;
+    int16_t IseqBody_location_label;
+    int16_t RString_as_heap_ptr;
+    int16_t RString_as_embed_ary;
+    int16_t RArray_as_heap_ptr;
+    int16_t RArray_as_ary;
+} rb_offset_config;
+
+typedef struct {
+    rb_offset_config offsets;
+    uint64_t vm_addr; // address of ruby_current_vm_ptr
+} rb_pid_data;
+
+typedef struct {
+    int64_t symbol_counter;
+    rb_offset_config offsets;
+    uint32_t cur_cpu;
+    uint64_t cfp;
+    uint64_t stack_end;
+    int64_t rb_stack_prog_call_cnt;
+    interp_event event;
+} rb_sample_state_t;
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __type(key, u32);
+    __type(value, rb_sample_state_t);
+    __uint(max_entries, 1);
+} rb_state_heap SEC(".maps");
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, pid_t);
+    __type(value, rb_pid_data);
+    __uint(max_entries, 10240);
+} rb_pid_config SEC(".maps");
+
+#define RB_PROG_IDX_READ_RUBY_STACK 0
+
+int read_ruby_stack(struct bpf_perf_event_data *ctx);
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PROG_ARRAY);
+    __uint(max_entries, 2);
+    __type(key, int);
+    __array(values, int (void *));
+} rb_progs SEC(".maps") = {
+        .values = {
+                [RB_PROG_IDX_READ_RUBY_STACK] = (void *) &read_ruby_stack,
+        },
+};
+
+static __always_inline rb_sample_state_t *get_state() {
+    int zero = 0;
+    return bpf_map_lookup_elem(&rb_state_heap, &zero);
+}
+
+#define GET_STATE()                      \
+  rb_sample_state_t* state = get_state(); \
+  if (!state) {                          \
+    return -1; /* should never happen */ \
+  }
+
+static __always_inline int read_rstring(rb_offset_config *offsets, void *str, char *dst, int size) {
+    uint64_t flags;
+    if (bpf_probe_read_user(&flags, sizeof(flags), str)) {
+        return -1;
+    }
+    if ((flags & RB_T_MASK) != RB_T_STRING) {
+        return -1;
+    }
+    void *chars = str + offsets->RString_as_embed_ary;
+    if (flags & RB_RSTRING_NOEMBED) {
+        if (bpf_probe_read_user(&chars, sizeof(chars), str + offsets->RString_as_heap_ptr)) {
+            return -1;
+        }
+    }
+    if (bpf_probe_read_user_str(dst, size, chars) < 0) {
+        return -1;
+    }
+    return 0;
+}
+
+// pathobj is either a path String or an Array of [path, realpath]
+static __always_inline int read_path(rb_offset_config *offsets, void *pathobj, interp_symbol *sym) {
+    uint64_t flags;
+    if (bpf_probe_read_user(&flags, sizeof(flags), pathobj)) {
+        return -1;
+    }
+    if ((flags & RB_T_MASK) == RB_T_ARRAY) {
+        void *items = pathobj + offsets->RArray_as_ary;
+        if (!(flags & RB_RARRAY_EMBED_FLAG)) {
+            if (bpf_probe_read_user(&items, sizeof(items), pathobj + offsets->RArray_as_heap_ptr)) {
+                return -1;
+            }
+        }
+        if (bpf_probe_read_user(&pathobj, sizeof(pathobj), items)) {
+            return -1;
+        }
+    }
+    return read_rstring(offsets, pathobj, sym->file, sizeof(sym->file));
+}
+
+// get_frame_data reads the label and the path of the iseq of the current control frame
+// and moves state->cfp to the caller frame
+// returns -RB_ERROR_XXX on error, 1 on success, 2 if the frame is not an iseq frame, 0 if no more frames
+static __always_inline int get_frame_data(rb_sample_state_t *state, interp_symbol *sym) {
+    rb_offset_config *offsets = &state->offsets;
+    void *cfp = (void *) state->cfp;
+    if (state->cfp >= state->stack_end) {
+        return 0;
+    }
+    state->cfp += offsets->ControlFrameSize;
+
+    void *iseq;
+    if (bpf_probe_read_user(&iseq, sizeof(iseq), cfp + offsets->ControlFrame_iseq)) {
+        return -RB_ERROR_CFP;
+    }
+    if (!iseq) {
+        return 2;
+    }
+    uint64_t flags;
+    if (bpf_probe_read_user(&flags, sizeof(flags), iseq)) {
+        return -RB_ERROR_ISEQ;
+    }
+    // cfunc frames keep a method entry there
+    if ((flags & RB_T_MASK) != RB_T_IMEMO || ((flags >> RB_FL_USHIFT) & RB_IMEMO_MASK) != RB_IMEMO_ISEQ) {
+        return 2;
+    }
+    void *body;
+    if (bpf_probe_read_user(&body, sizeof(body), iseq + offsets->Iseq_body)) {
+        return -RB_ERROR_ISEQ;
+    }
+    void *label;
+    if (bpf_probe_read_user(&label, sizeof(label), body + offsets->IseqBody_location_label)) {
+        return -RB_ERROR_LABEL;
+    }
+    void *pathobj;
+    if (bpf_probe_read_user(&pathobj, sizeof(pathobj), body + offsets->IseqBody_location_pathobj)) {
+        return -RB_ERROR_PATH;
+    }
+
+    __builtin_memset(sym, 0, sizeof(*sym));
+    if (read_rstring(offsets, label, sym->name, sizeof(sym->name))) {
+        return -RB_ERROR_LABEL;
+    }
+    if (read_path(offsets, pathobj, sym)) {
+        return -RB_ERROR_PATH;
+    }
+    return 1;
+}
+
+static __always_inline int rbperf_collect_impl(struct bpf_perf_event_data *ctx, pid_t pid, bool collect_kern_stack) {
+    rb_pid_data *pid_data = bpf_map_lookup_elem(&rb_pid_config, &pid);
+    if (!pid_data) {
+        return 0;
+    }
+
+    GET_STATE();
+
+    state->offsets = pid_data->offsets;
+    state->cur_cpu = bpf_get_smp_processor_id();
+    state->rb_stack_prog_call_cnt = 0;
+
+    interp_event *event = &state->event;
+    event->pid = pid;
+    if (collect_kern_stack) {
+        event->kern_stack = bpf_get_stackid(ctx, &stacks, KERN_STACKID_FLAGS);
+    } else {
+        event->kern_stack = -1;
+    }
+
+    // ruby_current_vm_ptr->ractor.main_ractor->threads.running_ec is the execution context of the thread
+    // holding the GVL of the main ractor
+    void *vm;
+    if (bpf_probe_read_user(&vm, sizeof(vm), (void *) pid_data->vm_addr) || !vm) {
+        return interp_submit_error_sample(ctx, event, RB_ERROR_VM);
+    }
+    void *ractor;
+    if (bpf_probe_read_user(&ractor, sizeof(ractor), vm + state->offsets.Vm_ractor_main_ractor) || !ractor) {
+        return interp_submit_error_sample(ctx, event, RB_ERROR_VM);
+    }
+    void *ec;
+    if (bpf_probe_read_user(&ec, sizeof(ec), ractor + state->offsets.Ractor_threads_running_ec)) {
+        return interp_submit_error_sample(ctx, event, RB_ERROR_EC);
+    }
+    if (!ec) {
+        return interp_submit_error_sample(ctx, event, RB_ERROR_EC_NULL);
+    }
+    uint64_t vm_stack;
+    uint64_t vm_stack_size;
+    if (bpf_probe_read_user(&vm_stack, sizeof(vm_stack), ec + state->offsets.ExecutionContext_vm_stack) ||
+        bpf_probe_read_user(&vm_stack_size, sizeof(vm_stack_size), ec + state->offsets.ExecutionContext_vm_stack_size) ||
+        bpf_probe_read_user(&state->cfp, sizeof(state->cfp), ec + state->offsets.ExecutionContext_cfp)) {
+        return interp_submit_error_sample(ctx, event, RB_ERROR_EC);
+    }
+    // control frames are pushed from the end of the vm stack towards its beginning
+    state->stack_end = vm_stack + vm_stack_size * sizeof(uint64_t);
+
+    event->stack_status = INTERP_STACK_STATUS_COMPLETE;
+    event->stack_len = 0;
+
+    bpf_tail_call(ctx, &rb_progs, RB_PROG_IDX_READ_RUBY_STACK);
+    // we won't ever get here
+    return interp_submit_error_sample(ctx, event, RB_ERROR_GENERIC);
+}
+
+SEC("perf_event")
+int rbperf_collect(struct bpf_perf_event_data *ctx) {
+    u32 pid;
+    current_pid(&pid);
+    if (pid == 0) {
+        return 0;
+    }
+    return rbperf_collect_impl(ctx, (pid_t) pid, false);
+}
+
+SEC("perf_event")
+int read_ruby_stack(struct bpf_perf_event_data *ctx) {
+    GET_STATE();
+
+    state->rb_stack_prog_call_cnt++;
+    interp_event *event = &state->event;
+
+    interp_symbol sym = {};
+    int last_res;
+#pragma unroll
+    for (int i = 0; i < INTERP_STACK_FRAMES_PER_PROG; i++) {
+        last_res = get_frame_data(state, &sym);
+        if (last_res < 0) {
+            return interp_submit_error_sample(ctx, event, (uint8_t) (-last_res));
+        }
+        if (last_res == 0) {
+            break;
+        }
+        if (last_res == 1) {
+            interp_symbol_id symbol_id;
+            if (interp_get_symbol_id(&state->symbol_counter, state->cur_cpu, &sym, &symbol_id)) {
+                return interp_submit_error_sample(ctx, event, RB_ERROR_SYMBOL);
+            }
+            interp_push_symbol(event, symbol_id);
+        }
+    }
+
+    if (last_res == 0) {
+        event->stack_status = INTERP_STACK_STATUS_COMPLETE;
+    } else {
+        event->stack_status = INTERP_STACK_STATUS_TRUNCATED;
+    }
+
+    if (event->stack_status == INTERP_STACK_STATUS_TRUNCATED &&
+        state->rb_stack_prog_call_cnt < INTERP_STACK_PROG_CNT) {
+        // read next batch of frames
+        bpf_tail_call(ctx, &rb_progs, RB_PROG_IDX_READ_RUBY_STACK);
+        return -1;
+    }
+
+    return interp_submit_sample(ctx, event);
+}
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,77 @@
+#ifndef PYROSCOPE_SAMPLE_H
+#define PYROSCOPE_SAMPLE_H
+
+// shared by the programs aggregating frame pointer stacks: profile.bpf.c and offcpu.bpf.c
+
+#include "pid.h"
+
+// flags of sample_key, set when the thread or the cpu fields are collected
+#define SAMPLE_KEY_FLAG_THREAD 1
+#define SAMPLE_KEY_FLAG_CPU 2
+
+struct sample_key {
+    __u32 pid;
+    __u32 flags;
+    __s64 kern_stack;
+    __s64 user_stack;
+    __u32 tid;
+    __u32 cpu;
+    char comm[16];
+};
+
+// sample_config enables the per-thread and per-cpu fields of sample_key.
+// They are disabled by default to keep the number of keys low.
+struct sample_config {
+    __u8 collect_thread;
+    __u8 collect_cpu;
+    __u16 padding_;
+};
+
+#define PROFILING_TYPE_UNKNOWN 1
+#define PROFILING_TYPE_FRAMEPOINTERS 2
+#define PROFILING_TYPE_PYTHON 3
+#define PROFILING_TYPE_ERROR 4
+#define PROFILING_TYPE_RUBY 5
+#define PROFILING_TYPE_NODEJS 6
+
+struct pid_config {
+    uint8_t type;
+    uint8_t collect_user;
+    uint8_t collect_kernel;
+    uint8_t padding_;
+};
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, u32);
+    __type(value, struct pid_config);
+    __uint(max_entries, 1024);
+} pids SEC(".maps");
+
+struct {
+    __uint(type, BPF_MAP_TYPE_ARRAY);
+    __type(key, u32);
+    __type(value, struct sample_config);
+    __uint(max_entries, 1);
+} sample_settings SEC(".maps");
+
+// sample_key_collect_thread sets the thread and the cpu fields of the key from the current task,
+// if they are enabled in sample_settings.
+static __always_inline void sample_key_collect_thread(struct sample_key *key) {
+    u32 zero = 0;
+    struct sample_config *config = bpf_map_lookup_elem(&sample_settings, &zero);
+    if (config == NULL) {
+        return;
+    }
+    if (config->collect_thread) {
+        key->flags |= SAMPLE_KEY_FLAG_THREAD;
+        current_tid(&key->tid);
+        bpf_get_current_comm(key->comm, sizeof(key->comm));
+    }
+    if (config->collect_cpu) {
+        key->flags |= SAMPLE_KEY_FLAG_CPU;
+        key->cpu = bpf_get_smp_processor_id();
+    }
+}
+
+#endif // PYROSCOPE_SAMPLE_H
@@ -0,0 +1,373 @@
+// SPDX-License-Identifier: GPL-2.0-only
+
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+#include "bpf_tracing.h"
+
+#include "pid.h"
+#include "interp.h"
+
+enum {
+    V8_ERROR_GENERIC = 1,
+    V8_ERROR_REGS = 2,
+    V8_ERROR_SYMBOL = 3,
+};
+
+// only x64/arm64 builds without pointer compression are supported, which node builds are by default
+#define V8_HEAP_OBJECT_TAG 1
+#define V8_HEAP_OBJECT_TAG_MASK 3
+#define V8_SMI_SHIFT 32
+#define V8_SEQ_STRING_TAG 0
+
+// the number of cons/thin strings followed when reading a string
+#define V8_STRING_MAX_INDIRECTIONS 3
+// context locals are not expected to be inlined in the scope info above that anyway
+#define V8_SCOPE_INFO_MAX_CONTEXT_LOCALS 4096
+
+typedef struct {
+    int16_t FpFunction;
+    int16_t FpContext;
+    int16_t HeapObjectMap;
+    int16_t MapInstanceType;
+    int16_t JSFunctionShared;
+    int16_t SharedFunctionInfoNameOrScopeInfo;
+    int16_t SharedFunctionInfoScriptOrDebugInfo;
+    int16_t ScriptName;
+    int16_t StringLength;
+    int16_t SeqOneByteStringChars;
+    int16_t ConsStringFirst;
+    int16_t ThinStringActual;
+    int16_t ScopeInfoContextLocalCount;
+    int16_t ScopeInfoFirstVars;
+    uint16_t ScopeInfoMaxInlinedLocalNames; // 0 if context local names are always inlined
+    uint16_t FirstNonstringType;
+    uint16_t SharedFunctionInfoType;
+    uint16_t ScriptType;
+    uint16_t ScopeInfoType;
+    uint8_t StringRepresentationMask;
+    uint8_t StringEncodingMask;
+    uint8_t OneByteStringTag;
+    uint8_t ConsStringTag;
+    uint8_t ThinStringTag;
+} v8_offset_config;
+
+typedef struct {
+    v8_offset_config offsets;
+} v8_pid_data;
+
+typedef struct {
+    int64_t symbol_counter;
+    v8_offset_config offsets;
+    uint32_t cur_cpu;
+    uint64_t fp;
+    int64_t v8_stack_prog_call_cnt;
+    interp_event event;
+} v8_sample_state_t;
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __type(key, u32);
+    __type(value, v8_sample_state_t);
+    __uint(max_entries, 1);
+} v8_state_heap SEC(".maps");
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, pid_t);
+    __type(value, v8_pid_data);
+    __uint(max_entries, 10240);
+} v8_pid_config SEC(".maps");
+
+#define V8_PROG_IDX_READ_V8_STACK 0
+
+int read_v8_stack(struct bpf_perf_event_data *ctx);
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PROG_ARRAY);
+    __uint(max_entries, 2);
+    __type(key, int);
+    __array(values, int (void *));
+} v8_progs SEC(".maps") = {
+        .values = {
+                [V8_PROG_IDX_READ_V8_STACK] = (void *) &read_v8_stack,
+        },
+};
+
+static __always_inline v8_sample_state_t *get_state() {
+    int zero = 0;
+    return bpf_map_lookup_elem(&v8_state_heap, &zero);
+}
+
+#define GET_STATE()                      \
+  v8_sample_state_t* state = get_state(); \
+  if (!state) {                          \
+    return -1; /* should never happen */ \
+  }
+
+static __always_inline bool is_heap_object(uint64_t ptr) {
+    return (ptr & V8_HEAP_OBJECT_TAG_MASK) == V8_HEAP_OBJECT_TAG;
+}
+
+// read_field reads a tagged field of a heap object
+static __always_inline int read_field(uint64_t obj, int16_t offset, uint64_t *out) {
+    return bpf_probe_read_user(out, sizeof(*out), (void *) (obj - V8_HEAP_OBJECT_TAG + offset));
+}
+
+static __always_inline int read_instance_type(v8_offset_config *offsets, uint64_t obj, uint16_t *out) {
+    if (!is_heap_object(obj)) {
+        return -1;
+    }
+    uint64_t map;
+    if (read_field(obj, offsets->HeapObjectMap, &map) || !is_heap_object(map)) {
+        return -1;
+    }
+    return bpf_probe_read_user(out, sizeof(*out), (void *) (map - V8_HEAP_OBJECT_TAG + offsets->MapInstanceType));
+}
+
+// read_string copies a one byte sequential string, following thin and cons strings.
+// cons strings are truncated to their first part. two byte and external strings are not supported.
+static __always_inline int read_string(v8_offset_config *offsets, uint64_t str, char *dst, uint32_t size) {
+#pragma unroll
+    for (int i = 0; i < V8_STRING_MAX_INDIRECTIONS; i++) {
+        uint16_t typ;
+        if (read_instance_type(offsets, str, &typ)) {
+            return -1;
+        }
+        if (typ >= offsets->FirstNonstringType) {
+            return -1;
+        }
+        uint8_t repr = typ & offsets->StringRepresentationMask;
+        if (repr == offsets->ThinStringTag) {
+            if (read_field(str, offsets->ThinStringActual, &str)) {
+                return -1;
+            }
+            continue;
+        }
+        if (repr == offsets->ConsStringTag) {
+            if (read_field(str, offsets->ConsStringFirst, &str)) {
+                return -1;
+            }
+            continue;
+        }
+        if (repr != V8_SEQ_STRING_TAG || (typ & offsets->StringEncodingMask) != offsets->OneByteStringTag) {
+            return -1;
+        }
+        int32_t len;
+        if (bpf_probe_read_user(&len, sizeof(len), (void *) (str - V8_HEAP_OBJECT_TAG + offsets->StringLength))) {
+            return -1;
+        }
+        if (len <= 0) {
+            return 0;
+        }
+        uint32_t n = (uint32_t) len;
+        if (n > size - 1) {
+            n = size - 1;
+        }
+        // size is a power of two, the mask after the barrier keeps clang from dropping the bound the verifier needs
+        asm volatile("" : "+r"(n));
+        n &= size - 1;
+        if (bpf_probe_read_user(dst, n, (void *) (str - V8_HEAP_OBJECT_TAG + offsets->SeqOneByteStringChars))) {
+            return -1;
+        }
+        return 0;
+    }
+    return -1;
+}
+
+// read_scope_info_name reads the name of a compiled function from its ScopeInfo.
+// The function variable name follows the context local names and infos. If the function has no
+// function variable, it is the inferred name, if any.
+static __always_inline int read_scope_info_name(v8_offset_config *offsets, uint64_t scope_info, interp_symbol *sym) {
+    uint64_t raw_count;
+    if (read_field(scope_info, offsets->ScopeInfoContextLocalCount, &raw_count)) {
+        return -1;
+    }
+    uint64_t count = raw_count >> V8_SMI_SHIFT;
+    if (count > V8_SCOPE_INFO_MAX_CONTEXT_LOCALS) {
+        return -1;
+    }
+    uint64_t names = count;
+    if (offsets->ScopeInfoMaxInlinedLocalNames != 0 && count >= offsets->ScopeInfoMaxInlinedLocalNames) {
+        names = 1; // a hash table of names
+    }
+    int16_t slot = offsets->ScopeInfoFirstVars + (int16_t) ((names + count) * sizeof(uint64_t));
+#pragma unroll
+    for (int i = 0; i < 2; i++) {
+        uint64_t name;
+        if (read_field(scope_info, slot, &name)) {
+            return -1;
+        }
+        if (read_string(offsets, name, sym->name, sizeof(sym->name)) == 0) {
+            return 0;
+        }
+        slot += sizeof(uint64_t);
+    }
+    return -1;
+}
+
+static __always_inline void read_function_name(v8_offset_config *offsets, uint64_t sfi, interp_symbol *sym) {
+    uint64_t name_or_scope_info;
+    if (read_field(sfi, offsets->SharedFunctionInfoNameOrScopeInfo, &name_or_scope_info)) {
+        return;
+    }
+    uint16_t typ;
+    if (read_instance_type(offsets, name_or_scope_info, &typ)) {
+        return;
+    }
+    if (typ == offsets->ScopeInfoType) {
+        read_scope_info_name(offsets, name_or_scope_info, sym);
+        return;
+    }
+    read_string(offsets, name_or_scope_info, sym->name, sizeof(sym->name));
+}
+
+static __always_inline void read_script_name(v8_offset_config *offsets, uint64_t sfi, interp_symbol *sym) {
+    uint64_t script;
+    if (read_field(sfi, offsets->SharedFunctionInfoScriptOrDebugInfo, &script)) {
+        return;
+    }
+    uint16_t typ;
+    if (read_instance_type(offsets, script, &typ) || typ != offsets->ScriptType) {
+        return;
+    }
+    uint64_t name;
+    if (read_field(script, offsets->ScriptName, &name)) {
+        return;
+    }
+    read_string(offsets, name, sym->file, sizeof(sym->file));
+}
+
+// get_frame_data reads the function of the current frame and moves state->fp to the caller frame
+// returns 1 on success, 2 if the frame is not a javascript frame, 0 if no more frames
+static __always_inline int get_frame_data(v8_sample_state_t *state, interp_symbol *sym) {
+    v8_offset_config *offsets = &state->offsets;
+    uint64_t fp = state->fp;
+    if (fp == 0) {
+        return 0;
+    }
+    uint64_t next_fp;
+    if (bpf_probe_read_user(&next_fp, sizeof(next_fp), (void *) fp)) {
+        return 0;
+    }
+    // the stack grows down, so the caller frame is always above
+    state->fp = next_fp > fp ? next_fp : 0;
+
+    uint64_t context_or_frame_type;
+    if (bpf_probe_read_user(&context_or_frame_type, sizeof(context_or_frame_type), (void *) (fp + offsets->FpContext))) {
+        return 2;
+    }
+    // typed frames (entry, exit, stubs) keep a frame type marker there instead of a context
+    if (!is_heap_object(context_or_frame_type)) {
+        return 2;
+    }
+    uint64_t function;
+    if (bpf_probe_read_user(&function, sizeof(function), (void *) (fp + offsets->FpFunction))) {
+        return 2;
+    }
+    if (!is_heap_object(function)) {
+        return 2;
+    }
+    uint64_t sfi;
+    if (read_field(function, offsets->JSFunctionShared, &sfi)) {
+        return 2;
+    }
+    uint16_t typ;
+    if (read_instance_type(offsets, sfi, &typ) || typ != offsets->SharedFunctionInfoType) {
+        return 2;
+    }
+
+    __builtin_memset(sym, 0, sizeof(*sym));
+    read_function_name(offsets, sfi, sym);
+    read_script_name(offsets, sfi, sym);
+    return 1;
+}
+
+static __always_inline int v8perf_collect_impl(struct bpf_perf_event_data *ctx, pid_t pid, bool collect_kern_stack) {
+    v8_pid_data *pid_data = bpf_map_lookup_elem(&v8_pid_config, &pid);
+    if (!pid_data) {
+        return 0;
+    }
+
+    GET_STATE();
+
+    state->offsets = pid_data->offsets;
+    state->cur_cpu = bpf_get_smp_processor_id();
+    state->v8_stack_prog_call_cnt = 0;
+
+    interp_event *event = &state->event;
+    event->pid = pid;
+    if (collect_kern_stack) {
+        event->kern_stack = bpf_get_stackid(ctx, &stacks, KERN_STACKID_FLAGS);
+    } else {
+        event->kern_stack = -1;
+    }
+
+    // the sample may be taken in the kernel, so take the frame pointer from the user registers
+    struct task_struct *task = bpf_get_current_task_btf();
+    struct pt_regs *regs = (struct pt_regs *) bpf_task_pt_regs(task);
+    if (!regs) {
+        return interp_submit_error_sample(ctx, event, V8_ERROR_REGS);
+    }
+    if (bpf_probe_read_kernel(&state->fp, sizeof(state->fp), (void *) &PT_REGS_FP(regs))) {
+        return interp_submit_error_sample(ctx, event, V8_ERROR_REGS);
+    }
+
+    event->stack_status = INTERP_STACK_STATUS_COMPLETE;
+    event->stack_len = 0;
+
+    bpf_tail_call(ctx, &v8_progs, V8_PROG_IDX_READ_V8_STACK);
+    // we won't ever get here
+    return interp_submit_error_sample(ctx, event, V8_ERROR_GENERIC);
+}
+
+SEC("perf_event")
+int v8perf_collect(struct bpf_perf_event_data *ctx) {
+    u32 pid;
+    current_pid(&pid);
+    if (pid == 0) {
+        return 0;
+    }
+    return v8perf_collect_impl(ctx, (pid_t) pid, false);
+}
+
+SEC("perf_event")
+int read_v8_stack(struct bpf_perf_event_data *ctx) {
+    GET_STATE();
+
+    state->v8_stack_prog_call_cnt++;
+    interp_event *event = &state->event;
+
+    interp_symbol sym = {};
+    int last_res;
+#pragma unroll
+    for (int i = 0; i < INTERP_STACK_FRAMES_PER_PROG; i++) {
+        last_res = get_frame_data(state, &sym);
+        if (last_res == 0) {
+            break;
+        }
+        if (last_res == 1) {
+            interp_symbol_id symbol_id;
+            if (interp_get_symbol_id(&state->symbol_counter, state->cur_cpu, &sym, &symbol_id)) {
+                return interp_submit_error_sample(ctx, event, V8_ERROR_SYMBOL);
+            }
+            interp_push_symbol(event, symbol_id);
+        }
+    }
+
+    if (last_res == 0) {
+        event->stack_status = INTERP_STACK_STATUS_COMPLETE;
+    } else {
+        event->stack_status = INTERP_STACK_STATUS_TRUNCATED;
+    }
+
+    if (event->stack_status == INTERP_STACK_STATUS_TRUNCATED &&
+        state->v8_stack_prog_call_cnt < INTERP_STACK_PROG_CNT) {
+        // read next batch of frames
+        bpf_tail_call(ctx, &v8_progs, V8_PROG_IDX_READ_V8_STACK);
+        return -1;
+    }
+
+    return interp_submit_sample(ctx, event);
+}
+
+char _license[] SEC("license") = "GPL";
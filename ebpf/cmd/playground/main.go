@@ -77,10 +77,10 @@ func main() {
 
 func collectProfiles(profiles chan *pushv1.PushRequest) {
 	builders := pprof.NewProfileBuilders(int64(config.SampleRate))
-	err := session.CollectProfiles(func(target *sd.Target, stack []string, value uint64, pid uint32) {
-		labelsHash, labels := target.Labels()
-		builder := builders.BuilderForTarget(labelsHash, labels)
-		builder.AddSample(stack, value)
+	err := session.CollectProfileSamples(func(sample ebpfspy.ProfileSample) {
+		labelsHash, labels := sample.Target.Labels()
+		builder := builders.BuilderForSampleType(labelsHash, labels, sample.SampleType)
+		builder.AddSampleWithLabels(sample.Stack, sample.Lines, sample.Labels, sample.Value)
 	})
 
 	if err != nil {
@@ -146,6 +146,7 @@ func convertTargetOptions() sd.TargetsOptions {
 		Targets:            relabelProcessTargets(getProcessTargets(), config.RelabelConfig),
 		DefaultTarget:      config.DefaultTarget,
 		ContainerCacheSize: config.ContainerCacheSize,
+		Metadata:           sd.MetadataOptions{Enabled: config.ProcessMetadata},
 	}
 }
 
@@ -157,8 +158,14 @@ func convertSessionOptions() ebpfspy.SessionOptions {
 		UnknownSymbolAddress:      config.UnknownSymbolAddress,
 		UnknownSymbolModuleOffset: config.UnknownSymbolModuleOffset,
 		PythonEnabled:             config.PythonEnabled,
+		RubyEnabled:               config.RubyEnabled,
+		NodejsEnabled:             config.NodejsEnabled,
 		Metrics:                   metrics,
 		CacheOptions:              config.CacheOptions,
+		OffCPU:                    config.OffCPU,
+		OffCPUMinBlockDuration:    config.OffCPUMinBlockDuration,
+		ThreadLabels:              config.ThreadLabels,
+		CPULabels:                 config.CPULabels,
 	}
 }
 
@@ -187,6 +194,8 @@ var defaultConfig = Config{
 	UnknownSymbolModuleOffset: true,
 	UnknownSymbolAddress:      true,
 	PythonEnabled:             true,
+	RubyEnabled:               true,
+	NodejsEnabled:             true,
 	CacheOptions: symtab.CacheOptions{
 		SymbolOptions: symtab.SymbolOptions{
 			GoTableFallback:    true,
@@ -219,12 +228,19 @@ type Config struct {
 	UnknownSymbolModuleOffset bool
 	UnknownSymbolAddress      bool
 	PythonEnabled             bool
+	RubyEnabled               bool
+	NodejsEnabled             bool
 	CacheOptions              symtab.CacheOptions
 	SampleRate                int
 	TargetsOnly               bool
 	DefaultTarget             map[string]string
 	ContainerCacheSize        int
+	ProcessMetadata           bool
 	RelabelConfig             []*RelabelConfig
+	OffCPU                    bool
+	OffCPUMinBlockDuration    time.Duration
+	ThreadLabels              bool
+	CPULabels                 bool
 }
 
 type RelabelConfig struct {
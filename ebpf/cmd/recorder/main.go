@@ -0,0 +1,168 @@
+//go:build linux
+
+// Command recorder runs the eBPF profiler without a Pyroscope server and records the profiles to local files,
+// for example for incident forensics on isolated hosts. The recorded profiles can be pushed later with
+//
+//	profilecli upload --dir <dir>
+//
+// which preserves their timestamps and labels.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	ebpfspy "github.com/grafana/pyroscope/ebpf"
+	ebpfmetrics "github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/pprof"
+	"github.com/grafana/pyroscope/ebpf/sd"
+	"github.com/grafana/pyroscope/ebpf/symtab"
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dir            = flag.String("dir", "", "directory to record the profiles to")
+	interval       = flag.Duration("interval", 15*time.Second, "collection interval, a new profile file is written per target every interval")
+	maxDiskBytes   = flag.Int64("max-disk-bytes", 1<<30, "disk budget of the recorded profiles, the oldest profiles are removed when it is exceeded, 0 for no limit")
+	maxAge         = flag.Duration("max-age", 24*time.Hour, "retention of the recorded profiles, 0 for no limit")
+	sampleRate     = flag.Int("sample-rate", 97, "sample rate in Hz")
+	collectKernel  = flag.Bool("collect-kernel", true, "collect kernel stacks")
+	pythonEnabled  = flag.Bool("python", true, "enable the python unwinder")
+	offCPU         = flag.Bool("off-cpu", false, "enable off-cpu profiling")
+	threadLabels   = flag.Bool("thread-labels", false, "add the thread id and name sample labels")
+	cpuLabels      = flag.Bool("cpu-labels", false, "add the cpu sample labels")
+	serviceName    = flag.String("service-name", "", "service_name of the processes without a systemd unit or a container name")
+	verbose        = flag.Bool("verbose", false, "enable debug logging")
+	containerCache = flag.Int("container-cache-size", 1024, "size of the pid to container id cache")
+	debuginfodURLs = flag.String("debuginfod-urls", "", "comma separated URLs of the debuginfod servers the debug files missing on the host are downloaded from, empty to disable")
+	debuginfodDir  = flag.String("debuginfod-cache-dir", filepath.Join(os.TempDir(), "pyroscope-debuginfod"), "directory of the debug files downloaded from the debuginfod servers, outside of the recording directory")
+)
+
+func main() {
+	flag.Parse()
+
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	if !*verbose {
+		logger = level.NewFilter(logger, level.AllowInfo())
+	}
+	if err := run(logger); err != nil {
+		_ = level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger log.Logger) error {
+	writer, err := pprof.NewDirWriter(pprof.DirWriterOptions{
+		Dir:      *dir,
+		MaxBytes: *maxDiskBytes,
+		MaxAge:   *maxAge,
+	})
+	if err != nil {
+		return err
+	}
+
+	defaultTarget := sd.DiscoveryTarget{}
+	if *serviceName != "" {
+		defaultTarget["service_name"] = *serviceName
+	}
+	targetFinder, err := sd.NewTargetFinder(os.DirFS("/"), logger, sd.TargetsOptions{
+		DefaultTarget:      defaultTarget,
+		ContainerCacheSize: *containerCache,
+		Metadata:           sd.MetadataOptions{Enabled: true},
+	})
+	if err != nil {
+		return fmt.Errorf("ebpf target finder create: %w", err)
+	}
+	session, err := ebpfspy.NewSession(logger, targetFinder, sessionOptions())
+	if err != nil {
+		return fmt.Errorf("ebpf session create: %w", err)
+	}
+	if err = session.Start(); err != nil {
+		return fmt.Errorf("ebpf session start: %w", err)
+	}
+	defer session.Stop()
+	_ = level.Info(logger).Log("msg", "recording profiles", "dir", *dir)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err = record(session, writer); err != nil {
+				_ = level.Error(logger).Log("msg", "recording profiles", "err", err)
+			}
+		case <-signals:
+			// flush the samples collected since the last tick
+			return record(session, writer)
+		}
+	}
+}
+
+func record(session ebpfspy.Session, writer *pprof.DirWriter) error {
+	builders := pprof.NewProfileBuilders(int64(*sampleRate))
+	err := session.CollectProfileSamples(func(sample ebpfspy.ProfileSample) {
+		labelsHash, labels := sample.Target.Labels()
+		builder := builders.BuilderForSampleType(labelsHash, labels, sample.SampleType)
+		builder.AddSampleWithLabels(sample.Stack, sample.Lines, sample.Labels, sample.Value)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Write(builders)
+}
+
+func sessionOptions() ebpfspy.SessionOptions {
+	return ebpfspy.SessionOptions{
+		CollectUser:               true,
+		CollectKernel:             *collectKernel,
+		UnknownSymbolModuleOffset: true,
+		UnknownSymbolAddress:      true,
+		PythonEnabled:             *pythonEnabled,
+		SampleRate:                *sampleRate,
+		Metrics:                   ebpfmetrics.New(prometheus.NewRegistry()),
+		OffCPU:                    *offCPU,
+		OffCPUMinBlockDuration:    time.Millisecond,
+		ThreadLabels:              *threadLabels,
+		CPULabels:                 *cpuLabels,
+		CacheOptions: symtab.CacheOptions{
+			SymbolOptions: symtab.SymbolOptions{
+				GoTableFallback: true,
+				DemangleOptions: elf.DemangleFull,
+			},
+			PidCacheOptions: symtab.GCacheOptions{
+				Size:       239,
+				KeepRounds: 8,
+			},
+			BuildIDCacheOptions: symtab.GCacheOptions{
+				Size:       239,
+				KeepRounds: 8,
+			},
+			SameFileCacheOptions: symtab.GCacheOptions{
+				Size:       239,
+				KeepRounds: 8,
+			},
+			DebuginfodOptions: debuginfodOptions(),
+		},
+	}
+}
+
+func debuginfodOptions() symtab.DebuginfodOptions {
+	if *debuginfodURLs == "" {
+		return symtab.DebuginfodOptions{}
+	}
+	options := symtab.DefaultDebuginfodOptions
+	options.URLs = strings.Split(*debuginfodURLs, ",")
+	options.CacheDir = *debuginfodDir
+	return options
+}
@@ -0,0 +1,77 @@
+// ruby_dwarfdump generates the ruby offsets table (ruby/versions_gen.go) from the DWARF debug info of
+// libruby or statically linked ruby binaries built with debug info
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/pyroscope/ebpf/ruby"
+)
+
+type entry struct {
+	version ruby.Version
+	path    string
+	offsets *ruby.UserOffsets
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Please provide ruby / libruby elf files.")
+		return
+	}
+	var es []entry
+	for _, fp := range os.Args[1:] {
+		es = append(es, dumpOne(fp))
+	}
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].version.Compare(&es[j].version) < 0
+	})
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(`// Code generated by ruby_dwarfdump. DO NOT EDIT.
+package ruby
+
+var rbVersions = map[Version]*UserOffsets{
+`)
+	for _, e := range es {
+		fmt.Fprintf(buf, "// %s %s\n", e.version, e.path)
+		fmt.Fprintf(buf, "{%d, %d, %d}: {\n", e.version.Major, e.version.Minor, e.version.Patch)
+		v := reflect.ValueOf(e.offsets).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			fmt.Fprintf(buf, "%s: %d,\n", v.Type().Field(i).Name, v.Field(i).Interface())
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(src))
+}
+
+func dumpOne(fp string) entry {
+	f, err := elf.Open(fp)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	version, _, err := ruby.GetRubySymbols(f)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	d, err := f.DWARF()
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	offsets, err := ruby.OffsetsFromDWARF(d)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	return entry{version: version, path: fp, offsets: offsets}
+}
@@ -0,0 +1,76 @@
+// v8dbg_dump generates the nodejs offsets table (nodejs/versions_gen.go) from the v8dbg_ symbols of node binaries
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/pyroscope/ebpf/nodejs"
+)
+
+type entry struct {
+	version nodejs.Version
+	path    string
+	offsets *nodejs.UserOffsets
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Please provide node / libnode elf files.")
+		return
+	}
+	var es []entry
+	for _, fp := range os.Args[1:] {
+		es = append(es, dumpOne(fp))
+	}
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].version.Compare(&es[j].version) < 0
+	})
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(`// Code generated by v8dbg_dump. DO NOT EDIT.
+package nodejs
+
+var nodeVersions = map[Version]*UserOffsets{
+`)
+	for _, e := range es {
+		fmt.Fprintf(buf, "// %s %s\n", e.version, e.path)
+		fmt.Fprintf(buf, "{%d, %d, %d}: {\n", e.version.Major, e.version.Minor, e.version.Patch)
+		v := reflect.ValueOf(e.offsets).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			fmt.Fprintf(buf, "%s: %d,\n", v.Type().Field(i).Name, v.Field(i).Interface())
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(src))
+}
+
+func dumpOne(fp string) entry {
+	f, err := os.Open(fp)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	version, err := nodejs.GetNodeVersion(f)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	ef, err := elf.NewFile(f)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	offsets, err := nodejs.OffsetsFromV8dbg(ef)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fp, err))
+	}
+	return entry{version: version, path: fp, offsets: offsets}
+}
@@ -18,6 +18,7 @@ require (
 	github.com/prometheus/prometheus v0.47.2
 	github.com/samber/lo v1.38.1
 	github.com/stretchr/testify v1.8.4
+	github.com/ulikunitz/xz v0.5.15
 	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1
 	golang.org/x/sys v0.13.0
 )
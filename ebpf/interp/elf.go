@@ -0,0 +1,45 @@
+package interp
+
+import (
+	"debug/elf"
+	"fmt"
+	"io"
+)
+
+// ReadSymbolData reads size bytes of a data symbol from the elf file.
+// Symbols in a NOBITS section, like .bss, are read as zeroes.
+func ReadSymbolData(f *elf.File, sym *elf.Symbol, size int) ([]byte, error) {
+	if int(sym.Section) >= len(f.Sections) || sym.Section == elf.SHN_UNDEF {
+		return nil, fmt.Errorf("symbol %s has no section", sym.Name)
+	}
+	sec := f.Sections[sym.Section]
+	if sym.Value < sec.Addr || sym.Value+uint64(size) > sec.Addr+sec.Size {
+		return nil, fmt.Errorf("symbol %s is out of section %s", sym.Name, sec.Name)
+	}
+	res := make([]byte, size)
+	if sec.Type == elf.SHT_NOBITS {
+		return res, nil
+	}
+	if _, err := sec.ReadAt(res, int64(sym.Value-sec.Addr)); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("reading symbol %s: %w", sym.Name, err)
+	}
+	return res, nil
+}
+
+// LookupSymbols looks up the symbols for which match returns true in the dynamic symbols
+// and then in the static symbols of the elf file.
+func LookupSymbols(f *elf.File, match func(name string) bool) map[string]*elf.Symbol {
+	res := make(map[string]*elf.Symbol)
+	dynamic, _ := f.DynamicSymbols()
+	static, _ := f.Symbols()
+	for _, symbols := range [][]elf.Symbol{dynamic, static} {
+		for i := range symbols {
+			sym := &symbols[i]
+			if _, ok := res[sym.Name]; ok || sym.Section == elf.SHN_UNDEF || !match(sym.Name) {
+				continue
+			}
+			res[sym.Name] = sym
+		}
+	}
+	return res
+}
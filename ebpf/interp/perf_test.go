@@ -0,0 +1,64 @@
+package interp
+
+import (
+	"encoding/binary"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEvent(t *testing.T) {
+	require.Equal(t, 408, int(unsafe.Sizeof(Event{})))
+	require.Equal(t, 192, int(unsafe.Sizeof(Symbol{})))
+	raw := make([]byte, 408)
+	raw[0] = 2                                                 //	StackStatus uint8
+	raw[1] = 42                                                //	Err         uint8
+	raw[2] = 0xef                                              //	Reserved2   uint8
+	raw[3] = 0xfe                                              //	Reserved3   uint8
+	binary.LittleEndian.PutUint32(raw[4:], 0xcafebabe)         //	Pid         uint32
+	binary.LittleEndian.PutUint64(raw[8:], 0x7acecefadeadbeef) //	KernStack   int64
+	binary.LittleEndian.PutUint32(raw[16:], 96)                //	StackLen    uint32
+	for i := 0; i < 96; i++ {
+		binary.LittleEndian.PutUint32(raw[20+i*4:], 0xcafe000+uint32(i)) //	Stack       [96]uint32
+	}
+	event := new(Event)
+	err := ReadEvent(raw, event)
+	require.NoError(t, err)
+	require.Equal(t, event.StackStatus, uint8(2))
+	require.Equal(t, event.Err, uint8(42))
+	require.Equal(t, event.Reserved2, uint8(0xef))
+	require.Equal(t, event.Reserved3, uint8(0xfe))
+	require.Equal(t, event.Pid, uint32(0xcafebabe))
+	require.Equal(t, event.KernStack, int64(0x7acecefadeadbeef))
+	require.Equal(t, event.StackLen, uint32(96))
+	for i := 0; i < 96; i++ {
+		require.Equal(t, event.Stack[i], 0xcafe000+uint32(i))
+	}
+}
+
+func TestReadEventError(t *testing.T) {
+	raw := make([]byte, 16)
+	raw[0] = 1                                                 //	StackStatus uint8
+	raw[1] = 42                                                //	Err         uint8
+	binary.LittleEndian.PutUint32(raw[4:], 0xcafebabe)         //	Pid         uint32
+	binary.LittleEndian.PutUint64(raw[8:], 0x7acecefadeadbeef) //	KernStack   int64
+	event := new(Event)
+	err := ReadEvent(raw, event)
+	require.NoError(t, err)
+	require.Equal(t, event.StackStatus, uint8(1))
+	require.Equal(t, event.Err, uint8(42))
+	require.Equal(t, event.Pid, uint32(0xcafebabe))
+	require.Equal(t, event.KernStack, int64(0x7acecefadeadbeef))
+	require.Equal(t, event.StackLen, uint32(0))
+}
+
+func TestReadEventInvalid(t *testing.T) {
+	event := new(Event)
+	require.Error(t, ReadEvent(nil, event))
+	require.Error(t, ReadEvent(make([]byte, 100), event))
+
+	raw := make([]byte, 408)
+	binary.LittleEndian.PutUint32(raw[16:], 97)
+	require.Error(t, ReadEvent(raw, event))
+}
@@ -0,0 +1,56 @@
+// Package interp contains the parts shared by the eBPF interpreter unwinders of the ruby and nodejs packages:
+// the perf events reader and the symbols map. The layout of Event and Symbol mirrors bpf/interp.h.
+package interp
+
+import "fmt"
+
+/*
+enum {
+    INTERP_STACK_STATUS_COMPLETE = 0,
+    INTERP_STACK_STATUS_ERROR = 1,
+    INTERP_STACK_STATUS_TRUNCATED = 2,
+};
+*/
+
+type StackStatus uint8
+
+var (
+	StackStatusComplete  StackStatus = 0
+	StackStatusError     StackStatus = 1
+	StackStatusTruncated StackStatus = 2
+)
+
+func (s StackStatus) String() string {
+	switch s {
+	case StackStatusComplete:
+		return "StackStatusComplete"
+	case StackStatusError:
+		return "StackStatusError"
+	case StackStatusTruncated:
+		return "StackStatusTruncated"
+	default:
+		return fmt.Sprintf("StackStatus(%d)", s)
+	}
+}
+
+// StackMaxLen is INTERP_STACK_MAX_LEN
+const StackMaxLen = 96
+
+// Event is interp_event
+type Event struct {
+	StackStatus uint8
+	Err         uint8
+	Reserved2   uint8
+	Reserved3   uint8
+	Pid         uint32
+	KernStack   int64
+	StackLen    uint32
+	Stack       [StackMaxLen]uint32
+	_           [4]byte
+}
+
+// Symbol is interp_symbol
+type Symbol struct {
+	Name [64]int8
+	File [128]int8
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// InterpreterMetrics are the metrics of an interpreter unwinder, like pyperf, rbperf or v8perf.
+type InterpreterMetrics struct {
+	PidDataError       *prometheus.CounterVec
+	LostSamples        prometheus.Counter
+	SymbolLookup       *prometheus.CounterVec
+	UnknownSymbols     *prometheus.CounterVec
+	StacktraceError    prometheus.Counter
+	ProcessInitSuccess *prometheus.CounterVec
+	Load               prometheus.Counter
+	LoadError          prometheus.Counter
+}
+
+func NewInterpreterMetrics(reg prometheus.Registerer, name string) *InterpreterMetrics {
+	m := &InterpreterMetrics{
+		PidDataError: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_pid_data_errors_total",
+			Help: "Total number of errors while trying to collect interpreter data (offsets and memory values) from a running process",
+		}, []string{"service_name"}),
+		LostSamples: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_lost_samples_total",
+			Help: "Total number of samples that were lost due to a buffer overflow",
+		}),
+		SymbolLookup: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_symbol_lookup_total",
+			Help: "Total number of symbol lookups",
+		}, []string{"service_name"}),
+		StacktraceError: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_stacktrace_errors_total",
+			Help: "Total number of errors while trying to collect stacktrace",
+		}),
+		UnknownSymbols: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_unknown_symbols_total",
+			Help: "Total number of unknown symbols",
+		}, []string{"service_name"}),
+		ProcessInitSuccess: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_process_init_success_total",
+			Help: "Total number of successful init calls",
+		}, []string{"service_name"}),
+		Load: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_load",
+			Help: "Total number of " + name + " loads",
+		}),
+		LoadError: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_" + name + "_load_error_total",
+			Help: "Total number of " + name + " load errors",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.PidDataError,
+			m.LostSamples,
+			m.SymbolLookup,
+			m.StacktraceError,
+			m.UnknownSymbols,
+			m.ProcessInitSuccess,
+		)
+	}
+
+	return m
+}
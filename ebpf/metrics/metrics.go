@@ -5,12 +5,16 @@ import "github.com/prometheus/client_golang/prometheus"
 type Metrics struct {
 	Symtab *SymtabMetrics
 	Python *PythonMetrics
+	Ruby   *InterpreterMetrics
+	Nodejs *InterpreterMetrics
 }
 
 func New(reg prometheus.Registerer) *Metrics {
 	res := &Metrics{
 		Symtab: NewSymtabMetrics(reg),
 		Python: NewPythonMetrics(reg),
+		Ruby:   NewInterpreterMetrics(reg, "rbperf"),
+		Nodejs: NewInterpreterMetrics(reg, "v8perf"),
 	}
 	if reg != nil {
 		reg.MustRegister()
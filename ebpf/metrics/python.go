@@ -2,16 +2,8 @@ package metrics
 
 import "github.com/prometheus/client_golang/prometheus"
 
-type PythonMetrics struct {
-	PidDataError       *prometheus.CounterVec
-	LostSamples        prometheus.Counter
-	SymbolLookup       *prometheus.CounterVec
-	UnknownSymbols     *prometheus.CounterVec
-	StacktraceError    prometheus.Counter
-	ProcessInitSuccess *prometheus.CounterVec
-	Load               prometheus.Counter
-	LoadError          prometheus.Counter
-}
+// PythonMetrics are the metrics of pyperf, the same as the ones of the other interpreter unwinders.
+type PythonMetrics = InterpreterMetrics
 
 func NewPythonMetrics(reg prometheus.Registerer) *PythonMetrics {
 	m := &PythonMetrics{
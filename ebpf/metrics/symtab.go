@@ -9,6 +9,16 @@ type SymtabMetrics struct {
 	UnknownSymbols *prometheus.CounterVec
 	UnknownModules *prometheus.CounterVec
 	UnknownStacks  *prometheus.CounterVec
+
+	PerfMapErrors  *prometheus.CounterVec
+	PerfMapLoads   prometheus.Counter
+	PerfMapResets  prometheus.Counter
+	PerfMapSymbols prometheus.Counter
+	PerfMapLookups *prometheus.CounterVec
+
+	DebuginfodFetches        *prometheus.CounterVec
+	DebuginfodCacheHits      prometheus.Counter
+	DebuginfodCacheEvictions prometheus.Counter
 }
 
 func NewSymtabMetrics(reg prometheus.Registerer) *SymtabMetrics {
@@ -37,6 +47,38 @@ func NewSymtabMetrics(reg prometheus.Registerer) *SymtabMetrics {
 			Name: "pyroscope_symtab_unknown_stacks_total",
 			Help: "Total number of stacks with unknowns > knowns",
 		}, []string{"service_name"}),
+		PerfMapErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_perf_map_errors_total",
+			Help: "Total number of errors while trying to read a /tmp/perf-PID.map file",
+		}, []string{"error"}),
+		PerfMapLoads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_perf_map_loads_total",
+			Help: "Total number of incremental perf map reads that loaded new symbols",
+		}),
+		PerfMapResets: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_perf_map_resets_total",
+			Help: "Total number of perf map files reloaded from scratch because they were truncated or replaced",
+		}),
+		PerfMapSymbols: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_perf_map_symbols_total",
+			Help: "Total number of symbols loaded from perf map files",
+		}),
+		PerfMapLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_perf_map_lookups_total",
+			Help: "Total number of perf map lookups for anonymous executable memory, by result",
+		}, []string{"result"}),
+		DebuginfodFetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_debuginfod_fetches_total",
+			Help: "Total number of debug files requested from debuginfod servers, by result",
+		}, []string{"result"}),
+		DebuginfodCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_debuginfod_cache_hits_total",
+			Help: "Total number of debug files found in the debuginfod on-disk cache",
+		}),
+		DebuginfodCacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pyroscope_symtab_debuginfod_cache_evictions_total",
+			Help: "Total number of debug files removed from the debuginfod on-disk cache to keep it under the size limit",
+		}),
 	}
 
 	if reg != nil {
@@ -47,6 +89,14 @@ func NewSymtabMetrics(reg prometheus.Registerer) *SymtabMetrics {
 			m.UnknownSymbols,
 			m.UnknownModules,
 			m.UnknownStacks,
+			m.PerfMapErrors,
+			m.PerfMapLoads,
+			m.PerfMapResets,
+			m.PerfMapSymbols,
+			m.PerfMapLookups,
+			m.DebuginfodFetches,
+			m.DebuginfodCacheHits,
+			m.DebuginfodCacheEvictions,
 		)
 	}
 
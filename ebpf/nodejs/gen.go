@@ -0,0 +1,4 @@
+package nodejs
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -type v8_offset_config -type v8_pid_data -target amd64 -cc clang -cflags "-O2 -Wall -fpie -Wno-unused-variable -Wno-unused-function" Perf ../bpf/v8perf.bpf.c -- -I../bpf/libbpf -I../bpf/vmlinux/
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -type v8_offset_config -type v8_pid_data -target arm64 -cc clang -cflags "-O2 -Wall -fpie -Wno-unused-variable -Wno-unused-function" Perf ../bpf/v8perf.bpf.c -- -I../bpf/libbpf -I../bpf/vmlinux/
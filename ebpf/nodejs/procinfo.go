@@ -0,0 +1,44 @@
+package nodejs
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/pyroscope/ebpf/symtab"
+)
+
+type ProcInfo struct {
+	NodeMaps    []*symtab.ProcMap
+	LibNodeMaps []*symtab.ProcMap
+}
+
+var reNode = regexp.MustCompile("/.*/(?:(node(?:js)?)|(libnode\\.so(?:\\.\\d+)*))$")
+
+// GetProcInfo parses /proc/pid/map of a node process.
+func GetProcInfo(s *bufio.Scanner) (ProcInfo, error) {
+	res := ProcInfo{}
+	for s.Scan() {
+		line := s.Bytes()
+		m, err := symtab.ParseProcMapLine(line, false)
+		if err != nil {
+			return res, err
+		}
+		if m.Pathname == "" {
+			continue
+		}
+		matches := reNode.FindStringSubmatch(m.Pathname)
+		if matches == nil {
+			continue
+		}
+		if matches[1] != "" {
+			res.NodeMaps = append(res.NodeMaps, m)
+		} else {
+			res.LibNodeMaps = append(res.LibNodeMaps, m)
+		}
+	}
+	if res.LibNodeMaps == nil && res.NodeMaps == nil {
+		return res, fmt.Errorf("no node found")
+	}
+	return res, nil
+}
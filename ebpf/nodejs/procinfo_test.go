@@ -0,0 +1,47 @@
+package nodejs
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeProcInfo(t *testing.T) {
+	maps := `00400000-00c4f000 r--p 00000000 fd:01 1061582                            /usr/bin/node
+00c4f000-03c4a000 r-xp 0084f000 fd:01 1061582                            /usr/bin/node
+03c4a000-03c4f000 r--p 0384a000 fd:01 1061582                            /usr/bin/node
+03c4f000-03c6b000 rw-p 0384f000 fd:01 1061582                            /usr/bin/node
+04c7a000-05134000 rw-p 00000000 00:00 0                                  [heap]
+7f7b8c000000-7f7b8c021000 rw-p 00000000 00:00 0
+7f7b93e00000-7f7b93e28000 r--p 00000000 fd:01 1057232                    /usr/lib/x86_64-linux-gnu/libc.so.6
+7f7b93e28000-7f7b93fbd000 r-xp 00028000 fd:01 1057232                    /usr/lib/x86_64-linux-gnu/libc.so.6
+7ffd0b8c2000-7ffd0b8e3000 rw-p 00000000 00:00 0                          [stack]`
+	info, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.NoError(t, err)
+	require.Len(t, info.NodeMaps, 4)
+	require.Nil(t, info.LibNodeMaps)
+	require.Equal(t, "/usr/bin/node", info.NodeMaps[0].Pathname)
+}
+
+func TestLibNodeProcInfo(t *testing.T) {
+	maps := `55d0e5b6d000-55d0e5b6e000 r--p 00000000 fd:01 2889                       /usr/bin/nodejs
+55d0e5b6e000-55d0e5b6f000 r-xp 00001000 fd:01 2889                       /usr/bin/nodejs
+7f2b6a000000-7f2b6a7f4000 r--p 00000000 fd:01 5561                       /usr/lib/x86_64-linux-gnu/libnode.so.108
+7f2b6a7f4000-7f2b6c1f2000 r-xp 007f4000 fd:01 5561                       /usr/lib/x86_64-linux-gnu/libnode.so.108
+7f2b6c1f2000-7f2b6c2a0000 r--p 021f2000 fd:01 5561                       /usr/lib/x86_64-linux-gnu/libnode.so.108
+7f2b6e200000-7f2b6e228000 r--p 00000000 fd:01 1057232                    /usr/lib/x86_64-linux-gnu/libc.so.6`
+	info, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.NoError(t, err)
+	require.Len(t, info.NodeMaps, 2)
+	require.Len(t, info.LibNodeMaps, 3)
+	require.Equal(t, "/usr/lib/x86_64-linux-gnu/libnode.so.108", info.LibNodeMaps[0].Pathname)
+}
+
+func TestNotNodeProcInfo(t *testing.T) {
+	maps := `55bb7ceb9000-55bb7ceba000 r--p 00000000 fd:01 57017250                   /usr/bin/nodemon-helper
+7f94a6200000-7f94a6222000 r--p 00000000 fd:01 45643280                   /usr/lib/x86_64-linux-gnu/libc.so.6`
+	_, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.Error(t, err)
+}
@@ -0,0 +1,36 @@
+// v8dbg_ symbols of node v20.19.5, built into v8dbg.o with
+//   gcc -c -O0 -o v8dbg.o v8dbg.c
+// the release url is there for GetNodeVersion
+const char release_url[] = "https://nodejs.org/download/release/v20.19.5/node-v20.19.5.tar.gz";
+
+int v8dbg_SmiShiftSize = 31;
+int v8dbg_HeapObjectTag = 1;
+int v8dbg_HeapObjectTagMask = 3;
+int v8dbg_off_fp_function = -16;
+int v8dbg_off_fp_context_or_frame_type = -8;
+int v8dbg_off_fp_context = -8;
+int v8dbg_class_HeapObject__map__Map;
+int v8dbg_class_Map__instance_type__uint16_t = 12;
+int v8dbg_class_JSFunction__shared__SharedFunctionInfo = 24;
+int v8dbg_class_SharedFunctionInfo__name_or_scope_info__Object = 16;
+int v8dbg_class_SharedFunctionInfo__script_or_debug_info__HeapObject = 32;
+int v8dbg_class_Script__name__Object = 16;
+int v8dbg_class_String__length__int32_t = 12;
+int v8dbg_class_SeqOneByteString__chars__char = 16;
+int v8dbg_class_ConsString__first__String = 16;
+int v8dbg_class_ConsString__second__String = 24;
+int v8dbg_class_ThinString__actual__String = 16;
+int v8dbg_scopeinfo_idx_ncontextlocals = 2;
+int v8dbg_scopeinfo_idx_first_vars = 3;
+int v8dbg_scopeinfo_idx_nparams = 1;
+int v8dbg_FirstNonstringType = 128;
+int v8dbg_type_SharedFunctionInfo__SHARED_FUNCTION_INFO_TYPE = 262;
+int v8dbg_type_Script__SCRIPT_TYPE = 167;
+int v8dbg_type_ScopeInfo__SCOPE_INFO_TYPE = 261;
+int v8dbg_StringRepresentationMask = 7;
+int v8dbg_StringEncodingMask = 8;
+int v8dbg_OneByteStringTag = 8;
+int v8dbg_ConsStringTag = 1;
+int v8dbg_ThinStringTag = 5;
+int v8dbg_SeqStringTag;
+int v8dbg_parent_ScopeInfo__HeapObject;
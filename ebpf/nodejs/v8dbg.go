@@ -0,0 +1,131 @@
+package nodejs
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/pyroscope/ebpf/interp"
+)
+
+const v8dbgPrefix = "v8dbg_"
+
+// scopeInfoMaxInlinedLocalNames is kScopeInfoMaxInlinedLocalNamesSize, context local names of a ScopeInfo
+// are stored in a hash table above that since V8 10 (node 18)
+const scopeInfoMaxInlinedLocalNames = 75
+
+// OffsetsFromV8dbg reads the offsets from the v8dbg_ postmortem debugging symbols V8 embeds into node binaries.
+func OffsetsFromV8dbg(f *elf.File) (*UserOffsets, error) {
+	syms := interp.LookupSymbols(f, func(name string) bool {
+		return strings.HasPrefix(name, v8dbgPrefix)
+	})
+	if len(syms) == 0 {
+		return nil, fmt.Errorf("no v8dbg symbols found")
+	}
+	v := v8dbgValues{f: f, syms: syms}
+
+	if smiShiftSize := v.get("SmiShiftSize"); smiShiftSize != 31 {
+		return nil, fmt.Errorf("unsupported v8 build, pointer compression or 32 bit smis, SmiShiftSize %d", smiShiftSize)
+	}
+	if v.get("HeapObjectTag") != 1 || v.get("HeapObjectTagMask") != 3 {
+		return nil, fmt.Errorf("unsupported v8 heap object tag")
+	}
+	if _, ok := syms[v8dbgPrefix+"off_fp_context_or_frame_type"]; !ok {
+		// before V8 9
+		v.alias("off_fp_context_or_frame_type", "off_fp_context")
+	}
+	// ScopeInfo used to be a FixedArray, with its length before the elements
+	scopeInfoHeader := int64(8)
+	if _, ok := syms[v8dbgPrefix+"parent_ScopeInfo__FixedArray"]; ok {
+		scopeInfoHeader = 16
+	}
+
+	res := &UserOffsets{
+		FpFunction:                          int16(v.get("off_fp_function")),
+		FpContext:                           int16(v.get("off_fp_context_or_frame_type")),
+		HeapObjectMap:                       int16(v.getPrefix("class_HeapObject__map__")),
+		MapInstanceType:                     int16(v.getPrefix("class_Map__instance_type__")),
+		JSFunctionShared:                    int16(v.getPrefix("class_JSFunction__shared__")),
+		SharedFunctionInfoNameOrScopeInfo:   int16(v.getPrefix("class_SharedFunctionInfo__name_or_scope_info__")),
+		SharedFunctionInfoScriptOrDebugInfo: int16(v.getPrefix("class_SharedFunctionInfo__script_or_debug_info__", "class_SharedFunctionInfo__script__")),
+		ScriptName:                          int16(v.getPrefix("class_Script__name__")),
+		StringLength:                        int16(v.getPrefix("class_String__length__")),
+		SeqOneByteStringChars:               int16(v.getPrefix("class_SeqOneByteString__chars__")),
+		ConsStringFirst:                     int16(v.getPrefix("class_ConsString__first__")),
+		ThinStringActual:                    int16(v.getPrefix("class_ThinString__actual__")),
+		ScopeInfoContextLocalCount:          int16(scopeInfoHeader + 8*v.get("scopeinfo_idx_ncontextlocals")),
+		ScopeInfoFirstVars:                  int16(scopeInfoHeader + 8*v.get("scopeinfo_idx_first_vars")),
+		ScopeInfoMaxInlinedLocalNames:       scopeInfoMaxInlinedLocalNames,
+		FirstNonstringType:                  uint16(v.get("FirstNonstringType")),
+		SharedFunctionInfoType:              uint16(v.getPrefix("type_SharedFunctionInfo__")),
+		ScriptType:                          uint16(v.getPrefix("type_Script__")),
+		ScopeInfoType:                       uint16(v.getPrefix("type_ScopeInfo__")),
+		StringRepresentationMask:            uint8(v.get("StringRepresentationMask")),
+		StringEncodingMask:                  uint8(v.get("StringEncodingMask")),
+		OneByteStringTag:                    uint8(v.get("OneByteStringTag")),
+		ConsStringTag:                       uint8(v.get("ConsStringTag")),
+		ThinStringTag:                       uint8(v.get("ThinStringTag")),
+	}
+	if v.err != nil {
+		return nil, v.err
+	}
+	return res, nil
+}
+
+type v8dbgValues struct {
+	f    *elf.File
+	syms map[string]*elf.Symbol
+	err  error
+}
+
+func (v *v8dbgValues) alias(name, existing string) {
+	if sym, ok := v.syms[v8dbgPrefix+existing]; ok {
+		v.syms[v8dbgPrefix+name] = sym
+	}
+}
+
+func (v *v8dbgValues) get(name string) int64 {
+	sym, ok := v.syms[v8dbgPrefix+name]
+	if !ok {
+		v.fail(fmt.Errorf("v8dbg symbol %s not found", name))
+		return 0
+	}
+	return v.read(sym)
+}
+
+// getPrefix reads a symbol by its name without the type suffix, which differs across V8 versions,
+// like class_SharedFunctionInfo__script_or_debug_info__Object and __HeapObject.
+// The fields renamed across V8 versions are looked up by each of their prefixes in order,
+// like script_or_debug_info which is script since V8 12 (node 22).
+func (v *v8dbgValues) getPrefix(prefixes ...string) int64 {
+	for _, prefix := range prefixes {
+		prefix = v8dbgPrefix + prefix
+		var found *elf.Symbol
+		for name, sym := range v.syms {
+			if strings.HasPrefix(name, prefix) && (found == nil || name < found.Name) {
+				found = sym
+			}
+		}
+		if found != nil {
+			return v.read(found)
+		}
+	}
+	v.fail(fmt.Errorf("v8dbg symbol %s* not found", v8dbgPrefix+prefixes[0]))
+	return 0
+}
+
+func (v *v8dbgValues) read(sym *elf.Symbol) int64 {
+	data, err := interp.ReadSymbolData(v.f, sym, 4)
+	if err != nil {
+		v.fail(err)
+		return 0
+	}
+	return int64(int32(binary.LittleEndian.Uint32(data)))
+}
+
+func (v *v8dbgValues) fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
@@ -0,0 +1,95 @@
+package nodejs
+
+import (
+	"bufio"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/symtab"
+)
+
+func GetV8PerfPidData(l log.Logger, pid uint32) (*PerfV8PidData, error) {
+	mapsFD, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil, fmt.Errorf("reading proc maps %d: %w", pid, err)
+	}
+	defer mapsFD.Close()
+
+	info, err := GetProcInfo(bufio.NewScanner(mapsFD))
+	if err != nil {
+		return nil, fmt.Errorf("GetProcInfo error %s: %w", fmt.Sprintf("/proc/%d/maps", pid), err)
+	}
+	// libnode contains v8 when node is built as a shared library
+	var nodeMeat []*symtab.ProcMap
+	if info.LibNodeMaps == nil {
+		nodeMeat = info.NodeMaps
+	} else {
+		nodeMeat = info.LibNodeMaps
+	}
+	nodePath := fmt.Sprintf("/proc/%d/root%s", pid, nodeMeat[0].Pathname)
+	nodeFD, err := os.Open(nodePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open node path %s %w", nodePath, err)
+	}
+	defer nodeFD.Close()
+
+	version, err := GetNodeVersion(nodeFD)
+	if err != nil {
+		return nil, fmt.Errorf("could not get node version %s %w", nodePath, err)
+	}
+	if version.Compare(Node18) < 0 {
+		return nil, fmt.Errorf("unsupported node version %v", version)
+	}
+	if _, err = nodeFD.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek %s %w", nodePath, err)
+	}
+	ef, err := elf.NewFile(nodeFD)
+	if err != nil {
+		return nil, fmt.Errorf("opening elf %s: %w", nodePath, err)
+	}
+	offsets, err := OffsetsFromV8dbg(ef)
+	if err != nil {
+		var guess bool
+		offsets, guess, err = GetUserOffsets(version)
+		if err != nil {
+			return nil, fmt.Errorf("no v8dbg symbols in %s and %w", nodePath, err)
+		}
+		if guess {
+			level.Warn(l).Log("msg", "node offsets were not found, but guessed from the closest version", "version", version)
+		}
+	}
+	return &PerfV8PidData{Offsets: offsets.config()}, nil
+}
+
+func (o *UserOffsets) config() PerfV8OffsetConfig {
+	return PerfV8OffsetConfig{
+		FpFunction:                          o.FpFunction,
+		FpContext:                           o.FpContext,
+		HeapObjectMap:                       o.HeapObjectMap,
+		MapInstanceType:                     o.MapInstanceType,
+		JSFunctionShared:                    o.JSFunctionShared,
+		SharedFunctionInfoNameOrScopeInfo:   o.SharedFunctionInfoNameOrScopeInfo,
+		SharedFunctionInfoScriptOrDebugInfo: o.SharedFunctionInfoScriptOrDebugInfo,
+		ScriptName:                          o.ScriptName,
+		StringLength:                        o.StringLength,
+		SeqOneByteStringChars:               o.SeqOneByteStringChars,
+		ConsStringFirst:                     o.ConsStringFirst,
+		ThinStringActual:                    o.ThinStringActual,
+		ScopeInfoContextLocalCount:          o.ScopeInfoContextLocalCount,
+		ScopeInfoFirstVars:                  o.ScopeInfoFirstVars,
+		ScopeInfoMaxInlinedLocalNames:       o.ScopeInfoMaxInlinedLocalNames,
+		FirstNonstringType:                  o.FirstNonstringType,
+		SharedFunctionInfoType:              o.SharedFunctionInfoType,
+		ScriptType:                          o.ScriptType,
+		ScopeInfoType:                       o.ScopeInfoType,
+		StringRepresentationMask:            o.StringRepresentationMask,
+		StringEncodingMask:                  o.StringEncodingMask,
+		OneByteStringTag:                    o.OneByteStringTag,
+		ConsStringTag:                       o.ConsStringTag,
+		ThinStringTag:                       o.ThinStringTag,
+	}
+}
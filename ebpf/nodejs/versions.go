@@ -0,0 +1,143 @@
+package nodejs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// Node18 is the oldest supported version
+var Node18 = &Version{Major: 18}
+
+func (p *Version) Compare(other *Version) int {
+	major := p.Major - other.Major
+	if major != 0 {
+		return major
+	}
+
+	minor := p.Minor - other.Minor
+	if minor != 0 {
+		return minor
+	}
+	return p.Patch - other.Patch
+}
+
+func (p Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", p.Major, p.Minor, p.Patch)
+}
+
+var reNodeVersion = regexp.MustCompile(`nodejs\.org/download/release/v(\d+)\.(\d+)\.(\d+)/`)
+
+// GetNodeVersion searches the node release url embedded in node or libnode binaries for the version
+func GetNodeVersion(r io.Reader) (Version, error) {
+	m, err := rgrep(r, reNodeVersion)
+	if err != nil {
+		return Version{}, err
+	}
+	res := Version{}
+	for i, dst := range []*int{&res.Major, &res.Minor, &res.Patch} {
+		*dst, err = strconv.Atoi(string(m[i+1]))
+		if err != nil {
+			return Version{}, fmt.Errorf("failed to parse node version %s", m[0])
+		}
+	}
+	return res, nil
+}
+
+func rgrep(r io.Reader, re *regexp.Regexp) ([][]byte, error) {
+	const bufSize = 0x10000
+	const lookBack = 0x80
+	buf := make([]byte, bufSize+lookBack)
+	prev := 0
+	for {
+		n, err := r.Read(buf[lookBack:])
+		if n > 0 {
+			it := buf[lookBack-prev : lookBack+n]
+			submatch := re.FindSubmatch(it)
+			if submatch != nil {
+				return submatch, nil
+			}
+			prev = lookBack
+			if len(it) < lookBack {
+				prev = len(it)
+			}
+			copy(buf[lookBack-prev:lookBack], it[len(it)-prev:])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error trying to grep node version %w", err)
+		}
+	}
+	return nil, fmt.Errorf("rgrep not found %v", re.String())
+}
+
+// UserOffsets keeps V8 offsets and constants which are passed to ebpf with PerfV8OffsetConfig.
+// They are read from the v8dbg_ postmortem debugging symbols of node binaries.
+type UserOffsets struct {
+	FpFunction                          int16
+	FpContext                           int16
+	HeapObjectMap                       int16
+	MapInstanceType                     int16
+	JSFunctionShared                    int16
+	SharedFunctionInfoNameOrScopeInfo   int16
+	SharedFunctionInfoScriptOrDebugInfo int16
+	ScriptName                          int16
+	StringLength                        int16
+	SeqOneByteStringChars               int16
+	ConsStringFirst                     int16
+	ThinStringActual                    int16
+	ScopeInfoContextLocalCount          int16
+	ScopeInfoFirstVars                  int16
+	ScopeInfoMaxInlinedLocalNames       uint16
+	FirstNonstringType                  uint16
+	SharedFunctionInfoType              uint16
+	ScriptType                          uint16
+	ScopeInfoType                       uint16
+	StringRepresentationMask            uint8
+	StringEncodingMask                  uint8
+	OneByteStringTag                    uint8
+	ConsStringTag                       uint8
+	ThinStringTag                       uint8
+}
+
+// GetUserOffsets returns the offsets of the given version, or of the closest version with the same major version,
+// as node does not change its V8 major version within a node major version.
+func GetUserOffsets(version Version) (*UserOffsets, bool, error) {
+	if version.Compare(Node18) < 0 {
+		return nil, false, fmt.Errorf("unsupported node version %v", version)
+	}
+	offsets, ok := nodeVersions[version]
+	if ok {
+		return offsets, false, nil
+	}
+	var (
+		foundVersion Version
+		foundDiff    int
+	)
+	for v, o := range nodeVersions {
+		if v.Major != version.Major {
+			continue
+		}
+		diff := v.Compare(&version)
+		if diff < 0 {
+			diff = -diff
+		}
+		if offsets == nil || diff < foundDiff || diff == foundDiff && v.Compare(&foundVersion) > 0 {
+			offsets = o
+			foundVersion = v
+			foundDiff = diff
+		}
+	}
+	if offsets == nil {
+		return nil, false, fmt.Errorf("unsupported node version %v", version)
+	}
+	return offsets, true, nil
+}
@@ -0,0 +1,70 @@
+package nodejs
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNodeVersion(t *testing.T) {
+	const url = "https://nodejs.org/download/release/v18.17.1/node-v18.17.1.tar.gz"
+	for _, prefix := range []int{0, 1, 0x10000 - 30, 0x10000, 0x20000 + 7} {
+		data := bytes.Repeat([]byte{'x'}, prefix)
+		data = append(data, url...)
+		data = append(data, 0)
+		version, err := GetNodeVersion(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, Version{18, 17, 1}, version)
+	}
+	_, err := GetNodeVersion(strings.NewReader("v18.17.1"))
+	require.Error(t, err)
+}
+
+func TestGetUserOffsets(t *testing.T) {
+	offsets, guess, err := GetUserOffsets(Version{20, 19, 5})
+	require.NoError(t, err)
+	require.False(t, guess)
+	require.Equal(t, int16(-16), offsets.FpFunction)
+
+	guessed, guess, err := GetUserOffsets(Version{20, 11, 0})
+	require.NoError(t, err)
+	require.True(t, guess)
+	require.Equal(t, offsets, guessed)
+
+	// Every supported LTS line has its own offsets.
+	for _, v := range []Version{{18, 20, 8}, {22, 20, 0}} {
+		_, guess, err = GetUserOffsets(v)
+		require.NoError(t, err)
+		require.False(t, guess)
+	}
+	guessed, guess, err = GetUserOffsets(Version{22, 11, 0})
+	require.NoError(t, err)
+	require.True(t, guess)
+	require.Equal(t, nodeVersions[Version{22, 20, 0}], guessed)
+	require.NotEqual(t, offsets, guessed)
+
+	_, _, err = GetUserOffsets(Version{16, 20, 2})
+	require.Error(t, err)
+	_, _, err = GetUserOffsets(Version{42, 0, 0})
+	require.Error(t, err)
+}
+
+func TestOffsetsFromV8dbg(t *testing.T) {
+	f, err := os.Open("testdata/v8dbg.o")
+	require.NoError(t, err)
+	defer f.Close()
+
+	version, err := GetNodeVersion(f)
+	require.NoError(t, err)
+	require.Equal(t, Version{20, 19, 5}, version)
+
+	ef, err := elf.NewFile(f)
+	require.NoError(t, err)
+	offsets, err := OffsetsFromV8dbg(ef)
+	require.NoError(t, err)
+	require.Equal(t, nodeVersions[version], offsets)
+}
@@ -0,0 +1,192 @@
+package pprof
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// The layout of the profiles recorded by DirWriter:
+//
+//	<dir>/<labels hash>/labels.json         the labels of the target, a json object
+//	<dir>/<labels hash>/<unix nanos>.pb.gz  a gzipped pprof per collection, named by its TimeNanos
+//
+// It is read by profilecli upload --dir, which pushes the profiles with the recorded labels.
+// The timestamps are preserved as they are the TimeNanos of the profiles.
+const (
+	DirLabelsFile    = "labels.json"
+	DirProfileSuffix = ".pb.gz"
+)
+
+type DirWriterOptions struct {
+	Dir string
+	// MaxBytes is the disk budget of the recorded profiles, the oldest profiles are removed
+	// when it is exceeded. Zero means no limit.
+	MaxBytes int64
+	// MaxAge is the retention of the recorded profiles. Zero means no limit.
+	MaxAge time.Duration
+}
+
+// DirWriter records the profiles of ProfileBuilders to local files for offline analysis.
+type DirWriter struct {
+	options DirWriterOptions
+	now     func() time.Time
+}
+
+func NewDirWriter(options DirWriterOptions) (*DirWriter, error) {
+	if options.Dir == "" {
+		return nil, fmt.Errorf("profiles dir not specified")
+	}
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create profiles dir %w", err)
+	}
+	return &DirWriter{options: options, now: time.Now}, nil
+}
+
+// Write writes a profile file per builder and removes the profiles exceeding the retention and the disk budget.
+func (w *DirWriter) Write(builders *ProfileBuilders) error {
+	for _, builder := range builders.Builders {
+		if len(builder.Profile.Sample) == 0 {
+			continue
+		}
+		if err := w.writeProfile(builder); err != nil {
+			return err
+		}
+	}
+	return w.Cleanup()
+}
+
+func (w *DirWriter) writeProfile(builder *ProfileBuilder) error {
+	dir := filepath.Join(w.options.Dir, targetDirName(builder.Labels))
+	labelsFile := filepath.Join(dir, DirLabelsFile)
+	if _, err := os.Stat(labelsFile); err != nil {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create target dir %w", err)
+		}
+		data, err := json.Marshal(builder.Labels.Map())
+		if err != nil {
+			return fmt.Errorf("encode labels %w", err)
+		}
+		if err = writeFileAtomic(labelsFile, func(f *os.File) error {
+			_, err := f.Write(data)
+			return err
+		}); err != nil {
+			return fmt.Errorf("write labels %w", err)
+		}
+	}
+	path := filepath.Join(dir, strconv.FormatInt(builder.Profile.TimeNanos, 10)+DirProfileSuffix)
+	if err := writeFileAtomic(path, func(f *os.File) error {
+		_, err := builder.Write(f)
+		return err
+	}); err != nil {
+		return fmt.Errorf("write profile %w", err)
+	}
+	return nil
+}
+
+func targetDirName(lbls labels.Labels) string {
+	return fmt.Sprintf("%016x", lbls.Hash())
+}
+
+// writeFileAtomic writes a temporary file and renames it, so the readers never see partial files.
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type recordedProfile struct {
+	path      string
+	timeNanos int64
+	size      int64
+}
+
+// Cleanup removes the profiles older than MaxAge, then the oldest profiles until the total size fits MaxBytes.
+// The target directories left without profiles are removed.
+func (w *DirWriter) Cleanup() error {
+	if w.options.MaxBytes <= 0 && w.options.MaxAge <= 0 {
+		return nil
+	}
+	targets, err := os.ReadDir(w.options.Dir)
+	if err != nil {
+		return fmt.Errorf("read profiles dir %w", err)
+	}
+	var profiles []recordedProfile
+	total := int64(0)
+	for _, target := range targets {
+		if !target.IsDir() {
+			continue
+		}
+		dir := filepath.Join(w.options.Dir, target.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("read target dir %w", err)
+		}
+		n := 0
+		for _, file := range files {
+			ts, ok := profileTimeNanos(file.Name())
+			if !ok {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+			profiles = append(profiles, recordedProfile{path: filepath.Join(dir, file.Name()), timeNanos: ts, size: info.Size()})
+			total += info.Size()
+			n++
+		}
+		if n == 0 {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].timeNanos < profiles[j].timeNanos
+	})
+	minTime := int64(0)
+	if w.options.MaxAge > 0 {
+		minTime = w.now().Add(-w.options.MaxAge).UnixNano()
+	}
+	for _, p := range profiles {
+		expired := p.timeNanos < minTime
+		overBudget := w.options.MaxBytes > 0 && total > w.options.MaxBytes
+		if !expired && !overBudget {
+			break
+		}
+		if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove profile %w", err)
+		}
+		total -= p.size
+	}
+	return nil
+}
+
+func profileTimeNanos(name string) (int64, bool) {
+	if !strings.HasSuffix(name, DirProfileSuffix) {
+		return 0, false
+	}
+	ts, err := strconv.ParseInt(strings.TrimSuffix(name, DirProfileSuffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ts, true
+}
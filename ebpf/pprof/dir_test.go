@@ -0,0 +1,108 @@
+package pprof
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirWriter(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewDirWriter(DirWriterOptions{Dir: dir})
+	require.NoError(t, err)
+
+	target := labels.Labels{{Name: "__name__", Value: "process_cpu"}, {Name: "service_name", Value: "foo"}}
+	builders := NewProfileBuilders(97)
+	cpu := builders.BuilderForTarget(target.Hash(), target)
+	cpu.AddSample([]string{"main", "work"}, 3)
+	offCpu := builders.BuilderForSampleType(target.Hash(), target, SampleTypeOffCpu)
+	offCpu.AddSample([]string{"main", "read"}, 1000)
+	// builders without samples are not written
+	empty := labels.Labels{{Name: "service_name", Value: "empty"}}
+	builders.BuilderForTarget(empty.Hash(), empty)
+	require.NoError(t, w.Write(builders))
+
+	targets, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+
+	for _, builder := range []*ProfileBuilder{cpu, offCpu} {
+		targetDir := filepath.Join(dir, targetDirName(builder.Labels))
+		data, err := os.ReadFile(filepath.Join(targetDir, DirLabelsFile))
+		require.NoError(t, err)
+		lbls := map[string]string{}
+		require.NoError(t, json.Unmarshal(data, &lbls))
+		require.Equal(t, builder.Labels.Map(), lbls)
+
+		f, err := os.Open(filepath.Join(targetDir, strconv.FormatInt(builder.Profile.TimeNanos, 10)+DirProfileSuffix))
+		require.NoError(t, err)
+		parsed, err := profile.Parse(f)
+		_ = f.Close()
+		require.NoError(t, err)
+		require.Equal(t, builder.Profile.TimeNanos, parsed.TimeNanos)
+		require.Equal(t, 1, len(parsed.Sample))
+	}
+}
+
+func TestDirWriterCleanup(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	w, err := NewDirWriter(DirWriterOptions{Dir: dir, MaxAge: time.Hour})
+	require.NoError(t, err)
+	w.now = func() time.Time { return now }
+
+	target := labels.Labels{{Name: "service_name", Value: "foo"}}
+	write := func(ts time.Time) {
+		builders := NewProfileBuilders(97)
+		builder := builders.BuilderForTarget(target.Hash(), target)
+		builder.Profile.TimeNanos = ts.UnixNano()
+		builder.AddSample([]string{"main", "work"}, 1)
+		require.NoError(t, w.Write(builders))
+	}
+	profiles := func() []string {
+		files, err := os.ReadDir(filepath.Join(dir, targetDirName(target)))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		var res []string
+		for _, f := range files {
+			if f.Name() != DirLabelsFile {
+				res = append(res, f.Name())
+			}
+		}
+		return res
+	}
+
+	write(now.Add(-2 * time.Hour))
+	require.Empty(t, profiles())
+	write(now.Add(-30 * time.Minute))
+	write(now.Add(-20 * time.Minute))
+	write(now.Add(-10 * time.Minute))
+	require.Equal(t, 3, len(profiles()))
+
+	info, err := os.Stat(filepath.Join(dir, targetDirName(target), profiles()[0]))
+	require.NoError(t, err)
+	w.options.MaxBytes = 2 * info.Size()
+	require.NoError(t, w.Cleanup())
+	require.Equal(t, []string{
+		strconv.FormatInt(now.Add(-20*time.Minute).UnixNano(), 10) + DirProfileSuffix,
+		strconv.FormatInt(now.Add(-10*time.Minute).UnixNano(), 10) + DirProfileSuffix,
+	}, profiles())
+
+	// the target dir is removed with its last profile
+	now = now.Add(time.Hour)
+	require.NoError(t, w.Cleanup())
+	require.Empty(t, profiles())
+	require.NoError(t, w.Cleanup())
+	targets, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, targets)
+}
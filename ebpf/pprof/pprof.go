@@ -3,6 +3,8 @@ package pprof
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,39 +25,85 @@ var (
 	}
 )
 
+// SampleType is the kind of the samples aggregated by a ProfileBuilder.
+type SampleType uint32
+
+const (
+	// SampleTypeCpu values are numbers of on-cpu samples taken at the sample rate.
+	SampleTypeCpu SampleType = iota
+	// SampleTypeOffCpu values are nanoseconds spent blocked off-cpu.
+	SampleTypeOffCpu
+)
+
+// OffCpuMetricName is the __name__ of the off-cpu profiles.
+const OffCpuMetricName = "off_cpu"
+
+// Names of the per-thread and per-cpu sample labels.
+const (
+	LabelThreadID   = "thread_id"
+	LabelThreadName = "thread_name"
+	LabelCPU        = "cpu"
+)
+
+// SampleLabel is a pprof label of a single sample. Unlike the target labels,
+// sample labels do not create new series, they stay within the profile.
+type SampleLabel struct {
+	Name  string
+	Value string
+}
+
+type builderKey struct {
+	labelsHash uint64
+	sampleType SampleType
+}
+
 type ProfileBuilders struct {
-	Builders   map[uint64]*ProfileBuilder
+	Builders   map[builderKey]*ProfileBuilder
 	SampleRate int64
 }
 
 func NewProfileBuilders(sampleRate int64) *ProfileBuilders {
-	return &ProfileBuilders{Builders: make(map[uint64]*ProfileBuilder), SampleRate: sampleRate}
+	return &ProfileBuilders{Builders: make(map[builderKey]*ProfileBuilder), SampleRate: sampleRate}
 }
 
 func (b ProfileBuilders) BuilderForTarget(hash uint64, labels labels.Labels) *ProfileBuilder {
-	res := b.Builders[hash]
+	return b.BuilderForSampleType(hash, labels, SampleTypeCpu)
+}
+
+// BuilderForSampleType returns the builder of the target profile of the given sample type.
+// The off-cpu profiles are named OffCpuMetricName and are separate from the cpu profiles of the same target.
+func (b ProfileBuilders) BuilderForSampleType(hash uint64, lbs labels.Labels, sampleType SampleType) *ProfileBuilder {
+	key := builderKey{labelsHash: hash, sampleType: sampleType}
+	res := b.Builders[key]
 	if res != nil {
 		return res
 	}
 
+	valueType := &profile.ValueType{Type: "cpu", Unit: "nanoseconds"}
+	period := time.Second.Nanoseconds() / b.SampleRate
+	if sampleType == SampleTypeOffCpu {
+		valueType = &profile.ValueType{Type: "off_cpu", Unit: "nanoseconds"}
+		period = 1
+		lbs = labels.NewBuilder(lbs).Set(labels.MetricName, OffCpuMetricName).Labels()
+	}
 	builder := &ProfileBuilder{
 		locations: make(map[string]*profile.Location),
 		functions: make(map[string]*profile.Function),
-		Labels:    labels,
+		Labels:    lbs,
 		Profile: &profile.Profile{
 			Mapping: []*profile.Mapping{
 				{
 					ID: 1,
 				},
 			},
-			SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
-			Period:     time.Second.Nanoseconds() / b.SampleRate,
-			PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+			SampleType: []*profile.ValueType{valueType},
+			Period:     period,
+			PeriodType: valueType,
 			TimeNanos:  time.Now().UnixNano(),
 		},
 	}
 	res = builder
-	b.Builders[hash] = res
+	b.Builders[key] = res
 	return res
 }
 
@@ -66,12 +114,42 @@ type ProfileBuilder struct {
 	Labels    labels.Labels
 }
 
+// Line is a source position of a frame, see symtab.Symbol Lines.
+type Line struct {
+	Function string
+	File     string
+	Line     int
+}
+
 func (p *ProfileBuilder) AddSample(stacktrace []string, value uint64) {
+	p.AddSampleWithLines(stacktrace, nil, value)
+}
+
+// AddSampleWithLines adds a sample with source lines of the frames.
+// lines is either nil or has the same length as stacktrace, lines[i] is the inline chain of stacktrace[i],
+// innermost function first. Frames without lines are added as a single line location named by stacktrace[i].
+func (p *ProfileBuilder) AddSampleWithLines(stacktrace []string, lines [][]Line, value uint64) {
+	p.AddSampleWithLabels(stacktrace, lines, nil, value)
+}
+
+// AddSampleWithLabels adds a sample with source lines of the frames, see AddSampleWithLines, and sample labels.
+func (p *ProfileBuilder) AddSampleWithLabels(stacktrace []string, lines [][]Line, sampleLabels []SampleLabel, value uint64) {
 	sample := &profile.Sample{
 		Value: []int64{int64(value) * p.Profile.Period},
 	}
-	for _, s := range stacktrace {
-		loc := p.addLocation(s)
+	if len(sampleLabels) > 0 {
+		sample.Label = make(map[string][]string, len(sampleLabels))
+		for _, l := range sampleLabels {
+			sample.Label[l.Name] = append(sample.Label[l.Name], l.Value)
+		}
+	}
+	for i, s := range stacktrace {
+		var loc *profile.Location
+		if i < len(lines) && len(lines[i]) > 0 {
+			loc = p.addInlineLocation(s, lines[i])
+		} else {
+			loc = p.addLocation(s)
+		}
 		sample.Location = append(sample.Location, loc)
 	}
 	p.Profile.Sample = append(p.Profile.Sample, sample)
@@ -89,7 +167,7 @@ func (p *ProfileBuilder) addLocation(function string) *profile.Location {
 		Mapping: p.Profile.Mapping[0],
 		Line: []profile.Line{
 			{
-				Function: p.addFunction(function),
+				Function: p.addFunction(function, ""),
 			},
 		},
 	}
@@ -98,19 +176,63 @@ func (p *ProfileBuilder) addLocation(function string) *profile.Location {
 	return loc
 }
 
-func (p *ProfileBuilder) addFunction(function string) *profile.Function {
-	f, ok := p.functions[function]
+func (p *ProfileBuilder) addInlineLocation(function string, lines []Line) *profile.Location {
+	sb := strings.Builder{}
+	sb.WriteString(function)
+	for _, l := range lines {
+		sb.WriteByte(0)
+		sb.WriteString(l.Function)
+		sb.WriteByte(0)
+		sb.WriteString(l.File)
+		sb.WriteByte(0)
+		sb.WriteString(strconv.Itoa(l.Line))
+	}
+	key := sb.String()
+	loc, ok := p.locations[key]
+	if ok {
+		return loc
+	}
+
+	id := uint64(len(p.Profile.Location) + 1)
+	loc = &profile.Location{
+		ID:      id,
+		Mapping: p.Profile.Mapping[0],
+		Line:    make([]profile.Line, 0, len(lines)),
+	}
+	for i, l := range lines {
+		name := l.Function
+		if name == "" && i == len(lines)-1 {
+			// the outermost function is the symbol the frame was resolved to
+			name = function
+		}
+		loc.Line = append(loc.Line, profile.Line{
+			Function: p.addFunction(name, l.File),
+			Line:     int64(l.Line),
+		})
+	}
+	p.Profile.Location = append(p.Profile.Location, loc)
+	p.locations[key] = loc
+	return loc
+}
+
+func (p *ProfileBuilder) addFunction(function string, file string) *profile.Function {
+	key := function
+	if file != "" {
+		key = function + "\x00" + file
+	}
+	f, ok := p.functions[key]
 	if ok {
 		return f
 	}
 
 	id := uint64(len(p.Profile.Function) + 1)
 	f = &profile.Function{
-		ID:   id,
-		Name: function,
+		ID:       id,
+		Name:     function,
+		Filename: file,
 	}
 	p.Profile.Function = append(p.Profile.Function, f)
-	p.functions[function] = f
+	p.functions[key] = f
 	return f
 }
 
@@ -48,3 +48,84 @@ func TestBackAndForth(t *testing.T) {
 	require.Equal(t, 239*period, stacks["a;b;c"])
 	require.Equal(t, 4242*period, stacks["a;b;d"])
 }
+
+func TestInlineLines(t *testing.T) {
+	builders := NewProfileBuilders(97)
+	builder := builders.BuilderForTarget(1, labels.Labels{{Name: "foo", Value: "bar"}})
+	inlined := []Line{
+		{Function: "leaf", File: "inline.c", Line: 6},
+		{Function: "middle", File: "inline.c", Line: 10},
+		{Function: "", File: "inline.c", Line: 15},
+	}
+	builder.AddSampleWithLines([]string{"main", "outer"}, [][]Line{nil, inlined}, 1)
+	builder.AddSampleWithLines([]string{"main", "outer"}, [][]Line{nil, inlined}, 2)
+	builder.AddSample([]string{"main", "outer"}, 3)
+
+	buf := bytes.NewBuffer(nil)
+	_, err := builder.Write(buf)
+	require.NoError(t, err)
+	parsed, err := profile.Parse(buf)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parsed.Sample))
+	require.Equal(t, 3, len(parsed.Location))
+	require.Equal(t, parsed.Sample[0].Location, parsed.Sample[1].Location)
+
+	loc := parsed.Sample[0].Location[1]
+	require.Equal(t, 3, len(loc.Line))
+	names := []string{"leaf", "middle", "outer"}
+	for i, line := range loc.Line {
+		require.Equal(t, names[i], line.Function.Name)
+		require.Equal(t, "inline.c", line.Function.Filename)
+		require.Equal(t, int64(inlined[i].Line), line.Line)
+	}
+	// The location without lines does not share the function with the one with a file name.
+	plain := parsed.Sample[2].Location[1]
+	require.Equal(t, 1, len(plain.Line))
+	require.Equal(t, "outer", plain.Line[0].Function.Name)
+	require.Equal(t, "", plain.Line[0].Function.Filename)
+	require.Equal(t, 5, len(parsed.Function))
+}
+
+func TestOffCpu(t *testing.T) {
+	builders := NewProfileBuilders(97)
+	target := labels.Labels{{Name: "__name__", Value: "process_cpu"}, {Name: "foo", Value: "bar"}}
+	cpu := builders.BuilderForTarget(1, target)
+	offCpu := builders.BuilderForSampleType(1, target, SampleTypeOffCpu)
+	require.NotSame(t, cpu, offCpu)
+	require.Same(t, offCpu, builders.BuilderForSampleType(1, target, SampleTypeOffCpu))
+	require.Equal(t, 2, len(builders.Builders))
+	require.Equal(t, "process_cpu", target.Get("__name__"))
+	require.Equal(t, "off_cpu", offCpu.Labels.Get("__name__"))
+	require.Equal(t, "bar", offCpu.Labels.Get("foo"))
+
+	offCpu.AddSample([]string{"main", "read"}, 1500000)
+
+	buf := bytes.NewBuffer(nil)
+	_, err := offCpu.Write(buf)
+	require.NoError(t, err)
+	parsed, err := profile.Parse(buf)
+	require.NoError(t, err)
+	require.Equal(t, "off_cpu", parsed.SampleType[0].Type)
+	require.Equal(t, "nanoseconds", parsed.SampleType[0].Unit)
+	require.Equal(t, int64(1500000), parsed.Sample[0].Value[0])
+}
+
+func TestSampleLabels(t *testing.T) {
+	builders := NewProfileBuilders(97)
+	builder := builders.BuilderForTarget(1, labels.Labels{{Name: "foo", Value: "bar"}})
+	worker := []SampleLabel{{Name: LabelThreadID, Value: "42"}, {Name: LabelThreadName, Value: "worker"}, {Name: LabelCPU, Value: "3"}}
+	builder.AddSampleWithLabels([]string{"main", "work"}, nil, worker, 1)
+	builder.AddSampleWithLabels([]string{"main", "work"}, nil, []SampleLabel{{Name: LabelCPU, Value: "0"}}, 2)
+	builder.AddSample([]string{"main", "work"}, 3)
+
+	buf := bytes.NewBuffer(nil)
+	_, err := builder.Write(buf)
+	require.NoError(t, err)
+	parsed, err := profile.Parse(buf)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parsed.Sample))
+	require.Equal(t, 2, len(parsed.Location))
+	require.Equal(t, map[string][]string{"thread_id": {"42"}, "thread_name": {"worker"}, "cpu": {"3"}}, parsed.Sample[0].Label)
+	require.Equal(t, map[string][]string{"cpu": {"0"}}, parsed.Sample[1].Label)
+	require.Empty(t, parsed.Sample[2].Label)
+}
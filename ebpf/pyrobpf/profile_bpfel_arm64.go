@@ -24,11 +24,20 @@ type ProfilePidEvent struct {
 	Pid uint32
 }
 
+type ProfileSampleConfig struct {
+	CollectThread uint8
+	CollectCpu    uint8
+	Padding       uint16
+}
+
 type ProfileSampleKey struct {
 	Pid       uint32
 	Flags     uint32
 	KernStack int64
 	UserStack int64
+	Tid       uint32
+	Cpu       uint32
+	Comm      [16]int8
 }
 
 // LoadProfile returns the embedded CollectionSpec for Profile.
@@ -81,11 +90,12 @@ type ProfileProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type ProfileMapSpecs struct {
-	Counts *ebpf.MapSpec `ebpf:"counts"`
-	Events *ebpf.MapSpec `ebpf:"events"`
-	Pids   *ebpf.MapSpec `ebpf:"pids"`
-	Progs  *ebpf.MapSpec `ebpf:"progs"`
-	Stacks *ebpf.MapSpec `ebpf:"stacks"`
+	Counts         *ebpf.MapSpec `ebpf:"counts"`
+	Events         *ebpf.MapSpec `ebpf:"events"`
+	Pids           *ebpf.MapSpec `ebpf:"pids"`
+	Progs          *ebpf.MapSpec `ebpf:"progs"`
+	SampleSettings *ebpf.MapSpec `ebpf:"sample_settings"`
+	Stacks         *ebpf.MapSpec `ebpf:"stacks"`
 }
 
 // ProfileObjects contains all objects after they have been loaded into the kernel.
@@ -107,11 +117,12 @@ func (o *ProfileObjects) Close() error {
 //
 // It can be passed to LoadProfileObjects or ebpf.CollectionSpec.LoadAndAssign.
 type ProfileMaps struct {
-	Counts *ebpf.Map `ebpf:"counts"`
-	Events *ebpf.Map `ebpf:"events"`
-	Pids   *ebpf.Map `ebpf:"pids"`
-	Progs  *ebpf.Map `ebpf:"progs"`
-	Stacks *ebpf.Map `ebpf:"stacks"`
+	Counts         *ebpf.Map `ebpf:"counts"`
+	Events         *ebpf.Map `ebpf:"events"`
+	Pids           *ebpf.Map `ebpf:"pids"`
+	Progs          *ebpf.Map `ebpf:"progs"`
+	SampleSettings *ebpf.Map `ebpf:"sample_settings"`
+	Stacks         *ebpf.Map `ebpf:"stacks"`
 }
 
 func (m *ProfileMaps) Close() error {
@@ -120,6 +131,7 @@ func (m *ProfileMaps) Close() error {
 		m.Events,
 		m.Pids,
 		m.Progs,
+		m.SampleSettings,
 		m.Stacks,
 	)
 }
@@ -6,12 +6,36 @@ type ProfilingType uint8
 //#define PROFILING_TYPE_FRAMEPOINTERS 2
 //#define PROFILING_TYPE_PYTHON 3
 //#define PROFILING_TYPE_ERROR 4
+//#define PROFILING_TYPE_RUBY 5
+//#define PROFILING_TYPE_NODEJS 6
 
 var (
 	ProfilingTypeUnknown       ProfilingType = 1
 	ProfilingTypeFramepointers ProfilingType = 2
 	ProfilingTypePython        ProfilingType = 3
 	ProfilingTypeError         ProfilingType = 4
+	ProfilingTypeRuby          ProfilingType = 5
+	ProfilingTypeNodejs        ProfilingType = 6
+)
+
+// progs map indexes
+//#define PROG_IDX_PYTHON 0
+//#define PROG_IDX_RUBY 1
+//#define PROG_IDX_NODEJS 2
+
+var (
+	ProgIdxPython uint32 = 0
+	ProgIdxRuby   uint32 = 1
+	ProgIdxNodejs uint32 = 2
+)
+
+// sample_key flags
+//#define SAMPLE_KEY_FLAG_THREAD 1
+//#define SAMPLE_KEY_FLAG_CPU 2
+
+var (
+	SampleKeyFlagThread uint32 = 1
+	SampleKeyFlagCPU    uint32 = 2
 )
 
 //#define OP_REQUEST_UNKNOWN_PROCESS_INFO 1
@@ -0,0 +1,152 @@
+package ruby
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+type dwarfNeed struct {
+	types []string // struct or typedef names, the first one found is used
+	path  string   // dotted member path or empty for the size of the type
+	dst   func(o *UserOffsets) *int16
+}
+
+var dwarfNeeds = []dwarfNeed{
+	{[]string{"rb_vm_t", "rb_vm_struct"}, "ractor.main_ractor", func(o *UserOffsets) *int16 { return &o.Vm_ractor_main_ractor }},
+	{[]string{"rb_ractor_t", "rb_ractor_struct"}, "threads.running_ec", func(o *UserOffsets) *int16 { return &o.Ractor_threads_running_ec }},
+	{[]string{"rb_execution_context_t", "rb_execution_context_struct"}, "vm_stack", func(o *UserOffsets) *int16 { return &o.ExecutionContext_vm_stack }},
+	{[]string{"rb_execution_context_t", "rb_execution_context_struct"}, "vm_stack_size", func(o *UserOffsets) *int16 { return &o.ExecutionContext_vm_stack_size }},
+	{[]string{"rb_execution_context_t", "rb_execution_context_struct"}, "cfp", func(o *UserOffsets) *int16 { return &o.ExecutionContext_cfp }},
+	{[]string{"rb_control_frame_t", "rb_control_frame_struct"}, "iseq", func(o *UserOffsets) *int16 { return &o.ControlFrame_iseq }},
+	{[]string{"rb_control_frame_t", "rb_control_frame_struct"}, "", func(o *UserOffsets) *int16 { return &o.ControlFrameSize }},
+	{[]string{"rb_iseq_t", "rb_iseq_struct"}, "body", func(o *UserOffsets) *int16 { return &o.Iseq_body }},
+	{[]string{"rb_iseq_constant_body"}, "location.pathobj", func(o *UserOffsets) *int16 { return &o.IseqBody_location_pathobj }},
+	{[]string{"rb_iseq_constant_body"}, "location.label", func(o *UserOffsets) *int16 { return &o.IseqBody_location_label }},
+	{[]string{"RString"}, "as.heap.ptr", func(o *UserOffsets) *int16 { return &o.RString_as_heap_ptr }},
+	{[]string{"RString"}, "as.embed.ary", func(o *UserOffsets) *int16 { return &o.RString_as_embed_ary }},
+	{[]string{"RArray"}, "as.heap.ptr", func(o *UserOffsets) *int16 { return &o.RArray_as_heap_ptr }},
+	{[]string{"RArray"}, "as.ary", func(o *UserOffsets) *int16 { return &o.RArray_as_ary }},
+}
+
+// OffsetsFromDWARF reads the offsets from the DWARF debug info of libruby or a statically linked ruby.
+// It is used by ruby_dwarfdump to generate versions_gen.go and for the versions missing there.
+func OffsetsFromDWARF(data *dwarf.Data) (*UserOffsets, error) {
+	wanted := map[string]bool{}
+	for _, need := range dwarfNeeds {
+		for _, name := range need.types {
+			wanted[name] = true
+		}
+	}
+	types, err := findStructs(data, wanted)
+	if err != nil {
+		return nil, err
+	}
+	res := &UserOffsets{}
+	var errs []string
+	for _, need := range dwarfNeeds {
+		var typ *dwarf.StructType
+		for _, name := range need.types {
+			if typ = types[name]; typ != nil {
+				break
+			}
+		}
+		if typ == nil {
+			errs = append(errs, fmt.Sprintf("type %s not found", need.types[0]))
+			continue
+		}
+		var offset int64
+		if need.path == "" {
+			offset = typ.ByteSize
+		} else if offset, err = memberOffset(typ, need.path); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", need.types[0], err))
+			continue
+		}
+		*need.dst(res) = int16(offset)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("ruby offsets: %s", strings.Join(errs, ", "))
+	}
+	return res, nil
+}
+
+// findStructs finds the complete struct types by their struct or typedef names.
+func findStructs(data *dwarf.Data, wanted map[string]bool) (map[string]*dwarf.StructType, error) {
+	res := map[string]*dwarf.StructType{}
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, fmt.Errorf("reading dwarf: %w", err)
+		}
+		if entry == nil {
+			return res, nil
+		}
+		if entry.Tag != dwarf.TagStructType && entry.Tag != dwarf.TagTypedef {
+			continue
+		}
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		if !wanted[name] || res[name] != nil {
+			continue
+		}
+		typ, err := data.Type(entry.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("reading dwarf type %s: %w", name, err)
+		}
+		if st := structType(typ); st != nil && !st.Incomplete {
+			res[name] = st
+		}
+	}
+}
+
+func memberOffset(typ *dwarf.StructType, path string) (int64, error) {
+	var offset int64
+	for _, name := range strings.Split(path, ".") {
+		if typ == nil {
+			return 0, fmt.Errorf("member %s: not a struct", path)
+		}
+		field, fieldOffset := findField(typ, name)
+		if field == nil {
+			return 0, fmt.Errorf("member %s not found", path)
+		}
+		offset += fieldOffset
+		typ = structType(field.Type)
+	}
+	return offset, nil
+}
+
+// findField finds a field by name, looking into anonymous struct and union members.
+func findField(typ *dwarf.StructType, name string) (*dwarf.StructField, int64) {
+	for _, field := range typ.Field {
+		if field.Name == name {
+			return field, field.ByteOffset
+		}
+	}
+	for _, field := range typ.Field {
+		if field.Name != "" {
+			continue
+		}
+		if st := structType(field.Type); st != nil {
+			if f, offset := findField(st, name); f != nil {
+				return f, field.ByteOffset + offset
+			}
+		}
+	}
+	return nil, 0
+}
+
+// structType strips typedefs and qualifiers, returns nil if the type is not a struct or an union
+func structType(typ dwarf.Type) *dwarf.StructType {
+	for {
+		switch t := typ.(type) {
+		case *dwarf.TypedefType:
+			typ = t.Type
+		case *dwarf.QualType:
+			typ = t.Type
+		case *dwarf.StructType:
+			return t
+		default:
+			return nil
+		}
+	}
+}
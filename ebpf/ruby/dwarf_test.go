@@ -0,0 +1,35 @@
+package ruby
+
+import (
+	"debug/elf"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetsFromDWARF(t *testing.T) {
+	f, err := elf.Open("testdata/rbstructs.o")
+	require.NoError(t, err)
+	defer f.Close()
+	d, err := f.DWARF()
+	require.NoError(t, err)
+
+	offsets, err := OffsetsFromDWARF(d)
+	require.NoError(t, err)
+	require.Equal(t, &UserOffsets{
+		Vm_ractor_main_ractor:          24,
+		Ractor_threads_running_ec:      56,
+		ExecutionContext_vm_stack:      0,
+		ExecutionContext_vm_stack_size: 8,
+		ExecutionContext_cfp:           16,
+		ControlFrame_iseq:              16,
+		ControlFrameSize:               56,
+		Iseq_body:                      16,
+		IseqBody_location_pathobj:      24,
+		IseqBody_location_label:        40,
+		RString_as_heap_ptr:            24,
+		RString_as_embed_ary:           24,
+		RArray_as_heap_ptr:             32,
+		RArray_as_ary:                  16,
+	}, offsets)
+}
@@ -0,0 +1,4 @@
+package ruby
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -type rb_offset_config -type rb_pid_data -target amd64 -cc clang -cflags "-O2 -Wall -fpie -Wno-unused-variable -Wno-unused-function" Perf ../bpf/rbperf.bpf.c -- -I../bpf/libbpf -I../bpf/vmlinux/
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -type rb_offset_config -type rb_pid_data -target arm64 -cc clang -cflags "-O2 -Wall -fpie -Wno-unused-variable -Wno-unused-function" Perf ../bpf/rbperf.bpf.c -- -I../bpf/libbpf -I../bpf/vmlinux/
@@ -0,0 +1,44 @@
+package ruby
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/pyroscope/ebpf/symtab"
+)
+
+type ProcInfo struct {
+	RubyMaps    []*symtab.ProcMap
+	LibRubyMaps []*symtab.ProcMap
+}
+
+var reRuby = regexp.MustCompile("/.*/(?:(ruby(?:\\d+\\.\\d+)?)|(libruby(?:-\\d+\\.\\d+)?\\.so(?:\\.\\d+)*))$")
+
+// GetProcInfo parses /proc/pid/map of a ruby process.
+func GetProcInfo(s *bufio.Scanner) (ProcInfo, error) {
+	res := ProcInfo{}
+	for s.Scan() {
+		line := s.Bytes()
+		m, err := symtab.ParseProcMapLine(line, false)
+		if err != nil {
+			return res, err
+		}
+		if m.Pathname == "" {
+			continue
+		}
+		matches := reRuby.FindStringSubmatch(m.Pathname)
+		if matches == nil {
+			continue
+		}
+		if matches[1] != "" {
+			res.RubyMaps = append(res.RubyMaps, m)
+		} else {
+			res.LibRubyMaps = append(res.LibRubyMaps, m)
+		}
+	}
+	if res.LibRubyMaps == nil && res.RubyMaps == nil {
+		return res, fmt.Errorf("no ruby found")
+	}
+	return res, nil
+}
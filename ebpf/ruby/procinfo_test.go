@@ -0,0 +1,44 @@
+package ruby
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRubyProcInfo(t *testing.T) {
+	maps := `55f1b0a38000-55f1b0a39000 r--p 00000000 fd:01 3018418                    /usr/local/bin/ruby
+55f1b0a39000-55f1b0a3a000 r-xp 00001000 fd:01 3018418                    /usr/local/bin/ruby
+55f1b0a3a000-55f1b0a3b000 r--p 00002000 fd:01 3018418                    /usr/local/bin/ruby
+55f1b18d4000-55f1b1c3e000 rw-p 00000000 00:00 0                          [heap]
+7f7d4c400000-7f7d4c47a000 r--p 00000000 fd:01 3018530                    /usr/local/lib/libruby.so.3.2.2
+7f7d4c47a000-7f7d4c7c5000 r-xp 0007a000 fd:01 3018530                    /usr/local/lib/libruby.so.3.2.2
+7f7d4c7c5000-7f7d4c906000 r--p 003c5000 fd:01 3018530                    /usr/local/lib/libruby.so.3.2.2
+7f7d4c906000-7f7d4c90c000 rw-p 00506000 fd:01 3018530                    /usr/local/lib/libruby.so.3.2.2
+7f7d4cb6a000-7f7d4cb6f000 r--p 00000000 fd:01 3018711                    /usr/local/lib/ruby/3.2.0/x86_64-linux/enc/encdb.so
+7f7d4cc00000-7f7d4cc28000 r--p 00000000 fd:01 1057232                    /usr/lib/x86_64-linux-gnu/libc.so.6`
+	info, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.NoError(t, err)
+	require.Len(t, info.RubyMaps, 3)
+	require.Len(t, info.LibRubyMaps, 4)
+	require.Equal(t, "/usr/local/lib/libruby.so.3.2.2", info.LibRubyMaps[0].Pathname)
+}
+
+func TestDebianRubyProcInfo(t *testing.T) {
+	maps := `5602f1d5d000-5602f1d5e000 r--p 00000000 fd:01 2370                       /usr/bin/ruby3.1
+7f0e8ce00000-7f0e8ce8a000 r--p 00000000 fd:01 4016                       /usr/lib/x86_64-linux-gnu/libruby-3.1.so.3.1.2
+7f0e8ce8a000-7f0e8d1b9000 r-xp 0008a000 fd:01 4016                       /usr/lib/x86_64-linux-gnu/libruby-3.1.so.3.1.2`
+	info, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.NoError(t, err)
+	require.Len(t, info.RubyMaps, 1)
+	require.Len(t, info.LibRubyMaps, 2)
+}
+
+func TestNotRubyProcInfo(t *testing.T) {
+	maps := `55bb7ceb9000-55bb7ceba000 r--p 00000000 fd:01 57017250                   /usr/local/lib/ruby/3.2.0/x86_64-linux/enc/encdb.so
+7f94a6200000-7f94a6222000 r--p 00000000 fd:01 45643280                   /usr/lib/x86_64-linux-gnu/libc.so.6`
+	_, err := GetProcInfo(bufio.NewScanner(strings.NewReader(maps)))
+	require.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package ruby
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/interp"
+	"github.com/grafana/pyroscope/ebpf/symtab"
+)
+
+const (
+	symRubyVersion = "ruby_version"
+	symCurrentVM   = "ruby_current_vm_ptr"
+)
+
+func GetRbPerfPidData(l log.Logger, pid uint32) (*PerfRbPidData, error) {
+	mapsFD, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil, fmt.Errorf("reading proc maps %d: %w", pid, err)
+	}
+	defer mapsFD.Close()
+
+	info, err := GetProcInfo(bufio.NewScanner(mapsFD))
+	if err != nil {
+		return nil, fmt.Errorf("GetProcInfo error %s: %w", fmt.Sprintf("/proc/%d/maps", pid), err)
+	}
+	var rubyMeat []*symtab.ProcMap
+	if info.LibRubyMaps == nil {
+		rubyMeat = info.RubyMaps
+	} else {
+		rubyMeat = info.LibRubyMaps
+	}
+	base_ := rubyMeat[0]
+	rubyPath := fmt.Sprintf("/proc/%d/root%s", pid, base_.Pathname)
+	ef, err := elf.Open(rubyPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening elf %s: %w", rubyPath, err)
+	}
+	defer ef.Close()
+
+	version, vmSym, err := GetRubySymbols(ef)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rubyPath, err)
+	}
+
+	offsets, guess, err := GetUserOffsets(version)
+	if err != nil {
+		if version.Compare(Ruby30) < 0 {
+			return nil, err
+		}
+		d, dwarfErr := ef.DWARF()
+		if dwarfErr != nil {
+			return nil, fmt.Errorf("%w, no debug info in %s", err, rubyPath)
+		}
+		if offsets, err = OffsetsFromDWARF(d); err != nil {
+			return nil, fmt.Errorf("ruby %v offsets from debug info %s: %w", version, rubyPath, err)
+		}
+	} else if guess {
+		level.Warn(l).Log("msg", "ruby offsets were not found, but guessed from the closest patch version", "version", version)
+	}
+
+	baseAddr := base_.StartAddr
+	if ef.FileHeader.Type == elf.ET_EXEC {
+		baseAddr = 0
+	}
+	return &PerfRbPidData{
+		Offsets: offsets.config(),
+		VmAddr:  baseAddr + vmSym.Value,
+	}, nil
+}
+
+// GetRubySymbols reads the ruby_version string and looks up the ruby_current_vm_ptr symbol
+func GetRubySymbols(ef *elf.File) (Version, *elf.Symbol, error) {
+	symbols := interp.LookupSymbols(ef, func(name string) bool {
+		return name == symRubyVersion || name == symCurrentVM
+	})
+	versionSym, vmSym := symbols[symRubyVersion], symbols[symCurrentVM]
+	if versionSym == nil || vmSym == nil {
+		return Version{}, nil, fmt.Errorf("missing symbols %s %s", symRubyVersion, symCurrentVM)
+	}
+	versionData, err := interp.ReadSymbolData(ef, versionSym, int(versionSym.Size))
+	if err != nil {
+		return Version{}, nil, fmt.Errorf("reading ruby version: %w", err)
+	}
+	if i := bytes.IndexByte(versionData, 0); i >= 0 {
+		versionData = versionData[:i]
+	}
+	version, err := ParseVersion(string(versionData))
+	if err != nil {
+		return Version{}, nil, err
+	}
+	return version, vmSym, nil
+}
@@ -0,0 +1,125 @@
+// Trimmed down MRI 3.2 structs, enough to exercise OffsetsFromDWARF, built into rbstructs.o with
+//   gcc -c -g -O0 -o rbstructs.o rbstructs.c
+
+typedef unsigned long VALUE;
+typedef unsigned long ID;
+
+struct RBasic {
+    VALUE flags;
+    const VALUE klass;
+};
+
+struct RString {
+    struct RBasic basic;
+    long len;
+    union {
+        struct {
+            char *ptr;
+            union {
+                long capa;
+                VALUE shared;
+            } aux;
+        } heap;
+        struct {
+            char ary[1];
+        } embed;
+    } as;
+};
+
+struct RArray {
+    struct RBasic basic;
+    union {
+        struct {
+            long len;
+            union {
+                long capa;
+                const VALUE shared_root;
+            } aux;
+            const VALUE *ptr;
+        } heap;
+        const VALUE ary[1];
+    } as;
+};
+
+typedef struct rb_iseq_location_struct {
+    VALUE pathobj;
+    VALUE base_label;
+    VALUE label;
+    int first_lineno;
+    int node_id;
+} rb_iseq_location_t;
+
+struct rb_iseq_constant_body {
+    int type;
+    unsigned int iseq_size;
+    VALUE *iseq_encoded;
+    struct {
+        unsigned int flags;
+        unsigned int size;
+    } param;
+    rb_iseq_location_t location;
+};
+
+struct rb_iseq_constant_body;
+
+typedef struct rb_iseq_struct {
+    VALUE flags;
+    VALUE wrapper;
+    struct rb_iseq_constant_body *body;
+} rb_iseq_t;
+
+typedef struct rb_control_frame_struct {
+    const VALUE *pc;
+    VALUE *sp;
+    const rb_iseq_t *iseq;
+    VALUE self;
+    const VALUE *ep;
+    const void *block_code;
+    void *jit_return;
+} rb_control_frame_t;
+
+typedef struct rb_execution_context_struct {
+    VALUE *vm_stack;
+    unsigned long vm_stack_size;
+    rb_control_frame_t *cfp;
+    struct rb_vm_tag *tag;
+} rb_execution_context_t;
+
+struct rb_ractor_struct;
+
+typedef struct rb_ractor_struct {
+    struct {
+        VALUE *recv_queue;
+        int wait;
+    } sync;
+    VALUE receiving_mutex;
+    struct rb_ractor_threads {
+        void *set;
+        unsigned int cnt;
+        unsigned int blocking_cnt;
+        unsigned int sleeper;
+        void *gvl;
+        rb_execution_context_t *running_ec;
+        void *main;
+    } threads;
+} rb_ractor_t;
+
+typedef struct rb_vm_struct {
+    VALUE self;
+    struct {
+        void *set;
+        unsigned int cnt;
+        unsigned int blocking_cnt;
+        struct rb_ractor_struct *main_ractor;
+        void *main_thread;
+    } ractor;
+    int running;
+} rb_vm_t;
+
+rb_vm_t *ruby_current_vm_ptr;
+struct RString str;
+struct RArray arr;
+rb_control_frame_t cfp;
+rb_iseq_t iseq;
+struct rb_iseq_constant_body body;
+const char ruby_version[] = "3.2.2";
@@ -0,0 +1,113 @@
+package ruby
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// Ruby30 is the oldest supported version, the first one with ractors
+var Ruby30 = &Version{Major: 3}
+
+func (p *Version) Compare(other *Version) int {
+	major := p.Major - other.Major
+	if major != 0 {
+		return major
+	}
+
+	minor := p.Minor - other.Minor
+	if minor != 0 {
+		return minor
+	}
+	return p.Patch - other.Patch
+}
+
+func (p Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", p.Major, p.Minor, p.Patch)
+}
+
+var reVersion = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+
+// ParseVersion parses the ruby_version string, like 3.2.2
+func ParseVersion(s string) (Version, error) {
+	m := reVersion.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid ruby version %q", s)
+	}
+	res := Version{}
+	var err error
+	for i, dst := range []*int{&res.Major, &res.Minor, &res.Patch} {
+		*dst, err = strconv.Atoi(m[i+1])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid ruby version %q", s)
+		}
+	}
+	return res, nil
+}
+
+// UserOffsets keeps MRI offsets which are passed to ebpf with PerfRbOffsetConfig
+//
+//goland:noinspection GoSnakeCaseUsage
+type UserOffsets struct {
+	Vm_ractor_main_ractor          int16
+	Ractor_threads_running_ec      int16
+	ExecutionContext_vm_stack      int16
+	ExecutionContext_vm_stack_size int16
+	ExecutionContext_cfp           int16
+	ControlFrame_iseq              int16
+	ControlFrameSize               int16
+	Iseq_body                      int16
+	IseqBody_location_pathobj      int16
+	IseqBody_location_label        int16
+	RString_as_heap_ptr            int16
+	RString_as_embed_ary           int16
+	RArray_as_heap_ptr             int16
+	RArray_as_ary                  int16
+}
+
+// GetUserOffsets returns the offsets of the given version, or of the closest patch version of the same minor version.
+func GetUserOffsets(version Version) (*UserOffsets, bool, error) {
+	if version.Compare(Ruby30) < 0 {
+		return nil, false, fmt.Errorf("unsupported ruby version %v", version)
+	}
+	offsets, ok := rbVersions[version]
+	if ok {
+		return offsets, false, nil
+	}
+	foundVersion := Version{}
+	for v, o := range rbVersions {
+		if v.Major == version.Major && v.Minor == version.Minor {
+			if offsets == nil || v.Compare(&foundVersion) > 0 {
+				offsets = o
+				foundVersion = v
+			}
+		}
+	}
+	if offsets == nil {
+		return nil, false, fmt.Errorf("unsupported ruby version %v", version)
+	}
+	return offsets, true, nil
+}
+
+func (o *UserOffsets) config() PerfRbOffsetConfig {
+	return PerfRbOffsetConfig{
+		VmRactorMainRactor:          o.Vm_ractor_main_ractor,
+		RactorThreadsRunningEc:      o.Ractor_threads_running_ec,
+		ExecutionContextVmStack:     o.ExecutionContext_vm_stack,
+		ExecutionContextVmStackSize: o.ExecutionContext_vm_stack_size,
+		ExecutionContextCfp:         o.ExecutionContext_cfp,
+		ControlFrameIseq:            o.ControlFrame_iseq,
+		ControlFrameSize:            o.ControlFrameSize,
+		IseqBody:                    o.Iseq_body,
+		IseqBodyLocationPathobj:     o.IseqBody_location_pathobj,
+		IseqBodyLocationLabel:       o.IseqBody_location_label,
+		RStringAsHeapPtr:            o.RString_as_heap_ptr,
+		RStringAsEmbedAry:           o.RString_as_embed_ary,
+		RArrayAsHeapPtr:             o.RArray_as_heap_ptr,
+		RArrayAsAry:                 o.RArray_as_ary,
+	}
+}
@@ -0,0 +1,49 @@
+package ruby
+
+import (
+	"debug/elf"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("3.2.2")
+	require.NoError(t, err)
+	require.Equal(t, Version{3, 2, 2}, v)
+
+	_, err = ParseVersion("3.2")
+	require.Error(t, err)
+}
+
+func TestGetUserOffsets(t *testing.T) {
+	_, _, err := GetUserOffsets(Version{2, 7, 8})
+	require.Error(t, err)
+
+	rbVersions[Version{3, 2, 2}] = &UserOffsets{Iseq_body: 16}
+	defer delete(rbVersions, Version{3, 2, 2})
+
+	offsets, guess, err := GetUserOffsets(Version{3, 2, 2})
+	require.NoError(t, err)
+	require.False(t, guess)
+	require.Equal(t, int16(16), offsets.Iseq_body)
+
+	offsets, guess, err = GetUserOffsets(Version{3, 2, 4})
+	require.NoError(t, err)
+	require.True(t, guess)
+	require.Equal(t, int16(16), offsets.Iseq_body)
+
+	_, _, err = GetUserOffsets(Version{3, 3, 0})
+	require.Error(t, err)
+}
+
+func TestGetRubySymbols(t *testing.T) {
+	f, err := elf.Open("testdata/rbstructs.o")
+	require.NoError(t, err)
+	defer f.Close()
+
+	version, vmSym, err := GetRubySymbols(f)
+	require.NoError(t, err)
+	require.Equal(t, Version{3, 2, 2}, version)
+	require.Equal(t, "ruby_current_vm_ptr", vmSym.Name)
+}
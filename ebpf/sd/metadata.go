@@ -0,0 +1,169 @@
+package sd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	labelSystemdUnit   = "systemd_unit"
+	labelContainerName = "container_name"
+	labelImage         = "image"
+
+	defaultDockerRoot      = "var/lib/docker"
+	defaultContainerdState = "run/containerd"
+)
+
+// MetadataOptions configures the enrichment of the processes not matched by any target with the labels
+// systemd_unit, container_name and image. The systemd unit is derived from the cgroup path, the container
+// name and image are read from the local Docker and containerd state files.
+type MetadataOptions struct {
+	Enabled bool
+	// DockerRoot is the docker data root relative to the TargetFinder fs, var/lib/docker by default.
+	DockerRoot string
+	// ContainerdState is the containerd state directory relative to the TargetFinder fs, run/containerd by default.
+	ContainerdState string
+}
+
+// dockerConfig is the part of /var/lib/docker/containers/{id}/config.v2.json we need.
+type dockerConfig struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+}
+
+// ociSpec is the part of the OCI runtime spec config.json of a containerd task we need.
+type ociSpec struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+var (
+	containerdNameAnnotations = []string{
+		"io.kubernetes.cri.container-name",
+		"nerdctl/name",
+	}
+	containerdImageAnnotations = []string{
+		"io.kubernetes.cri.image-name",
+	}
+)
+
+// getProcessMetadata returns the metadata labels of the process, the container id is empty for
+// processes running outside containers.
+func (tf *targetFinder) getProcessMetadata(pid uint32, cid containerID) DiscoveryTarget {
+	res := DiscoveryTarget{}
+	if unit := tf.getSystemdUnitFromPID(pid); unit != "" {
+		res[labelSystemdUnit] = unit
+	}
+	if cid != "" {
+		if !tf.dockerMetadata(cid, res) {
+			tf.containerdMetadata(cid, res)
+		}
+	}
+	return res
+}
+
+func (tf *targetFinder) getSystemdUnitFromPID(pid uint32) string {
+	f, err := tf.fs.Open(fmt.Sprintf("proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	unit := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// prefer the unified hierarchy and the systemd named hierarchy of cgroup v1
+		if !bytes.HasPrefix(line, []byte("0::")) && !bytes.Contains(line, []byte(":name=systemd:")) {
+			continue
+		}
+		if u := getSystemdUnitFromCGroup(line); u != "" {
+			unit = u
+		}
+	}
+	return unit
+}
+
+// getSystemdUnitFromCGroup returns the innermost service of a cgroup path
+// 0::/system.slice/nginx.service
+// 1:name=systemd:/user.slice/user-1000.slice/user@1000.service/app.slice/app.service
+func getSystemdUnitFromCGroup(line []byte) string {
+	i := bytes.IndexByte(line, '/')
+	if i < 0 {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSpace(string(line[i:])), "/")
+	for j := len(parts) - 1; j >= 0; j-- {
+		if strings.HasSuffix(parts[j], ".service") {
+			return parts[j]
+		}
+	}
+	return ""
+}
+
+func (tf *targetFinder) dockerMetadata(cid containerID, res DiscoveryTarget) bool {
+	root := tf.metadata.DockerRoot
+	if root == "" {
+		root = defaultDockerRoot
+	}
+	data, err := fs.ReadFile(tf.fs, path.Join(root, "containers", string(cid), "config.v2.json"))
+	if err != nil {
+		return false
+	}
+	config := dockerConfig{}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	if name := strings.TrimPrefix(config.Name, "/"); name != "" {
+		res[labelContainerName] = name
+	}
+	if config.Config.Image != "" {
+		res[labelImage] = config.Config.Image
+	}
+	return true
+}
+
+func (tf *targetFinder) containerdMetadata(cid containerID, res DiscoveryTarget) bool {
+	state := tf.metadata.ContainerdState
+	if state == "" {
+		state = defaultContainerdState
+	}
+	tasks := path.Join(state, "io.containerd.runtime.v2.task")
+	namespaces, err := fs.ReadDir(tf.fs, tasks)
+	if err != nil {
+		return false
+	}
+	for _, ns := range namespaces {
+		data, err := fs.ReadFile(tf.fs, path.Join(tasks, ns.Name(), string(cid), "config.json"))
+		if err != nil {
+			continue
+		}
+		spec := ociSpec{}
+		if err = json.Unmarshal(data, &spec); err != nil {
+			return false
+		}
+		if name := firstAnnotation(spec.Annotations, containerdNameAnnotations); name != "" {
+			res[labelContainerName] = name
+		}
+		if image := firstAnnotation(spec.Annotations, containerdImageAnnotations); image != "" {
+			res[labelImage] = image
+		}
+		return true
+	}
+	return false
+}
+
+func firstAnnotation(annotations map[string]string, keys []string) string {
+	for _, k := range keys {
+		if v := annotations[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
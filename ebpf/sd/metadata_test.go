@@ -0,0 +1,83 @@
+package sd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/pyroscope/ebpf/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdUnitFromCGroup(t *testing.T) {
+	testcases := []struct {
+		cgroup, expected string
+	}{
+		{"0::/system.slice/nginx.service", "nginx.service"},
+		{"1:name=systemd:/system.slice/postgresql@14-main.service", "postgresql@14-main.service"},
+		{"0::/user.slice/user-1000.slice/user@1000.service/app.slice/app.service", "app.service"},
+		{"0::/user.slice/user-1000.slice/user@1000.service/init.scope", "user@1000.service"},
+		{"0::/system.slice/docker-656959d9ee87a0b131c601ce9d9f8f76b1dda60e8608c503b5979d849cbdc714.scope", ""},
+		{"0::/", ""},
+	}
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintf("testcase %d %s", i, tc.cgroup), func(t *testing.T) {
+			require.Equal(t, tc.expected, getSystemdUnitFromCGroup([]byte(tc.cgroup)))
+		})
+	}
+}
+
+func TestTargetFinderMetadata(t *testing.T) {
+	const (
+		dockerID     = "656959d9ee87a0b131c601ce9d9f8f76b1dda60e8608c503b5979d849cbdc714"
+		containerdID = "a534eb629135e43beb13213976e37bb2ab95cba4c0d1d0b4e27c6bc4d8091b83"
+	)
+	fs, err := newMockFS()
+	require.NoError(t, err)
+	defer fs.rm()
+	require.NoError(t, fs.add("/proc/100/cgroup", []byte("0::/system.slice/nginx.service\n")))
+	require.NoError(t, fs.add("/proc/101/cgroup", []byte("0::/system.slice/docker-"+dockerID+".scope\n")))
+	require.NoError(t, fs.add("/proc/102/cgroup", []byte("0::/system.slice/containerd.service/"+containerdID+"\n")))
+	require.NoError(t, fs.add("/proc/103/cgroup", []byte("0::/init.scope\n")))
+	require.NoError(t, fs.add("/var/lib/docker/containers/"+dockerID+"/config.v2.json",
+		[]byte(`{"ID":"`+dockerID+`","Name":"/web","Config":{"Image":"nginx:1.25","Labels":{}}}`)))
+	require.NoError(t, fs.add("/run/containerd/io.containerd.runtime.v2.task/default/"+containerdID+"/config.json",
+		[]byte(`{"ociVersion":"1.1.0","annotations":{"nerdctl/name":"redis-1"}}`)))
+
+	options := TargetsOptions{
+		DefaultTarget:      DiscoveryTarget{"env": "prod"},
+		ContainerCacheSize: 1024,
+		Metadata:           MetadataOptions{Enabled: true},
+	}
+	tf, err := NewTargetFinder(fs.root, util.TestLogger(t), options)
+	require.NoError(t, err)
+
+	target := tf.FindTarget(100)
+	require.Equal(t, "nginx", target.ServiceName())
+	require.Equal(t, "nginx.service", target.labels.Get(labelSystemdUnit))
+	require.Equal(t, "prod", target.labels.Get("env"))
+
+	target = tf.FindTarget(101)
+	require.Equal(t, "web", target.ServiceName())
+	require.Equal(t, "web", target.labels.Get(labelContainerName))
+	require.Equal(t, "nginx:1.25", target.labels.Get(labelImage))
+	require.Equal(t, dockerID, target.labels.Get(labelContainerID))
+	require.Equal(t, "", target.labels.Get(labelSystemdUnit))
+
+	target = tf.FindTarget(102)
+	require.Equal(t, "redis-1", target.ServiceName())
+	require.Equal(t, "containerd.service", target.labels.Get(labelSystemdUnit))
+	require.Equal(t, "", target.labels.Get(labelImage))
+
+	target = tf.FindTarget(103)
+	require.Equal(t, "unspecified", target.ServiceName())
+
+	// the default target labels take precedence
+	options.DefaultTarget = DiscoveryTarget{"service_name": "vm"}
+	tf.Update(options)
+	require.Equal(t, "vm", tf.FindTarget(100).ServiceName())
+	require.Equal(t, "nginx.service", tf.FindTarget(100).labels.Get(labelSystemdUnit))
+
+	options.Metadata.Enabled = false
+	tf.Update(options)
+	require.Equal(t, "", tf.FindTarget(100).labels.Get(labelSystemdUnit))
+}
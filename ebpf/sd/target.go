@@ -95,6 +95,12 @@ func inferServiceName(target DiscoveryTarget) string {
 	if dockerContainer != "" {
 		return dockerContainer
 	}
+	if container := target[labelContainerName]; container != "" {
+		return container
+	}
+	if unit := target[labelSystemdUnit]; unit != "" {
+		return strings.TrimSuffix(unit, ".service")
+	}
 	return "unspecified"
 }
 
@@ -123,6 +129,8 @@ type TargetsOptions struct {
 	TargetsOnly        bool
 	DefaultTarget      DiscoveryTarget
 	ContainerCacheSize int
+	// Metadata enriches the default target of the processes with their systemd unit and container name and image.
+	Metadata MetadataOptions
 }
 
 type targetFinder struct {
@@ -135,6 +143,10 @@ type targetFinder struct {
 	defaultTarget    *Target
 	fs               fs.FS
 
+	metadata             MetadataOptions
+	defaultDiscovery     DiscoveryTarget
+	metadataTargetsCache *lru.Cache[uint32, *Target]
+
 	sync sync.Mutex
 }
 
@@ -143,10 +155,15 @@ func NewTargetFinder(fs fs.FS, l log.Logger, options TargetsOptions) (TargetFind
 	if err != nil {
 		return nil, fmt.Errorf("containerIDCache create: %w", err)
 	}
+	metadataTargetsCache, err := lru.New[uint32, *Target](options.ContainerCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("metadataTargetsCache create: %w", err)
+	}
 	res := &targetFinder{
-		l:                l,
-		containerIDCache: containerIDCache,
-		fs:               fs,
+		l:                    l,
+		containerIDCache:     containerIDCache,
+		metadataTargetsCache: metadataTargetsCache,
+		fs:                   fs,
 	}
 	res.setTargets(options)
 	return res, nil
@@ -159,6 +176,9 @@ func (tf *targetFinder) FindTarget(pid uint32) *Target {
 	if res != nil {
 		return res
 	}
+	if tf.defaultTarget != nil && tf.metadata.Enabled {
+		return tf.findMetadataTarget(pid)
+	}
 	return tf.defaultTarget
 }
 
@@ -166,6 +186,7 @@ func (tf *targetFinder) RemoveDeadPID(pid uint32) {
 	tf.sync.Lock()
 	defer tf.sync.Unlock()
 	tf.containerIDCache.Remove(pid)
+	tf.metadataTargetsCache.Remove(pid)
 	delete(tf.pid2target, pid)
 }
 
@@ -200,6 +221,9 @@ func (tf *targetFinder) setTargets(opts TargetsOptions) {
 		t := NewTarget("", 0, opts.DefaultTarget)
 		tf.defaultTarget = t
 	}
+	tf.metadata = opts.Metadata
+	tf.defaultDiscovery = opts.DefaultTarget
+	tf.metadataTargetsCache.Purge()
 	_ = level.Debug(tf.l).Log("msg", "created targets", "count", len(tf.cid2target))
 }
 
@@ -217,8 +241,35 @@ func (tf *targetFinder) findTarget(pid uint32) *Target {
 	return tf.cid2target[cid]
 }
 
+// findMetadataTarget returns the default target with the metadata labels of the process added.
+func (tf *targetFinder) findMetadataTarget(pid uint32) *Target {
+	if t, ok := tf.metadataTargetsCache.Get(pid); ok {
+		return t
+	}
+	cid, ok := tf.containerIDCache.Get(pid)
+	if !ok {
+		cid = tf.getContainerIDFromPID(pid)
+	}
+	t := tf.defaultTarget
+	metadata := tf.getProcessMetadata(pid, cid)
+	if len(metadata) > 0 {
+		lset := make(DiscoveryTarget, len(tf.defaultDiscovery)+len(metadata))
+		for k, v := range metadata {
+			lset[k] = v
+		}
+		// the labels of the default target take precedence
+		for k, v := range tf.defaultDiscovery {
+			lset[k] = v
+		}
+		t = NewTarget(cid, 0, lset)
+	}
+	tf.metadataTargetsCache.Add(pid, t)
+	return t
+}
+
 func (tf *targetFinder) resizeContainerIDCache(size int) {
 	tf.containerIDCache.Resize(size)
+	tf.metadataTargetsCache.Resize(size)
 }
 
 func (tf *targetFinder) DebugInfo() []string {
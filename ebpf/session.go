@@ -12,8 +12,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/btf"
@@ -23,11 +25,12 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/pyroscope/ebpf/cpuonline"
 	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/pprof"
 	"github.com/grafana/pyroscope/ebpf/pyrobpf"
-	"github.com/grafana/pyroscope/ebpf/python"
 	"github.com/grafana/pyroscope/ebpf/rlimit"
 	"github.com/grafana/pyroscope/ebpf/sd"
 	"github.com/grafana/pyroscope/ebpf/symtab"
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
 	"github.com/samber/lo"
 )
 
@@ -37,17 +40,54 @@ type SessionOptions struct {
 	UnknownSymbolModuleOffset bool // use libfoo.so+0xef instead of libfoo.so for unknown symbols
 	UnknownSymbolAddress      bool // use 0xcafebabe instead of [unknown]
 	PythonEnabled             bool
+	RubyEnabled               bool
+	NodejsEnabled             bool
 	CacheOptions              symtab.CacheOptions
 	Metrics                   *metrics.Metrics
 	SampleRate                int
-}
+	// OffCPU enables off-cpu profiling: the time threads of the frame pointer profiled processes
+	// spend switched out, blocked on locks, IO or waiting in the run queue, is aggregated per stack
+	// using the sched_switch tracepoint and collected as pprof.SampleTypeOffCpu samples.
+	OffCPU bool
+	// OffCPUMinBlockDuration is the minimum blocked duration accounted by the off-cpu profiling.
+	OffCPUMinBlockDuration time.Duration
+	// ThreadLabels adds the thread id and the thread name (comm) of the frame pointer and off-cpu samples
+	// as pprof.LabelThreadID and pprof.LabelThreadName sample labels.
+	ThreadLabels bool
+	// CPULabels adds the cpu of the frame pointer and off-cpu samples as pprof.LabelCPU sample labels.
+	CPULabels bool
+}
+
+type ProfileSample struct {
+	Target *sd.Target
+	Pid    uint32
+	// Stack is the resolved stack, the root frame first.
+	Stack []string
+	// Lines holds the inline chains of Stack frames, innermost function first.
+	// It is only set when CacheOptions.SymbolOptions.InlineFrames is enabled,
+	// frames resolved without DWARF debug info have nil lines.
+	Lines [][]pprof.Line
+	// SampleType is pprof.SampleTypeCpu for on-cpu samples with Value in number of samples,
+	// or pprof.SampleTypeOffCpu with Value in nanoseconds.
+	SampleType pprof.SampleType
+	Value      uint64
+	// Labels are the per-thread and per-cpu sample labels enabled by SessionOptions ThreadLabels and CPULabels.
+	Labels []pprof.SampleLabel
+}
+
+type CollectProfilesCallback func(sample ProfileSample)
 
 type Session interface {
 	Start() error
 	Stop()
 	Update(SessionOptions) error
 	UpdateTargets(args sd.TargetsOptions)
+	// CollectProfiles calls f with the stacks of the on-cpu samples only.
+	//
+	// Deprecated: use CollectProfileSamples, which also reports the off-cpu samples,
+	// the inline frames and the sample labels.
 	CollectProfiles(f func(target *sd.Target, stack []string, value uint64, pid uint32)) error
+	CollectProfileSamples(f CollectProfilesCallback) error
 	DebugInfo() interface{}
 }
 
@@ -95,13 +135,15 @@ type session struct {
 	started bool
 	kprobes []link.Link
 
-	pyperf       *python.Perf
-	pyperfEvents []*python.PerfPyEvent
-	pyperfBpf    python.PerfObjects
-	pyperfError  error
+	pyperf *interpreter
+	rbperf *interpreter
+	v8perf *interpreter
 
 	pids            pids
 	pidExecRequests chan uint32
+
+	offcpuBpf  pyrobpf.OffcpuObjects
+	offcpuLink link.Link
 }
 
 func NewSession(
@@ -126,6 +168,9 @@ func NewSession(
 			dead:    make(map[uint32]struct{}),
 			all:     make(map[uint32]procInfoLite),
 		},
+		pyperf: newPyPerf(sessionOptions),
+		rbperf: newRbPerf(sessionOptions),
+		v8perf: newV8Perf(sessionOptions),
 	}, nil
 }
 
@@ -150,6 +195,11 @@ func (s *session) Start() error {
 
 	btf.FlushKernelSpec() // save some memory
 
+	if err = s.updateSampleConfig(s.options); err != nil {
+		s.stopLocked()
+		return err
+	}
+
 	s.perfEvents, err = attachPerfEvents(s.options.SampleRate, s.bpf.DoPerfEvent)
 	if err != nil {
 		s.stopLocked()
@@ -167,6 +217,13 @@ func (s *session) Start() error {
 		return fmt.Errorf("link kprobes: %w", err)
 	}
 
+	if s.options.OffCPU {
+		if err = s.startOffCPU(); err != nil {
+			s.stopLocked()
+			return fmt.Errorf("start off-cpu profiling: %w", err)
+		}
+	}
+
 	s.eventsReader = eventsReader
 	pidInfoRequests := make(chan uint32, 1024)
 	pidExecRequests := make(chan uint32, 1024)
@@ -204,6 +261,26 @@ func (s *session) Update(options SessionOptions) error {
 	defer s.mutex.Unlock()
 
 	s.symCache.UpdateOptions(options.CacheOptions)
+	if s.started && options.OffCPU != s.options.OffCPU {
+		if options.OffCPU {
+			if err := s.startOffCPU(); err != nil {
+				s.stopOffCPU()
+				return fmt.Errorf("start off-cpu profiling: %w", err)
+			}
+		} else {
+			s.stopOffCPU()
+		}
+	}
+	if s.started && (options.ThreadLabels != s.options.ThreadLabels || options.CPULabels != s.options.CPULabels) {
+		if err := s.updateSampleConfig(options); err != nil {
+			return err
+		}
+	}
+	if s.started && options.OffCPU && options.OffCPUMinBlockDuration != s.options.OffCPUMinBlockDuration {
+		if err := s.updateOffCPUConfig(options.OffCPUMinBlockDuration); err != nil {
+			return err
+		}
+	}
 	s.options = options
 	return nil
 }
@@ -224,23 +301,47 @@ func (s *session) UpdateTargets(args sd.TargetsOptions) {
 	}
 }
 
-func (s *session) CollectProfiles(cb func(t *sd.Target, stack []string, value uint64, pid uint32)) error {
+func (s *session) CollectProfiles(f func(target *sd.Target, stack []string, value uint64, pid uint32)) error {
+	return s.CollectProfileSamples(func(sample ProfileSample) {
+		if sample.SampleType == pprof.SampleTypeCpu {
+			f(sample.Target, sample.Stack, sample.Value, sample.Pid)
+		}
+	})
+}
+
+func (s *session) CollectProfileSamples(cb CollectProfilesCallback) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.symCache.NextRound()
 	s.roundNumber++
 
-	err := s.collectPythonProfile(cb)
+	for _, it := range s.interpreters() {
+		if err := s.collectInterpreterProfile(it, cb); err != nil {
+			return err
+		}
+	}
+
+	knownStacks := map[uint32]bool{}
+	err := s.collectRegularProfile(cb, knownStacks)
+	if err != nil {
+		return err
+	}
+
+	err = s.collectOffCPUProfile(cb, knownStacks)
 	if err != nil {
 		return err
 	}
 
-	err = s.collectRegularProfile(cb)
+	pendingStacks, err := s.getOffCPUStartStacks()
 	if err != nil {
 		return err
 	}
 
+	if err = s.clearStacksMap(knownStacks, pendingStacks); err != nil {
+		return fmt.Errorf("clear stacks map %w", err)
+	}
+
 	s.cleanup()
 
 	return nil
@@ -256,7 +357,7 @@ func (s *session) DebugInfo() interface{} {
 	}
 }
 
-func (s *session) collectRegularProfile(cb func(t *sd.Target, stack []string, value uint64, pid uint32)) error {
+func (s *session) collectRegularProfile(cb CollectProfilesCallback, knownStacks map[uint32]bool) error {
 	sb := &stackBuilder{}
 
 	keys, values, batch, err := s.getCountsMapValues()
@@ -264,65 +365,100 @@ func (s *session) collectRegularProfile(cb func(t *sd.Target, stack []string, va
 		return fmt.Errorf("get counts map: %w", err)
 	}
 
-	knownStacks := map[uint32]bool{}
-
 	for i := range keys {
-		ck := &keys[i]
-		value := values[i]
+		s.collectSample(sb, &keys[i], pprof.SampleTypeCpu, uint64(values[i]), knownStacks, cb)
+	}
 
-		if ck.UserStack >= 0 {
-			knownStacks[uint32(ck.UserStack)] = true
-		}
-		if ck.KernStack >= 0 {
-			knownStacks[uint32(ck.KernStack)] = true
-		}
-		labels := s.targetFinder.FindTarget(ck.Pid)
-		if labels == nil {
-			continue
-		}
-		if _, ok := s.pids.dead[ck.Pid]; ok {
-			continue
-		}
+	if err = s.clearCountsMap(keys, batch); err != nil {
+		return fmt.Errorf("clear counts map %w", err)
+	}
+	return nil
+}
 
-		proc := s.symCache.GetProcTable(symtab.PidKey(ck.Pid))
-		if proc.Error() != nil {
-			s.pids.dead[uint32(proc.Pid())] = struct{}{}
-			// in theory if we saw this process alive before, we could try resolving tack anyway
-			// it may succeed if we have same binary loaded in another process, not doing it for now
-			continue
-		}
+// collectSample resolves the stacks of the sample key and passes the sample to cb.
+// The stack ids of the key are added to knownStacks to be cleared after the collection.
+func (s *session) collectSample(sb *stackBuilder, ck *pyrobpf.ProfileSampleKey, sampleType pprof.SampleType, value uint64, knownStacks map[uint32]bool, cb CollectProfilesCallback) {
+	if ck.UserStack >= 0 {
+		knownStacks[uint32(ck.UserStack)] = true
+	}
+	if ck.KernStack >= 0 {
+		knownStacks[uint32(ck.KernStack)] = true
+	}
+	labels := s.targetFinder.FindTarget(ck.Pid)
+	if labels == nil {
+		return
+	}
+	if _, ok := s.pids.dead[ck.Pid]; ok {
+		return
+	}
 
-		var uStack []byte
-		var kStack []byte
-		if s.options.CollectUser {
-			uStack = s.GetStack(ck.UserStack)
-		}
-		if s.options.CollectKernel {
-			kStack = s.GetStack(ck.KernStack)
-		}
+	proc := s.symCache.GetProcTable(symtab.PidKey(ck.Pid))
+	if proc.Error() != nil {
+		s.pids.dead[uint32(proc.Pid())] = struct{}{}
+		// in theory if we saw this process alive before, we could try resolving tack anyway
+		// it may succeed if we have same binary loaded in another process, not doing it for now
+		return
+	}
 
-		stats := StackResolveStats{}
-		sb.reset()
-		sb.append(s.comm(ck.Pid))
-		if s.options.CollectUser {
-			s.WalkStack(sb, uStack, proc, &stats)
-		}
-		if s.options.CollectKernel {
-			s.WalkStack(sb, kStack, s.symCache.GetKallsyms(), &stats)
-		}
-		if len(sb.stack) == 1 {
-			continue // only comm
-		}
-		lo.Reverse(sb.stack)
-		cb(labels, sb.stack, uint64(value), ck.Pid)
-		s.collectMetrics(labels, &stats, sb)
+	var uStack []byte
+	var kStack []byte
+	if s.options.CollectUser {
+		uStack = s.GetStack(ck.UserStack)
+	}
+	if s.options.CollectKernel {
+		kStack = s.GetStack(ck.KernStack)
 	}
 
-	if err = s.clearCountsMap(keys, batch); err != nil {
-		return fmt.Errorf("clear counts map %w", err)
+	stats := StackResolveStats{}
+	sb.reset()
+	sb.append(s.comm(ck.Pid))
+	if s.options.CollectUser {
+		s.WalkStack(sb, uStack, proc, &stats)
 	}
-	if err = s.clearStacksMap(knownStacks); err != nil {
-		return fmt.Errorf("clear stacks map %w", err)
+	if s.options.CollectKernel {
+		s.WalkStack(sb, kStack, s.symCache.GetKallsyms(), &stats)
+	}
+	if len(sb.stack) == 1 {
+		return // only comm
+	}
+	sb.reverse()
+	cb(ProfileSample{
+		Target:     labels,
+		Pid:        ck.Pid,
+		Stack:      sb.stack,
+		Lines:      sb.sampleLines(),
+		SampleType: sampleType,
+		Value:      value,
+		Labels:     sampleLabels(ck),
+	})
+	s.collectMetrics(labels, &stats, sb)
+}
+
+// sampleLabels returns the thread and cpu labels of the key, if they were collected.
+func sampleLabels(ck *pyrobpf.ProfileSampleKey) []pprof.SampleLabel {
+	if ck.Flags&(pyrobpf.SampleKeyFlagThread|pyrobpf.SampleKeyFlagCPU) == 0 {
+		return nil
+	}
+	res := make([]pprof.SampleLabel, 0, 3)
+	if ck.Flags&pyrobpf.SampleKeyFlagThread != 0 {
+		res = append(res,
+			pprof.SampleLabel{Name: pprof.LabelThreadID, Value: strconv.FormatUint(uint64(ck.Tid), 10)},
+			pprof.SampleLabel{Name: pprof.LabelThreadName, Value: cStringFromI8Unsafe(ck.Comm[:])},
+		)
+	}
+	if ck.Flags&pyrobpf.SampleKeyFlagCPU != 0 {
+		res = append(res, pprof.SampleLabel{Name: pprof.LabelCPU, Value: strconv.FormatUint(uint64(ck.Cpu), 10)})
+	}
+	return res
+}
+
+func (s *session) updateSampleConfig(options SessionOptions) error {
+	config := pyrobpf.ProfileSampleConfig{
+		CollectThread: uint8FromBool(options.ThreadLabels),
+		CollectCpu:    uint8FromBool(options.CPULabels),
+	}
+	if err := s.bpf.SampleSettings.Update(uint32(0), &config, ebpf.UpdateAny); err != nil {
+		return fmt.Errorf("update sample config: %w", err)
 	}
 	return nil
 }
@@ -366,9 +502,10 @@ func (s *session) stopLocked() {
 	}
 	s.kprobes = nil
 	_ = s.bpf.Close()
-	if s.pyperf != nil {
-		s.pyperf.Close()
+	for _, it := range s.interpreters() {
+		s.stopInterpreter(it)
 	}
+	s.stopOffCPU()
 	if s.eventsReader != nil {
 		err := s.eventsReader.Close()
 		if err != nil {
@@ -463,7 +600,10 @@ func (s *session) WalkStack(sb *stackBuilder, stack []byte, resolver symtab.Symb
 	if len(stack) == 0 {
 		return
 	}
-	var stackFrames []string
+	var (
+		stackFrames []string
+		stackLines  [][]pprof.Line
+	)
 	for i := 0; i < 127; i++ {
 		instructionPointerBytes := stack[i*8 : i*8+8]
 		instructionPointer := binary.LittleEndian.Uint64(instructionPointerBytes)
@@ -493,11 +633,30 @@ func (s *session) WalkStack(sb *stackBuilder, stack []byte, resolver symtab.Symb
 			}
 		}
 		stackFrames = append(stackFrames, name)
+		if s.options.CacheOptions.SymbolOptions.InlineFrames {
+			stackLines = append(stackLines, convertLines(sym.Lines))
+		}
 	}
 	lo.Reverse(stackFrames)
-	for _, s := range stackFrames {
-		sb.append(s)
+	lo.Reverse(stackLines)
+	for i, name := range stackFrames {
+		if stackLines != nil {
+			sb.appendLines(name, stackLines[i])
+		} else {
+			sb.append(name)
+		}
+	}
+}
+
+func convertLines(lines []elf.Line) []pprof.Line {
+	if len(lines) == 0 {
+		return nil
+	}
+	res := make([]pprof.Line, len(lines))
+	for i, l := range lines {
+		res[i] = pprof.Line{Function: l.Function, File: l.File, Line: l.Line}
 	}
+	return res
 }
 
 func (s *session) readEvents(events *perf.Reader,
@@ -584,8 +743,8 @@ func (s *session) startProfilingLocked(pid uint32, target *sd.Target) {
 		return
 	}
 	typ := s.selectProfilingType(pid, target)
-	if typ.typ == pyrobpf.ProfilingTypePython {
-		go s.tryStartPythonProfiling(pid, target, typ)
+	if it := s.interpreter(typ.typ); it != nil {
+		go s.tryStartInterpreterProfiling(it, pid, target, typ)
 		return
 	}
 	s.setPidConfig(pid, typ, s.options.CollectUser, s.options.CollectKernel)
@@ -619,6 +778,12 @@ func (s *session) selectProfilingType(pid uint32, target *sd.Target) procInfoLit
 	if s.options.PythonEnabled && strings.HasPrefix(exe, "python") || exe == "uwsgi" {
 		return procInfoLite{pid: pid, comm: string(comm), typ: pyrobpf.ProfilingTypePython}
 	}
+	if s.options.RubyEnabled && strings.HasPrefix(exe, "ruby") {
+		return procInfoLite{pid: pid, comm: string(comm), typ: pyrobpf.ProfilingTypeRuby}
+	}
+	if s.options.NodejsEnabled && (exe == "node" || exe == "nodejs") {
+		return procInfoLite{pid: pid, comm: string(comm), typ: pyrobpf.ProfilingTypeNodejs}
+	}
 	return procInfoLite{pid: pid, comm: string(comm), typ: pyrobpf.ProfilingTypeFramepointers}
 }
 
@@ -715,8 +880,10 @@ func (s *session) cleanup() {
 		delete(s.pids.unknown, pid)
 		delete(s.pids.all, pid)
 		s.symCache.RemoveDeadPID(symtab.PidKey(pid))
-		if s.pyperf != nil {
-			s.pyperf.RemoveDeadPID(pid)
+		for _, it := range s.interpreters() {
+			if it.perf != nil {
+				it.perf.RemoveDeadPID(pid)
+			}
 		}
 		if err := s.bpf.Pids.Delete(pid); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
 			_ = level.Error(s.logger).Log("msg", "delete pid config", "pid", pid, "err", err)
@@ -741,12 +908,42 @@ func (s *session) cleanup() {
 
 type stackBuilder struct {
 	stack []string
+	// lines is parallel to stack, it is only filled once a frame with lines is appended
+	lines    [][]pprof.Line
+	hasLines bool
 }
 
 func (s *stackBuilder) reset() {
 	s.stack = s.stack[:0]
+	for i := range s.lines {
+		s.lines[i] = nil
+	}
+	s.lines = s.lines[:0]
+	s.hasLines = false
 }
 
 func (s *stackBuilder) append(sym string) {
 	s.stack = append(s.stack, sym)
+	s.lines = append(s.lines, nil)
+}
+
+func (s *stackBuilder) appendLines(sym string, lines []pprof.Line) {
+	s.stack = append(s.stack, sym)
+	s.lines = append(s.lines, lines)
+	if lines != nil {
+		s.hasLines = true
+	}
+}
+
+func (s *stackBuilder) reverse() {
+	lo.Reverse(s.stack)
+	lo.Reverse(s.lines)
+}
+
+// sampleLines returns nil if none of the frames has lines.
+func (s *stackBuilder) sampleLines() [][]pprof.Line {
+	if !s.hasLines {
+		return nil
+	}
+	return s.lines
 }
@@ -0,0 +1,314 @@
+package ebpfspy
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/interp"
+	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/pyrobpf"
+	"github.com/grafana/pyroscope/ebpf/sd"
+	"github.com/samber/lo"
+)
+
+// interpreter is an eBPF interpreter unwinder: pyperf, or rbperf and v8perf built on top of the interp package.
+// It is loaded lazily, when the first process of its type is found.
+type interpreter struct {
+	name    string
+	typ     pyrobpf.ProfilingType
+	progIdx uint32
+	metrics *metrics.InterpreterMetrics
+
+	// load loads the ebpf objects and returns the perf reading them and the program to link to the progs map
+	load    func(l log.Logger, opts *ebpf.CollectionOptions) (interpreterPerf, *ebpf.Program, error)
+	pidData func(l log.Logger, pid uint32) (any, error)
+
+	perf interpreterPerf
+	err  error
+}
+
+// interpreterPerf is the userspace part of an unwinder, owning its ebpf objects.
+type interpreterPerf interface {
+	StartProfiling(pid uint32, data any, serviceName string) error
+	RemoveDeadPID(pid uint32)
+	// collectEvents returns the stacks collected since the previous call, valid until the next call.
+	collectEvents() []interpreterEvent
+	// symbols returns the function formatting the frames of the collected stacks.
+	symbols(s *session) func(id uint32, svc string) (string, error)
+	Close()
+}
+
+// interpreterEvent is a stack collected by an unwinder.
+type interpreterEvent struct {
+	pid       uint32
+	kernStack int64
+	stack     []uint32
+	// failed is set if the unwinder could not collect the stack, status and err are the reason
+	failed      bool
+	status, err any
+}
+
+func (s *session) interpreters() []*interpreter {
+	return []*interpreter{s.pyperf, s.rbperf, s.v8perf}
+}
+
+func (s *session) interpreter(typ pyrobpf.ProfilingType) *interpreter {
+	for _, it := range s.interpreters() {
+		if it.typ == typ {
+			return it
+		}
+	}
+	return nil
+}
+
+func (s *session) collectInterpreterProfile(it *interpreter, cb CollectProfilesCallback) error {
+	if it.perf == nil {
+		return nil
+	}
+	events := it.perf.collectEvents()
+	if len(events) == 0 {
+		return nil
+	}
+	defer func() {
+		for i := range events {
+			events[i] = interpreterEvent{}
+		}
+	}()
+	symbols := it.perf.symbols(s)
+
+	sb := &stackBuilder{}
+	stacktraceErrors := 0
+	unknownSymbols := 0
+	for _, event := range events {
+		stats := StackResolveStats{}
+		labels := s.targetFinder.FindTarget(event.pid)
+		if labels == nil {
+			continue
+		}
+		svc := labels.ServiceName()
+
+		sb.reset()
+
+		sb.append(s.comm(event.pid))
+		if event.failed {
+			_ = level.Debug(s.logger).Log("msg", "collect "+it.name,
+				"stack_status", event.status,
+				"pid", event.pid,
+				"err", event.err)
+			it.metrics.StacktraceError.Inc()
+			stacktraceErrors += 1
+		} else {
+			begin := len(sb.stack)
+			for _, id := range event.stack {
+				sym, err := symbols(id, svc)
+				if err == nil {
+					sb.append(sym)
+				} else {
+					sb.append(it.name + "_unknown")
+					it.metrics.UnknownSymbols.WithLabelValues(svc).Inc()
+					unknownSymbols += 1
+				}
+			}
+			end := len(sb.stack)
+			lo.Reverse(sb.stack[begin:end])
+		}
+		if s.options.CollectKernel && event.kernStack != -1 {
+			kStack := s.GetStack(event.kernStack)
+			s.WalkStack(sb, kStack, s.symCache.GetKallsyms(), &stats)
+		}
+		if len(sb.stack) == 1 {
+			continue // only comm
+		}
+		sb.reverse()
+		cb(ProfileSample{
+			Target: labels,
+			Pid:    event.pid,
+			Stack:  sb.stack,
+			Lines:  sb.sampleLines(),
+			Value:  1,
+		})
+		s.collectMetrics(labels, &stats, sb)
+	}
+	if stacktraceErrors > 0 {
+		_ = level.Error(s.logger).Log("msg", it.name+" stacktrace errors", "count", stacktraceErrors)
+	}
+	if unknownSymbols > 0 {
+		_ = level.Error(s.logger).Log("msg", it.name+" unknown symbols", "count", unknownSymbols)
+	}
+	return nil
+}
+
+func (s *session) tryStartInterpreterProfiling(it *interpreter, pid uint32, target *sd.Target, pi procInfoLite) {
+	const nTries = 4
+	for i := 0; i < nTries; i++ {
+		shouldRetry := s.startInterpreterProfiling(it, pid, target, pi, i == nTries-1)
+		if !shouldRetry {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *session) startInterpreterProfiling(it *interpreter, pid uint32, target *sd.Target, pi procInfoLite, lastAttempt bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.started {
+		return false
+	}
+	_, dead := s.pids.dead[pid]
+	if dead {
+		return false
+	}
+	perf := s.getInterpreterPerf(it)
+	if perf == nil {
+		_ = level.Error(s.logger).Log("err", it.name+" process profiling init failed. perf == nil", "pid", pid)
+		pi.typ = pyrobpf.ProfilingTypeError
+		s.setPidConfig(pid, pi, false, false)
+		return false
+	}
+
+	data, err := it.pidData(s.logger, pid)
+	svc := target.ServiceName()
+	if err != nil {
+		alive := processAlive(pid)
+		if alive && lastAttempt {
+			it.metrics.PidDataError.WithLabelValues(svc).Inc()
+			_ = level.Error(s.logger).Log("err", err, "msg", it.name+" get process data failed", "pid", pid, "target", target.String())
+		} else {
+			_ = level.Debug(s.logger).Log("err", err, "msg", it.name+" get process data failed", "pid", pid, "target", target.String())
+		}
+		pi.typ = pyrobpf.ProfilingTypeError
+		s.setPidConfig(pid, pi, false, false)
+		return alive
+	}
+
+	err = perf.StartProfiling(pid, data, svc)
+	if err != nil {
+		_ = level.Error(s.logger).Log("err", err, "msg", it.name+" process profiling init failed", "pid", pid)
+		pi.typ = pyrobpf.ProfilingTypeError
+		s.setPidConfig(pid, pi, false, false)
+		return false
+	}
+	_ = level.Info(s.logger).Log("msg", it.name+" process profiling init success", "pid", pid,
+		"data", fmt.Sprintf("%+v", data), "target", target.String())
+	s.setPidConfig(pid, pi, s.options.CollectUser, s.options.CollectKernel)
+	return false
+}
+
+// may return nil if loadInterpreterPerf returns error
+func (s *session) getInterpreterPerf(it *interpreter) interpreterPerf {
+	if it.perf != nil {
+		return it.perf
+	}
+	if it.err != nil {
+		return nil
+	}
+	it.metrics.Load.Inc()
+	perf, err := s.loadInterpreterPerf(it)
+	if err != nil {
+		it.err = err
+		it.metrics.LoadError.Inc()
+		_ = level.Error(s.logger).Log("err", err, "msg", "load "+it.name)
+		return nil
+	}
+	it.perf = perf
+	return it.perf
+}
+
+func (s *session) loadInterpreterPerf(it *interpreter) (interpreterPerf, error) {
+	defer btf.FlushKernelSpec() // save some memory
+	opts := &ebpf.CollectionOptions{
+		Programs: ebpf.ProgramOptions{
+			LogDisabled: true,
+		},
+		MapReplacements: map[string]*ebpf.Map{
+			"stacks": s.bpf.Stacks,
+		},
+	}
+	perf, collect, err := it.load(s.logger, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s load %w", it.name, err)
+	}
+	err = s.bpf.ProfileMaps.Progs.Update(it.progIdx, collect, ebpf.UpdateAny)
+	if err != nil {
+		perf.Close()
+		return nil, fmt.Errorf("%s link %w", it.name, err)
+	}
+	_ = level.Info(s.logger).Log("msg", it.name+" loaded")
+	return perf, nil
+}
+
+func (s *session) stopInterpreter(it *interpreter) {
+	if it.perf != nil {
+		it.perf.Close()
+		it.perf = nil
+	}
+	it.err = nil
+}
+
+// interpPerf is the interpreterPerf of the unwinders built on top of the interp package.
+type interpPerf struct {
+	*interp.Perf
+	objects io.Closer
+	events  []*interp.Event
+	stacks  []interpreterEvent
+}
+
+func newInterpPerf(it *interpreter, l log.Logger, objects io.Closer, events, pidData, symbols *ebpf.Map) (*interpPerf, error) {
+	perf, err := interp.NewPerf(it.name, l, it.metrics, events, pidData, symbols)
+	if err != nil {
+		_ = objects.Close()
+		return nil, fmt.Errorf("create %w", err)
+	}
+	return &interpPerf{Perf: perf, objects: objects}, nil
+}
+
+func (p *interpPerf) collectEvents() []interpreterEvent {
+	p.events = p.Perf.CollectEvents(p.events)
+	p.stacks = p.stacks[:0]
+	for i, event := range p.events {
+		p.stacks = append(p.stacks, interpreterEvent{
+			pid:       event.Pid,
+			kernStack: event.KernStack,
+			stack:     event.Stack[:event.StackLen],
+			failed:    event.StackStatus == uint8(interp.StackStatusError),
+			status:    interp.StackStatus(event.StackStatus),
+			err:       event.Err,
+		})
+		p.events[i] = nil
+	}
+	return p.stacks
+}
+
+func (p *interpPerf) symbols(_ *session) func(id uint32, svc string) (string, error) {
+	symbols := p.Perf.GetLazySymbols()
+	return func(id uint32, svc string) (string, error) {
+		sym, err := symbols.GetSymbol(id, svc)
+		if err != nil {
+			return "", err
+		}
+		return formatInterpreterSymbol(sym), nil
+	}
+}
+
+func (p *interpPerf) Close() {
+	p.Perf.Close()
+	_ = p.objects.Close()
+}
+
+func formatInterpreterSymbol(sym *interp.Symbol) string {
+	name := cStringFromI8Unsafe(sym.Name[:])
+	if name == "" {
+		name = "(anonymous)"
+	}
+	file := cStringFromI8Unsafe(sym.File[:])
+	if file == "" {
+		return name
+	}
+	return fmt.Sprintf("%s %s", file, name)
+}
@@ -15,20 +15,29 @@ import (
 )
 
 func (s *session) getCountsMapValues() (keys []pyrobpf.ProfileSampleKey, values []uint32, batch bool, err error) {
+	return getSampleMapValues[uint32](s, s.bpf.ProfileMaps.Counts)
+}
+
+func (s *session) clearCountsMap(keys []pyrobpf.ProfileSampleKey, batch bool) error {
+	return clearSampleMap(s, s.bpf.ProfileMaps.Counts, keys, batch)
+}
+
+// getSampleMapValues reads a map keyed by sample_key, like counts or offcpu_counts.
+func getSampleMapValues[V uint32 | uint64](s *session, m *ebpf.Map) (keys []pyrobpf.ProfileSampleKey, values []V, batch bool, err error) {
 	// try batch first
 	var (
-		m       = s.bpf.ProfileMaps.Counts
 		mapSize = m.MaxEntries()
 		nextKey = pyrobpf.ProfileSampleKey{}
 	)
 	keys = make([]pyrobpf.ProfileSampleKey, mapSize)
-	values = make([]uint32, mapSize)
+	values = make([]V, mapSize)
 
 	opts := &ebpf.BatchOptions{}
 	n, err := m.BatchLookupAndDelete(nil, &nextKey, keys, values, opts)
 	if n > 0 {
 		level.Debug(s.logger).Log(
-			"msg", "getCountsMapValues BatchLookupAndDelete",
+			"msg", "getSampleMapValues BatchLookupAndDelete",
+			"map", m.String(),
 			"count", n,
 		)
 		return keys[:n], values[:n], true, nil
@@ -41,7 +50,7 @@ func (s *session) getCountsMapValues() (keys []pyrobpf.ProfileSampleKey, values
 	resultValues := values[:0]
 	it := m.Iterate()
 	k := pyrobpf.ProfileSampleKey{}
-	v := uint32(0)
+	var v V
 	for {
 		ok := it.Next(&k, &v)
 		if !ok {
@@ -56,21 +65,21 @@ func (s *session) getCountsMapValues() (keys []pyrobpf.ProfileSampleKey, values
 		resultValues = append(resultValues, v)
 	}
 	level.Debug(s.logger).Log(
-		"msg", "getCountsMapValues iter",
+		"msg", "getSampleMapValues iter",
+		"map", m.String(),
 		"count", len(keys),
 	)
 	return resultKeys, resultValues, false, nil
 }
 
-func (s *session) clearCountsMap(keys []pyrobpf.ProfileSampleKey, batch bool) error {
+func clearSampleMap(s *session, m *ebpf.Map, keys []pyrobpf.ProfileSampleKey, batch bool) error {
 	if len(keys) == 0 {
 		return nil
 	}
 	if batch {
-		// do nothing, already deleted with GetValueAndDeleteBatch in getCountsMapValues
+		// do nothing, already deleted with GetValueAndDeleteBatch in getSampleMapValues
 		return nil
 	}
-	m := s.bpf.ProfileMaps.Counts
 	for i := range keys {
 		err := m.Delete(&keys[i])
 		if err != nil {
@@ -78,13 +87,16 @@ func (s *session) clearCountsMap(keys []pyrobpf.ProfileSampleKey, batch bool) er
 		}
 	}
 	level.Debug(s.logger).Log(
-		"msg", "clearCountsMap",
+		"msg", "clearSampleMap",
+		"map", m.String(),
 		"count", len(keys),
 	)
 	return nil
 }
 
-func (s *session) clearStacksMap(knownKeys map[uint32]bool) error {
+// clearStacksMap deletes the stacks of the collected samples, or all the stacks once in a while.
+// The pending stacks, still referenced by the off-cpu starts, are kept.
+func (s *session) clearStacksMap(knownKeys, pendingKeys map[uint32]bool) error {
 	m := s.bpf.Stacks
 	cnt := 0
 	errs := 0
@@ -103,7 +115,9 @@ func (s *session) clearStacksMap(knownKeys map[uint32]bool) error {
 				}
 				break
 			}
-			keys = append(keys, k)
+			if !pendingKeys[k] {
+				keys = append(keys, k)
+			}
 		}
 		for i := range keys {
 			if err := m.Delete(&keys[i]); err != nil {
@@ -120,6 +134,9 @@ func (s *session) clearStacksMap(knownKeys map[uint32]bool) error {
 		return nil
 	}
 	for stackId := range knownKeys {
+		if pendingKeys[stackId] {
+			continue
+		}
 		k := stackId
 		if err := m.Delete(&k); err != nil {
 			errs += 1
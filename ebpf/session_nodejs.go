@@ -0,0 +1,32 @@
+package ebpfspy
+
+import (
+	"github.com/cilium/ebpf"
+	"github.com/go-kit/log"
+	"github.com/grafana/pyroscope/ebpf/nodejs"
+	"github.com/grafana/pyroscope/ebpf/pyrobpf"
+)
+
+func newV8Perf(options SessionOptions) *interpreter {
+	it := &interpreter{
+		name:    "v8perf",
+		typ:     pyrobpf.ProfilingTypeNodejs,
+		progIdx: pyrobpf.ProgIdxNodejs,
+		metrics: options.Metrics.Nodejs,
+		pidData: func(l log.Logger, pid uint32) (any, error) {
+			return nodejs.GetV8PerfPidData(l, pid)
+		},
+	}
+	it.load = func(l log.Logger, opts *ebpf.CollectionOptions) (interpreterPerf, *ebpf.Program, error) {
+		objs := new(nodejs.PerfObjects)
+		if err := nodejs.LoadPerfObjects(objs, opts); err != nil {
+			return nil, nil, err
+		}
+		perf, err := newInterpPerf(it, l, objs, objs.InterpEvents, objs.V8PidConfig, objs.InterpSymbols)
+		if err != nil {
+			return nil, nil, err
+		}
+		return perf, objs.V8perfCollect, nil
+	}
+	return it
+}
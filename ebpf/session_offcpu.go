@@ -0,0 +1,110 @@
+//go:build linux
+
+package ebpfspy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/link"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/pprof"
+	"github.com/grafana/pyroscope/ebpf/pyrobpf"
+)
+
+// startOffCPU loads the sched_switch program sharing the pids and stacks maps with the profile program.
+func (s *session) startOffCPU() error {
+	if s.offcpuLink != nil {
+		return nil
+	}
+	defer btf.FlushKernelSpec() // save some memory
+	opts := &ebpf.CollectionOptions{
+		Programs: ebpf.ProgramOptions{
+			LogDisabled: true,
+		},
+		MapReplacements: map[string]*ebpf.Map{
+			"pids":            s.bpf.Pids,
+			"stacks":          s.bpf.Stacks,
+			"sample_settings": s.bpf.SampleSettings,
+		},
+	}
+	if err := pyrobpf.LoadOffcpuObjects(&s.offcpuBpf, opts); err != nil {
+		return fmt.Errorf("load offcpu bpf objects: %w", err)
+	}
+	if err := s.updateOffCPUConfig(s.options.OffCPUMinBlockDuration); err != nil {
+		return err
+	}
+	l, err := link.Tracepoint("sched", "sched_switch", s.offcpuBpf.OffcpuSchedSwitch, nil)
+	if err != nil {
+		return fmt.Errorf("link tracepoint sched_switch: %w", err)
+	}
+	s.offcpuLink = l
+	_ = level.Info(s.logger).Log("msg", "off-cpu profiling started")
+	return nil
+}
+
+func (s *session) stopOffCPU() {
+	if s.offcpuLink != nil {
+		_ = s.offcpuLink.Close()
+		s.offcpuLink = nil
+	}
+	_ = s.offcpuBpf.Close()
+	s.offcpuBpf = pyrobpf.OffcpuObjects{}
+}
+
+func (s *session) updateOffCPUConfig(minBlock time.Duration) error {
+	config := pyrobpf.OffcpuOffcpuConfig{MinBlockNs: uint64(minBlock.Nanoseconds())}
+	if err := s.offcpuBpf.OffcpuSettings.Update(uint32(0), &config, ebpf.UpdateAny); err != nil {
+		return fmt.Errorf("update offcpu config: %w", err)
+	}
+	return nil
+}
+
+// collectOffCPUProfile collects the blocked durations aggregated since the previous collection.
+// Threads still switched out are accounted in a later collection, when they are switched back in.
+func (s *session) collectOffCPUProfile(cb CollectProfilesCallback, knownStacks map[uint32]bool) error {
+	if s.offcpuLink == nil {
+		return nil
+	}
+	sb := &stackBuilder{}
+
+	keys, values, batch, err := getSampleMapValues[uint64](s, s.offcpuBpf.OffcpuCounts)
+	if err != nil {
+		return fmt.Errorf("get offcpu counts map: %w", err)
+	}
+
+	for i := range keys {
+		s.collectSample(sb, &keys[i], pprof.SampleTypeOffCpu, values[i], knownStacks, cb)
+	}
+
+	if err = clearSampleMap(s, s.offcpuBpf.OffcpuCounts, keys, batch); err != nil {
+		return fmt.Errorf("clear offcpu counts map %w", err)
+	}
+	return nil
+}
+
+// getOffCPUStartStacks returns the stack ids of the threads still switched out. Their stacks are
+// looked up when the threads are switched back in, so they must survive the stacks map cleanup.
+func (s *session) getOffCPUStartStacks() (map[uint32]bool, error) {
+	res := map[uint32]bool{}
+	if s.offcpuLink == nil {
+		return res, nil
+	}
+	it := s.offcpuBpf.OffcpuStarts.Iterate()
+	tid := uint32(0)
+	start := pyrobpf.OffcpuOffcpuStart{}
+	for it.Next(&tid, &start) {
+		if start.Key.UserStack >= 0 {
+			res[uint32(start.Key.UserStack)] = true
+		}
+		if start.Key.KernStack >= 0 {
+			res[uint32(start.Key.KernStack)] = true
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("offcpu starts map iteration: %w", err)
+	}
+	return res, nil
+}
@@ -5,209 +5,95 @@ import (
 	"os"
 	"reflect"
 	"strings"
-	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
-	"github.com/cilium/ebpf/btf"
-	"github.com/go-kit/log/level"
+	"github.com/go-kit/log"
 	"github.com/grafana/pyroscope/ebpf/pyrobpf"
 	"github.com/grafana/pyroscope/ebpf/python"
-	"github.com/grafana/pyroscope/ebpf/sd"
-	"github.com/samber/lo"
 )
 
-func (s *session) collectPythonProfile(cb func(t *sd.Target, stack []string, value uint64, pid uint32)) error {
-	if s.pyperf == nil {
-		return nil
-	}
-	s.pyperfEvents = s.pyperf.CollectEvents(s.pyperfEvents)
-	if len(s.pyperfEvents) == 0 {
-		return nil
-	}
-	defer func() {
-		for i := range s.pyperfEvents {
-			s.pyperfEvents[i] = nil
-		}
-	}()
-	pySymbols := s.pyperf.GetLazySymbols()
-
-	sb := &stackBuilder{}
-	stacktraceErrors := 0
-	unknownSymbols := 0
-	for _, event := range s.pyperfEvents {
-		stats := StackResolveStats{}
-		labels := s.targetFinder.FindTarget(event.Pid)
-		if labels == nil {
-			continue
-		}
-		svc := labels.ServiceName()
-
-		sb.reset()
-
-		sb.append(s.comm(event.Pid))
-		var kStack []byte
-		if event.StackStatus == uint8(python.StackStatusError) {
-			_ = level.Debug(s.logger).Log("msg", "collect python",
-				"stack_status", python.StackStatus(event.StackStatus),
-				"pid", event.Pid,
-				"err", python.PyError(event.Err))
-			s.options.Metrics.Python.StacktraceError.Inc()
-			stacktraceErrors += 1
-		} else {
-			begin := len(sb.stack)
-			if event.StackStatus == uint8(python.StackStatusTruncated) {
-
+func newPyPerf(options SessionOptions) *interpreter {
+	return &interpreter{
+		name:    "pyperf",
+		typ:     pyrobpf.ProfilingTypePython,
+		progIdx: pyrobpf.ProgIdxPython,
+		metrics: options.Metrics.Python,
+		load: func(l log.Logger, opts *ebpf.CollectionOptions) (interpreterPerf, *ebpf.Program, error) {
+			p := &pyPerf{}
+			if err := python.LoadPerfObjects(&p.objects, opts); err != nil {
+				return nil, nil, err
 			}
-			for i := 0; i < int(event.StackLen); i++ {
-				sym, err := pySymbols.GetSymbol(event.Stack[i], svc)
-				if err == nil {
-					filename := cStringFromI8Unsafe(sym.File[:])
-					if !s.options.CacheOptions.SymbolOptions.PythonFullFilePath {
-						iSep := strings.LastIndexByte(filename, '/')
-						if iSep != 1 {
-							filename = filename[iSep+1:]
-						}
-					}
-					classname := cStringFromI8Unsafe(sym.Classname[:])
-					name := cStringFromI8Unsafe(sym.Name[:])
-					if classname == "" {
-						sb.append(fmt.Sprintf("%s %s", filename, name))
-					} else {
-						sb.append(fmt.Sprintf("%s %s.%s", filename, classname, name))
-					}
-				} else {
-					sb.append("pyperf_unknown")
-					s.options.Metrics.Python.UnknownSymbols.WithLabelValues(svc).Inc()
-					unknownSymbols += 1
-				}
+			perf, err := python.NewPerf(l, options.Metrics.Python, p.objects.PerfMaps.PyEvents, p.objects.PerfMaps.PyPidConfig, p.objects.PerfMaps.PySymbols)
+			if err != nil {
+				_ = p.objects.Close()
+				return nil, nil, fmt.Errorf("create %w", err)
 			}
-
-			end := len(sb.stack)
-			lo.Reverse(sb.stack[begin:end])
-		}
-		if s.options.CollectKernel && event.KernStack != -1 {
-			kStack = s.GetStack(event.KernStack)
-			s.WalkStack(sb, kStack, s.symCache.GetKallsyms(), &stats)
-		}
-		if len(sb.stack) == 1 {
-			continue // only comm .. todo skip with an option
-		}
-		lo.Reverse(sb.stack)
-		cb(labels, sb.stack, uint64(1), event.Pid)
-		s.collectMetrics(labels, &stats, sb)
-	}
-	if stacktraceErrors > 0 {
-		_ = level.Error(s.logger).Log("msg", "python stacktrace errors", "count", stacktraceErrors)
-	}
-	if unknownSymbols > 0 {
-		_ = level.Error(s.logger).Log("msg", "python unknown symbols", "count", unknownSymbols)
+			p.Perf = perf
+			return p, p.objects.PerfPrograms.PyperfCollect, nil
+		},
+		pidData: func(l log.Logger, pid uint32) (any, error) {
+			return python.GetPyPerfPidData(l, pid)
+		},
 	}
-	return nil
 }
 
-func (s *session) tryStartPythonProfiling(pid uint32, target *sd.Target, pi procInfoLite) {
-	const nTries = 4
-	for i := 0; i < nTries; i++ {
-		shouldRetry := s.startPythonProfiling(pid, target, pi, i == nTries-1)
-		if !shouldRetry {
-			return
-		}
-		time.Sleep(10 * time.Millisecond)
-	}
+// pyPerf is the interpreterPerf of pyperf.
+type pyPerf struct {
+	*python.Perf
+	objects python.PerfObjects
+	events  []*python.PerfPyEvent
+	stacks  []interpreterEvent
 }
 
-func (s *session) startPythonProfiling(pid uint32, target *sd.Target, pi procInfoLite, lastAttempt bool) bool {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	if !s.started {
-		return false
-	}
-	_, dead := s.pids.dead[pid]
-	if dead {
-		return false
-	}
-	pyPerf := s.getPyPerf()
-	if pyPerf == nil {
-		_ = level.Error(s.logger).Log("err", "pyperf process profiling init failed. pyperf == nil", "pid", pid)
-		pi.typ = pyrobpf.ProfilingTypeError
-		s.setPidConfig(pid, pi, false, false)
-		return false
-	}
-
-	pyData, err := python.GetPyPerfPidData(s.logger, pid)
-	svc := target.ServiceName()
-	if err != nil {
-		alive := processAlive(pid)
-		if alive && lastAttempt {
-			s.options.Metrics.Python.PidDataError.WithLabelValues(svc).Inc()
-			_ = level.Error(s.logger).Log("err", err, "msg", "pyperf get python process data failed", "pid", pid, "target", target.String())
-		} else {
-			_ = level.Debug(s.logger).Log("err", err, "msg", "pyperf get python process data failed", "pid", pid, "target", target.String())
-		}
-		pi.typ = pyrobpf.ProfilingTypeError
-		s.setPidConfig(pid, pi, false, false)
-		return alive
-	}
-
-	err = pyPerf.StartPythonProfiling(pid, pyData, svc)
-	if err != nil {
-		_ = level.Error(s.logger).Log("err", err, "msg", "pyperf process profiling init failed", "pid", pid)
-		pi.typ = pyrobpf.ProfilingTypeError
-		s.setPidConfig(pid, pi, false, false)
-		return false
-	}
-	_ = level.Info(s.logger).Log("msg", "pyperf process profiling init success", "pid", pid,
-		"py_data", fmt.Sprintf("%+v", pyData), "target", target.String())
-	s.setPidConfig(pid, pi, s.options.CollectUser, s.options.CollectKernel)
-	return false
+func (p *pyPerf) StartProfiling(pid uint32, data any, serviceName string) error {
+	return p.Perf.StartPythonProfiling(pid, data.(*python.PerfPyPidData), serviceName)
 }
 
-// may return nil if loadPyPerf returns error
-func (s *session) getPyPerf() *python.Perf {
-	if s.pyperf != nil {
-		return s.pyperf
-	}
-	if s.pyperfError != nil {
-		return nil
-	}
-	s.options.Metrics.Python.Load.Inc()
-	pyperf, err := s.loadPyPerf()
-	if err != nil {
-		s.pyperfError = err
-		s.options.Metrics.Python.LoadError.Inc()
-		_ = level.Error(s.logger).Log("err", err, "msg", "load pyperf")
-		return nil
-	}
-	s.pyperf = pyperf
-	return s.pyperf
+func (p *pyPerf) collectEvents() []interpreterEvent {
+	p.events = p.Perf.CollectEvents(p.events)
+	p.stacks = p.stacks[:0]
+	for i, event := range p.events {
+		p.stacks = append(p.stacks, interpreterEvent{
+			pid:       event.Pid,
+			kernStack: event.KernStack,
+			stack:     event.Stack[:event.StackLen],
+			failed:    event.StackStatus == uint8(python.StackStatusError),
+			status:    python.StackStatus(event.StackStatus),
+			err:       python.PyError(event.Err),
+		})
+		p.events[i] = nil
+	}
+	return p.stacks
 }
 
-func (s *session) loadPyPerf() (*python.Perf, error) {
-	defer btf.FlushKernelSpec() // save some memory
-	opts := &ebpf.CollectionOptions{
-		Programs: ebpf.ProgramOptions{
-			LogDisabled: true,
-		},
-		MapReplacements: map[string]*ebpf.Map{
-			"stacks": s.bpf.Stacks,
-		},
+func (p *pyPerf) symbols(s *session) func(id uint32, svc string) (string, error) {
+	symbols := p.Perf.GetLazySymbols()
+	fullFilePath := s.options.CacheOptions.SymbolOptions.PythonFullFilePath
+	return func(id uint32, svc string) (string, error) {
+		sym, err := symbols.GetSymbol(id, svc)
+		if err != nil {
+			return "", err
+		}
+		filename := cStringFromI8Unsafe(sym.File[:])
+		if !fullFilePath {
+			iSep := strings.LastIndexByte(filename, '/')
+			if iSep != 1 {
+				filename = filename[iSep+1:]
+			}
+		}
+		classname := cStringFromI8Unsafe(sym.Classname[:])
+		name := cStringFromI8Unsafe(sym.Name[:])
+		if classname == "" {
+			return fmt.Sprintf("%s %s", filename, name), nil
+		}
+		return fmt.Sprintf("%s %s.%s", filename, classname, name), nil
 	}
+}
 
-	err := python.LoadPerfObjects(&s.pyperfBpf, opts)
-	if err != nil {
-		return nil, fmt.Errorf("pyperf load %w", err)
-	}
-	pyperf, err := python.NewPerf(s.logger, s.options.Metrics.Python, s.pyperfBpf.PerfMaps.PyEvents, s.pyperfBpf.PerfMaps.PyPidConfig, s.pyperfBpf.PerfMaps.PySymbols)
-	if err != nil {
-		return nil, fmt.Errorf("pyperf create %w", err)
-	}
-	err = s.bpf.ProfileMaps.Progs.Update(uint32(0), s.pyperfBpf.PerfPrograms.PyperfCollect, ebpf.UpdateAny)
-	if err != nil {
-		return nil, fmt.Errorf("pyperf link %w", err)
-	}
-	_ = level.Info(s.logger).Log("msg", "pyperf loaded")
-	return pyperf, nil
+func (p *pyPerf) Close() {
+	p.Perf.Close()
+	_ = p.objects.Close()
 }
 
 func cStringFromI8Unsafe(tok []int8) string {
@@ -0,0 +1,32 @@
+package ebpfspy
+
+import (
+	"github.com/cilium/ebpf"
+	"github.com/go-kit/log"
+	"github.com/grafana/pyroscope/ebpf/pyrobpf"
+	"github.com/grafana/pyroscope/ebpf/ruby"
+)
+
+func newRbPerf(options SessionOptions) *interpreter {
+	it := &interpreter{
+		name:    "rbperf",
+		typ:     pyrobpf.ProfilingTypeRuby,
+		progIdx: pyrobpf.ProgIdxRuby,
+		metrics: options.Metrics.Ruby,
+		pidData: func(l log.Logger, pid uint32) (any, error) {
+			return ruby.GetRbPerfPidData(l, pid)
+		},
+	}
+	it.load = func(l log.Logger, opts *ebpf.CollectionOptions) (interpreterPerf, *ebpf.Program, error) {
+		objs := new(ruby.PerfObjects)
+		if err := ruby.LoadPerfObjects(objs, opts); err != nil {
+			return nil, nil, err
+		}
+		perf, err := newInterpPerf(it, l, objs, objs.InterpEvents, objs.RbPidConfig, objs.InterpSymbols)
+		if err != nil {
+			return nil, nil, err
+		}
+		return perf, objs.RbperfCollect, nil
+	}
+	return it
+}
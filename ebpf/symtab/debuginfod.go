@@ -0,0 +1,302 @@
+package symtab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
+)
+
+type DebuginfodOptions struct {
+	// URLs of the debuginfod servers, queried in order. Empty disables debuginfod.
+	URLs []string
+	// CacheDir is where the downloaded debug files are stored.
+	CacheDir string
+	// CacheSizeBytes bounds the size of CacheDir, least recently used files are removed first.
+	CacheSizeBytes int64
+	// Timeout of a single debug file download.
+	Timeout time.Duration
+	// NegativeCacheTTL is how long build IDs not found on any server are not requested again.
+	// Failed downloads (timeouts, server errors) are not cached and are retried on the next lookup.
+	NegativeCacheTTL time.Duration
+	// MaxConcurrentFetches limits the number of downloads running at the same time.
+	MaxConcurrentFetches int
+}
+
+var DefaultDebuginfodOptions = DebuginfodOptions{
+	CacheSizeBytes:       1 << 30,
+	Timeout:              time.Minute,
+	NegativeCacheTTL:     time.Hour,
+	MaxConcurrentFetches: 4,
+}
+
+var (
+	errDebuginfodNotFound = errors.New("debug file not found")
+	errDebuginfodTooLarge = errors.New("debug file is larger than the cache")
+)
+
+// DebuginfodClient downloads debug files by build ID from debuginfod servers
+// https://sourceware.org/elfutils/Debuginfod.html
+// Downloads happen in the background so that symbolization never waits for the network:
+// DebugFile returns the cached file if there is one and schedules a fetch otherwise.
+type DebuginfodClient struct {
+	logger  log.Logger
+	options DebuginfodOptions
+	metrics *metrics.SymtabMetrics
+	client  *http.Client
+	sem     chan struct{}
+
+	mtx      sync.Mutex
+	pending  map[string]struct{}
+	notFound map[string]time.Time
+	now      func() time.Time
+}
+
+func NewDebuginfodClient(logger log.Logger, options DebuginfodOptions, metrics *metrics.SymtabMetrics) (*DebuginfodClient, error) {
+	if options.CacheDir == "" {
+		return nil, fmt.Errorf("debuginfod cache dir is not specified")
+	}
+	if options.CacheSizeBytes <= 0 {
+		options.CacheSizeBytes = DefaultDebuginfodOptions.CacheSizeBytes
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultDebuginfodOptions.Timeout
+	}
+	if options.NegativeCacheTTL <= 0 {
+		options.NegativeCacheTTL = DefaultDebuginfodOptions.NegativeCacheTTL
+	}
+	if options.MaxConcurrentFetches <= 0 {
+		options.MaxConcurrentFetches = DefaultDebuginfodOptions.MaxConcurrentFetches
+	}
+	if err := os.MkdirAll(options.CacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create debuginfod cache dir %w", err)
+	}
+	return &DebuginfodClient{
+		logger:   logger,
+		options:  options,
+		metrics:  metrics,
+		client:   &http.Client{Timeout: options.Timeout},
+		sem:      make(chan struct{}, options.MaxConcurrentFetches),
+		pending:  make(map[string]struct{}),
+		notFound: make(map[string]time.Time),
+		now:      time.Now,
+	}, nil
+}
+
+// DebugFile returns the path of the debug file with the given build ID on the host,
+// or an empty string if it is not downloaded yet. In the latter case a download is started
+// unless one is already running or the build ID was recently not found.
+func (c *DebuginfodClient) DebugFile(buildID elf.BuildID) string {
+	if !buildID.GNU() || !validBuildID(buildID.ID) {
+		return ""
+	}
+	id := buildID.ID
+	f := c.cachePath(id)
+	if _, err := os.Stat(f); err == nil {
+		c.metrics.DebuginfodCacheHits.Inc()
+		now := c.now()
+		_ = os.Chtimes(f, now, now) // keep the file in the cache
+		return f
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.pending[id]; ok {
+		return ""
+	}
+	if t, ok := c.notFound[id]; ok {
+		if c.now().Sub(t) < c.options.NegativeCacheTTL {
+			return ""
+		}
+		delete(c.notFound, id)
+	}
+	c.pending[id] = struct{}{}
+	go c.fetch(id)
+	return ""
+}
+
+// Pending returns true if the debug file with the given build ID is being downloaded.
+func (c *DebuginfodClient) Pending(buildID elf.BuildID) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	_, ok := c.pending[buildID.ID]
+	return ok
+}
+
+func (c *DebuginfodClient) fetch(id string) {
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	err := c.download(id)
+	c.mtx.Lock()
+	delete(c.pending, id)
+	if errors.Is(err, errDebuginfodNotFound) {
+		c.notFound[id] = c.now()
+	}
+	c.mtx.Unlock()
+
+	switch {
+	case err == nil:
+		c.metrics.DebuginfodFetches.WithLabelValues("success").Inc()
+		c.evict()
+	case errors.Is(err, errDebuginfodNotFound):
+		c.metrics.DebuginfodFetches.WithLabelValues("not_found").Inc()
+		level.Debug(c.logger).Log("msg", "debug file not found on debuginfod servers", "build_id", id)
+	default:
+		c.metrics.DebuginfodFetches.WithLabelValues("error").Inc()
+		level.Warn(c.logger).Log("msg", "failed to fetch debug file from debuginfod", "build_id", id, "err", err)
+	}
+}
+
+// download tries the servers in order. It returns errDebuginfodNotFound
+// only if every server responded that it doesn't have the file.
+func (c *DebuginfodClient) download(id string) error {
+	var lastErr error = errDebuginfodNotFound
+	for _, u := range c.options.URLs {
+		err := c.downloadFrom(u, id)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errDebuginfodNotFound) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (c *DebuginfodClient) downloadFrom(server string, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+	u := strings.TrimSuffix(server, "/") + "/buildid/" + id + "/debuginfo"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errDebuginfodNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", u, resp.Status)
+	}
+	// A file that doesn't fit into the cache is rejected rather than
+	// truncated: a truncated elf file must never end up in the cache.
+	limit := c.options.CacheSizeBytes
+	if resp.ContentLength > limit {
+		return fmt.Errorf("%s: %w: %d bytes", u, errDebuginfodTooLarge, resp.ContentLength)
+	}
+
+	tmp, err := os.CreateTemp(c.options.CacheDir, id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, limit+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > limit {
+		return fmt.Errorf("%s: %w", u, errDebuginfodTooLarge)
+	}
+	if err = c.validate(tmp.Name(), id); err != nil {
+		return fmt.Errorf("%s: %w", u, err)
+	}
+	return os.Rename(tmp.Name(), c.cachePath(id))
+}
+
+// validate checks the downloaded file is an elf file with the expected build ID.
+func (c *DebuginfodClient) validate(f string, id string) error {
+	me, err := elf.NewMMapedElfFile(f)
+	if err != nil {
+		return err
+	}
+	defer me.Close()
+	buildID, err := me.GNUBuildID()
+	if err != nil {
+		return err
+	}
+	if buildID.ID != id {
+		return fmt.Errorf("build ID mismatch: %s", buildID.ID)
+	}
+	return nil
+}
+
+// evict removes the least recently used files until the cache fits into CacheSizeBytes.
+func (c *DebuginfodClient) evict() {
+	entries, err := os.ReadDir(c.options.CacheDir)
+	if err != nil {
+		return
+	}
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		files []cachedFile
+		total int64
+	)
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".debug") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cachedFile{
+			path:    filepath.Join(c.options.CacheDir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= c.options.CacheSizeBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			continue
+		}
+		total -= f.size
+		c.metrics.DebuginfodCacheEvictions.Inc()
+	}
+}
+
+func (c *DebuginfodClient) cachePath(id string) string {
+	return filepath.Join(c.options.CacheDir, id+".debug")
+}
+
+func validBuildID(id string) bool {
+	if len(id) < 3 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
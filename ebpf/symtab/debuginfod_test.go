@@ -0,0 +1,235 @@
+package symtab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
+	"github.com/grafana/pyroscope/ebpf/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type debuginfodServer struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	files    map[string]string
+	requests map[string]int
+}
+
+func newDebuginfodServer(t *testing.T, files ...string) *debuginfodServer {
+	s := &debuginfodServer{
+		files:    make(map[string]string),
+		requests: make(map[string]int),
+	}
+	for _, f := range files {
+		s.files[testBuildID(t, f).ID] = f
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/buildid/"), "/debuginfo")
+		s.mtx.Lock()
+		s.requests[id]++
+		f, ok := s.files[id]
+		s.mtx.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, f)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *debuginfodServer) requestsFor(id elf.BuildID) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.requests[id.ID]
+}
+
+func testBuildID(t *testing.T, f string) elf.BuildID {
+	me, err := elf.NewMMapedElfFile(f)
+	require.NoError(t, err)
+	defer me.Close()
+	id, err := me.GNUBuildID()
+	require.NoError(t, err)
+	return id
+}
+
+func waitDebuginfod(t *testing.T, c *DebuginfodClient, id elf.BuildID) {
+	require.Eventually(t, func() bool {
+		return !c.Pending(id)
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestDebuginfodClient(t *testing.T) {
+	const debugFile = "elf/testdata/elfs/elf.debug"
+	server := newDebuginfodServer(t, debugFile)
+	m := metrics.NewSymtabMetrics(nil)
+	c, err := NewDebuginfodClient(util.TestLogger(t), DebuginfodOptions{
+		URLs:     []string{"http://127.0.0.1:0", server.URL + "/"},
+		CacheDir: t.TempDir(),
+	}, m)
+	require.NoError(t, err)
+
+	id := testBuildID(t, debugFile)
+	require.Equal(t, "", c.DebugFile(id))
+	waitDebuginfod(t, c, id)
+	f := c.DebugFile(id)
+	require.NotEmpty(t, f)
+	expected, err := os.ReadFile(debugFile)
+	require.NoError(t, err)
+	actual, err := os.ReadFile(f)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+	require.Equal(t, 1, server.requestsFor(id))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.DebuginfodCacheHits))
+	// The first server is unreachable, the second one has the file.
+	require.Equal(t, float64(1), testutil.ToFloat64(m.DebuginfodFetches.WithLabelValues("success")))
+
+	// The first server is unreachable: the build ID is not known
+	// to be missing, and it is requested again.
+	missing := elf.GNUBuildID("0123456789abcdef")
+	require.Equal(t, "", c.DebugFile(missing))
+	waitDebuginfod(t, c, missing)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.DebuginfodFetches.WithLabelValues("error")))
+	require.Equal(t, "", c.DebugFile(missing))
+	waitDebuginfod(t, c, missing)
+	require.Equal(t, 2, server.requestsFor(missing))
+
+	// Build IDs not found are not requested again until the negative cache expires.
+	c.options.URLs = []string{server.URL}
+	require.Equal(t, "", c.DebugFile(missing))
+	waitDebuginfod(t, c, missing)
+	require.Equal(t, "", c.DebugFile(missing))
+	require.False(t, c.Pending(missing))
+	require.Equal(t, 3, server.requestsFor(missing))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.DebuginfodFetches.WithLabelValues("not_found")))
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.Equal(t, "", c.DebugFile(missing))
+	waitDebuginfod(t, c, missing)
+	require.Equal(t, 4, server.requestsFor(missing))
+
+	// Only GNU build IDs are requested.
+	require.Equal(t, "", c.DebugFile(elf.GoBuildID("0123456789abcdef")))
+	require.Equal(t, "", c.DebugFile(elf.GNUBuildID("../../etc/passwd")))
+}
+
+func TestDebuginfodClientFailures(t *testing.T) {
+	const debugFile = "elf/testdata/elfs/elf.debug"
+	id := testBuildID(t, debugFile)
+	var requests int
+	var handler http.HandlerFunc
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	m := metrics.NewSymtabMetrics(nil)
+	c, err := NewDebuginfodClient(util.TestLogger(t), DebuginfodOptions{
+		URLs:           []string{server.URL},
+		CacheDir:       t.TempDir(),
+		CacheSizeBytes: 1 << 10,
+	}, m)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			},
+		},
+		{
+			name: "too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, debugFile)
+			},
+		},
+		{
+			name: "too large without content length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				b, _ := os.ReadFile(debugFile)
+				w.(http.Flusher).Flush()
+				_, _ = w.Write(b)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler = tc.handler
+			requests = 0
+			// The failures are not cached: the file is requested again.
+			for i := 1; i <= 2; i++ {
+				require.Equal(t, "", c.DebugFile(id))
+				waitDebuginfod(t, c, id)
+				require.Equal(t, i, requests)
+			}
+			_, err := os.Stat(c.cachePath(id.ID))
+			require.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+	require.Equal(t, float64(6), testutil.ToFloat64(m.DebuginfodFetches.WithLabelValues("error")))
+}
+
+func TestDebuginfodClientEviction(t *testing.T) {
+	files := []string{"elf/testdata/elfs/elf.debug", "elf/testdata/elfs/libexample.so"}
+	server := newDebuginfodServer(t, files...)
+	m := metrics.NewSymtabMetrics(nil)
+	c, err := NewDebuginfodClient(util.TestLogger(t), DebuginfodOptions{
+		URLs:           []string{server.URL},
+		CacheDir:       t.TempDir(),
+		CacheSizeBytes: 16 << 10,
+	}, m)
+	require.NoError(t, err)
+
+	ids := []elf.BuildID{testBuildID(t, files[0]), testBuildID(t, files[1])}
+	c.DebugFile(ids[0])
+	waitDebuginfod(t, c, ids[0])
+	require.NotEmpty(t, c.DebugFile(ids[0]))
+
+	// The cache can not hold both files, the least recently used one is removed.
+	c.now = func() time.Time { return time.Now().Add(time.Hour) }
+	c.DebugFile(ids[1])
+	waitDebuginfod(t, c, ids[1])
+	require.NotEmpty(t, c.DebugFile(ids[1]))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.DebuginfodCacheEvictions))
+	_, err = os.Stat(c.cachePath(ids[0].ID))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDebuginfodElfTable(t *testing.T) {
+	server := newDebuginfodServer(t, "elf/testdata/elfs/elf.debug")
+	m := metrics.NewSymtabMetrics(nil)
+	c, err := NewDebuginfodClient(util.TestLogger(t), DebuginfodOptions{
+		URLs:     []string{server.URL},
+		CacheDir: t.TempDir(),
+	}, m)
+	require.NoError(t, err)
+	elfCache, _ := NewElfCache(testCacheOptions, testCacheOptions)
+	tab := NewElfTable(util.TestLogger(t), &ProcMap{StartAddr: 0x1000, Offset: 0x1000}, ".", "elf/testdata/elfs/elf.stripped",
+		ElfTableOptions{
+			ElfCache:   elfCache,
+			Metrics:    m,
+			Debuginfod: c,
+		})
+
+	// The symbols are not available until the debug file is downloaded.
+	require.Equal(t, "", tab.Resolve(0x1149))
+	waitDebuginfod(t, c, tab.buildID)
+	require.Equal(t, "", tab.Resolve(0x1149))
+
+	tab.Refresh()
+	require.Equal(t, "iter", tab.Resolve(0x1149))
+	require.Equal(t, "main", tab.Resolve(0x115e))
+	require.NotNil(t, elfCache.GetSymbolsByBuildID(tab.buildID))
+}
@@ -28,6 +28,10 @@ type ElfTable struct {
 	loadedCached bool
 	err          error
 
+	buildID elf2.BuildID
+	// debuginfodPending is set when the table was loaded while the debug file was being downloaded
+	debuginfodPending bool
+
 	options ElfTableOptions
 	logger  log.Logger
 	procMap *ProcMap
@@ -36,6 +40,10 @@ type SymbolOptions struct {
 	GoTableFallback    bool
 	PythonFullFilePath bool
 	DemangleOptions    []demangle.Option
+	// InlineFrames enables resolving inlined functions and source lines from DWARF debug info,
+	// or from .gopclntab for Go binaries.
+	// The DWARF debug info of every symbolized binary is kept in memory, which may take a lot of space.
+	InlineFrames bool
 }
 
 var DefaultSymbolOptions = &SymbolOptions{
@@ -46,6 +54,8 @@ type ElfTableOptions struct {
 	ElfCache      *ElfCache
 	Metrics       *metrics.SymtabMetrics
 	SymbolOptions *SymbolOptions
+	// Debuginfod may be nil
+	Debuginfod *DebuginfodClient
 }
 
 func NewElfTable(logger log.Logger, procMap *ProcMap, fs string, elfFilePath string, options ElfTableOptions) *ElfTable {
@@ -108,6 +118,7 @@ func (et *ElfTable) load() {
 		et.onLoadError()
 		return
 	}
+	et.buildID = buildID
 
 	symbols := et.options.ElfCache.GetSymbolsByBuildID(buildID)
 	if symbols != nil {
@@ -130,23 +141,16 @@ func (et *ElfTable) load() {
 
 	debugFilePath := et.findDebugFile(buildID, me)
 	if debugFilePath != "" {
-		debugMe, err := elf2.NewMMapedElfFile(path.Join(et.fs, debugFilePath))
-		if err != nil {
-			et.err = err
-			et.onLoadError()
-			return
-		}
-		defer debugMe.Close() // todo do not close if it is the selected elf
-
-		symbols, err = et.createSymbolTable(debugMe)
-		if err != nil {
-			et.err = err
-			et.onLoadError()
+		et.loadDebugFile(path.Join(et.fs, debugFilePath), buildID)
+		return
+	}
+	if et.options.Debuginfod != nil && me.Section(".symtab") == nil {
+		// The debug file is downloaded to the host, not to the process mount namespace.
+		if debugFilePath = et.options.Debuginfod.DebugFile(buildID); debugFilePath != "" {
+			et.loadDebugFile(debugFilePath, buildID)
 			return
 		}
-		et.table = symbols
-		et.options.ElfCache.CacheByBuildID(buildID, symbols)
-		return
+		et.debuginfodPending = et.options.Debuginfod.Pending(buildID)
 	}
 
 	symbols, err = et.createSymbolTable(me)
@@ -156,8 +160,13 @@ func (et *ElfTable) load() {
 		et.onLoadError()
 		return
 	}
+	symbols = et.withLines(me, symbols)
 
 	et.table = symbols
+	if et.debuginfodPending {
+		// Do not share the table, it is replaced once the debug file is downloaded.
+		return
+	}
 	if buildID.Empty() {
 		et.options.ElfCache.CacheByStat(statFromFileInfo(fileInfo), symbols)
 	} else {
@@ -165,6 +174,39 @@ func (et *ElfTable) load() {
 	}
 }
 
+func (et *ElfTable) loadDebugFile(fsDebugFile string, buildID elf2.BuildID) {
+	debugMe, err := elf2.NewMMapedElfFile(fsDebugFile)
+	if err != nil {
+		et.err = err
+		et.onLoadError()
+		return
+	}
+	defer debugMe.Close() // todo do not close if it is the selected elf
+
+	symbols, err := et.createSymbolTable(debugMe)
+	if err != nil {
+		et.err = err
+		et.onLoadError()
+		return
+	}
+	symbols = et.withLines(debugMe, symbols)
+	et.table = symbols
+	et.options.ElfCache.CacheByBuildID(buildID, symbols)
+}
+
+// Refresh makes the table load again once the debug file download started by load is finished.
+func (et *ElfTable) Refresh() {
+	if !et.debuginfodPending || et.options.Debuginfod.Pending(et.buildID) {
+		return
+	}
+	et.debuginfodPending = false
+	et.table.Cleanup()
+	et.table = &noopSymbolNameResolver{}
+	et.loaded = false
+	et.loadedCached = false
+	et.err = nil
+}
+
 func (et *ElfTable) createSymbolTable(me *elf2.MMapedElfFile) (SymbolNameResolver, error) {
 	goTable, goErr := me.NewGoTable()
 	if !et.options.SymbolOptions.GoTableFallback && goErr == nil {
@@ -178,6 +220,11 @@ func (et *ElfTable) createSymbolTable(me *elf2.MMapedElfFile) (SymbolNameResolve
 		symbolOptions.FilterTo = goTable.Index.End
 	}
 	symTable, symErr := me.NewSymbolTable(&symbolOptions)
+	if goErr != nil {
+		if miniDebugInfo := et.createMiniDebugInfoTable(me, symTable, &symbolOptions); miniDebugInfo != nil {
+			return miniDebugInfo, nil
+		}
+	}
 	if symErr != nil && goErr != nil {
 		return nil, fmt.Errorf("s: %s g: %s", symErr.Error(), goErr.Error())
 	}
@@ -196,6 +243,66 @@ func (et *ElfTable) createSymbolTable(me *elf2.MMapedElfFile) (SymbolNameResolve
 	panic("unreachable")
 }
 
+// createMiniDebugInfoTable returns nil if the file has no usable .gnu_debugdata section.
+func (et *ElfTable) createMiniDebugInfoTable(me *elf2.MMapedElfFile, symTable *elf2.SymbolTable, symbolOptions *elf2.SymbolsOptions) SymbolNameResolver {
+	if me.Section(".gnu_debugdata") == nil {
+		return nil
+	}
+	mini, err := me.MiniDebugInfo()
+	if err != nil {
+		level.Debug(et.logger).Log("msg", "failed to load mini debug info", "f", me.FilePath(), "err", err)
+		return nil
+	}
+	miniSymTable, err := mini.NewSymbolTable(symbolOptions)
+	if err != nil {
+		level.Debug(et.logger).Log("msg", "failed to load mini debug info symbols", "f", me.FilePath(), "err", err)
+		return nil
+	}
+	return &elf2.SymbolTableWithMiniDebugInfo{
+		SymTable:      symTable,
+		MiniDebugInfo: miniSymTable,
+	}
+}
+
+// withLines adds the DWARF line table of the file to symbols if InlineFrames is enabled.
+func (et *ElfTable) withLines(me *elf2.MMapedElfFile, symbols SymbolNameResolver) SymbolNameResolver {
+	if !et.options.SymbolOptions.InlineFrames {
+		return symbols
+	}
+	if _, ok := symbols.(lineResolver); ok {
+		// .gopclntab has the inline trees and line tables of Go binaries
+		return symbols
+	}
+	lines, err := me.NewDwarfTable(et.options.SymbolOptions.DemangleOptions)
+	if err != nil {
+		if !errors.Is(err, elf2.ErrNoDwarf) {
+			level.Debug(et.logger).Log("msg", "failed to load dwarf", "f", me.FilePath(), "err", err)
+		}
+		return symbols
+	}
+	return &symbolsWithLines{SymbolNameResolver: symbols, lines: lines}
+}
+
+type lineResolver interface {
+	ResolveLines(addr uint64) []elf2.Line
+}
+
+// symbolsWithLines is cached in ElfCache the same way as the symbols, so the DWARF is parsed once per binary.
+type symbolsWithLines struct {
+	SymbolNameResolver
+	lines *elf2.DwarfTable
+}
+
+func (s *symbolsWithLines) DebugInfo() elf2.SymTabDebugInfo {
+	res := s.SymbolNameResolver.DebugInfo()
+	res.Size += s.lines.Size()
+	return res
+}
+
+func (s *symbolsWithLines) ResolveLines(addr uint64) []elf2.Line {
+	return s.lines.Resolve(addr)
+}
+
 var errTableDead = fmt.Errorf("non cached table dead")
 
 func (et *ElfTable) Resolve(pc uint64) string {
@@ -227,6 +334,19 @@ func (et *ElfTable) Resolve(pc uint64) string {
 	return et.table.Resolve(pc)
 }
 
+// ResolveLines returns the inline chain at pc, innermost function first,
+// or nil if InlineFrames is disabled or the binary has neither DWARF debug info nor .gopclntab.
+// It must be called after Resolve.
+func (et *ElfTable) ResolveLines(pc uint64) []elf2.Line {
+	if et.err != nil || !et.options.SymbolOptions.InlineFrames {
+		return nil
+	}
+	if t, ok := et.table.(lineResolver); ok {
+		return t.ResolveLines(pc - et.base)
+	}
+	return nil
+}
+
 func (et *ElfTable) Cleanup() {
 	if et.table != nil {
 		et.table.Cleanup()
@@ -0,0 +1,125 @@
+package elf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ErrNoMiniDebugInfo is returned by MMapedElfFile.MiniDebugInfo
+// if the file has no .gnu_debugdata section.
+var ErrNoMiniDebugInfo = errors.New("no .gnu_debugdata section")
+
+// elf.COMPRESS_ZSTD is not available before go1.21
+const compressZstd elf.CompressionType = 2
+
+// decompressSection decompresses the data of a SHF_COMPRESSED section,
+// as produced by objcopy --compress-debug-sections or ld --compress-debug-sections.
+func (f *MMapedElfFile) decompressSection(s *elf.SectionHeader, data []byte) ([]byte, error) {
+	var (
+		typ  elf.CompressionType
+		size uint64
+		hdr  int
+	)
+	switch f.Class {
+	case elf.ELFCLASS64:
+		hdr = binary.Size(elf.Chdr64{})
+		if len(data) < hdr {
+			return nil, fmt.Errorf("section %s: invalid compression header", s.Name)
+		}
+		typ = elf.CompressionType(f.ByteOrder.Uint32(data[0:4]))
+		size = f.ByteOrder.Uint64(data[8:16])
+	case elf.ELFCLASS32:
+		hdr = binary.Size(elf.Chdr32{})
+		if len(data) < hdr {
+			return nil, fmt.Errorf("section %s: invalid compression header", s.Name)
+		}
+		typ = elf.CompressionType(f.ByteOrder.Uint32(data[0:4]))
+		size = uint64(f.ByteOrder.Uint32(data[4:8]))
+	default:
+		return nil, fmt.Errorf("section %s: unknown elf class %s", s.Name, f.Class)
+	}
+	var (
+		r   io.Reader
+		err error
+	)
+	switch typ {
+	case elf.COMPRESS_ZLIB:
+		r, err = zlib.NewReader(bytes.NewReader(data[hdr:]))
+	case compressZstd:
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(bytes.NewReader(data[hdr:]), zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			defer d.Close()
+			r = d
+		}
+	default:
+		return nil, fmt.Errorf("section %s: unsupported compression type %s", s.Name, typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+	return readDecompressed(s, r, size)
+}
+
+// decompressZDebugSection decompresses the data of a legacy GNU .zdebug_* section:
+// the "ZLIB" magic is followed by the big endian uncompressed size and the zlib stream.
+func decompressZDebugSection(s *elf.SectionHeader, data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "ZLIB" {
+		return data, nil
+	}
+	size := binary.BigEndian.Uint64(data[4:12])
+	r, err := zlib.NewReader(bytes.NewReader(data[12:]))
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+	return readDecompressed(s, r, size)
+}
+
+func readDecompressed(s *elf.SectionHeader, r io.Reader, size uint64) ([]byte, error) {
+	if size > maxDecompressedSectionSize {
+		return nil, fmt.Errorf("section %s: decompressed size %d is too large", s.Name, size)
+	}
+	res := make([]byte, size)
+	if _, err := io.ReadFull(r, res); err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+	return res, nil
+}
+
+// maxDecompressedSectionSize protects from corrupted headers claiming huge sizes.
+const maxDecompressedSectionSize = 1 << 30
+
+// MiniDebugInfo returns the elf file embedded in the xz compressed .gnu_debugdata section.
+// See https://sourceware.org/gdb/onlinedocs/gdb/MiniDebugInfo.html
+// Stripped binaries shipped by Fedora and RHEL keep the symbols of non exported functions there.
+func (f *MMapedElfFile) MiniDebugInfo() (*MMapedElfFile, error) {
+	s := f.Section(".gnu_debugdata")
+	if s == nil {
+		return nil, ErrNoMiniDebugInfo
+	}
+	data, err := f.SectionData(s)
+	if err != nil {
+		return nil, fmt.Errorf("read .gnu_debugdata: %w", err)
+	}
+	r, err := xz.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress .gnu_debugdata: %w", err)
+	}
+	data, err = io.ReadAll(io.LimitReader(r, maxDecompressedSectionSize))
+	if err != nil {
+		return nil, fmt.Errorf("decompress .gnu_debugdata: %w", err)
+	}
+	res, err := newMemElfFile(f.fpath+":.gnu_debugdata", data)
+	if err != nil {
+		return nil, fmt.Errorf("parse .gnu_debugdata: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiniDebugInfo(t *testing.T) {
+	e, err := elf.Open("./testdata/elfs/elf")
+	require.NoError(t, err)
+	defer e.Close()
+	expectedSymbols := GetELFSymbolsFromSymtab(e)
+	require.NotEmpty(t, expectedSymbols)
+
+	me, err := NewMMapedElfFile("./testdata/elfs/elf.minidebuginfo")
+	require.NoError(t, err)
+	defer me.Close()
+
+	_, err = me.NewSymbolTable(new(SymbolsOptions))
+	require.ErrorIs(t, err, ErrNoSymbols)
+
+	mini, err := me.MiniDebugInfo()
+	require.NoError(t, err)
+	miniSymTable, err := mini.NewSymbolTable(new(SymbolsOptions))
+	require.NoError(t, err)
+	tab := &SymbolTableWithMiniDebugInfo{MiniDebugInfo: miniSymTable}
+
+	for _, symbol := range expectedSymbols {
+		require.Equal(t, symbol.Name, tab.Resolve(symbol.Start))
+	}
+	// The in-memory file survives cleanup.
+	tab.Cleanup()
+	require.False(t, tab.IsDead())
+	require.Equal(t, expectedSymbols[0].Name, tab.Resolve(expectedSymbols[0].Start))
+
+	noMini, err := NewMMapedElfFile("./testdata/elfs/elf")
+	require.NoError(t, err)
+	defer noMini.Close()
+	_, err = noMini.MiniDebugInfo()
+	require.ErrorIs(t, err, ErrNoMiniDebugInfo)
+}
+
+func TestCompressedSection(t *testing.T) {
+	e, err := elf.Open("./testdata/elfs/elf.compressed")
+	require.NoError(t, err)
+	defer e.Close()
+	expected, err := e.Section(".debug_info").Data()
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+
+	me, err := NewMMapedElfFile("./testdata/elfs/elf.compressed")
+	require.NoError(t, err)
+	defer me.Close()
+
+	s := me.Section(".debug_info")
+	require.NotZero(t, s.Flags&elf.SHF_COMPRESSED)
+	actual, err := me.SectionData(s)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	t.Run("zstd", func(t *testing.T) {
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		chdr := elf.Chdr64{Type: uint32(compressZstd), Size: uint64(len(expected)), Addralign: 1}
+		var data bytes.Buffer
+		require.NoError(t, binary.Write(&data, me.ByteOrder, chdr))
+		data.Write(enc.EncodeAll(expected, nil))
+
+		actual, err := me.decompressSection(s, data.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		data, err := me.SectionData(&elf.SectionHeader{Name: s.Name, Offset: s.Offset, Size: s.Size})
+		require.NoError(t, err)
+		me.ByteOrder.PutUint32(data[0:4], 42)
+		_, err = me.decompressSection(s, data)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,411 @@
+package elf
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ianlancetaylor/demangle"
+)
+
+// ErrNoDwarf is returned by MMapedElfFile.NewDwarfTable if the file has no .debug_info section.
+var ErrNoDwarf = errors.New("no .debug_info section")
+
+// Line is a source position of a function. Function is empty if the name is not known from DWARF.
+type Line struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// DwarfTable resolves addresses into inline chains using .debug_info and .debug_line.
+// The whole debug info is parsed and kept in memory, which is expensive for big binaries.
+type DwarfTable struct {
+	data        *dwarf.Data
+	units       []dwarfUnit
+	unitRanges  []dwarfRange // sorted by low, idx is an index in units
+	subprograms []dwarfSubprogram
+	fpath       string
+}
+
+type dwarfRange struct {
+	low, high uint64
+	idx       int
+}
+
+type dwarfUnit struct {
+	entry *dwarf.Entry
+	files []*dwarf.LineFile
+	// subprograms ranges sorted by low, idx is an index in DwarfTable.subprograms
+	subprograms []dwarfRange
+
+	linesLoaded bool
+	lines       []dwarfLine
+}
+
+type dwarfLine struct {
+	addr uint64
+	file int
+	line int
+	// end marks the first address after a sequence
+	end bool
+}
+
+type dwarfSubprogram struct {
+	name    string
+	inlines []dwarfInline
+}
+
+type dwarfInline struct {
+	low, high uint64
+	// depth is 1 for functions inlined directly into the subprogram
+	depth    int
+	name     string
+	callFile int
+	callLine int
+}
+
+var dwarfSections = []string{"abbrev", "aranges", "frame", "info", "line", "pubnames", "ranges", "str"}
+
+// DWARF 5 sections, optional
+var dwarfExtraSections = []string{"addr", "line_str", "loclists", "rnglists", "str_offsets"}
+
+// NewDwarfTable parses the DWARF debug info of the file.
+// The returned table does not reference the file, so it can be closed.
+func (f *MMapedElfFile) NewDwarfTable(demangleOptions []demangle.Option) (*DwarfTable, error) {
+	if f.debugSection("info") == nil {
+		return nil, ErrNoDwarf
+	}
+	data := make([][]byte, len(dwarfSections))
+	for i, name := range dwarfSections {
+		s := f.debugSection(name)
+		if s == nil {
+			continue
+		}
+		b, err := f.SectionData(s)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = b
+	}
+	d, err := dwarf.New(data[0], data[1], data[2], data[3], data[4], data[5], data[6], data[7])
+	if err != nil {
+		return nil, fmt.Errorf("dwarf %s: %w", f.fpath, err)
+	}
+	for _, name := range dwarfExtraSections {
+		s := f.debugSection(name)
+		if s == nil {
+			continue
+		}
+		b, err := f.SectionData(s)
+		if err != nil {
+			return nil, err
+		}
+		if err = d.AddSection(".debug_"+name, b); err != nil {
+			return nil, fmt.Errorf("dwarf %s: %w", f.fpath, err)
+		}
+	}
+	res := &DwarfTable{data: d, fpath: f.fpath}
+	if err = res.parse(demangleOptions); err != nil {
+		return nil, fmt.Errorf("dwarf %s: %w", f.fpath, err)
+	}
+	return res, nil
+}
+
+func (f *MMapedElfFile) debugSection(name string) *elf.SectionHeader {
+	if s := f.Section(".debug_" + name); s != nil {
+		return s
+	}
+	return f.Section(".zdebug_" + name)
+}
+
+type dwarfNames struct {
+	name    string
+	linkage string
+	origin  dwarf.Offset
+}
+
+func (t *DwarfTable) parse(demangleOptions []demangle.Option) error {
+	names := make(map[dwarf.Offset]dwarfNames)
+	// functions to name once all the entries are read, abstract origins may be defined later
+	type pending struct {
+		sub, inline int
+		origin      dwarf.Offset
+	}
+	var unnamed []pending
+
+	type scope struct {
+		sub, depth int
+	}
+	var (
+		stack []scope
+		cur   = scope{sub: -1}
+		unit  = -1
+	)
+	r := t.data.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag == 0 {
+			if len(stack) > 0 {
+				cur = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		next := cur
+		switch e.Tag {
+		case dwarf.TagCompileUnit, dwarf.TagPartialUnit:
+			stack = stack[:0]
+			cur = scope{sub: -1}
+			next = cur
+			unit = len(t.units)
+			u := dwarfUnit{entry: e}
+			if lr, err := t.data.LineReader(e); err == nil && lr != nil {
+				u.files = lr.Files()
+			}
+			t.units = append(t.units, u)
+			ranges, _ := t.data.Ranges(e)
+			for _, rng := range ranges {
+				t.unitRanges = append(t.unitRanges, dwarfRange{low: rng[0], high: rng[1], idx: unit})
+			}
+		case dwarf.TagSubprogram:
+			names[e.Offset] = entryNames(e)
+			if unit == -1 {
+				break
+			}
+			ranges, _ := t.data.Ranges(e)
+			if len(ranges) == 0 {
+				break
+			}
+			next = scope{sub: len(t.subprograms)}
+			t.subprograms = append(t.subprograms, dwarfSubprogram{})
+			unnamed = append(unnamed, pending{sub: next.sub, inline: -1, origin: e.Offset})
+			for _, rng := range ranges {
+				t.units[unit].subprograms = append(t.units[unit].subprograms, dwarfRange{low: rng[0], high: rng[1], idx: next.sub})
+			}
+		case dwarf.TagInlinedSubroutine:
+			if cur.sub == -1 {
+				break
+			}
+			next.depth = cur.depth + 1
+			origin, _ := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+			callFile, _ := e.Val(dwarf.AttrCallFile).(int64)
+			callLine, _ := e.Val(dwarf.AttrCallLine).(int64)
+			ranges, _ := t.data.Ranges(e)
+			sub := &t.subprograms[cur.sub]
+			for _, rng := range ranges {
+				unnamed = append(unnamed, pending{sub: cur.sub, inline: len(sub.inlines), origin: origin})
+				sub.inlines = append(sub.inlines, dwarfInline{
+					low:      rng[0],
+					high:     rng[1],
+					depth:    next.depth,
+					callFile: int(callFile),
+					callLine: int(callLine),
+				})
+			}
+		default:
+			if e.Val(dwarf.AttrName) != nil || e.Val(dwarf.AttrLinkageName) != nil {
+				names[e.Offset] = entryNames(e)
+			}
+		}
+		if e.Children {
+			stack = append(stack, cur)
+			cur = next
+		}
+	}
+
+	for _, p := range unnamed {
+		name := resolveName(names, p.origin, demangleOptions)
+		if p.inline == -1 {
+			t.subprograms[p.sub].name = name
+		} else {
+			t.subprograms[p.sub].inlines[p.inline].name = name
+		}
+	}
+	sort.Slice(t.unitRanges, func(i, j int) bool {
+		return t.unitRanges[i].low < t.unitRanges[j].low
+	})
+	for i := range t.units {
+		subprograms := t.units[i].subprograms
+		sort.Slice(subprograms, func(i, j int) bool {
+			return subprograms[i].low < subprograms[j].low
+		})
+	}
+	return nil
+}
+
+func entryNames(e *dwarf.Entry) dwarfNames {
+	res := dwarfNames{}
+	res.name, _ = e.Val(dwarf.AttrName).(string)
+	res.linkage, _ = e.Val(dwarf.AttrLinkageName).(string)
+	if origin, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
+		res.origin = origin
+	} else if spec, ok := e.Val(dwarf.AttrSpecification).(dwarf.Offset); ok {
+		res.origin = spec
+	}
+	return res
+}
+
+// resolveName follows abstract origins and specifications, preferring the demangled linkage name.
+func resolveName(names map[dwarf.Offset]dwarfNames, off dwarf.Offset, demangleOptions []demangle.Option) string {
+	name := ""
+	for i := 0; i < 8; i++ {
+		n, ok := names[off]
+		if !ok {
+			break
+		}
+		if n.linkage != "" {
+			if len(demangleOptions) > 0 {
+				return demangle.Filter(n.linkage, demangleOptions...)
+			}
+			return n.linkage
+		}
+		if name == "" {
+			name = n.name
+		}
+		if n.origin == 0 {
+			break
+		}
+		off = n.origin
+	}
+	return name
+}
+
+// Resolve returns the inline chain at addr: the innermost inlined function first,
+// the function the code was inlined into last. It returns nil if addr is not covered by DWARF.
+func (t *DwarfTable) Resolve(addr uint64) []Line {
+	u := t.findUnit(addr)
+	if u == nil {
+		return nil
+	}
+	file, line := t.findLine(u, addr)
+	i := findRange(u.subprograms, addr)
+	if i == -1 {
+		if file == "" {
+			return nil
+		}
+		return []Line{{File: file, Line: line}}
+	}
+	sub := &t.subprograms[u.subprograms[i].idx]
+	var chain []*dwarfInline
+	for j := range sub.inlines {
+		in := &sub.inlines[j]
+		if addr >= in.low && addr < in.high {
+			chain = append(chain, in)
+		}
+	}
+	sort.SliceStable(chain, func(i, j int) bool {
+		return chain[i].depth > chain[j].depth
+	})
+	res := make([]Line, 0, len(chain)+1)
+	for _, in := range chain {
+		res = append(res, Line{Function: in.name, File: file, Line: line})
+		file, line = u.file(in.callFile), in.callLine
+	}
+	return append(res, Line{Function: sub.name, File: file, Line: line})
+}
+
+// Size returns the number of parsed functions, including inlined ones.
+func (t *DwarfTable) Size() int {
+	res := len(t.subprograms)
+	for i := range t.subprograms {
+		res += len(t.subprograms[i].inlines)
+	}
+	return res
+}
+
+func (t *DwarfTable) File() string {
+	return t.fpath
+}
+
+func (t *DwarfTable) findUnit(addr uint64) *dwarfUnit {
+	i := findRange(t.unitRanges, addr)
+	if i == -1 {
+		return nil
+	}
+	return &t.units[t.unitRanges[i].idx]
+}
+
+// findRange returns the index of the last range starting at or before addr if it contains addr, -1 otherwise.
+func findRange(ranges []dwarfRange, addr uint64) int {
+	i := sort.Search(len(ranges), func(i int) bool {
+		return addr < ranges[i].low
+	}) - 1
+	if i < 0 || addr >= ranges[i].high {
+		return -1
+	}
+	return i
+}
+
+func (t *DwarfTable) findLine(u *dwarfUnit, addr uint64) (string, int) {
+	if !u.linesLoaded {
+		u.linesLoaded = true
+		u.lines = t.readLines(u)
+	}
+	i := sort.Search(len(u.lines), func(i int) bool {
+		return addr < u.lines[i].addr
+	}) - 1
+	if i < 0 || u.lines[i].end {
+		return "", 0
+	}
+	return u.file(u.lines[i].file), u.lines[i].line
+}
+
+func (t *DwarfTable) readLines(u *dwarfUnit) []dwarfLine {
+	lr, err := t.data.LineReader(u.entry)
+	if err != nil || lr == nil {
+		return nil
+	}
+	var (
+		res     []dwarfLine
+		entry   dwarf.LineEntry
+		indices = make(map[*dwarf.LineFile]int)
+	)
+	for lr.Next(&entry) == nil {
+		l := dwarfLine{addr: entry.Address, line: entry.Line, end: entry.EndSequence}
+		if entry.File != nil {
+			i, ok := indices[entry.File]
+			if !ok {
+				i = u.fileIndex(entry.File, lr)
+				indices[entry.File] = i
+			}
+			l.file = i
+		}
+		res = append(res, l)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].addr < res[j].addr
+	})
+	return res
+}
+
+// fileIndex returns the index of f in the unit file table, the table may grow while the lines are read.
+func (u *dwarfUnit) fileIndex(f *dwarf.LineFile, lr *dwarf.LineReader) int {
+	for i, ff := range u.files {
+		if ff == f {
+			return i
+		}
+	}
+	u.files = lr.Files()
+	for i, ff := range u.files {
+		if ff == f {
+			return i
+		}
+	}
+	return -1
+}
+
+func (u *dwarfUnit) file(i int) string {
+	if i < 0 || i >= len(u.files) || u.files[i] == nil {
+		return ""
+	}
+	return u.files[i].Name
+}
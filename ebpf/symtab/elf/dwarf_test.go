@@ -0,0 +1,83 @@
+package elf
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDwarfInlineFrames(t *testing.T) {
+	me, err := NewMMapedElfFile("./testdata/elfs/elf.inline")
+	require.NoError(t, err)
+	defer me.Close()
+	symbols, err := me.NewSymbolTable(new(SymbolsOptions))
+	require.NoError(t, err)
+	defer symbols.Cleanup()
+	tab, err := me.NewDwarfTable(nil)
+	require.NoError(t, err)
+	me.Close()
+
+	outer := symbolAddress(t, symbols, "outer")
+	// counter += i, leaf and middle are inlined into outer
+	require.Equal(t, []Line{
+		{Function: "leaf", File: "inline.c", Line: 6},
+		{Function: "middle", File: "inline.c", Line: 10},
+		{Function: "outer", File: "inline.c", Line: 15},
+	}, baseNames(tab.Resolve(outer)))
+	// counter ^= i
+	require.Equal(t, []Line{
+		{Function: "middle", File: "inline.c", Line: 11},
+		{Function: "outer", File: "inline.c", Line: 15},
+	}, baseNames(tab.Resolve(outer+0x15)))
+
+	main := symbolAddress(t, symbols, "main")
+	lines := tab.Resolve(main)
+	require.NotEmpty(t, lines)
+	require.Equal(t, "main", lines[len(lines)-1].Function)
+
+	require.Nil(t, tab.Resolve(0))
+	require.NotZero(t, tab.Size())
+
+	noDwarf, err := NewMMapedElfFile("./testdata/elfs/elf")
+	require.NoError(t, err)
+	defer noDwarf.Close()
+	_, err = noDwarf.NewDwarfTable(nil)
+	require.ErrorIs(t, err, ErrNoDwarf)
+}
+
+func TestDwarfCompressed(t *testing.T) {
+	me, err := NewMMapedElfFile("./testdata/elfs/elf.compressed")
+	require.NoError(t, err)
+	defer me.Close()
+	symbols, err := me.NewSymbolTable(new(SymbolsOptions))
+	require.NoError(t, err)
+	defer symbols.Cleanup()
+	tab, err := me.NewDwarfTable(nil)
+	require.NoError(t, err)
+
+	lines := baseNames(tab.Resolve(symbolAddress(t, symbols, "iter")))
+	require.Len(t, lines, 1)
+	require.Equal(t, Line{Function: "iter", File: "src.c", Line: 9}, lines[0])
+}
+
+func symbolAddress(t *testing.T, symbols *SymbolTable, name string) uint64 {
+	t.Helper()
+	for i := 0; i < symbols.Index.Values.Length(); i++ {
+		n, err := symbols.symbolName(i)
+		require.NoError(t, err)
+		if n == name {
+			return symbols.Index.Values.Get(i)
+		}
+	}
+	t.Fatalf("symbol %s not found", name)
+	return 0
+}
+
+// baseNames strips the build directory from the file names.
+func baseNames(lines []Line) []Line {
+	for i := range lines {
+		lines[i].File = path.Base(lines[i].File)
+	}
+	return lines
+}
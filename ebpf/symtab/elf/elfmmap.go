@@ -24,6 +24,8 @@ type MMapedElfFile struct {
 	fpath string
 	err   error
 	fd    *os.File
+	// data holds the contents of files living in memory only, like .gnu_debugdata
+	data *bytes.Reader
 
 	stringCache map[int]string
 }
@@ -42,6 +44,26 @@ func NewMMapedElfFile(fpath string) (*MMapedElfFile, error) {
 		res.Close()
 		return nil, err
 	}
+	res.init(elfFile)
+	runtime.SetFinalizer(res, (*MMapedElfFile).Finalize)
+	return res, nil
+}
+
+// newMemElfFile creates an elf file from the data in memory. It does not need to be closed.
+func newMemElfFile(fpath string, data []byte) (*MMapedElfFile, error) {
+	res := &MMapedElfFile{
+		fpath: fpath,
+		data:  bytes.NewReader(data),
+	}
+	elfFile, err := elf.NewFile(res.data)
+	if err != nil {
+		return nil, err
+	}
+	res.init(elfFile)
+	return res, nil
+}
+
+func (f *MMapedElfFile) init(elfFile *elf.File) {
 	progs := make([]elf.ProgHeader, 0, len(elfFile.Progs))
 	sections := make([]elf.SectionHeader, 0, len(elfFile.Sections))
 	for i := range elfFile.Progs {
@@ -50,12 +72,9 @@ func NewMMapedElfFile(fpath string) (*MMapedElfFile, error) {
 	for i := range elfFile.Sections {
 		sections = append(sections, elfFile.Sections[i].SectionHeader)
 	}
-	res.FileHeader = elfFile.FileHeader
-	res.Progs = progs
-	res.Sections = sections
-
-	runtime.SetFinalizer(res, (*MMapedElfFile).Finalize)
-	return res, nil
+	f.FileHeader = elfFile.FileHeader
+	f.Progs = progs
+	f.Sections = sections
 }
 
 func (f *MMapedElfFile) Section(name string) *elf.SectionHeader {
@@ -79,7 +98,7 @@ func (f *MMapedElfFile) sectionByType(typ elf.SectionType) *elf.SectionHeader {
 }
 
 func (f *MMapedElfFile) ensureOpen() error {
-	if f.fd != nil {
+	if f.fd != nil || f.data != nil {
 		return nil
 	}
 	return f.open()
@@ -97,7 +116,9 @@ func (f *MMapedElfFile) Close() {
 		f.fd = nil
 	}
 	f.stringCache = nil
-	f.Sections = nil
+	if f.data == nil {
+		f.Sections = nil
+	}
 }
 func (f *MMapedElfFile) open() error {
 	if f.err != nil {
@@ -116,13 +137,29 @@ func (f *MMapedElfFile) SectionData(s *elf.SectionHeader) ([]byte, error) {
 	if err := f.ensureOpen(); err != nil {
 		return nil, err
 	}
+	if s.Type == elf.SHT_NOBITS {
+		return nil, fmt.Errorf("section %s has no data", s.Name)
+	}
 	res := make([]byte, s.Size)
-	if _, err := f.fd.ReadAt(res, int64(s.Offset)); err != nil {
+	if _, err := f.readAt(res, int64(s.Offset)); err != nil {
 		return nil, err
 	}
+	if s.Flags&elf.SHF_COMPRESSED != 0 {
+		return f.decompressSection(s, res)
+	}
+	if strings.HasPrefix(s.Name, ".zdebug_") {
+		return decompressZDebugSection(s, res)
+	}
 	return res, nil
 }
 
+func (f *MMapedElfFile) readAt(b []byte, off int64) (int, error) {
+	if f.data != nil {
+		return f.data.ReadAt(b, off)
+	}
+	return f.fd.ReadAt(b, off)
+}
+
 func (f *MMapedElfFile) FilePath() string {
 	return f.fpath
 }
@@ -139,11 +176,11 @@ func (f *MMapedElfFile) getString(start int, demangleOptions []demangle.Option)
 	var tmpBuf [tmpBufSize]byte
 	sb := strings.Builder{}
 	for i := 0; i < 10; i++ {
-		_, err := f.fd.ReadAt(tmpBuf[:], int64(start+i*tmpBufSize))
-		if err != nil {
+		n, err := f.readAt(tmpBuf[:], int64(start+i*tmpBufSize))
+		if n == 0 && err != nil {
 			return "", false
 		}
-		idx := bytes.IndexByte(tmpBuf[:], 0)
+		idx := bytes.IndexByte(tmpBuf[:n], 0)
 		if idx >= 0 {
 			sb.Write(tmpBuf[:idx])
 			s := sb.String()
@@ -156,7 +193,7 @@ func (f *MMapedElfFile) getString(start int, demangleOptions []demangle.Option)
 			f.stringCache[start] = s
 			return s, true
 		} else {
-			sb.Write(tmpBuf[:])
+			sb.Write(tmpBuf[:n])
 		}
 	}
 	return "", false
@@ -4,6 +4,7 @@ import (
 	"debug/elf"
 	"errors"
 	"fmt"
+	"io"
 
 	gosym2 "github.com/grafana/pyroscope/ebpf/symtab/gosym"
 )
@@ -13,6 +14,11 @@ type GoTable struct {
 	File           *MMapedElfFile
 	gopclnSection  elf.SectionHeader
 	funcNameOffset uint64
+	textStart      uint64
+	// allocSections are kept to read funcdata by address, File drops its sections on Cleanup
+	allocSections []elf.SectionHeader
+	// lines is created on the first ResolveLines call
+	lines *gosym2.LineTable
 }
 
 func (g *GoTable) IsDead() bool {
@@ -125,9 +131,112 @@ func (f *MMapedElfFile) NewGoTable() (*GoTable, error) {
 		File:           f,
 		gopclnSection:  *pclntab,
 		funcNameOffset: funcNameOffset,
+		textStart:      textStart,
+		allocSections:  allocSections(f.Sections),
 	}, nil
 }
 
+func allocSections(sections []elf.SectionHeader) []elf.SectionHeader {
+	var res []elf.SectionHeader
+	for _, s := range sections {
+		if s.Type != elf.SHT_NOBITS && s.Flags&elf.SHF_ALLOC != 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// ResolveLines returns the inline chain at addr decoded from .gopclntab, innermost function first.
+func (g *GoTable) ResolveLines(addr uint64) []Line {
+	if len(g.Index.Name) == 0 || addr >= g.Index.End {
+		return nil
+	}
+	i := g.Index.Entry.FindIndex(addr)
+	if i == -1 {
+		return nil
+	}
+	if g.lines == nil {
+		g.lines = g.newLineTable()
+	}
+	frames := g.lines.Go12InlineFrames(i, addr)
+	if len(frames) == 0 {
+		return nil
+	}
+	res := make([]Line, len(frames))
+	for j, frame := range frames {
+		name, _ := g.File.getString(int(g.gopclnSection.Offset)+int(g.funcNameOffset)+int(frame.NameOff), DemangleNone)
+		res[j] = Line{Function: name, File: frame.File, Line: frame.Line}
+	}
+	return res
+}
+
+// newLineTable reads the file through File, so that it survives Cleanup.
+func (g *GoTable) newLineTable() *gosym2.LineTable {
+	res := gosym2.NewLineTableStreaming(&fileData{f: g.File, offset: int64(g.gopclnSection.Offset)}, g.textStart)
+	res.IsGo12()
+	var goFunc uint64
+	for i := range g.allocSections {
+		if g.allocSections[i].Name != ".noptrdata" {
+			continue
+		}
+		if data, err := g.File.SectionData(&g.allocSections[i]); err == nil {
+			goFunc = res.ModuleDataGoFunc(data, g.gopclnSection.Addr)
+		}
+	}
+	res.SetFuncData(&vaddrData{fileData: fileData{f: g.File}, sections: g.allocSections}, goFunc)
+	return res
+}
+
+// fileData implements gosym.PCLNData, caching the last read page of the file.
+type fileData struct {
+	f      *MMapedElfFile
+	offset int64
+	page   []byte
+	pageAt int64
+}
+
+const fileDataPageSize = 4096
+
+func (d *fileData) ReadAt(data []byte, offset int) error {
+	at := d.offset + int64(offset)
+	if at < d.pageAt || at+int64(len(data)) > d.pageAt+int64(len(d.page)) {
+		if err := d.f.ensureOpen(); err != nil {
+			return err
+		}
+		size := fileDataPageSize
+		if len(data) > size {
+			size = len(data)
+		}
+		if cap(d.page) < size {
+			d.page = make([]byte, size)
+		}
+		n, _ := d.f.readAt(d.page[:size], at)
+		d.page = d.page[:n]
+		d.pageAt = at
+		if n < len(data) {
+			copy(data, d.page)
+			return io.EOF
+		}
+	}
+	copy(data, d.page[at-d.pageAt:])
+	return nil
+}
+
+// vaddrData implements gosym.PCLNData reading by virtual address.
+type vaddrData struct {
+	fileData
+	sections []elf.SectionHeader
+}
+
+func (d *vaddrData) ReadAt(data []byte, addr int) error {
+	for _, s := range d.sections {
+		if uint64(addr) >= s.Addr && uint64(addr)+uint64(len(data)) <= s.Addr+s.Size {
+			return d.fileData.ReadAt(data, int(s.Offset+uint64(addr)-s.Addr))
+		}
+	}
+	return fmt.Errorf("address %x is not mapped", addr)
+}
+
 func (g *GoTable) goSymbolName(idx int) (string, error) {
 	offsetGpcln := g.gopclnSection.Offset
 	if idx >= len(g.Index.Name) {
@@ -175,6 +284,10 @@ func (g *GoTableWithFallback) Resolve(addr uint64) string {
 	return g.SymTable.Resolve(addr)
 }
 
+func (g *GoTableWithFallback) ResolveLines(addr uint64) []Line {
+	return g.GoTable.ResolveLines(addr)
+}
+
 func (g *GoTableWithFallback) Cleanup() {
 	g.GoTable.Cleanup()
 	g.SymTable.Cleanup() // second call is no op now, but call anyway just in case
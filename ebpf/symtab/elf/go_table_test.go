@@ -1,6 +1,7 @@
 package elf
 
 import (
+	"debug/elf"
 	"strings"
 	"testing"
 
@@ -67,3 +68,60 @@ func TestSelfGoSymbolComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestGoTableLines(t *testing.T) {
+	ts := []struct {
+		f       string
+		inlined bool
+	}{
+		{"./testdata/elfs/go12", false},
+		{"./testdata/elfs/go16", true},
+		{"./testdata/elfs/go18", true},
+		{"./testdata/elfs/go20", true},
+		{"./testdata/elfs/go12-static", false},
+		{"./testdata/elfs/go16-static", true},
+		{"./testdata/elfs/go18-static", true},
+		{"./testdata/elfs/go20-static", true},
+	}
+	for _, testcase := range ts {
+		t.Run(testcase.f, func(t *testing.T) {
+			obj, err := elf.Open(testcase.f)
+			require.NoError(t, err)
+			defer obj.Close()
+			expected, err := getGoTableFromPCLN(obj, strings.Contains(testcase.f, "go20"))
+			require.NoError(t, err)
+
+			me, err := NewMMapedElfFile(testcase.f)
+			require.NoError(t, err)
+			defer me.Close()
+			goTable, err := me.NewGoTable()
+			require.NoError(t, err)
+
+			inlined := 0
+			for i := 0; i < len(expected.Funcs); i += 3 {
+				fn := expected.Funcs[i]
+				for pc := fn.Entry; pc < fn.End; pc += (fn.End-fn.Entry)/4 + 1 {
+					lines := goTable.ResolveLines(pc)
+					require.NotEmpty(t, lines)
+					file, line, _ := expected.PCToLine(pc)
+					require.Equal(t, file, lines[0].File)
+					require.Equal(t, line, lines[0].Line)
+					require.Equal(t, fn.Name, lines[len(lines)-1].Function)
+					if len(lines) > 1 {
+						inlined++
+						for _, l := range lines {
+							require.NotEmpty(t, l.Function)
+							require.NotEmpty(t, l.File)
+						}
+					}
+				}
+				// The file survives cleanup.
+				if i%1000 == 0 {
+					goTable.Cleanup()
+				}
+			}
+			require.Equal(t, testcase.inlined, inlined > 0)
+			require.Nil(t, goTable.ResolveLines(goTable.Index.End))
+		})
+	}
+}
@@ -148,3 +148,61 @@ type SymTabDebugInfo struct {
 	File          string `river:"file,attr,optional"`
 	LastUsedRound int    `river:"last_used_round,attr,optional"`
 }
+
+// SymbolTableWithMiniDebugInfo combines the symbols of a stripped file with the ones
+// from its .gnu_debugdata section. The dynamic symbols are only in the file itself,
+// while .gnu_debugdata holds the local function symbols.
+type SymbolTableWithMiniDebugInfo struct {
+	// may be nil if the file has no symbols on its own
+	SymTable      *SymbolTable
+	MiniDebugInfo *SymbolTable
+}
+
+func (m *SymbolTableWithMiniDebugInfo) IsDead() bool {
+	return m.SymTable != nil && m.SymTable.IsDead()
+}
+
+func (m *SymbolTableWithMiniDebugInfo) DebugInfo() SymTabDebugInfo {
+	return SymTabDebugInfo{
+		Name: fmt.Sprintf("SymbolTableWithMiniDebugInfo %p", m),
+		Size: m.Size(),
+		File: m.MiniDebugInfo.File.fpath,
+	}
+}
+
+func (m *SymbolTableWithMiniDebugInfo) Size() int {
+	res := m.MiniDebugInfo.Size()
+	if m.SymTable != nil {
+		res += m.SymTable.Size()
+	}
+	return res
+}
+
+func (m *SymbolTableWithMiniDebugInfo) Refresh() {
+
+}
+
+// Resolve picks the closest symbol preceding addr from both tables.
+func (m *SymbolTableWithMiniDebugInfo) Resolve(addr uint64) string {
+	i := m.MiniDebugInfo.Index.Values.FindIndex(addr)
+	j := -1
+	if m.SymTable != nil {
+		j = m.SymTable.Index.Values.FindIndex(addr)
+	}
+	if j != -1 && (i == -1 || m.SymTable.Index.Values.Value(j) > m.MiniDebugInfo.Index.Values.Value(i)) {
+		name, _ := m.SymTable.symbolName(j)
+		return name
+	}
+	if i == -1 {
+		return ""
+	}
+	name, _ := m.MiniDebugInfo.symbolName(i)
+	return name
+}
+
+func (m *SymbolTableWithMiniDebugInfo) Cleanup() {
+	if m.SymTable != nil {
+		m.SymTable.Cleanup()
+	}
+	m.MiniDebugInfo.Cleanup()
+}
@@ -0,0 +1,23 @@
+#include <stdio.h>
+
+volatile int counter;
+
+static inline __attribute__((always_inline)) void leaf(int i) {
+    counter += i;
+}
+
+static inline __attribute__((always_inline)) void middle(int i) {
+    leaf(i * 2);
+    counter ^= i;
+}
+
+__attribute__((noinline)) void outer(int i) {
+    middle(i);
+}
+
+int main() {
+    for (int i = 0; ; i++) {
+        outer(i);
+    }
+    return 0;
+}
@@ -64,6 +64,19 @@ func GetGoSymbols(file string, patchGo20Magic bool) ([]TestSym, error) {
 }
 
 func getGoSymbolsFromPCLN(obj *elf.File, patchGo20Magic bool) ([]TestSym, error) {
+	table, err := getGoTableFromPCLN(obj, patchGo20Magic)
+	if err != nil {
+		return nil, err
+	}
+	es := make([]TestSym, 0, len(table.Funcs))
+	for _, fun := range table.Funcs {
+		es = append(es, TestSym{Start: fun.Entry, Name: fun.Name})
+	}
+
+	return es, nil
+}
+
+func getGoTableFromPCLN(obj *elf.File, patchGo20Magic bool) (*gosym.Table, error) {
 	var err error
 	var pclntab []byte
 	text := obj.Section(".text")
@@ -103,11 +116,5 @@ func getGoSymbolsFromPCLN(obj *elf.File, patchGo20Magic bool) ([]TestSym, error)
 	if len(table.Funcs) == 0 {
 		return nil, errors.New("gosymtab: no symbols found")
 	}
-
-	es := make([]TestSym, 0, len(table.Funcs))
-	for _, fun := range table.Funcs {
-		es = append(es, TestSym{Start: fun.Entry, Name: fun.Name})
-	}
-
-	return es, nil
+	return table, nil
 }
@@ -67,6 +67,11 @@ func (e *ElfCache) Update(buildIDCacheOptions GCacheOptions, sameFileCacheOption
 	e.SameFileCache.Update(sameFileCacheOptions)
 }
 
+func (e *ElfCache) Clear() {
+	e.BuildIDCache.Clear()
+	e.SameFileCache.Clear()
+}
+
 func (e *ElfCache) NextRound() {
 	e.BuildIDCache.NextRound()
 	e.SameFileCache.NextRound()
@@ -33,6 +33,57 @@ func TestElf(t *testing.T) {
 	}
 }
 
+func TestElfInlineFrames(t *testing.T) {
+	elfCache, _ := NewElfCache(testCacheOptions, testCacheOptions)
+	logger := util.TestLogger(t)
+	newTable := func(inlineFrames bool) *ElfTable {
+		return NewElfTable(logger, &ProcMap{StartAddr: 0x7000, Offset: 0x1000}, ".", "elf/testdata/elfs/elf.inline",
+			ElfTableOptions{
+				ElfCache:      elfCache,
+				SymbolOptions: &SymbolOptions{InlineFrames: inlineFrames},
+				Metrics:       metrics.NewSymtabMetrics(nil),
+			})
+	}
+	expected := []string{"leaf", "middle", "outer"}
+	tab := newTable(true)
+	require.Equal(t, "outer", tab.Resolve(0x7150))
+	lines := tab.ResolveLines(0x7150)
+	require.Len(t, lines, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i], lines[i].Function)
+		require.Contains(t, lines[i].File, "inline.c")
+	}
+
+	// The lines are cached along with the symbols.
+	cached := newTable(true)
+	require.Equal(t, "outer", cached.Resolve(0x7150))
+	require.True(t, cached.loadedCached)
+	require.Equal(t, lines, cached.ResolveLines(0x7150))
+
+	elfCache, _ = NewElfCache(testCacheOptions, testCacheOptions)
+	noLines := newTable(false)
+	require.Equal(t, "outer", noLines.Resolve(0x7150))
+	require.Nil(t, noLines.ResolveLines(0x7150))
+}
+
+func TestElfGoInlineFrames(t *testing.T) {
+	elfCache, _ := NewElfCache(testCacheOptions, testCacheOptions)
+	tab := NewElfTable(util.TestLogger(t), &ProcMap{StartAddr: 0x401000, Offset: 0x1000}, ".", "elf/testdata/elfs/go20",
+		ElfTableOptions{
+			ElfCache:      elfCache,
+			SymbolOptions: &SymbolOptions{InlineFrames: true},
+			Metrics:       metrics.NewSymtabMetrics(nil),
+		})
+	// runtime.bucketShift and runtime.bucketMask are inlined into runtime.mapassign_fast64ptr
+	require.Equal(t, "runtime.mapassign_fast64ptr", tab.Resolve(0x40f939))
+	lines := tab.ResolveLines(0x40f939)
+	require.Equal(t, []elf.Line{
+		{Function: "runtime.bucketShift", File: "/usr/local/go/src/runtime/map.go", Line: 186},
+		{Function: "runtime.bucketMask", File: "/usr/local/go/src/runtime/map.go", Line: 191},
+		{Function: "runtime.mapassign_fast64ptr", File: "/usr/local/go/src/runtime/map_fast64.go", Line: 204},
+	}, lines)
+}
+
 func TestGoTableFallbackFiltering(t *testing.T) {
 	ts := []struct {
 		f string
@@ -111,6 +111,15 @@ func (g *GCache[K, V]) Cleanup() {
 	//level.Debug(sc.logger).Log("msg", "symbolCache cleanup", "was", len(prev), "now", len(sc.roundCache))
 }
 
+// Clear removes and cleans up all the entries.
+func (g *GCache[K, V]) Clear() {
+	g.lruCache.Purge()
+	for _, e := range g.roundCache {
+		e.v.Cleanup()
+	}
+	g.roundCache = make(map[K]*entry[V])
+}
+
 func (g *GCache[K, V]) LRUSize() int {
 	return g.lruCache.Len()
 }
@@ -0,0 +1,274 @@
+package gosym
+
+import "bytes"
+
+// https://github.com/golang/go/blob/go1.20.5/src/internal/abi/symtab.go
+const (
+	pcdataInlTreeIndex = 2
+	funcdataInlTree    = 3
+)
+
+// maxInlineDepth protects from loops in corrupted inline trees.
+const maxInlineDepth = 128
+
+// InlineFrame is a function on the inline chain of a pc.
+type InlineFrame struct {
+	// NameOff is the offset of the function name in funcnametab, see FuncNameOffset.
+	NameOff uint32
+	File    string
+	Line    int
+}
+
+// SetFuncData enables decoding of the inline trees.
+// data reads the memory of the binary by virtual address.
+// goFunc is the address of the go:func.* symbol, funcdata offsets are relative to it since go1.18.
+func (t *LineTable) SetFuncData(data PCLNData, goFunc uint64) {
+	t.funcdataMem = data
+	t.goFunc = goFunc
+}
+
+// ModuleDataGoFunc returns the gofunc field of runtime.firstmoduledata, or 0 if it is not found.
+// data is the contents of the section holding the moduledata, usually .noptrdata,
+// the moduledata is recognized by its pcHeader and text fields. Only go1.18+ binaries need it.
+// The fields are not relocated in position independent binaries, so it is not found there.
+func (t *LineTable) ModuleDataGoFunc(data []byte, pclntabAddr uint64) uint64 {
+	// https://github.com/golang/go/blob/go1.20.5/src/runtime/symtab.go#L415
+	var goFuncWord int
+	switch t.version {
+	case ver118:
+		goFuncWord = 38
+	case ver120:
+		goFuncWord = 40 // covctrs and ecovctrs added
+	default:
+		return 0
+	}
+	const textWord = 22
+	ptrsize := int(t.ptrsize)
+	word := func(at int) uint64 {
+		if ptrsize == 4 {
+			return uint64(t.binary.Uint32(data[at:]))
+		}
+		return t.binary.Uint64(data[at:])
+	}
+	for at := 0; at+(goFuncWord+1)*ptrsize <= len(data); at += ptrsize {
+		if word(at) == pclntabAddr && word(at+textWord*ptrsize) == t.textStart {
+			return word(at + goFuncWord*ptrsize)
+		}
+	}
+	return 0
+}
+
+// Go12InlineFrames returns the inline chain at pc of the i'th function in the functab:
+// the innermost inlined function first and the i'th function itself last.
+// Inlined functions are only decoded for go1.16+ binaries, see SetFuncData.
+// It returns nil if the table is malformed.
+func (t *LineTable) Go12InlineFrames(i int, pc uint64) (res []InlineFrame) {
+	if !disableRecover {
+		defer func() {
+			if err := recover(); err != nil {
+				res = nil
+			}
+		}()
+	}
+	f := t.funcData(uint32(i))
+	entry := t.funcTab().pc(i)
+	if tree, ok := t.inlTree(f); ok {
+		for depth := 0; depth < maxInlineDepth; depth++ {
+			ix := t.pcdata(f, entry, pcdataInlTreeIndex, pc)
+			if ix < 0 {
+				break
+			}
+			nameOff, parentPc := t.inlinedCall(tree, ix)
+			file, line := t.fileLine(f, entry, pc)
+			res = append(res, InlineFrame{NameOff: nameOff, File: file, Line: line})
+			// the position of parentPc is the call site in the caller
+			pc = entry + uint64(parentPc)
+		}
+	}
+	file, line := t.fileLine(f, entry, pc)
+	return append(res, InlineFrame{NameOff: f.nameOff(), File: file, Line: line})
+}
+
+// funcSize returns the size of the fixed part of the _func struct, which is followed by pcdata and funcdata.
+func (t *LineTable) funcSize() uint64 {
+	switch t.version {
+	case ver120:
+		return 44 // startLine added
+	case ver118:
+		return 40
+	default:
+		return uint64(t.ptrsize) + 36
+	}
+}
+
+// inlTree returns the address of the inline tree of the function.
+func (t *LineTable) inlTree(f funcData) (uint64, bool) {
+	if t.version < ver116 || t.funcdataMem == nil {
+		return 0, false
+	}
+	at := f.t.funcdataOffset + f.dataOffset
+	size := t.funcSize()
+	nfuncdata := t.uint8At(int(at + size - 1))
+	if uint32(nfuncdata) <= funcdataInlTree {
+		return 0, false
+	}
+	off := size + uint64(f.npcdata())*4
+	if t.version >= ver118 {
+		raw := t.uint32At(int(at + off + funcdataInlTree*4))
+		if raw == ^uint32(0) || t.goFunc == 0 {
+			return 0, false
+		}
+		return t.goFunc + uint64(raw), true
+	}
+	// go1.16 and go1.17 store pointer aligned absolute addresses
+	if t.ptrsize == 8 && off&4 != 0 {
+		off += 4
+	}
+	addr := t.uintptrAt(int(at + off + funcdataInlTree*uint64(t.ptrsize)))
+	return addr, addr != 0
+}
+
+// inlinedCall decodes the ix'th runtime.inlinedCall of the tree.
+func (t *LineTable) inlinedCall(tree uint64, ix int32) (nameOff uint32, parentPc int32) {
+	buf := make([]byte, 20)
+	if t.version >= ver120 {
+		// funcID uint8, _ [3]byte, nameOff int32, parentPc int32, startLine int32
+		buf = buf[:16]
+		if err := t.funcdataMem.ReadAt(buf, int(tree+uint64(ix)*16)); err != nil {
+			panic(err)
+		}
+		return t.binary.Uint32(buf[4:]), int32(t.binary.Uint32(buf[8:]))
+	}
+	// parent int16, funcID uint8, _ byte, file int32, line int32, func_ int32, parentPc int32
+	if err := t.funcdataMem.ReadAt(buf, int(tree+uint64(ix)*20)); err != nil {
+		panic(err)
+	}
+	return t.binary.Uint32(buf[12:]), int32(t.binary.Uint32(buf[16:]))
+}
+
+// pcdata returns the value of the given pcdata table at pc.
+func (t *LineTable) pcdata(f funcData, entry uint64, table uint32, pc uint64) int32 {
+	if table >= f.npcdata() {
+		return -1
+	}
+	off := t.uint32At(int(f.t.funcdataOffset + f.dataOffset + t.funcSize() + uint64(table)*4))
+	if off == 0 {
+		return -1
+	}
+	return t.pcvalue(off, entry, pc)
+}
+
+func (t *LineTable) fileLine(f funcData, entry uint64, pc uint64) (string, int) {
+	line := t.pcvalue(f.pcln(), entry, pc)
+	fno := t.pcvalue(f.pcfile(), entry, pc)
+	return t.fileName(f, fno), int(line)
+}
+
+// fileName is go12PCToFile from debug/gosym.
+func (t *LineTable) fileName(f funcData, fno int32) string {
+	if t.version == ver12 {
+		if fno <= 0 || uint32(fno) >= t.nfiletab {
+			return ""
+		}
+		return t.stringAt(uint64(t.uint32At(int(t.filetabOffset) + 4*int(fno))))
+	}
+	// 0 is valid since go1.16
+	if fno < 0 {
+		return ""
+	}
+	fnoff := t.uint32At(int(t.cutabOffset) + int(f.cuOffset()+uint32(fno))*4)
+	if fnoff == ^uint32(0) {
+		return ""
+	}
+	return t.stringAt(t.filetabOffset + uint64(fnoff))
+}
+
+// pcvalue reports the value associated with the target pc.
+// off is the offset to the beginning of the pc-value table,
+// and entry is the start PC for the corresponding function.
+func (t *LineTable) pcvalue(off uint32, entry, targetpc uint64) int32 {
+	p := int(t.pctabOffset) + int(off)
+	val := int32(-1)
+	pc := entry
+	for t.step(&p, &pc, &val, pc == entry) {
+		if targetpc < pc {
+			return val
+		}
+	}
+	return -1
+}
+
+// step advances to the next pc, value pair in the encoded table.
+func (t *LineTable) step(p *int, pc *uint64, val *int32, first bool) bool {
+	uvdelta := t.readvarint(p)
+	if uvdelta == 0 && !first {
+		return false
+	}
+	if uvdelta&1 != 0 {
+		uvdelta = ^(uvdelta >> 1)
+	} else {
+		uvdelta >>= 1
+	}
+	vdelta := int32(uvdelta)
+	pcdelta := t.readvarint(p) * t.quantum
+	*pc += uint64(pcdelta)
+	*val += vdelta
+	return true
+}
+
+// readvarint reads and returns a varint at *p, advancing *p.
+func (t *LineTable) readvarint(p *int) uint32 {
+	var v, shift uint32
+	for shift = 0; shift < 35; shift += 7 {
+		b := t.uint8At(*p)
+		*p++
+		v |= (uint32(b) & 0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return v
+}
+
+func (t *LineTable) uint8At(at int) uint8 {
+	buf := t.tmpbuf[:1]
+	if err := t.PCLNData.ReadAt(buf, at); err != nil {
+		panic(err)
+	}
+	return buf[0]
+}
+
+func (t *LineTable) uint32At(at int) uint32 {
+	buf := t.tmpbuf[:4]
+	if err := t.PCLNData.ReadAt(buf, at); err != nil {
+		panic(err)
+	}
+	return t.binary.Uint32(buf)
+}
+
+// stringAt returns the NUL terminated string at the given offset of the table.
+func (t *LineTable) stringAt(at uint64) string {
+	if s, ok := t.fileCache[at]; ok {
+		return s
+	}
+	var res []byte
+	for len(res) < 4096 {
+		var buf [64]byte
+		// the last string may end closer than len(buf) to the end of the table
+		err := t.PCLNData.ReadAt(buf[:], int(at)+len(res))
+		if i := bytes.IndexByte(buf[:], 0); i >= 0 {
+			res = append(res, buf[:i]...)
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, buf[:]...)
+	}
+	s := string(res)
+	if t.fileCache == nil {
+		t.fileCache = make(map[uint64]string)
+	}
+	t.fileCache[at] = s
+	return s
+}
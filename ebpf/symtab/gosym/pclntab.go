@@ -6,6 +6,7 @@
 // https://github.com/golang/go/blob/go1.20.5/src/debug/gosym/pclntab.go
 // modified go12Funcs function to be exported return a FlatFuncIndex instead of []Func
 // added FuncNameOffset to export the funcnametabOffset
+// added filetab, cutab and pctab offsets used to decode file:line and inline trees, see inline.go
 
 /*
  * Line tables
@@ -64,8 +65,18 @@ type LineTable struct {
 	functabOffset     uint64
 	nfunctab          uint32
 	funcnametabOffset uint64
+	cutabOffset       uint64
+	filetabOffset     uint64
+	pctabOffset       uint64
+	nfiletab          uint32
 	failed            bool
 	tmpbuf            [8]uint8
+
+	// funcdataMem reads funcdata referenced from _func by virtual address, may be nil
+	funcdataMem PCLNData
+	// goFunc is the address of go:func.* the funcdata offsets are relative to since go1.18
+	goFunc    uint64
+	fileCache map[uint64]string
 }
 
 // NewLineTable returns a new PC/line table
@@ -198,20 +209,32 @@ func (t *LineTable) parsePclnTab() {
 	switch possibleVersion {
 	case ver118, ver120:
 		t.nfunctab = uint32(offset(0))
+		t.nfiletab = uint32(offset(1))
 		t.textStart = t.PC // use the start PC instead of reading from the table, which may be unrelocated
 		t.funcnametabOffset = offset(3)
+		t.cutabOffset = offset(4)
+		t.filetabOffset = offset(5)
+		t.pctabOffset = offset(6)
 		t.funcdataOffset = offset(7)
 		t.functabOffset = offset(7)
 	case ver116:
 		t.nfunctab = uint32(offset(0))
+		t.nfiletab = uint32(offset(1))
 		t.funcnametabOffset = offset(2)
+		t.cutabOffset = offset(3)
+		t.filetabOffset = offset(4)
+		t.pctabOffset = offset(5)
 		t.funcdataOffset = offset(6)
 		t.functabOffset = offset(6)
 	case ver12:
 		t.nfunctab = uint32(t.uintptrAt(8))
 		t.funcdataOffset = 0
 		t.funcnametabOffset = 0
+		t.pctabOffset = 0
 		t.functabOffset = uint64(8 + t.ptrsize)
+		functabsize := (int(t.nfunctab)*2 + 1) * t.functabFieldSize()
+		t.filetabOffset = uint64(t.uint32At(int(t.functabOffset) + functabsize))
+		t.nfiletab = t.uint32At(int(t.filetabOffset))
 	default:
 		panic("unreachable")
 	}
@@ -333,7 +356,11 @@ func (t *LineTable) funcData(i uint32) funcData {
 //	return f.t == nil && f.data == nil
 //}
 
-func (f funcData) nameOff() uint32 { return f.field(1) }
+func (f funcData) nameOff() uint32  { return f.field(1) }
+func (f funcData) pcfile() uint32   { return f.field(5) }
+func (f funcData) pcln() uint32     { return f.field(6) }
+func (f funcData) npcdata() uint32  { return f.field(7) }
+func (f funcData) cuOffset() uint32 { return f.field(8) }
 
 // field returns the nth field of the _func struct.
 // It panics if n == 0 or n > 9; for n == 0, call f.entryPC.
@@ -0,0 +1,238 @@
+package symtab
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
+)
+
+// PerfMap resolves symbols of JIT compiled code from a /tmp/perf-PID.map file,
+// as written by node --perf-basic-prof, perf-map-agent or DOTNET_PerfMapEnabled.
+// Each line of the file is "START SIZE NAME", START and SIZE are hex numbers.
+// The file is append only, so it is read incrementally as it grows.
+type PerfMap struct {
+	logger  log.Logger
+	metrics *metrics.SymtabMetrics
+	// path of the file in the agent mount namespace
+	path string
+	// path of the file in the process mount namespace
+	module string
+
+	stat    Stat
+	offset  int64
+	symbols []perfMapSymbol
+	sorted  bool
+}
+
+type perfMapSymbol struct {
+	start uint64
+	end   uint64
+	name  string
+}
+
+func NewPerfMap(logger log.Logger, rootFS string, nsPid int, metrics *metrics.SymtabMetrics) *PerfMap {
+	module := fmt.Sprintf("/tmp/perf-%d.map", nsPid)
+	return &PerfMap{
+		logger:  logger,
+		metrics: metrics,
+		path:    path.Join(rootFS, module),
+		module:  module,
+		sorted:  true,
+	}
+}
+
+// Refresh reads the lines appended to the file since the previous call.
+// The symbols are reloaded from scratch if the file is truncated or replaced.
+func (p *PerfMap) Refresh() {
+	f, err := os.Open(p.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			p.metrics.PerfMapErrors.WithLabelValues(errorType(err)).Inc()
+		}
+		p.reset()
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		p.metrics.PerfMapErrors.WithLabelValues(errorType(err)).Inc()
+		return
+	}
+	stat := statFromFileInfo(fi)
+	if stat != p.stat || fi.Size() < p.offset {
+		if p.offset != 0 {
+			p.metrics.PerfMapResets.Inc()
+		}
+		p.reset()
+		p.stat = stat
+	}
+	if fi.Size() == p.offset {
+		return
+	}
+	data := make([]byte, fi.Size()-p.offset)
+	n, err := f.ReadAt(data, p.offset)
+	if n == 0 && err != nil {
+		p.metrics.PerfMapErrors.WithLabelValues(errorType(err)).Inc()
+		return
+	}
+	// The last line may still be being written, leave it for the next refresh.
+	end := bytes.LastIndexByte(data[:n], '\n')
+	if end == -1 {
+		return
+	}
+	data = data[:end+1]
+	p.offset += int64(len(data))
+
+	loaded := p.parse(data)
+	if loaded > 0 {
+		p.sorted = false
+		p.metrics.PerfMapLoads.Inc()
+		p.metrics.PerfMapSymbols.Add(float64(loaded))
+	}
+}
+
+func (p *PerfMap) parse(data []byte) int {
+	loaded := 0
+	invalid := 0
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		line := data[:i]
+		data = data[i+1:]
+		if len(line) == 0 {
+			continue
+		}
+		sym, ok := parsePerfMapLine(string(line))
+		if !ok {
+			invalid++
+			continue
+		}
+		p.symbols = append(p.symbols, sym)
+		loaded++
+	}
+	if invalid > 0 {
+		p.metrics.PerfMapErrors.WithLabelValues("InvalidLine").Add(float64(invalid))
+		level.Debug(p.logger).Log("msg", "invalid perf map lines", "file", p.path, "count", invalid)
+	}
+	return loaded
+}
+
+func parsePerfMapLine(line string) (perfMapSymbol, bool) {
+	start, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return perfMapSymbol{}, false
+	}
+	size, name, ok := strings.Cut(rest, " ")
+	if !ok || name == "" {
+		return perfMapSymbol{}, false
+	}
+	istart, err := strconv.ParseUint(strings.TrimPrefix(start, "0x"), 16, 64)
+	if err != nil {
+		return perfMapSymbol{}, false
+	}
+	isize, err := strconv.ParseUint(strings.TrimPrefix(size, "0x"), 16, 64)
+	if err != nil {
+		return perfMapSymbol{}, false
+	}
+	return perfMapSymbol{start: istart, end: istart + isize, name: name}, true
+}
+
+func (p *PerfMap) reset() {
+	p.stat = Stat{}
+	p.offset = 0
+	p.symbols = p.symbols[:0]
+	p.sorted = true
+}
+
+// sort orders the symbols by start address. When the code at the same address
+// is compiled more than once, the most recent entry wins.
+func (p *PerfMap) sort() {
+	sort.SliceStable(p.symbols, func(i, j int) bool {
+		return p.symbols[i].start < p.symbols[j].start
+	})
+	j := 0
+	for i := range p.symbols {
+		if j > 0 && p.symbols[j-1].start == p.symbols[i].start {
+			p.symbols[j-1] = p.symbols[i]
+			continue
+		}
+		p.symbols[j] = p.symbols[i]
+		j++
+	}
+	p.symbols = p.symbols[:j]
+	p.sorted = true
+}
+
+func (p *PerfMap) Resolve(addr uint64) string {
+	if !p.sorted {
+		p.sort()
+	}
+	i := sort.Search(len(p.symbols), func(i int) bool {
+		return addr < p.symbols[i].start
+	})
+	if i == 0 || addr >= p.symbols[i-1].end {
+		p.metrics.PerfMapLookups.WithLabelValues("miss").Inc()
+		return ""
+	}
+	p.metrics.PerfMapLookups.WithLabelValues("hit").Inc()
+	return p.symbols[i-1].name
+}
+
+// Module returns the path of the perf map file as seen by the process.
+func (p *PerfMap) Module() string {
+	return p.module
+}
+
+func (p *PerfMap) Cleanup() {
+}
+
+func (p *PerfMap) IsDead() bool {
+	return false
+}
+
+func (p *PerfMap) DebugInfo() elf.SymTabDebugInfo {
+	return elf.SymTabDebugInfo{
+		Name: fmt.Sprintf("PerfMap %p", p),
+		Size: len(p.symbols),
+		File: p.path,
+	}
+}
+
+// nsPid returns the pid of the process in its own pid namespace,
+// which is the pid the perf map file is named after.
+func nsPid(pid int) int {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return pid
+	}
+	return nsPidFromStatus(status, pid)
+}
+
+var nsPidPrefix = []byte("NSpid:")
+
+func nsPidFromStatus(status []byte, pid int) int {
+	for _, line := range bytes.Split(status, []byte{'\n'}) {
+		if !bytes.HasPrefix(line, nsPidPrefix) {
+			continue
+		}
+		fields := bytes.Fields(line[len(nsPidPrefix):])
+		if len(fields) == 0 {
+			return pid
+		}
+		res, err := strconv.Atoi(string(fields[len(fields)-1]))
+		if err != nil {
+			return pid
+		}
+		return res
+	}
+	return pid
+}
@@ -0,0 +1,77 @@
+package symtab
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/pyroscope/ebpf/metrics"
+	"github.com/grafana/pyroscope/ebpf/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writePerfMap(t *testing.T, rootFS string, pid int, data string, flag int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootFS, "tmp"), 0o755))
+	f, err := os.OpenFile(filepath.Join(rootFS, "tmp", fmt.Sprintf("perf-%d.map", pid)), flag|os.O_WRONLY|os.O_CREATE, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestPerfMap(t *testing.T) {
+	rootFS := t.TempDir()
+	m := metrics.NewSymtabMetrics(nil)
+	p := NewPerfMap(util.TestLogger(t), rootFS, 42, m)
+	require.Equal(t, "/tmp/perf-42.map", p.Module())
+
+	// Missing file is not an error.
+	p.Refresh()
+	require.Equal(t, "", p.Resolve(0x1000))
+	require.Equal(t, float64(0), testutil.ToFloat64(m.PerfMapErrors.WithLabelValues("ErrNotExist")))
+
+	writePerfMap(t, rootFS, 42, "1000 100 LazyCompile:~foo /app/index.js:1\n"+
+		"0x2000 0x10 bar\n"+
+		"invalid\n"+
+		"3000 20 ba", os.O_TRUNC)
+	p.Refresh()
+	require.Equal(t, "LazyCompile:~foo /app/index.js:1", p.Resolve(0x1000))
+	require.Equal(t, "LazyCompile:~foo /app/index.js:1", p.Resolve(0x10ff))
+	require.Equal(t, "", p.Resolve(0x1100))
+	require.Equal(t, "bar", p.Resolve(0x200f))
+	// The incomplete last line is not loaded yet.
+	require.Equal(t, "", p.Resolve(0x3000))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.PerfMapErrors.WithLabelValues("InvalidLine")))
+
+	// The file grows: only the new lines are read.
+	writePerfMap(t, rootFS, 42, "z\n2000 10 bar2\n", os.O_APPEND)
+	p.Refresh()
+	require.Equal(t, "baz", p.Resolve(0x3010))
+	// The code at the same address was recompiled.
+	require.Equal(t, "bar2", p.Resolve(0x2000))
+	require.Equal(t, "LazyCompile:~foo /app/index.js:1", p.Resolve(0x1000))
+	require.Equal(t, float64(2), testutil.ToFloat64(m.PerfMapLoads))
+	require.Equal(t, float64(4), testutil.ToFloat64(m.PerfMapSymbols))
+	require.Equal(t, float64(0), testutil.ToFloat64(m.PerfMapResets))
+
+	// The file is truncated: symbols are loaded from scratch.
+	writePerfMap(t, rootFS, 42, "5000 10 qux\n", os.O_TRUNC)
+	p.Refresh()
+	require.Equal(t, "", p.Resolve(0x1000))
+	require.Equal(t, "qux", p.Resolve(0x5000))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.PerfMapResets))
+
+	require.NoError(t, os.Remove(filepath.Join(rootFS, "tmp", "perf-42.map")))
+	p.Refresh()
+	require.Equal(t, "", p.Resolve(0x5000))
+}
+
+func TestNsPidFromStatus(t *testing.T) {
+	status := "Name:\tnode\nTgid:\t239\nPid:\t239\nNSpid:\t239\t7\nNSpgid:\t239\t7\n"
+	require.Equal(t, 7, nsPidFromStatus([]byte(status), 239))
+	require.Equal(t, 239, nsPidFromStatus([]byte("NSpid:\t239\n"), 239))
+	require.Equal(t, 239, nsPidFromStatus([]byte("Name:\tnode\n"), 239))
+}
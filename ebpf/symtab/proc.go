@@ -19,9 +19,11 @@ type ProcTable struct {
 	logger     log.Logger
 	ranges     []elfRange
 	file2Table map[file]*ElfTable
-	options    ProcTableOptions
-	rootFS     string
-	err        error
+	// perfMap resolves JIT compiled code in anonymous executable mappings, may be nil
+	perfMap *PerfMap
+	options ProcTableOptions
+	rootFS  string
+	err     error
 }
 
 type ProcTableDebugInfo struct {
@@ -43,6 +45,9 @@ func (p *ProcTable) DebugInfo() ProcTableDebugInfo {
 			res.ElfTables[fmt.Sprintf("%x %x %s", f.dev, f.inode, f.path)] = d
 		}
 	}
+	if p.perfMap != nil {
+		res.ElfTables[p.perfMap.Module()] = p.perfMap.DebugInfo()
+	}
 	return res
 }
 
@@ -91,12 +96,12 @@ func (p *ProcTable) Error() error {
 }
 
 func (p *ProcTable) refreshProcMap(procMaps []byte) error {
-	// todo support perf map files
 	for i := range p.ranges {
 		p.ranges[i].elfTable = nil
 	}
 	p.ranges = p.ranges[:0]
 	filesToKeep := make(map[file]struct{})
+	anonymous := false
 	maps, err := ParseProcMapsExecutableModules(procMaps, true)
 	if err != nil {
 		return err
@@ -107,6 +112,10 @@ func (p *ProcTable) refreshProcMap(procMaps []byte) error {
 			mapRange: m,
 		})
 		r := &p.ranges[len(p.ranges)-1]
+		if r.mapRange.anonymous() {
+			anonymous = true
+			continue
+		}
 		e := p.getElfTable(r)
 		if e != nil {
 			r.elfTable = e
@@ -123,6 +132,14 @@ func (p *ProcTable) refreshProcMap(procMaps []byte) error {
 	for _, f := range filesToDelete {
 		delete(p.file2Table, f)
 	}
+	if anonymous {
+		if p.perfMap == nil {
+			p.perfMap = NewPerfMap(p.logger, p.rootFS, nsPid(p.options.Pid), p.options.Metrics)
+		}
+		p.perfMap.Refresh()
+	} else {
+		p.perfMap = nil
+	}
 	return nil
 }
 
@@ -134,6 +151,8 @@ func (p *ProcTable) getElfTable(r *elfRange) *ElfTable {
 		if e != nil {
 			p.file2Table[f] = e
 		}
+	} else {
+		e.Refresh()
 	}
 	return e
 }
@@ -149,6 +168,9 @@ func (p *ProcTable) Resolve(pc uint64) Symbol {
 	r := p.ranges[i]
 	t := r.elfTable
 	if t == nil {
+		if p.perfMap != nil && r.mapRange.anonymous() {
+			return p.resolvePerfMap(pc, r.mapRange)
+		}
 		return Symbol{}
 	}
 	s := t.Resolve(pc)
@@ -157,7 +179,15 @@ func (p *ProcTable) Resolve(pc uint64) Symbol {
 		return Symbol{Start: moduleOffset, Module: r.mapRange.Pathname}
 	}
 
-	return Symbol{Start: moduleOffset, Name: s, Module: r.mapRange.Pathname}
+	return Symbol{Start: moduleOffset, Name: s, Module: r.mapRange.Pathname, Lines: t.ResolveLines(pc)}
+}
+
+func (p *ProcTable) resolvePerfMap(pc uint64, m *ProcMap) Symbol {
+	s := p.perfMap.Resolve(pc)
+	if s == "" {
+		return Symbol{}
+	}
+	return Symbol{Start: pc - m.StartAddr, Name: s, Module: p.perfMap.Module()}
 }
 
 func (p *ProcTable) createElfTable(m *ProcMap) *ElfTable {
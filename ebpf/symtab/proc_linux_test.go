@@ -92,3 +92,45 @@ func TestSelfElfSymbolsLazy(t *testing.T) {
 		require.GreaterOrEqualf(t, nameMatches, 1, "symbol %v at %x (found %v)", symbol.Name, symbol.Start, found)
 	}
 }
+
+func TestSymbolCacheDebuginfod(t *testing.T) {
+	options := CacheOptions{
+		PidCacheOptions:      testCacheOptions,
+		BuildIDCacheOptions:  testCacheOptions,
+		SameFileCacheOptions: testCacheOptions,
+	}
+	sc, err := NewSymbolCache(util.TestLogger(t), options, metrics.NewSymtabMetrics(nil))
+	require.NoError(t, err)
+	require.Nil(t, sc.GetProcTable(PidKey(os.Getpid())).options.Debuginfod)
+
+	options.DebuginfodOptions = DebuginfodOptions{
+		URLs:     []string{"http://localhost"},
+		CacheDir: t.TempDir(),
+	}
+	sc, err = NewSymbolCache(util.TestLogger(t), options, metrics.NewSymtabMetrics(nil))
+	require.NoError(t, err)
+	require.NotNil(t, sc.debuginfod)
+	require.Same(t, sc.debuginfod, sc.GetProcTable(PidKey(os.Getpid())).options.Debuginfod)
+}
+
+func TestSymbolCacheUpdateSymbolOptions(t *testing.T) {
+	options := CacheOptions{
+		PidCacheOptions:      testCacheOptions,
+		BuildIDCacheOptions:  testCacheOptions,
+		SameFileCacheOptions: testCacheOptions,
+	}
+	sc, err := NewSymbolCache(util.TestLogger(t), options, metrics.NewSymtabMetrics(nil))
+	require.NoError(t, err)
+	pid := PidKey(os.Getpid())
+	tab := sc.GetProcTable(pid)
+
+	sc.UpdateOptions(options)
+	require.Same(t, tab, sc.GetProcTable(pid))
+
+	// The tables built without the line tables are not reused.
+	options.SymbolOptions.InlineFrames = true
+	sc.UpdateOptions(options)
+	updated := sc.GetProcTable(pid)
+	require.NotSame(t, tab, updated)
+	require.True(t, updated.options.SymbolOptions.InlineFrames)
+}
@@ -3,6 +3,7 @@ package symtab
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"path"
 	"strings"
@@ -21,6 +22,9 @@ func TestTestdataMD5(t *testing.T) {
 		{"5201d962e9f71ea220b9610d6352b57e", "elf"},
 		{"e300c975548c3d7617a263243592ae46", "elf.debug"},
 		{"668e90be1ac8a0e8e89ebd47284bf7fc", "elf.debuglink"},
+		{"582ac0df3ac181052aa845c1ca66e9fa", "elf.minidebuginfo"},
+		{"99670fd0182b79ddcbd1f5e2bf426682", "elf.compressed"},
+		{"d39a7c020ea392c124c631db56ab84f7", "elf.inline"},
 		{"7ecf01cd4fe52e4a31d7840e8d93ac56", "elf.nobuildid"},
 		{"4284c6ba06fedfe6e05627ddd5ccff18", "elf.nopie"},
 		{"635fd79c77b9de925647fe566668ea6d", "elf.stripped"},
@@ -389,3 +393,50 @@ ffffffffff600000-ffffffffff601000 --xp 00000000 00:00 0                  [vsysca
 	require.NotEmpty(t, sym.Module)
 	require.NotEmpty(t, sym.Start)
 }
+
+func TestProcPerfMap(t *testing.T) {
+	// The perf map of the process is named after its pid in its own pid
+	// namespace, read from /proc: the test process is used, as it's the
+	// same in the namespace of the test.
+	pid := os.Getpid()
+	perfMap := fmt.Sprintf("/tmp/perf-%d.map", pid)
+	elfCache, _ := NewElfCache(testCacheOptions, testCacheOptions)
+	m := NewProcTable(util.TestLogger(t), ProcTableOptions{
+		Pid: pid,
+		ElfTableOptions: ElfTableOptions{
+			ElfCache: elfCache,
+			Metrics:  metrics.NewSymtabMetrics(nil),
+		},
+	})
+	m.rootFS = t.TempDir()
+	writePerfMap(t, m.rootFS, pid, "7fa9f6fdb000 40 LazyCompile:*foo /app/index.js:42\n", os.O_TRUNC)
+	maps := `7fa9f6fda000-7fa9f6fdd000 rwxp 00000000 00:00 0 
+7fa9f6fdd000-7fa9f7005000 r-xp 00000000 09:00 533580                     /usr/lib/x86_64-linux-gnu/libc.so.6`
+	require.NoError(t, m.refreshProcMap([]byte(maps)))
+
+	sym := m.Resolve(0x7fa9f6fdb010)
+	require.Equal(t, "LazyCompile:*foo /app/index.js:42", sym.Name)
+	require.Equal(t, perfMap, sym.Module)
+	require.Equal(t, uint64(0x1010), sym.Start)
+	require.Equal(t, Symbol{}, m.Resolve(0x7fa9f6fdc000))
+
+	// Symbols appended by the JIT are picked up on refresh.
+	writePerfMap(t, m.rootFS, pid, "7fa9f6fdc000 40 LazyCompile:*bar /app/index.js:43\n", os.O_APPEND)
+	require.NoError(t, m.refreshProcMap([]byte(maps)))
+	require.Equal(t, "LazyCompile:*bar /app/index.js:43", m.Resolve(0x7fa9f6fdc000).Name)
+	require.Contains(t, m.DebugInfo().ElfTables, perfMap)
+}
+
+func TestMiniDebugInfo(t *testing.T) {
+	maps := `56483a0ee000-56483a0ef000 r--p 00000000 09:00 9469561                    /elfs/elf.minidebuginfo
+56483a0ef000-56483a0f0000 r-xp 00001000 09:00 9469561                    /elfs/elf.minidebuginfo
+56483a0f0000-56483a0f1000 r--p 00002000 09:00 9469561                    /elfs/elf.minidebuginfo
+56483a0f1000-56483a0f2000 r--p 00002000 09:00 9469561                    /elfs/elf.minidebuginfo
+56483a0f2000-56483a0f3000 rw-p 00003000 09:00 9469561                    /elfs/elf.minidebuginfo
+`
+	var syms = []procTestdata{
+		{"iter", "elf.minidebuginfo", 0x1149, 0x56483a0ee000},
+		{"main", "elf.minidebuginfo", 0x115e, 0x56483a0ee000},
+	}
+	testProc(t, maps, syms)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -55,6 +56,12 @@ func (m *ProcMap) file() file {
 	}
 }
 
+// anonymous returns true for mappings not backed by a file,
+// like the ones JIT compilers generate code into.
+func (m *ProcMap) anonymous() bool {
+	return m.Pathname == "" || m.Pathname == "//anon" || strings.HasPrefix(m.Pathname, "[anon:")
+}
+
 // parseDevice parses the device token of a line and converts it to a dev_t
 // (mkdev) like structure.
 func parseDevice(s []byte) (uint64, error) {
@@ -4,6 +4,7 @@ package symtab
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -19,8 +20,10 @@ type SymbolCache struct {
 	pidCache *GCache[PidKey, *ProcTable]
 
 	elfCache *ElfCache
-	kallsyms *SymbolTab
-	logger   log.Logger
+	// debuginfod may be nil
+	debuginfod *DebuginfodClient
+	kallsyms   *SymbolTab
+	logger     log.Logger
 
 	options CacheOptions
 
@@ -31,6 +34,8 @@ type CacheOptions struct {
 	BuildIDCacheOptions  GCacheOptions
 	SameFileCacheOptions GCacheOptions
 	SymbolOptions        SymbolOptions
+	// DebuginfodOptions are not changed by UpdateOptions.
+	DebuginfodOptions DebuginfodOptions
 }
 
 func NewSymbolCache(logger log.Logger, options CacheOptions, metrics *metrics.SymtabMetrics) (*SymbolCache, error) {
@@ -46,13 +51,21 @@ func NewSymbolCache(logger log.Logger, options CacheOptions, metrics *metrics.Sy
 	if err != nil {
 		return nil, fmt.Errorf("create pid cache %w", err)
 	}
+	var debuginfod *DebuginfodClient
+	if len(options.DebuginfodOptions.URLs) > 0 {
+		debuginfod, err = NewDebuginfodClient(logger, options.DebuginfodOptions, metrics)
+		if err != nil {
+			return nil, fmt.Errorf("create debuginfod client %w", err)
+		}
+	}
 	return &SymbolCache{
-		logger:   logger,
-		pidCache: cache,
-		kallsyms: nil,
-		elfCache: elfCache,
-		options:  options,
-		metrics:  metrics,
+		logger:     logger,
+		pidCache:   cache,
+		kallsyms:   nil,
+		elfCache:   elfCache,
+		debuginfod: debuginfod,
+		options:    options,
+		metrics:    metrics,
 	}, nil
 }
 
@@ -79,6 +92,7 @@ func (sc *SymbolCache) GetProcTable(pid PidKey) *ProcTable {
 			ElfCache:      sc.elfCache,
 			Metrics:       sc.metrics,
 			SymbolOptions: &sc.options.SymbolOptions,
+			Debuginfod:    sc.debuginfod,
 		},
 	})
 
@@ -111,6 +125,13 @@ func (sc *SymbolCache) initKallsyms() SymbolTable {
 func (sc *SymbolCache) UpdateOptions(options CacheOptions) {
 	sc.pidCache.Update(options.PidCacheOptions)
 	sc.elfCache.Update(options.BuildIDCacheOptions, options.SameFileCacheOptions)
+	if !reflect.DeepEqual(sc.options.SymbolOptions, options.SymbolOptions) {
+		// The symbol tables are built with the symbol options, for instance with the
+		// DWARF line tables if InlineFrames is enabled: they are dropped to be rebuilt.
+		sc.options.SymbolOptions = options.SymbolOptions
+		sc.pidCache.Clear()
+		sc.elfCache.Clear()
+	}
 }
 
 func (sc *SymbolCache) PidCacheDebugInfo() GCacheDebugInfo[ProcTableDebugInfo] {
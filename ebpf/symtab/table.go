@@ -2,6 +2,8 @@ package symtab
 
 import (
 	"sort"
+
+	"github.com/grafana/pyroscope/ebpf/symtab/elf"
 )
 
 type SymTab struct {
@@ -53,6 +55,9 @@ type Symbol struct {
 	Start  uint64
 	Name   string
 	Module string
+	// Lines is the inline chain of the address, innermost function first.
+	// It is only set when SymbolOptions.InlineFrames is enabled and the binary has DWARF debug info.
+	Lines []elf.Line
 }
 
 func NewSymbolTab(symbols []Symbol) *SymbolTab {
@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/dskit/middleware"
 	"github.com/grafana/dskit/server"
 	grpcgw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/pyroscope/public"
 
@@ -39,10 +40,13 @@ import (
 	"github.com/grafana/pyroscope/pkg/querier"
 	"github.com/grafana/pyroscope/pkg/scheduler"
 	"github.com/grafana/pyroscope/pkg/scheduler/schedulerpb/schedulerpbconnect"
+	"github.com/grafana/pyroscope/pkg/scrape"
 	"github.com/grafana/pyroscope/pkg/storegateway"
+	"github.com/grafana/pyroscope/pkg/usagetracker"
 	"github.com/grafana/pyroscope/pkg/util"
 	"github.com/grafana/pyroscope/pkg/util/gziphandler"
 	"github.com/grafana/pyroscope/pkg/validation/exporter"
+	"github.com/grafana/pyroscope/pkg/validation/tenantoverrides"
 )
 
 type Config struct {
@@ -179,15 +183,27 @@ func (a *API) RegisterCatchAll() error {
 }
 
 // RegisterRuntimeConfig registers the endpoints associates with the runtime configuration
-func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc, userLimitsHandler http.HandlerFunc) {
+func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc) {
 	a.RegisterRoute("/runtime_config", runtimeConfigHandler, false, true, "GET")
-	a.RegisterRoute("/api/v1/tenant_limits", userLimitsHandler, true, true, "GET")
 	a.indexPage.AddLinks(runtimeConfigWeight, "Current runtime config", []IndexPageLink{
 		{Desc: "Entire runtime config (including overrides)", Path: "/runtime_config"},
 		{Desc: "Only values that differ from the defaults", Path: "/runtime_config?mode=diff"},
 	})
 }
 
+// RegisterTenantOverrides registers the endpoints associated with the tenant limits and
+// the admin API of the tenant overrides.
+func (a *API) RegisterTenantOverrides(s *tenantoverrides.Store, userLimitsHandler http.HandlerFunc) {
+	a.RegisterRoute("/api/v1/tenant_limits", userLimitsHandler, true, true, "GET")
+	a.RegisterRoute("/api/v1/tenant_overrides", s.TenantsHandler(), false, true, "GET")
+	// The overrides and the audit log require the admin credentials, checked by the store.
+	a.RegisterRoute("/api/v1/tenant_overrides/{tenant}", s.Handler(), false, true, "GET", "PUT", "DELETE")
+	a.RegisterRoute("/api/v1/tenant_overrides/{tenant}/audit", s.AuditLogHandler(), false, true, "GET")
+	a.indexPage.AddLinks(runtimeConfigWeight, "Tenant overrides", []IndexPageLink{
+		{Desc: "Tenants with overrides set with the admin API", Path: "/api/v1/tenant_overrides"},
+	})
+}
+
 // RegisterOverridesExporter registers the endpoints associated with the overrides exporter.
 func (a *API) RegisterOverridesExporter(oe *exporter.OverridesExporter) {
 	a.RegisterRoute("/overrides-exporter/ring", http.HandlerFunc(oe.RingHandler), false, true, "GET", "POST")
@@ -208,6 +224,14 @@ func (a *API) RegisterDistributor(d *distributor.Distributor) {
 	})
 }
 
+// RegisterUsage registers the endpoints associated with the usage tracker.
+func (a *API) RegisterUsage(bucket objstore.BucketReader) {
+	a.RegisterRoute("/api/v1/usage", usagetracker.Handler(bucket), true, true, "GET")
+	a.indexPage.AddLinks(defaultWeight, "Usage", []IndexPageLink{
+		{Desc: "Usage of the tenant per service", Path: "/api/v1/usage"},
+	})
+}
+
 // RegisterMemberlistKV registers the endpoints associated with the memberlist KV store.
 func (a *API) RegisterMemberlistKV(pathPrefix string, kvs *memberlist.KVInitService) {
 	a.RegisterRoute("/memberlist", MemberlistStatusHandler(pathPrefix, kvs), false, true, "GET")
@@ -261,6 +285,14 @@ func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, true, "GET", "POST")
 }
 
+// RegisterScrape registers routes associated with the scrape module.
+func (a *API) RegisterScrape(s *scrape.Scraper) {
+	a.indexPage.AddLinks(defaultWeight, "Scrape", []IndexPageLink{
+		{Desc: "Ring status", Path: "/scrape/ring"},
+	})
+	a.RegisterRoute("/scrape/ring", http.HandlerFunc(s.RingHandler), false, true, "GET", "POST")
+}
+
 // RegisterQueryFrontend registers the endpoints associated with the query frontend.
 func (a *API) RegisterQueryFrontend(frontendSvc *frontend.Frontend) {
 	frontendpbconnect.RegisterFrontendForQuerierHandler(a.server.HTTP, frontendSvc, a.grpcAuthMiddleware)
@@ -38,6 +38,7 @@ import (
 	"github.com/grafana/pyroscope/pkg/slices"
 	"github.com/grafana/pyroscope/pkg/tenant"
 	"github.com/grafana/pyroscope/pkg/usagestats"
+	"github.com/grafana/pyroscope/pkg/usagetracker"
 	"github.com/grafana/pyroscope/pkg/util"
 	"github.com/grafana/pyroscope/pkg/validation"
 )
@@ -235,6 +236,7 @@ func (d *Distributor) PushParsed(ctx context.Context, req *distributormodel.Push
 	var (
 		totalPushUncompressedBytes int64
 		totalProfiles              int64
+		serviceUsage               = make(map[string]*usagetracker.Usage)
 	)
 
 	for _, series := range req.Series {
@@ -257,6 +259,13 @@ func (d *Distributor) PushParsed(ctx context.Context, req *distributormodel.Push
 	}
 
 	for _, series := range req.Series {
+		serviceName := phlaremodel.Labels(series.Labels).Get(phlaremodel.LabelNameServiceName)
+		su, ok := serviceUsage[serviceName]
+		if !ok {
+			su = &usagetracker.Usage{TenantID: tenantID, ServiceName: serviceName}
+			serviceUsage[serviceName] = su
+		}
+		seriesStartBytes := totalPushUncompressedBytes
 		// include the labels in the size calculation
 		for _, lbs := range series.Labels {
 			totalPushUncompressedBytes += int64(len(lbs.Name))
@@ -294,6 +303,8 @@ func (d *Distributor) PushParsed(ctx context.Context, req *distributormodel.Push
 			d.metrics.receivedSamplesBytes.WithLabelValues(profName, tenantID).Observe(float64(samplesSize))
 			d.metrics.receivedSymbolsBytes.WithLabelValues(profName, tenantID).Observe(float64(symbolsSize))
 		}
+		su.ProfilesReceived += int64(len(series.Samples))
+		su.BytesIngested += totalPushUncompressedBytes - seriesStartBytes
 	}
 
 	if totalProfiles == 0 {
@@ -384,6 +395,9 @@ func (d *Distributor) PushParsed(ctx context.Context, req *distributormodel.Push
 	case err := <-tracker.err:
 		return nil, err
 	case <-tracker.done:
+		for _, su := range serviceUsage {
+			usagetracker.Ingested(tenantID, su.ServiceName, su.ProfilesReceived, su.BytesIngested)
+		}
 		return connect.NewResponse(&pushv1.PushResponse{}), nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
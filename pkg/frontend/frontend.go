@@ -31,6 +31,7 @@ import (
 
 	"github.com/grafana/pyroscope/pkg/frontend/frontendpb"
 	"github.com/grafana/pyroscope/pkg/querier/stats"
+	"github.com/grafana/pyroscope/pkg/scheduler/queue"
 	"github.com/grafana/pyroscope/pkg/scheduler/schedulerdiscovery"
 	"github.com/grafana/pyroscope/pkg/util/httpgrpc"
 	"github.com/grafana/pyroscope/pkg/util/httpgrpcutil"
@@ -50,6 +51,9 @@ type Config struct {
 	Addr string `yaml:"address" category:"advanced"`
 	Port int    `yaml:"-"`
 
+	HighPriorityQueryLength time.Duration `yaml:"high_priority_query_length" category:"experimental"`
+	LowPriorityQueryLength  time.Duration `yaml:"low_priority_query_length" category:"experimental"`
+
 	// This configuration is injected internally.
 	QuerySchedulerDiscovery schedulerdiscovery.Config `yaml:"-"`
 	MaxLoopDuration         time.Duration             `yaml:"-"`
@@ -62,6 +66,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.Var((*flagext.StringSlice)(&cfg.InfNames), "query-frontend.instance-interface-names", "List of network interface names to look up when finding the instance IP address. This address is sent to query-scheduler and querier, which uses it to send the query response back to query-frontend.")
 	f.StringVar(&cfg.Addr, "query-frontend.instance-addr", "", "IP address to advertise to the querier (via scheduler) (default is auto-detected from network interfaces).")
 
+	f.DurationVar(&cfg.HighPriorityQueryLength, "query-frontend.high-priority-query-length", 0, "Queries with a time range up to this length are scheduled with the high priority class, unless the "+queue.PriorityHeader+" header is set. 0 to disable.")
+	f.DurationVar(&cfg.LowPriorityQueryLength, "query-frontend.low-priority-query-length", 0, "Queries with a time range of at least this length are scheduled with the low priority class, unless the "+queue.PriorityHeader+" header is set. 0 to disable.")
+
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-frontend.grpc-client-config", f)
 }
 
@@ -188,6 +195,21 @@ func (f *Frontend) stopping(_ error) error {
 	return errors.Wrap(services.StopAndAwaitTerminated(context.Background(), f.schedulerWorkers), "failed to stop frontend scheduler workers")
 }
 
+// setQueryPriority sets the priority class of the query from the length of its time range,
+// unless it is set by the client. The split requests share the headers of the query.
+func (f *Frontend) setQueryPriority(req connect.AnyRequest, start, end int64) {
+	if req.Header().Get(queue.PriorityHeader) != "" {
+		return
+	}
+	length := time.Duration(end-start) * time.Millisecond
+	switch {
+	case f.cfg.HighPriorityQueryLength > 0 && length <= f.cfg.HighPriorityQueryLength:
+		req.Header().Set(queue.PriorityHeader, queue.PriorityHigh.String())
+	case f.cfg.LowPriorityQueryLength > 0 && length >= f.cfg.LowPriorityQueryLength:
+		req.Header().Set(queue.PriorityHeader, queue.PriorityLow.String())
+	}
+}
+
 // RoundTripGRPC round trips a proto (instead of an HTTP request).
 func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
 	if s := f.State(); s != services.Running {
@@ -30,5 +30,6 @@ func (f *Frontend) SelectMergeProfile(ctx context.Context, c *connect.Request[qu
 	}
 	c.Msg.Start = int64(validated.Start)
 	c.Msg.End = int64(validated.End)
+	f.setQueryPriority(c, c.Msg.Start, c.Msg.End)
 	return connectgrpc.RoundTripUnary[querierv1.SelectMergeProfileRequest, profilev1.Profile](ctx, f, c)
 }
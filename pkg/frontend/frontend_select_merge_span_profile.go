@@ -41,6 +41,7 @@ func (f *Frontend) SelectMergeSpanProfile(ctx context.Context,
 	if validated.IsEmpty {
 		return connect.NewResponse(&querierv1.SelectMergeSpanProfileResponse{Flamegraph: &querierv1.FlameGraph{}}), nil
 	}
+	f.setQueryPriority(c, int64(validated.Start), int64(validated.End))
 
 	g, ctx := errgroup.WithContext(ctx)
 	if maxConcurrent := validationutil.SmallestPositiveNonZeroIntPerTenant(tenantIDs, f.limits.MaxQueryParallelism); maxConcurrent > 0 {
@@ -41,6 +41,7 @@ func (f *Frontend) SelectMergeStacktraces(ctx context.Context,
 	if validated.IsEmpty {
 		return connect.NewResponse(&querierv1.SelectMergeStacktracesResponse{}), nil
 	}
+	f.setQueryPriority(c, int64(validated.Start), int64(validated.End))
 
 	g, ctx := errgroup.WithContext(ctx)
 	if maxConcurrent := validationutil.SmallestPositiveNonZeroIntPerTenant(tenantIDs, f.limits.MaxQueryParallelism); maxConcurrent > 0 {
@@ -60,6 +61,7 @@ func (f *Frontend) SelectMergeStacktraces(ctx context.Context,
 				Start:         r.Start.UnixMilli(),
 				End:           r.End.UnixMilli(),
 				MaxNodes:      c.Msg.MaxNodes,
+				FunctionNames: c.Msg.FunctionNames,
 			})
 			resp, err := connectgrpc.RoundTripUnary[
 				querierv1.SelectMergeStacktracesRequest,
@@ -43,6 +43,7 @@ func (f *Frontend) SelectSeries(ctx context.Context,
 	}
 	c.Msg.Start = int64(validated.Start)
 	c.Msg.End = int64(validated.End)
+	f.setQueryPriority(c, c.Msg.Start, c.Msg.End)
 
 	g, ctx := errgroup.WithContext(ctx)
 	if maxConcurrent := validationutil.SmallestPositiveNonZeroIntPerTenant(tenantIDs, f.limits.MaxQueryParallelism); maxConcurrent > 0 {
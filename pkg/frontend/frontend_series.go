@@ -35,6 +35,7 @@ func (f *Frontend) Series(ctx context.Context, c *connect.Request[querierv1.Seri
 		}
 		c.Msg.Start = int64(validated.Start)
 		c.Msg.End = int64(validated.End)
+		f.setQueryPriority(c, c.Msg.Start, c.Msg.End)
 	}
 
 	return connectgrpc.RoundTripUnary[querierv1.SeriesRequest, querierv1.SeriesResponse](ctx, f, c)
@@ -19,6 +19,7 @@ import (
 
 	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
 	ingesterv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1/ingesterv1connect"
 	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
 	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
 	phlareobjclient "github.com/grafana/pyroscope/pkg/objstore/client"
@@ -35,15 +36,17 @@ var activeTenantsStats = usagestats.NewInt("ingester_active_tenants")
 
 type Config struct {
 	LifecyclerConfig ring.LifecyclerConfig `yaml:"lifecycler,omitempty"`
+	HandOff          HandOffConfig         `yaml:"hand_off"`
 }
 
 // RegisterFlags registers the flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f, util.Logger)
+	cfg.HandOff.RegisterFlagsWithPrefix("ingester.hand-off", f)
 }
 
 func (cfg *Config) Validate() error {
-	return nil
+	return cfg.HandOff.Validate()
 }
 
 type Ingester struct {
@@ -66,6 +69,10 @@ type Ingester struct {
 
 	limits Limits
 	reg    prometheus.Registerer
+
+	// transferMtx serializes the hand-offs received.
+	transferMtx    sync.Mutex
+	transferClient func(addr string) ingesterv1connect.IngesterServiceClient
 }
 
 type ingesterFlusherCompat struct {
@@ -89,6 +96,8 @@ func New(phlarectx context.Context, cfg Config, dbConfig phlaredb.Config, storag
 		dbConfig:      dbConfig,
 		storageBucket: storageBucket,
 		limits:        limits,
+
+		transferClient: newTransferClient,
 	}
 
 	// initialise the local bucket client
@@ -275,10 +284,6 @@ func (i *Ingester) Flush(ctx context.Context, req *connect.Request[ingesterv1.Fl
 	return connect.NewResponse(&ingesterv1.FlushResponse{}), nil
 }
 
-func (i *Ingester) TransferOut(ctx context.Context) error {
-	return ring.ErrTransferDisabled
-}
-
 // CheckReady is used to indicate to k8s when the ingesters are ready for
 // the addition removal of another ingester. Returns 204 when the ingester is
 // ready, 500 otherwise.
@@ -10,6 +10,7 @@ import (
 
 	phlaremodel "github.com/grafana/pyroscope/pkg/model"
 	"github.com/grafana/pyroscope/pkg/util"
+	querylimiter "github.com/grafana/pyroscope/pkg/util/limiter"
 	"github.com/grafana/pyroscope/pkg/validation"
 )
 
@@ -26,6 +27,7 @@ type Limits interface {
 	MaxLocalSeriesPerTenant(tenantID string) int
 	MaxGlobalSeriesPerTenant(tenantID string) int
 	IngestionTenantShardSize(tenantID string) int
+	querylimiter.QueryLimits
 }
 
 type Limiter interface {
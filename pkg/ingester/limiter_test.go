@@ -31,6 +31,12 @@ func (f *fakeLimits) IngestionTenantShardSize(userID string) int {
 	return f.ingestionTenantShardSize
 }
 
+func (f *fakeLimits) MaxQueryProfilesSelected(userID string) int { return 0 }
+
+func (f *fakeLimits) MaxQueryBytesFetched(userID string) int { return 0 }
+
+func (f *fakeLimits) MaxQueryFlameGraphNodes(userID string) int { return 0 }
+
 type fakeRingCount struct {
 	healthyInstancesCount int
 }
@@ -7,6 +7,7 @@ import (
 
 	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	querylimiter "github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 // LabelValues returns the possible label values for a given label name.
@@ -37,25 +38,36 @@ func (i *Ingester) Series(ctx context.Context, req *connect.Request[ingestv1.Ser
 	})
 }
 
+// IngestedBytes returns the size of the profiles held in memory, per service name.
+func (i *Ingester) IngestedBytes(ctx context.Context, req *connect.Request[ingestv1.IngestedBytesRequest]) (*connect.Response[ingestv1.IngestedBytesResponse], error) {
+	return forInstanceUnary(ctx, i, func(instance *instance) (*connect.Response[ingestv1.IngestedBytesResponse], error) {
+		return instance.IngestedBytes(ctx, req)
+	})
+}
+
 func (i *Ingester) MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesStacktracesRequest, ingestv1.MergeProfilesStacktracesResponse]) error {
+	ctx = querylimiter.WithTenantQueryLimiter(ctx, i.limits)
 	return i.forInstance(ctx, func(instance *instance) error {
 		return instance.MergeProfilesStacktraces(ctx, stream)
 	})
 }
 
 func (i *Ingester) MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesLabelsRequest, ingestv1.MergeProfilesLabelsResponse]) error {
+	ctx = querylimiter.WithTenantQueryLimiter(ctx, i.limits)
 	return i.forInstance(ctx, func(instance *instance) error {
 		return instance.MergeProfilesLabels(ctx, stream)
 	})
 }
 
 func (i *Ingester) MergeProfilesPprof(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesPprofRequest, ingestv1.MergeProfilesPprofResponse]) error {
+	ctx = querylimiter.WithTenantQueryLimiter(ctx, i.limits)
 	return i.forInstance(ctx, func(instance *instance) error {
 		return instance.MergeProfilesPprof(ctx, stream)
 	})
 }
 
 func (i *Ingester) MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeSpanProfileRequest, ingestv1.MergeSpanProfileResponse]) error {
+	ctx = querylimiter.WithTenantQueryLimiter(ctx, i.limits)
 	return i.forInstance(ctx, func(instance *instance) error {
 		return instance.MergeSpanProfile(ctx, stream)
 	})
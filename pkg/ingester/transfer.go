@@ -0,0 +1,253 @@
+package ingester
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/multierror"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/tenant"
+
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	ingesterv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1/ingesterv1connect"
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	"github.com/grafana/pyroscope/pkg/phlaredb"
+	"github.com/grafana/pyroscope/pkg/pprof"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+// transferBatchSize is the maximum number of profiles per transfer message.
+const transferBatchSize = 64
+
+// HandOffConfig configures the transfer of the heads of a leaving ingester.
+type HandOffConfig struct {
+	Enabled bool          `yaml:"enabled" category:"experimental"`
+	Timeout time.Duration `yaml:"timeout" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers the flags.
+func (cfg *HandOffConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "On shutdown, transfer the in-memory heads to a pending ingester, which then takes over the tokens, or else to a healthy peer, instead of flushing them to blocks. Pending ingesters wait for a transfer for -ingester.join-after.")
+	f.DurationVar(&cfg.Timeout, prefix+".timeout", 5*time.Minute, "Timeout of the transfer of the heads, including the wait for an ingester to receive them. The heads are flushed if the transfer fails.")
+}
+
+func (cfg *HandOffConfig) Validate() error {
+	if cfg.Enabled && cfg.Timeout <= 0 {
+		return errors.New("the ingester hand-off timeout must be positive")
+	}
+	return nil
+}
+
+func newTransferClient(addr string) ingesterv1connect.IngesterServiceClient {
+	return ingesterv1connect.NewIngesterServiceClient(util.InstrumentedHTTPClient(), "http://"+addr)
+}
+
+// TransferOut transfers the heads of all the tenants to another ingester, before
+// the ingester leaves the ring. If the transfer fails, the heads are flushed.
+func (i *Ingester) TransferOut(ctx context.Context) error {
+	if !i.cfg.HandOff.Enabled {
+		return ring.ErrTransferDisabled
+	}
+	ctx, cancel := context.WithTimeout(ctx, i.cfg.HandOff.Timeout)
+	defer cancel()
+
+	target, err := i.findTransferTarget(ctx)
+	if err != nil {
+		return err
+	}
+	level.Info(i.logger).Log("msg", "transferring heads", "target", target.Id, "target_addr", target.Addr, "target_state", target.State)
+
+	// New heads created during the transfer are flushed once the instances stop.
+	i.instancesMtx.RLock()
+	transfers := make(map[string]*phlaredb.HeadTransfer, len(i.instances))
+	for tenantID, inst := range i.instances {
+		transfers[tenantID] = inst.StartHeadTransfer()
+	}
+	i.instancesMtx.RUnlock()
+
+	start := time.Now()
+	err = i.transferHeads(ctx, i.transferClient(target.Addr), transfers)
+	errs := multierror.New()
+	for tenantID, t := range transfers {
+		if err != nil {
+			// The context may have expired: the heads are flushed regardless.
+			if abortErr := t.Abort(context.Background()); abortErr != nil {
+				level.Error(i.logger).Log("msg", "failed to flush heads after failed transfer", "tenant", tenantID, "err", abortErr)
+			}
+			continue
+		}
+		errs.Add(t.Commit())
+	}
+	if err != nil {
+		return fmt.Errorf("transferring heads to %s: %w", target.Addr, err)
+	}
+	level.Info(i.logger).Log("msg", "heads transferred", "target", target.Id, "duration", time.Since(start))
+	return errs.Err()
+}
+
+// findTransferTarget returns the ingester to transfer the heads to: a pending
+// ingester if any, or else a healthy active one. It waits for one until the
+// context is done.
+func (i *Ingester) findTransferTarget(ctx context.Context) (*ring.InstanceDesc, error) {
+	b := backoff.New(ctx, backoff.Config{
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	})
+	for b.Ongoing() {
+		desc, err := i.lifecycler.KVStore.Get(ctx, i.lifecycler.RingKey)
+		if err != nil {
+			level.Warn(i.logger).Log("msg", "failed to get the ring for the transfer", "err", err)
+		} else if d, ok := desc.(*ring.Desc); ok && d != nil {
+			if target := transferTarget(d, i.lifecycler.ID, i.cfg.LifecyclerConfig.RingConfig.HeartbeatTimeout, time.Now()); target != nil {
+				return target, nil
+			}
+		}
+		b.Wait()
+	}
+	return nil, fmt.Errorf("no ingester to transfer the heads to: %w", b.Err())
+}
+
+func transferTarget(d *ring.Desc, self string, heartbeatTimeout time.Duration, now time.Time) *ring.InstanceDesc {
+	var pending, active []ring.InstanceDesc
+	for id, inst := range d.Ingesters {
+		if id == self || !inst.IsHeartbeatHealthy(heartbeatTimeout, now) {
+			continue
+		}
+		switch inst.State {
+		case ring.PENDING:
+			pending = append(pending, inst)
+		case ring.ACTIVE:
+			active = append(active, inst)
+		}
+	}
+	candidates := pending
+	if len(candidates) == 0 {
+		candidates = active
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return &candidates[rand.Intn(len(candidates))]
+}
+
+func (i *Ingester) transferHeads(ctx context.Context, client ingesterv1connect.IngesterServiceClient, transfers map[string]*phlaredb.HeadTransfer) error {
+	stream := client.Transfer(ctx)
+	send := func(req *ingesterv1.TransferRequest) error {
+		req.FromIngesterId = i.lifecycler.ID
+		if err := stream.Send(req); err != nil {
+			// The actual error is returned on close.
+			if _, closeErr := stream.CloseAndReceive(); closeErr != nil {
+				return closeErr
+			}
+			return err
+		}
+		return nil
+	}
+	// The first message identifies the leaving ingester, even if there are no heads.
+	if err := send(&ingesterv1.TransferRequest{}); err != nil {
+		return err
+	}
+	for tenantID, t := range transfers {
+		err := t.Send(ctx, transferBatchSize, func(series []*pushv1.RawProfileSeries) error {
+			return send(&ingesterv1.TransferRequest{TenantId: tenantID, Series: series})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err := stream.CloseAndReceive()
+	return err
+}
+
+// Transfer receives the heads of a leaving ingester. A pending ingester then
+// joins the ring with the tokens of the leaving one.
+//
+// The profiles are ingested as they are received: if the transfer fails, the
+// leaving ingester flushes its heads, and the profiles received are duplicated.
+func (i *Ingester) Transfer(ctx context.Context, stream *connect.ClientStream[ingesterv1.TransferRequest]) (*connect.Response[ingesterv1.TransferResponse], error) {
+	i.transferMtx.Lock()
+	defer i.transferMtx.Unlock()
+
+	pending := i.lifecycler.GetState() == ring.PENDING
+	if pending {
+		if err := i.lifecycler.ChangeState(ctx, ring.JOINING); err != nil {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+	}
+	fromID, profiles, err := i.receiveTransfer(ctx, stream)
+	if err != nil {
+		if pending {
+			if stateErr := i.lifecycler.ChangeState(context.Background(), ring.PENDING); stateErr != nil {
+				level.Error(i.logger).Log("msg", "failed to revert to pending after failed transfer", "err", stateErr)
+			}
+		}
+		level.Error(i.logger).Log("msg", "failed to receive heads", "from", fromID, "err", err)
+		return nil, err
+	}
+	if pending {
+		if err = i.lifecycler.ClaimTokensFor(ctx, fromID); err != nil {
+			return nil, err
+		}
+		if err = i.lifecycler.ChangeState(ctx, ring.ACTIVE); err != nil {
+			return nil, err
+		}
+	}
+	level.Info(i.logger).Log("msg", "received heads", "from", fromID, "profiles", profiles)
+	return connect.NewResponse(&ingesterv1.TransferResponse{}), nil
+}
+
+func (i *Ingester) receiveTransfer(ctx context.Context, stream *connect.ClientStream[ingesterv1.TransferRequest]) (fromID string, profiles int, err error) {
+	for stream.Receive() {
+		req := stream.Msg()
+		fromID = req.FromIngesterId
+		if len(req.Series) == 0 {
+			continue
+		}
+		if err = validTransferTenantID(req.TenantId); err != nil {
+			return fromID, profiles, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		inst, err := i.GetOrCreateInstance(req.TenantId)
+		if err != nil {
+			return fromID, profiles, connect.NewError(connect.CodeInternal, err)
+		}
+		for _, series := range req.Series {
+			for _, sample := range series.Samples {
+				err = pprof.FromBytes(sample.RawProfile, func(p *profilev1.Profile, _ int) error {
+					id, err := uuid.Parse(sample.ID)
+					if err != nil {
+						return err
+					}
+					return inst.IngestTransferred(ctx, p, id, series.Labels...)
+				})
+				if err != nil {
+					return fromID, profiles, err
+				}
+				profiles++
+			}
+		}
+	}
+	if err = stream.Err(); err != nil {
+		return fromID, profiles, err
+	}
+	if fromID == "" {
+		return fromID, profiles, connect.NewError(connect.CodeInvalidArgument, errors.New("the leaving ingester is not identified"))
+	}
+	return fromID, profiles, nil
+}
+
+func validTransferTenantID(tenantID string) error {
+	switch tenantID {
+	case "", ".", "..":
+		return fmt.Errorf("invalid tenant ID %q", tenantID)
+	}
+	return tenant.ValidTenantID(tenantID)
+}
@@ -0,0 +1,130 @@
+package ingester
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log"
+	"github.com/google/uuid"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+
+	ingesterv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1/ingesterv1connect"
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	phlarecontext "github.com/grafana/pyroscope/pkg/phlare/context"
+	"github.com/grafana/pyroscope/pkg/phlaredb"
+	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/testhelper"
+)
+
+func newTransferTestIngester(t *testing.T, cfg Config, id string) (*Ingester, string) {
+	t.Helper()
+	cfg.LifecyclerConfig.ID = id
+	cfg.LifecyclerConfig.Addr = id
+	cfg.HandOff.Enabled = true
+	dbPath := t.TempDir()
+	ctx := phlarecontext.WithLogger(context.Background(), log.NewNopLogger())
+	ing, err := New(ctx, cfg, phlaredb.Config{
+		DataPath:         dbPath,
+		MaxBlockDuration: 30 * time.Hour,
+	}, nil, &fakeLimits{})
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	return ing, dbPath
+}
+
+func pushTestProfile(t *testing.T, ing *Ingester, tenantID string) {
+	t.Helper()
+	_, err := ing.Push(tenant.InjectTenantID(context.Background(), tenantID), connect.NewRequest(&pushv1.PushRequest{
+		Series: []*pushv1.RawProfileSeries{{
+			Labels:  phlaremodel.LabelsFromStrings("service_name", "svc"),
+			Samples: []*pushv1.RawSample{{ID: uuid.NewString(), RawProfile: testProfile(t)}},
+		}},
+	}))
+	require.NoError(t, err)
+}
+
+func localBlocks(t *testing.T, dbPath, tenantID string) []os.DirEntry {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(dbPath, tenantID, phlaredb.PathLocal))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return entries
+}
+
+func ringTokens(t *testing.T, cfg Config, id string) []uint32 {
+	t.Helper()
+	desc, err := cfg.LifecyclerConfig.RingConfig.KVStore.Mock.Get(context.Background(), "ring")
+	require.NoError(t, err)
+	return desc.(*ring.Desc).Ingesters[id].Tokens
+}
+
+func Test_TransferOut(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	leaving, leavingPath := newTransferTestIngester(t, cfg, "leaving")
+	require.Eventually(t, func() bool { return leaving.lifecycler.GetState() == ring.ACTIVE }, 5*time.Second, 10*time.Millisecond)
+	pushTestProfile(t, leaving, "foo")
+
+	// The joining ingester waits for a transfer.
+	cfg.LifecyclerConfig.JoinAfter = time.Hour
+	joining, joiningPath := newTransferTestIngester(t, cfg, "joining")
+	defer func() {
+		joining.cfg.HandOff.Enabled = false
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), joining))
+	}()
+	require.Equal(t, ring.PENDING, joining.lifecycler.GetState())
+
+	mux := http.NewServeMux()
+	mux.Handle(ingesterv1connect.NewIngesterServiceHandler(joining))
+	serv := testhelper.NewInMemoryServer(mux)
+	defer serv.Close()
+	var targetAddr string
+	leaving.transferClient = func(addr string) ingesterv1connect.IngesterServiceClient {
+		targetAddr = addr
+		return ingesterv1connect.NewIngesterServiceClient(serv.Client(), serv.URL())
+	}
+	ctx := tenant.InjectTenantID(context.Background(), "foo")
+	leavingTypes, err := leaving.ProfileTypes(ctx, connect.NewRequest(&ingesterv1.ProfileTypesRequest{}))
+	require.NoError(t, err)
+	tokens := ringTokens(t, cfg, "leaving")
+	require.NotEmpty(t, tokens)
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), leaving))
+
+	// The joining ingester takes over the tokens and the profiles.
+	require.Equal(t, "joining:0", targetAddr)
+	require.Equal(t, ring.ACTIVE, joining.lifecycler.GetState())
+	require.Equal(t, tokens, ringTokens(t, cfg, "joining"))
+	values, err := joining.LabelValues(ctx, connect.NewRequest(&typesv1.LabelValuesRequest{Name: "service_name"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"svc"}, values.Msg.Names)
+	types, err := joining.ProfileTypes(ctx, connect.NewRequest(&ingesterv1.ProfileTypesRequest{}))
+	require.NoError(t, err)
+	require.Equal(t, leavingTypes.Msg.ProfileTypes, types.Msg.ProfileTypes)
+
+	// The heads are not flushed by the leaving ingester.
+	require.Empty(t, localBlocks(t, leavingPath, "foo"))
+	require.Empty(t, localBlocks(t, joiningPath, "foo"))
+}
+
+func Test_TransferOut_FallbackToFlush(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.HandOff.Timeout = 100 * time.Millisecond
+	ing, dbPath := newTransferTestIngester(t, cfg, "leaving")
+	require.Eventually(t, func() bool { return ing.lifecycler.GetState() == ring.ACTIVE }, 5*time.Second, 10*time.Millisecond)
+	pushTestProfile(t, ing, "foo")
+
+	// There is no other ingester to transfer the heads to.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), ing))
+	require.Len(t, localBlocks(t, dbPath, "foo"), 1)
+}
@@ -31,6 +31,7 @@ const (
 	LabelNameProfileName = pmodel.MetricNameLabel
 	LabelNameServiceName = "service_name"
 	LabelNameSessionID   = "__session_id__"
+	LabelNameTenantID    = "__tenant_id__"
 
 	LabelNameServiceNameK8s = "__meta_kubernetes_pod_annotation_pyroscope_io_service_name"
 
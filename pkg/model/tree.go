@@ -109,7 +109,8 @@ func (t *Tree) IterateStacks(cb func(name string, self int64, stack []string)) {
 // slice will grow to 1-4K nodes, depending on the trace branching.
 const defaultDFSSize = 128
 
-func (t *Tree) Merge(src *Tree) {
+// Merge merges src into the tree and returns the number of nodes added.
+func (t *Tree) Merge(src *Tree) int64 {
 	srcNodes := make([]*node, 0, defaultDFSSize)
 	srcRoot := &node{children: src.root}
 	srcNodes = append(srcNodes, srcRoot)
@@ -119,6 +120,7 @@ func (t *Tree) Merge(src *Tree) {
 	dstNodes = append(dstNodes, dstRoot)
 
 	var st, dt *node
+	var added int64
 	for len(srcNodes) > 0 {
 		st, srcNodes = srcNodes[len(srcNodes)-1], srcNodes[:len(srcNodes)-1]
 		dt, dstNodes = dstNodes[len(dstNodes)-1], dstNodes[:len(dstNodes)-1]
@@ -128,13 +130,18 @@ func (t *Tree) Merge(src *Tree) {
 
 		for _, srcChildNode := range st.children {
 			// Note that we don't copy the name, but reference it.
+			n := len(dt.children)
 			dstChildNode := dt.insert(srcChildNode.name)
+			if len(dt.children) > n {
+				added++
+			}
 			srcNodes = append(srcNodes, srcChildNode)
 			dstNodes = append(dstNodes, dstChildNode)
 		}
 	}
 
 	t.root = dstRoot.children
+	return added
 }
 
 func (t *Tree) FormatNodeNames(fn func(string) string) {
@@ -113,6 +113,7 @@ func Test_TreeMerge(t *testing.T) {
 	type testCase struct {
 		description        string
 		src, dst, expected *Tree
+		added              int64
 	}
 
 	testCases := func() []testCase {
@@ -136,6 +137,7 @@ func Test_TreeMerge(t *testing.T) {
 				expected: newTree([]stacktraces{
 					{locations: []string{"c", "b", "a"}, value: 1},
 				}),
+				added: 3,
 			},
 			{
 				description: "empty both",
@@ -160,6 +162,7 @@ func Test_TreeMerge(t *testing.T) {
 					{locations: []string{"c", "b1", "a"}, value: 1},
 					{locations: []string{"c1", "b", "a"}, value: 1},
 				}),
+				added: 6,
 			},
 			{
 				description: "missing nodes in src",
@@ -186,7 +189,7 @@ func Test_TreeMerge(t *testing.T) {
 		for _, tc := range testCases() {
 			tc := tc
 			t.Run(tc.description, func(t *testing.T) {
-				tc.dst.Merge(tc.src)
+				require.Equal(t, tc.added, tc.dst.Merge(tc.src))
 				require.Equal(t, tc.expected.String(), tc.dst.String())
 			})
 		}
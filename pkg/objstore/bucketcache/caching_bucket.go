@@ -0,0 +1,250 @@
+package bucketcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
+)
+
+const (
+	profilesFilename = "profiles.parquet"
+	symbolsDirname   = "symbols"
+
+	fileTypeProfiles = "profiles"
+	fileTypeSymbols  = "symbols"
+
+	opGetRange   = "get_range"
+	opAttributes = "attributes"
+)
+
+// CachingBucket caches ranges of the block files read by queries: the
+// profiles table and the symbols. The ranges are aligned to subranges of
+// a fixed size, so that overlapping reads share cache items. All the other
+// objects and operations are passed through to the underlying bucket.
+type CachingBucket struct {
+	phlareobj.Bucket
+
+	cache   cache.Cache
+	cfg     Config
+	metrics *metrics
+}
+
+type metrics struct {
+	hits   *prometheus.CounterVec
+	misses *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	return &metrics{
+		hits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_bucket_cache_hits_total",
+			Help: "Total number of items found in the bucket cache.",
+		}, []string{"operation", "file_type"}),
+		misses: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_bucket_cache_misses_total",
+			Help: "Total number of items not found in the bucket cache.",
+		}, []string{"operation", "file_type"}),
+	}
+}
+
+// NewCachingBucket wraps the bucket with the cache. If the cache is nil,
+// the bucket is returned as is.
+func NewCachingBucket(bkt phlareobj.Bucket, c cache.Cache, cfg Config, reg prometheus.Registerer) phlareobj.Bucket {
+	if c == nil {
+		return bkt
+	}
+	return &CachingBucket{
+		Bucket:  bkt,
+		cache:   c,
+		cfg:     cfg,
+		metrics: newMetrics(reg),
+	}
+}
+
+// fileType returns the type of the block file and the TTL of its cache items.
+// The object name is expected to end with <block>/<file>.
+func (cb *CachingBucket) fileType(name string) (string, time.Duration, bool) {
+	dir, file := path.Split(name)
+	switch {
+	case file == profilesFilename:
+		return fileTypeProfiles, cb.cfg.ProfilesTTL, true
+	case path.Base(dir) == symbolsDirname:
+		return fileTypeSymbols, cb.cfg.SymbolsTTL, true
+	}
+	return "", 0, false
+}
+
+func (cb *CachingBucket) ReaderAt(ctx context.Context, name string) (phlareobj.ReaderAtCloser, error) {
+	// ReaderAtBucket reads through our GetRange.
+	return (&phlareobj.ReaderAtBucket{Bucket: cb}).ReaderAt(ctx, name)
+}
+
+func (cb *CachingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	fileType, ttl, ok := cb.fileType(name)
+	if !ok || off < 0 || length <= 0 {
+		return cb.Bucket.GetRange(ctx, name, off, length)
+	}
+	attrs, err := cb.attributes(ctx, name, fileType)
+	if err != nil {
+		return nil, err
+	}
+	end := off + length
+	if end > attrs.Size {
+		end = attrs.Size
+	}
+	if off >= end {
+		return cb.Bucket.GetRange(ctx, name, off, length)
+	}
+
+	subrangeSize := cb.cfg.SubrangeSize
+	first := (off / subrangeSize) * subrangeSize
+	keys := make([]string, 0, (end-first+subrangeSize-1)/subrangeSize)
+	offsets := make(map[string]int64, cap(keys))
+	for o := first; o < end; o += subrangeSize {
+		k := subrangeKey(name, o, subrangeSize)
+		keys = append(keys, k)
+		offsets[k] = o
+	}
+	// subrangeLen returns the expected length of the subrange at the offset.
+	subrangeLen := func(o int64) int64 {
+		if o+subrangeSize > attrs.Size {
+			return attrs.Size - o
+		}
+		return subrangeSize
+	}
+
+	found := cb.cache.Fetch(ctx, keys)
+	for k, v := range found {
+		if int64(len(v)) != subrangeLen(offsets[k]) {
+			delete(found, k)
+		}
+	}
+	cb.metrics.hits.WithLabelValues(opGetRange, fileType).Add(float64(len(found)))
+	cb.metrics.misses.WithLabelValues(opGetRange, fileType).Add(float64(len(keys) - len(found)))
+
+	// Adjacent missing subranges are fetched with a single request.
+	missing := make(map[string][]byte)
+	for i := 0; i < len(keys); {
+		if _, ok := found[keys[i]]; ok {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(keys) {
+			if _, ok := found[keys[j]]; ok {
+				break
+			}
+			j++
+		}
+		from := offsets[keys[i]]
+		to := offsets[keys[j-1]] + subrangeLen(offsets[keys[j-1]])
+		b, err := cb.fetchRange(ctx, name, from, to-from)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys[i:j] {
+			o := offsets[k] - from
+			v := b[o : o+subrangeLen(offsets[k])]
+			found[k] = v
+			missing[k] = v
+		}
+		i = j
+	}
+	if len(missing) > 0 {
+		cb.cache.StoreAsync(missing, ttl)
+	}
+
+	buf := make([]byte, 0, end-off)
+	for _, k := range keys {
+		v := found[k]
+		o := offsets[k]
+		lo, hi := int64(0), int64(len(v))
+		if o < off {
+			lo = off - o
+		}
+		if o+hi > end {
+			hi = end - o
+		}
+		buf = append(buf, v[lo:hi]...)
+	}
+	return io.NopCloser(bytes.NewReader(buf)), nil
+}
+
+func (cb *CachingBucket) fetchRange(ctx context.Context, name string, off, length int64) ([]byte, error) {
+	rc, err := cb.Bucket.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b := make([]byte, length)
+	if _, err = io.ReadFull(rc, b); err != nil {
+		return nil, fmt.Errorf("read range %d-%d of %s: %w", off, off+length, name, err)
+	}
+	return b, nil
+}
+
+func (cb *CachingBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	fileType, _, ok := cb.fileType(name)
+	if !ok {
+		return cb.Bucket.Attributes(ctx, name)
+	}
+	return cb.attributes(ctx, name, fileType)
+}
+
+func (cb *CachingBucket) attributes(ctx context.Context, name, fileType string) (objstore.ObjectAttributes, error) {
+	key := attributesKey(name)
+	if b, ok := cb.cache.Fetch(ctx, []string{key})[key]; ok {
+		var attrs objstore.ObjectAttributes
+		if err := json.Unmarshal(b, &attrs); err == nil {
+			cb.metrics.hits.WithLabelValues(opAttributes, fileType).Inc()
+			return attrs, nil
+		}
+	}
+	cb.metrics.misses.WithLabelValues(opAttributes, fileType).Inc()
+	attrs, err := cb.Bucket.Attributes(ctx, name)
+	if err != nil {
+		return attrs, err
+	}
+	if b, err := json.Marshal(attrs); err == nil {
+		cb.cache.StoreAsync(map[string][]byte{key: b}, cb.cfg.AttributesTTL)
+	}
+	return attrs, nil
+}
+
+// ReaderWithExpectedErrs implements objstore.Bucket.
+func (cb *CachingBucket) ReaderWithExpectedErrs(fn phlareobj.IsOpFailureExpectedFunc) phlareobj.BucketReader {
+	return cb.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.Bucket.
+func (cb *CachingBucket) WithExpectedErrs(fn phlareobj.IsOpFailureExpectedFunc) phlareobj.Bucket {
+	if ib, ok := cb.Bucket.(phlareobj.InstrumentedBucket); ok {
+		return &CachingBucket{
+			Bucket:  ib.WithExpectedErrs(fn),
+			cache:   cb.cache,
+			cfg:     cb.cfg,
+			metrics: cb.metrics,
+		}
+	}
+	return cb
+}
+
+func subrangeKey(name string, off, size int64) string {
+	return "subrange:" + name + ":" + strconv.FormatInt(off, 10) + ":" + strconv.FormatInt(off+size, 10)
+}
+
+func attributesKey(name string) string {
+	return "attrs:" + name
+}
@@ -0,0 +1,140 @@
+package bucketcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
+)
+
+type countingBucket struct {
+	objstore.Bucket
+	getRange   int
+	attributes int
+}
+
+func (b *countingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	b.getRange++
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
+
+func (b *countingBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	b.attributes++
+	return b.Bucket.Attributes(ctx, name)
+}
+
+func newTestCachingBucket(t *testing.T) (*CachingBucket, *countingBucket, *prometheus.Registry) {
+	t.Helper()
+	cfg := Config{
+		Backend:       BackendDisk,
+		Disk:          DiskConfig{Dir: t.TempDir(), MaxSizeBytes: 1 << 20},
+		SubrangeSize:  10,
+		AttributesTTL: time.Hour,
+		ProfilesTTL:   time.Hour,
+		SymbolsTTL:    time.Hour,
+	}
+	require.NoError(t, cfg.Validate())
+	reg := prometheus.NewRegistry()
+	c, err := NewCache("test", cfg, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+	inner := &countingBucket{Bucket: objstore.NewInMemBucket()}
+	cb := NewCachingBucket(phlareobj.NewBucket(inner), c, cfg, reg)
+	return cb.(*CachingBucket), inner, reg
+}
+
+func TestCachingBucket_GetRange(t *testing.T) {
+	ctx := context.Background()
+	cb, inner, reg := newTestCachingBucket(t)
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyzABCDE")
+	const name = "tenant/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/profiles.parquet"
+	require.NoError(t, inner.Upload(ctx, name, bytes.NewReader(data)))
+
+	for _, tc := range []struct {
+		off, length int64
+	}{
+		{0, 5},
+		{3, 15},
+		{8, 30},
+		{35, 10}, // Goes past the end of the object.
+		{0, int64(len(data))},
+	} {
+		rc, err := cb.GetRange(ctx, name, tc.off, tc.length)
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		end := tc.off + tc.length
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		require.Equal(t, string(data[tc.off:end]), string(b))
+		cb.cache.(*DiskCache).flush()
+	}
+	// All the subranges have been fetched at this point.
+	requests := inner.getRange
+	require.Equal(t, 1, inner.attributes)
+
+	ra, err := cb.ReaderAt(ctx, name)
+	require.NoError(t, err)
+	b := make([]byte, 20)
+	n, err := ra.ReadAt(b, 12)
+	require.NoError(t, err)
+	require.Equal(t, 20, n)
+	require.Equal(t, string(data[12:32]), string(b))
+	require.NoError(t, ra.Close())
+	require.Equal(t, requests, inner.getRange)
+
+	require.Equal(t, float64(5), testutil.ToFloat64(cb.metrics.misses.WithLabelValues(opGetRange, fileTypeProfiles)))
+	require.Equal(t, float64(1), testutil.ToFloat64(cb.metrics.misses.WithLabelValues(opAttributes, fileTypeProfiles)))
+	n, err = testutil.GatherAndCount(reg, "pyroscope_bucket_cache_hits_total")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestCachingBucket_PassThrough(t *testing.T) {
+	ctx := context.Background()
+	cb, inner, _ := newTestCachingBucket(t)
+	const name = "tenant/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/index.tsdb"
+	require.NoError(t, inner.Upload(ctx, name, bytes.NewReader([]byte("index"))))
+
+	for i := 0; i < 2; i++ {
+		rc, err := cb.GetRange(ctx, name, 1, 3)
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.Equal(t, "nde", string(b))
+	}
+	require.Equal(t, 2, inner.getRange)
+	require.Equal(t, 0, inner.attributes)
+}
+
+func TestCachingBucket_FileType(t *testing.T) {
+	cb, _, _ := newTestCachingBucket(t)
+	cb.cfg.ProfilesTTL = time.Minute
+	cb.cfg.SymbolsTTL = time.Hour
+	for _, tc := range []struct {
+		name     string
+		fileType string
+		ttl      time.Duration
+	}{
+		{name: "t/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/profiles.parquet", fileType: fileTypeProfiles, ttl: time.Minute},
+		{name: "t/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/symbols/stacktraces.symdb", fileType: fileTypeSymbols, ttl: time.Hour},
+		{name: "t/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/symbols/locations.parquet", fileType: fileTypeSymbols, ttl: time.Hour},
+		{name: "t/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/index.tsdb"},
+		{name: "t/phlaredb/01HA2V3CPSZ9E0HMQNNHH89WSS/meta.json"},
+	} {
+		fileType, ttl, ok := cb.fileType(tc.name)
+		require.Equal(t, tc.fileType != "", ok, tc.name)
+		require.Equal(t, tc.fileType, fileType, tc.name)
+		require.Equal(t, tc.ttl, ttl, tc.name)
+	}
+}
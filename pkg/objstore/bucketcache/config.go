@@ -0,0 +1,122 @@
+package bucketcache
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	BackendDisk      = "disk"
+	BackendMemcached = cache.BackendMemcached
+	BackendRedis     = cache.BackendRedis
+)
+
+var supportedBackends = []string{BackendDisk, BackendMemcached, BackendRedis}
+
+type Config struct {
+	Backend   string                      `yaml:"backend"`
+	Disk      DiskConfig                  `yaml:"disk"`
+	Memcached cache.MemcachedClientConfig `yaml:"memcached"`
+	Redis     cache.RedisClientConfig     `yaml:"redis"`
+
+	SubrangeSize  int64         `yaml:"subrange_size" category:"advanced"`
+	AttributesTTL time.Duration `yaml:"attributes_ttl" category:"advanced"`
+	ProfilesTTL   time.Duration `yaml:"profiles_ttl" category:"advanced"`
+	SymbolsTTL    time.Duration `yaml:"symbols_ttl" category:"advanced"`
+}
+
+type DiskConfig struct {
+	Dir          string `yaml:"dir"`
+	MaxSizeBytes int64  `yaml:"max_size_bytes"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("Backend for the block files cache. Supported values: %s. An empty value disables the cache.", joinBackends()))
+	f.StringVar(&cfg.Disk.Dir, prefix+"disk.dir", "./data/pyroscope-cache/", "Directory to store cached block files ranges. The directory is cleaned up at startup.")
+	f.Int64Var(&cfg.Disk.MaxSizeBytes, prefix+"disk.max-size-bytes", 10<<30, "Maximum size in bytes of the on-disk cache. Least recently used items are evicted once the limit is reached.")
+	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
+	cfg.Redis.RegisterFlagsWithPrefix(prefix+"redis.", f)
+	f.Int64Var(&cfg.SubrangeSize, prefix+"subrange-size", 64<<10, "Size in bytes of the ranges block files are split into for caching.")
+	f.DurationVar(&cfg.AttributesTTL, prefix+"attributes-ttl", 168*time.Hour, "TTL for cached object attributes.")
+	f.DurationVar(&cfg.ProfilesTTL, prefix+"profiles-ttl", 24*time.Hour, "TTL for cached ranges of profiles tables.")
+	f.DurationVar(&cfg.SymbolsTTL, prefix+"symbols-ttl", 168*time.Hour, "TTL for cached ranges of symbols files.")
+}
+
+func (cfg *Config) Validate() error {
+	switch cfg.Backend {
+	case "":
+		return nil
+	case BackendDisk:
+		if cfg.Disk.Dir == "" {
+			return errors.New("disk cache directory is not set")
+		}
+		if cfg.Disk.MaxSizeBytes <= 0 {
+			return errors.New("disk cache max size must be positive")
+		}
+	case BackendMemcached:
+		if err := cfg.Memcached.Validate(); err != nil {
+			return err
+		}
+	case BackendRedis:
+		if err := cfg.Redis.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported cache backend: %s, supported values: %s", cfg.Backend, joinBackends())
+	}
+	if cfg.SubrangeSize <= 0 {
+		return errors.New("cache subrange size must be positive")
+	}
+	return nil
+}
+
+func joinBackends() string {
+	return strings.Join(supportedBackends, ", ")
+}
+
+// Cache is a cache backend which releases its background workers and
+// clients when stopped.
+type Cache interface {
+	cache.Cache
+	Stop()
+}
+
+// NewCache creates the cache backend for the given configuration.
+// It returns nil if caching is disabled.
+func NewCache(name string, cfg Config, logger log.Logger, reg prometheus.Registerer) (Cache, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendDisk:
+		return NewDiskCache(name, cfg.Disk, logger, reg)
+	case BackendMemcached:
+		client, err := cache.NewMemcachedClientWithConfig(logger, name, cfg.Memcached, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create memcached client")
+		}
+		return &remoteCache{Cache: cache.NewMemcachedCache(name, logger, client, reg), client: client}, nil
+	case BackendRedis:
+		client, err := cache.NewRedisClient(logger, name, cfg.Redis, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create redis client")
+		}
+		return &remoteCache{Cache: cache.NewRedisCache(name, logger, client, reg), client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cache backend: %s", cfg.Backend)
+	}
+}
+
+// remoteCache keeps the client of a remote cache, to stop it.
+type remoteCache struct {
+	cache.Cache
+	client cache.RemoteCacheClient
+}
+
+func (c *remoteCache) Stop() { c.client.Stop() }
@@ -0,0 +1,305 @@
+package bucketcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var _ cache.Cache = (*DiskCache)(nil)
+
+const (
+	// storeQueueSize is the number of pending asynchronous writes beyond
+	// which new items are not stored.
+	storeQueueSize   = 1024
+	storeConcurrency = 4
+)
+
+// DiskCache is a size bounded LRU cache storing items as files in a local
+// directory. The index of the cache is only kept in memory, therefore the
+// directory is cleaned up when the cache is created.
+//
+// Each value is written to its own file, which is removed but never
+// overwritten once the item is evicted or replaced: the files are read
+// and removed without holding the lock of the index.
+type DiskCache struct {
+	name   string
+	dir    string
+	max    int64
+	logger log.Logger
+
+	mtx     sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+
+	queue   chan storeOp
+	pending sync.WaitGroup
+	stop    chan struct{}
+	workers sync.WaitGroup
+
+	evictions prometheus.Counter
+	dropped   prometheus.Counter
+}
+
+type diskEntry struct {
+	key     string
+	path    string
+	size    int64
+	expires time.Time
+}
+
+type storeOp struct {
+	key   string
+	value []byte
+	ttl   time.Duration
+}
+
+func NewDiskCache(name string, cfg DiskConfig, logger log.Logger, reg prometheus.Registerer) (*DiskCache, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create cache directory")
+	}
+	if err := cleanupDir(cfg.Dir); err != nil {
+		return nil, errors.Wrap(err, "clean up cache directory")
+	}
+	c := &DiskCache{
+		name:    name,
+		dir:     cfg.Dir,
+		max:     cfg.MaxSizeBytes,
+		logger:  logger,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		queue:   make(chan storeOp, storeQueueSize),
+		stop:    make(chan struct{}),
+	}
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"name": name}, reg)
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pyroscope_disk_cache_items",
+		Help: "Current number of items in the disk cache.",
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		return float64(c.lru.Len())
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pyroscope_disk_cache_size_bytes",
+		Help: "Current size of the items in the disk cache.",
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		return float64(c.size)
+	})
+	c.evictions = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "pyroscope_disk_cache_evictions_total",
+		Help: "Total number of items evicted from the disk cache.",
+	})
+	c.dropped = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "pyroscope_disk_cache_dropped_writes_total",
+		Help: "Total number of items not stored in the disk cache because the write queue was full.",
+	})
+	c.workers.Add(storeConcurrency)
+	for i := 0; i < storeConcurrency; i++ {
+		go c.runStores()
+	}
+	return c, nil
+}
+
+func (c *DiskCache) Name() string { return c.name }
+
+// StoreAsync queues the items to be written by the background workers.
+// The items are dropped if the queue is full.
+func (c *DiskCache) StoreAsync(data map[string][]byte, ttl time.Duration) {
+	for k, v := range data {
+		if int64(len(v)) > c.max {
+			continue
+		}
+		c.pending.Add(1)
+		select {
+		case c.queue <- storeOp{key: k, value: v, ttl: ttl}:
+		default:
+			c.pending.Done()
+			c.dropped.Inc()
+		}
+	}
+}
+
+// Stop stops the background workers. The queued writes are dropped.
+func (c *DiskCache) Stop() {
+	close(c.stop)
+	c.workers.Wait()
+}
+
+// flush waits for the queued writes.
+func (c *DiskCache) flush() {
+	c.pending.Wait()
+}
+
+func (c *DiskCache) runStores() {
+	defer c.workers.Done()
+	for {
+		select {
+		case op := <-c.queue:
+			if err := c.store(op.key, op.value, op.ttl); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to store item in disk cache", "err", err)
+			}
+			c.pending.Done()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *DiskCache) store(key string, value []byte, ttl time.Duration) error {
+	dir, name := c.path(key)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// The item is only added to the index once its file is complete.
+	f, err := os.CreateTemp(dir, name+"-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	var removed []string
+	c.mtx.Lock()
+	if e, ok := c.entries[key]; ok {
+		removed = append(removed, c.removeElement(e))
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	c.entries[key] = c.lru.PushFront(&diskEntry{
+		key:     key,
+		path:    f.Name(),
+		size:    int64(len(value)),
+		expires: expires,
+	})
+	c.size += int64(len(value))
+	for c.size > c.max {
+		removed = append(removed, c.removeElement(c.lru.Back()))
+		c.evictions.Inc()
+	}
+	c.mtx.Unlock()
+	c.removeFiles(removed...)
+	return nil
+}
+
+func (c *DiskCache) Fetch(_ context.Context, keys []string, _ ...cache.Option) map[string][]byte {
+	found := make(map[string][]byte, len(keys))
+	now := time.Now()
+	for _, k := range keys {
+		c.mtx.Lock()
+		e, ok := c.entries[k]
+		if !ok {
+			c.mtx.Unlock()
+			continue
+		}
+		entry := e.Value.(*diskEntry)
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			path := c.removeElement(e)
+			c.mtx.Unlock()
+			c.removeFiles(path)
+			continue
+		}
+		c.lru.MoveToFront(e)
+		c.mtx.Unlock()
+
+		b, err := os.ReadFile(entry.path)
+		switch {
+		case err == nil:
+			found[k] = b
+		case os.IsNotExist(err):
+			// The item has been evicted or replaced in the meantime.
+		default:
+			level.Warn(c.logger).Log("msg", "failed to read item from disk cache", "err", err)
+			c.mtx.Lock()
+			var path string
+			if current, ok := c.entries[k]; ok && current == e {
+				path = c.removeElement(e)
+			}
+			c.mtx.Unlock()
+			c.removeFiles(path)
+		}
+	}
+	return found
+}
+
+func (c *DiskCache) Delete(_ context.Context, key string) error {
+	var path string
+	c.mtx.Lock()
+	if e, ok := c.entries[key]; ok {
+		path = c.removeElement(e)
+	}
+	c.mtx.Unlock()
+	c.removeFiles(path)
+	return nil
+}
+
+// removeElement removes the item from the index, and returns the path
+// of its file, to be removed once the lock is released.
+func (c *DiskCache) removeElement(e *list.Element) string {
+	entry := c.lru.Remove(e).(*diskEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+	return entry.path
+}
+
+func (c *DiskCache) removeFiles(paths ...string) {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			level.Warn(c.logger).Log("msg", "failed to remove item from disk cache", "err", err)
+		}
+	}
+}
+
+// cleanupDir removes the items left by a previous instance of the cache.
+// Only the directories created by the cache are removed.
+func cleanupDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || len(e.Name()) != 2 {
+			continue
+		}
+		if _, err = hex.DecodeString(e.Name()); err != nil {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// path returns the directory of the files of the key, and their name prefix.
+func (c *DiskCache) path(key string) (dir, name string) {
+	h := sha256.Sum256([]byte(key))
+	name = hex.EncodeToString(h[:])
+	return filepath.Join(c.dir, name[:2]), name
+}
@@ -0,0 +1,79 @@
+package bucketcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewDiskCache("test", DiskConfig{Dir: t.TempDir(), MaxSizeBytes: 10}, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	c.StoreAsync(map[string][]byte{"a": []byte("aaaa"), "b": []byte("bbbb")}, time.Hour)
+	c.flush()
+	require.Equal(t, map[string][]byte{"a": []byte("aaaa"), "b": []byte("bbbb")}, c.Fetch(ctx, []string{"a", "b", "c"}))
+
+	// Access "a" so that "b" becomes the least recently used item.
+	require.Len(t, c.Fetch(ctx, []string{"a"}), 1)
+	c.StoreAsync(map[string][]byte{"c": []byte("cccc")}, time.Hour)
+	c.flush()
+	require.Equal(t, map[string][]byte{"a": []byte("aaaa"), "c": []byte("cccc")}, c.Fetch(ctx, []string{"a", "b", "c"}))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.evictions))
+
+	// Items larger than the cache are never stored.
+	c.StoreAsync(map[string][]byte{"d": make([]byte, 11)}, time.Hour)
+	c.flush()
+	require.Empty(t, c.Fetch(ctx, []string{"d"}))
+
+	// Overwrites replace the previous value.
+	c.StoreAsync(map[string][]byte{"a": []byte("AA")}, time.Hour)
+	c.flush()
+	require.Equal(t, map[string][]byte{"a": []byte("AA")}, c.Fetch(ctx, []string{"a"}))
+	require.Equal(t, int64(6), c.size)
+
+	require.NoError(t, c.Delete(ctx, "a"))
+	require.Empty(t, c.Fetch(ctx, []string{"a"}))
+	require.Equal(t, int64(4), c.size)
+}
+
+func TestDiskCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewDiskCache("test", DiskConfig{Dir: t.TempDir(), MaxSizeBytes: 10}, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	c.StoreAsync(map[string][]byte{"a": []byte("a")}, time.Nanosecond)
+	c.flush()
+	c.StoreAsync(map[string][]byte{"b": []byte("b")}, 0)
+	time.Sleep(time.Millisecond)
+	require.Equal(t, map[string][]byte{"b": []byte("b")}, c.Fetch(ctx, []string{"a", "b"}))
+	require.Equal(t, int64(1), c.size)
+}
+
+func TestDiskCache_Cleanup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep"), []byte("keep"), 0o644))
+	c, err := NewDiskCache("test", DiskConfig{Dir: dir, MaxSizeBytes: 10}, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+	c.StoreAsync(map[string][]byte{"a": []byte("a")}, 0)
+	c.flush()
+
+	c, err = NewDiskCache("test", DiskConfig{Dir: dir, MaxSizeBytes: 10}, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "keep", entries[0].Name())
+}
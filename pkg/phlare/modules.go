@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -35,18 +36,24 @@ import (
 	"github.com/grafana/pyroscope/pkg/distributor"
 	"github.com/grafana/pyroscope/pkg/frontend"
 	"github.com/grafana/pyroscope/pkg/ingester"
+	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
 	objstoreclient "github.com/grafana/pyroscope/pkg/objstore/client"
 	"github.com/grafana/pyroscope/pkg/objstore/providers/filesystem"
 	phlarecontext "github.com/grafana/pyroscope/pkg/phlare/context"
 	"github.com/grafana/pyroscope/pkg/querier"
+	"github.com/grafana/pyroscope/pkg/querier/federation"
 	"github.com/grafana/pyroscope/pkg/querier/worker"
 	"github.com/grafana/pyroscope/pkg/scheduler"
+	"github.com/grafana/pyroscope/pkg/scrape"
+	"github.com/grafana/pyroscope/pkg/selfprofiling"
 	"github.com/grafana/pyroscope/pkg/storegateway"
 	"github.com/grafana/pyroscope/pkg/usagestats"
+	"github.com/grafana/pyroscope/pkg/usagetracker"
 	"github.com/grafana/pyroscope/pkg/util"
 	"github.com/grafana/pyroscope/pkg/util/build"
 	"github.com/grafana/pyroscope/pkg/validation"
 	"github.com/grafana/pyroscope/pkg/validation/exporter"
+	"github.com/grafana/pyroscope/pkg/validation/tenantoverrides"
 )
 
 // The various modules that make up Pyroscope.
@@ -63,12 +70,16 @@ const (
 	GRPCGateway       string = "grpc-gateway"
 	Storage           string = "storage"
 	UsageReport       string = "usage-stats"
+	UsageTracker      string = "usage-tracker"
 	QueryFrontend     string = "query-frontend"
 	QueryScheduler    string = "query-scheduler"
 	RuntimeConfig     string = "runtime-config"
 	Overrides         string = "overrides"
+	TenantOverrides   string = "tenant-overrides"
 	OverridesExporter string = "overrides-exporter"
 	Compactor         string = "compactor"
+	SelfProfiling     string = "self-profiling"
+	Scrape            string = "scrape"
 
 	// QueryFrontendTripperware string = "query-frontend-tripperware"
 	// IndexGateway             string = "index-gateway"
@@ -96,9 +107,10 @@ func (f *Phlare) initQueryFrontend() (services.Service, error) {
 		return nil, err
 	}
 
-	f.API.RegisterPyroscopeHandlers(frontendSvc)
+	federatedSvc := federation.NewQuerier(frontendSvc, f.Overrides)
+	f.API.RegisterPyroscopeHandlers(federatedSvc)
 	f.API.RegisterQueryFrontend(frontendSvc)
-	f.API.RegisterQuerier(frontendSvc)
+	f.API.RegisterQuerier(federatedSvc)
 
 	return frontendSvc, nil
 }
@@ -123,11 +135,27 @@ func (f *Phlare) initRuntimeConfig() (services.Service, error) {
 	}
 
 	f.RuntimeConfig = serv
-	f.API.RegisterRuntimeConfig(runtimeConfigHandler(f.RuntimeConfig, f.Cfg.LimitsConfig), validation.TenantLimitsHandler(f.Cfg.LimitsConfig, f.TenantLimits))
+	f.API.RegisterRuntimeConfig(runtimeConfigHandler(f.RuntimeConfig, f.Cfg.LimitsConfig))
 
 	return serv, err
 }
 
+func (f *Phlare) initTenantOverrides() (services.Service, error) {
+	b, err := f.bucketOrLocal()
+	if err != nil {
+		return nil, err
+	}
+	// make sure to set default limits before we start loading overrides into memory
+	validation.SetDefaultLimitsForYAMLUnmarshalling(f.Cfg.LimitsConfig)
+
+	// The tenant overrides set with the admin API take precedence over the runtime config.
+	store := tenantoverrides.NewStore(f.Cfg.TenantOverrides, b, f.Cfg.LimitsConfig, f.TenantLimits, log.With(f.logger, "component", "tenant-overrides"))
+	f.TenantLimits = store
+	f.API.RegisterTenantOverrides(store, validation.TenantLimitsHandler(f.Cfg.LimitsConfig, f.TenantLimits))
+
+	return store, nil
+}
+
 func (f *Phlare) initOverrides() (serv services.Service, err error) {
 	f.Overrides, err = validation.NewOverrides(f.Cfg.LimitsConfig, f.TenantLimits)
 	// overrides don't have operational state, nor do they need to do anything more in starting/stopping phase,
@@ -205,7 +233,7 @@ func (f *Phlare) initQuerier() (services.Service, error) {
 
 	// if a storage bucket is configure we need to create a store gateway querier
 	if f.storageBucket != nil {
-		storeGatewayQuerier, err = querier.NewStoreGatewayQuerier(f.Cfg.StoreGateway, nil, f.Overrides, log.With(f.logger, "component", "store-gateway-querier"), f.reg, f.auth)
+		storeGatewayQuerier, err = querier.NewStoreGatewayQuerier(f.Cfg.StoreGateway, f.Cfg.Querier, nil, f.Overrides, log.With(f.logger, "component", "store-gateway-querier"), f.reg, f.auth)
 		if err != nil {
 			return nil, err
 		}
@@ -216,8 +244,9 @@ func (f *Phlare) initQuerier() (services.Service, error) {
 		return nil, err
 	}
 	if !f.isModuleActive(QueryFrontend) {
-		f.API.RegisterPyroscopeHandlers(querierSvc)
-		f.API.RegisterQuerier(querierSvc)
+		federatedSvc := federation.NewQuerier(querierSvc, f.Overrides)
+		f.API.RegisterPyroscopeHandlers(federatedSvc)
+		f.API.RegisterQuerier(federatedSvc)
 	}
 	worker, err := worker.NewQuerierWorker(f.Cfg.Worker, querier.NewGRPCHandler(querierSvc), log.With(f.logger, "component", "querier-worker"), f.reg)
 	if err != nil {
@@ -273,9 +302,50 @@ func (f *Phlare) initDistributor() (services.Service, error) {
 	}
 
 	f.API.RegisterDistributor(d)
+	f.distributor = d
 	return d, nil
 }
 
+// initSelfProfiling pushes the profiles of the process through the local distributor: the
+// self-profiling target adds a full distributor, with its push API, to the other components.
+func (f *Phlare) initSelfProfiling() (services.Service, error) {
+	if f.Cfg.SelfProfiling.DisablePush {
+		return nil, nil
+	}
+	instance, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return selfprofiling.New(f.Cfg.SelfProfiling, f.distributor, f.selfProfiledComponent(), instance, version.Version, log.With(f.logger, "component", "self-profiling")), nil
+}
+
+// selfProfiledComponent returns the targets of the process, besides the self-profiling one.
+func (f *Phlare) selfProfiledComponent() string {
+	components := make([]string, 0, len(f.Cfg.Target))
+	for _, target := range f.Cfg.Target {
+		if target != SelfProfiling {
+			components = append(components, target)
+		}
+	}
+	if len(components) == 0 {
+		return SelfProfiling
+	}
+	return strings.Join(components, ",")
+}
+
+func (f *Phlare) initScrape() (services.Service, error) {
+	if f.Cfg.Scrape.ConfigFile == "" {
+		return nil, nil
+	}
+	f.Cfg.Scrape.Ring.ListenPort = f.Cfg.Server.HTTPListenPort
+	s, err := scrape.New(f.Cfg.Scrape, f.distributor, f.reg, log.With(f.logger, "component", "scrape"))
+	if err != nil {
+		return nil, err
+	}
+	f.API.RegisterScrape(s)
+	return s, nil
+}
+
 func (f *Phlare) initMemberlistKV() (services.Service, error) {
 	f.Cfg.MemberlistKV.Codecs = []codec.Codec{
 		ring.GetCodec(),
@@ -299,6 +369,7 @@ func (f *Phlare) initMemberlistKV() (services.Service, error) {
 	f.Cfg.OverridesExporter.Ring.Ring.KVStore.MemberlistKV = f.MemberlistKV.GetMemberlistKV
 	f.Cfg.StoreGateway.ShardingRing.Ring.KVStore.MemberlistKV = f.MemberlistKV.GetMemberlistKV
 	f.Cfg.Compactor.ShardingRing.Common.KVStore.MemberlistKV = f.MemberlistKV.GetMemberlistKV
+	f.Cfg.Scrape.Ring.KVStore.MemberlistKV = f.MemberlistKV.GetMemberlistKV
 	f.Cfg.Frontend.QuerySchedulerDiscovery = f.Cfg.QueryScheduler.ServiceDiscovery
 	f.Cfg.Worker.QuerySchedulerDiscovery = f.Cfg.QueryScheduler.ServiceDiscovery
 
@@ -456,18 +527,10 @@ func (f *Phlare) initUsageReport() (services.Service, error) {
 
 	usagestats.Target(f.Cfg.Target.String())
 
-	b := f.storageBucket
-	if f.storageBucket == nil {
-		if err := os.MkdirAll(f.Cfg.PhlareDB.DataPath, 0o777); err != nil {
-			return nil, fmt.Errorf("mkdir %s: %w", f.Cfg.PhlareDB.DataPath, err)
-		}
-		fs, err := filesystem.NewBucket(f.Cfg.PhlareDB.DataPath)
-		if err != nil {
-			return nil, err
-		}
-		b = fs
+	b, err := f.bucketOrLocal()
+	if err != nil {
+		return nil, err
 	}
-
 	if b == nil {
 		level.Warn(f.logger).Log("msg", "no storage bucket configured, usage report will not be sent")
 		return nil, nil
@@ -482,6 +545,35 @@ func (f *Phlare) initUsageReport() (services.Service, error) {
 	return ur, nil
 }
 
+// bucketOrLocal returns the storage bucket, or a filesystem bucket in the data path
+// if no object storage is configured.
+func (f *Phlare) bucketOrLocal() (phlareobj.Bucket, error) {
+	if f.storageBucket != nil {
+		return f.storageBucket, nil
+	}
+	if err := os.MkdirAll(f.Cfg.PhlareDB.DataPath, 0o777); err != nil {
+		return nil, fmt.Errorf("mkdir %s: %w", f.Cfg.PhlareDB.DataPath, err)
+	}
+	fs, err := filesystem.NewBucket(f.Cfg.PhlareDB.DataPath)
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func (f *Phlare) initUsageTracker() (services.Service, error) {
+	b, err := f.bucketOrLocal()
+	if err != nil {
+		return nil, err
+	}
+	f.API.RegisterUsage(b)
+
+	if f.Cfg.Usage.ReportInterval <= 0 {
+		return nil, nil
+	}
+	return usagetracker.NewReporter(f.Cfg.Usage, b, usagetracker.DefaultTracker(), log.With(f.logger, "component", "usage-reporter")), nil
+}
+
 type statusService struct {
 	statusv1.UnimplementedStatusServiceServer
 	defaultConfig *Config
@@ -28,12 +28,12 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/signals"
 	wwtracing "github.com/grafana/dskit/tracing"
-	"github.com/grafana/pyroscope-go"
 	grpcgw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/version"
 	"github.com/samber/lo"
+	"google.golang.org/grpc"
 
 	"github.com/grafana/pyroscope/pkg/api"
 	"github.com/grafana/pyroscope/pkg/cfg"
@@ -49,15 +49,19 @@ import (
 	"github.com/grafana/pyroscope/pkg/querier/worker"
 	"github.com/grafana/pyroscope/pkg/scheduler"
 	"github.com/grafana/pyroscope/pkg/scheduler/schedulerdiscovery"
+	"github.com/grafana/pyroscope/pkg/scrape"
+	"github.com/grafana/pyroscope/pkg/selfprofiling"
 	"github.com/grafana/pyroscope/pkg/storegateway"
 	"github.com/grafana/pyroscope/pkg/tenant"
 	"github.com/grafana/pyroscope/pkg/tracing"
 	"github.com/grafana/pyroscope/pkg/usagestats"
+	"github.com/grafana/pyroscope/pkg/usagetracker"
 	"github.com/grafana/pyroscope/pkg/util"
 	"github.com/grafana/pyroscope/pkg/util/cli"
 	"github.com/grafana/pyroscope/pkg/util/spanprofiler"
 	"github.com/grafana/pyroscope/pkg/validation"
 	"github.com/grafana/pyroscope/pkg/validation/exporter"
+	"github.com/grafana/pyroscope/pkg/validation/tenantoverrides"
 )
 
 type Config struct {
@@ -77,13 +81,17 @@ type Config struct {
 	Tracing           tracing.Config         `yaml:"tracing"`
 	OverridesExporter exporter.Config        `yaml:"overrides_exporter" doc:"hidden"`
 	RuntimeConfig     runtimeconfig.Config   `yaml:"runtime_config"`
+	TenantOverrides   tenantoverrides.Config `yaml:"tenant_overrides"`
 	Compactor         compactor.Config       `yaml:"compactor"`
 
-	Storage       StorageConfig       `yaml:"storage"`
-	SelfProfiling SelfProfilingConfig `yaml:"self_profiling,omitempty"`
+	Storage       StorageConfig        `yaml:"storage"`
+	SelfProfiling selfprofiling.Config `yaml:"self_profiling,omitempty"`
+	Scrape        scrape.Config        `yaml:"scrape"`
 
-	MultitenancyEnabled bool              `yaml:"multitenancy_enabled,omitempty"`
-	Analytics           usagestats.Config `yaml:"analytics"`
+	MultitenancyEnabled bool                       `yaml:"multitenancy_enabled,omitempty"`
+	Auth                tenant.AuthenticatorConfig `yaml:"auth"`
+	Analytics           usagestats.Config          `yaml:"analytics"`
+	Usage               usagetracker.Config        `yaml:"usage"`
 
 	ConfigFile      string `yaml:"-"`
 	ConfigExpandEnv bool   `yaml:"-"`
@@ -104,19 +112,6 @@ func (c *StorageConfig) RegisterFlagsWithContext(ctx context.Context, f *flag.Fl
 	c.Bucket.RegisterFlagsWithPrefix("storage.", f, phlarecontext.Logger(ctx))
 }
 
-type SelfProfilingConfig struct {
-	DisablePush          bool `yaml:"disable_push,omitempty"`
-	MutexProfileFraction int  `yaml:"mutex_profile_fraction,omitempty"`
-	BlockProfileRate     int  `yaml:"block_profile_rate,omitempty"`
-}
-
-func (c *SelfProfilingConfig) RegisterFlags(f *flag.FlagSet) {
-	// these are values that worked well in OG Pyroscope Cloud without adding much overhead
-	f.IntVar(&c.MutexProfileFraction, "self-profiling.mutex-profile-fraction", 5, "")
-	f.IntVar(&c.BlockProfileRate, "self-profiling.block-profile-rate", 5, "")
-	f.BoolVar(&c.DisablePush, "self-profiling.disable-push", false, "When running in single binary (--target=all) Pyroscope will push (Go SDK) profiles to itself. Set to true to disable self-profiling.")
-}
-
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.RegisterFlagsWithContext(context.Background(), f)
 }
@@ -129,6 +124,7 @@ func (c *Config) RegisterFlagsWithContext(ctx context.Context, f *flag.FlagSet)
 	f.Var(&c.Target, "target", "Comma-separated list of Pyroscope modules to load. "+
 		"The alias 'all' can be used in the list to load a number of core modules and will enable single-binary mode. ")
 	f.BoolVar(&c.MultitenancyEnabled, "auth.multitenancy-enabled", false, "When set to true, incoming HTTP requests must specify tenant ID in HTTP X-Scope-OrgId header. When set to false, tenant ID anonymous is used instead.")
+	c.Auth.RegisterFlags(f)
 	f.BoolVar(&c.ConfigExpandEnv, "config.expand-env", false, "Expands ${var} in config according to the values of the environment variables.")
 
 	c.registerServerFlagsWithChangedDefaultValues(f)
@@ -139,8 +135,11 @@ func (c *Config) RegisterFlagsWithContext(ctx context.Context, f *flag.FlagSet)
 	c.Tracing.RegisterFlags(f)
 	c.Storage.RegisterFlagsWithContext(ctx, f)
 	c.SelfProfiling.RegisterFlags(f)
+	c.Scrape.RegisterFlags(f, util.Logger)
 	c.RuntimeConfig.RegisterFlags(f)
+	c.TenantOverrides.RegisterFlags(f)
 	c.Analytics.RegisterFlags(f)
+	c.Usage.RegisterFlags(f)
 	c.LimitsConfig.RegisterFlags(f)
 	c.Compactor.RegisterFlags(f, log.NewLogfmtLogger(os.Stderr))
 	c.API.RegisterFlags(f)
@@ -181,6 +180,15 @@ func (c *Config) Validate() error {
 	if err := c.Compactor.Validate(c.PhlareDB.MaxBlockDuration); err != nil {
 		return err
 	}
+	if err := c.QueryScheduler.Validate(); err != nil {
+		return err
+	}
+	if err := c.SelfProfiling.Validate(); err != nil {
+		return err
+	}
+	if c.Auth.CredentialsFile != "" && c.Target.String() != All {
+		return errors.New("the built-in authenticator is only supported in single binary mode")
+	}
 	return c.Ingester.Validate()
 }
 
@@ -193,6 +201,7 @@ func (c *Config) ApplyDynamicConfig() cfg.Source {
 	c.QueryScheduler.ServiceDiscovery.SchedulerRing.KVStore.Store = c.Ingester.LifecyclerConfig.RingConfig.KVStore.Store
 	c.StoreGateway.ShardingRing.Ring.KVStore.Store = c.Ingester.LifecyclerConfig.RingConfig.KVStore.Store
 	c.Compactor.ShardingRing.Common.KVStore.Store = c.Ingester.LifecyclerConfig.RingConfig.KVStore.Store
+	c.Scrape.Ring.KVStore.Store = c.Ingester.LifecyclerConfig.RingConfig.KVStore.Store
 
 	return func(dst cfg.Cloneable) error {
 		return nil
@@ -224,6 +233,7 @@ type Phlare struct {
 	RuntimeConfig *runtimeconfig.Manager
 	Overrides     *validation.Overrides
 	Compactor     *compactor.MultitenantCompactor
+	distributor   *distributor.Distributor
 
 	TenantLimits validation.TenantLimits
 
@@ -231,7 +241,8 @@ type Phlare struct {
 
 	grpcGatewayMux *grpcgw.ServeMux
 
-	auth connect.Option
+	auth          connect.Option
+	authenticator *tenant.Authenticator
 }
 
 func New(cfg Config) (*Phlare, error) {
@@ -266,8 +277,17 @@ func New(cfg Config) (*Phlare, error) {
 		phlare.tracer = trace
 	}
 
-	phlare.auth = connect.WithInterceptors(tenant.NewAuthInterceptor(cfg.MultitenancyEnabled))
-	phlare.Cfg.API.HTTPAuthMiddleware = util.AuthenticateUser(cfg.MultitenancyEnabled)
+	authenticator, err := tenant.NewAuthenticator(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	if authenticator != nil {
+		phlare.authenticator = authenticator
+		phlare.Cfg.Worker.FrontendClientInterceptors = []grpc.UnaryClientInterceptor{authenticator.UnaryClientInterceptor()}
+		phlare.Cfg.QueryScheduler.FrontendClientInterceptors = []grpc.UnaryClientInterceptor{authenticator.UnaryClientInterceptor()}
+	}
+	phlare.auth = connect.WithInterceptors(tenant.NewAuthInterceptorWithAuthenticator(cfg.MultitenancyEnabled, authenticator))
+	phlare.Cfg.API.HTTPAuthMiddleware = util.AuthenticateUserWithAuthenticator(cfg.MultitenancyEnabled, authenticator)
 	phlare.Cfg.API.GrpcAuthMiddleware = phlare.auth
 
 	return phlare, nil
@@ -281,6 +301,7 @@ func (f *Phlare) setupModuleManager() error {
 	mm.RegisterModule(MemberlistKV, f.initMemberlistKV, modules.UserInvisibleModule)
 	mm.RegisterModule(Ring, f.initRing, modules.UserInvisibleModule)
 	mm.RegisterModule(RuntimeConfig, f.initRuntimeConfig, modules.UserInvisibleModule)
+	mm.RegisterModule(TenantOverrides, f.initTenantOverrides, modules.UserInvisibleModule)
 	mm.RegisterModule(Overrides, f.initOverrides, modules.UserInvisibleModule)
 	mm.RegisterModule(OverridesExporter, f.initOverridesExporter)
 	mm.RegisterModule(Ingester, f.initIngester)
@@ -290,26 +311,33 @@ func (f *Phlare) setupModuleManager() error {
 	mm.RegisterModule(Querier, f.initQuerier)
 	mm.RegisterModule(StoreGateway, f.initStoreGateway)
 	mm.RegisterModule(UsageReport, f.initUsageReport)
+	mm.RegisterModule(UsageTracker, f.initUsageTracker, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontend, f.initQueryFrontend)
 	mm.RegisterModule(QueryScheduler, f.initQueryScheduler)
 	mm.RegisterModule(Compactor, f.initCompactor)
+	mm.RegisterModule(SelfProfiling, f.initSelfProfiling)
+	mm.RegisterModule(Scrape, f.initScrape)
 	mm.RegisterModule(All, nil)
 
 	// Add dependencies
 	deps := map[string][]string{
-		All: {Ingester, Distributor, QueryScheduler, QueryFrontend, Querier, StoreGateway},
+		All: {Ingester, Distributor, QueryScheduler, QueryFrontend, Querier, StoreGateway, SelfProfiling, Scrape},
 
 		Server:            {GRPCGateway},
 		API:               {Server},
-		Distributor:       {Overrides, Ring, API, UsageReport},
+		Distributor:       {Overrides, Ring, API, UsageReport, UsageTracker},
 		Querier:           {Overrides, API, MemberlistKV, Ring, UsageReport},
 		QueryFrontend:     {OverridesExporter, API, MemberlistKV, UsageReport},
 		QueryScheduler:    {Overrides, API, MemberlistKV, UsageReport},
 		Ingester:          {Overrides, API, MemberlistKV, Storage, UsageReport},
 		StoreGateway:      {API, Storage, Overrides, MemberlistKV, UsageReport},
-		Compactor:         {API, Storage, Overrides, MemberlistKV, UsageReport},
+		Compactor:         {API, Storage, Overrides, MemberlistKV, UsageReport, UsageTracker},
+		SelfProfiling:     {Distributor},
+		Scrape:            {Distributor, MemberlistKV},
 		UsageReport:       {Storage, MemberlistKV},
-		Overrides:         {RuntimeConfig},
+		UsageTracker:      {API, Storage},
+		Overrides:         {RuntimeConfig, TenantOverrides},
+		TenantOverrides:   {RuntimeConfig, API, Storage},
 		OverridesExporter: {Overrides, MemberlistKV},
 		RuntimeConfig:     {API},
 		Ring:              {API, MemberlistKV},
@@ -366,34 +394,6 @@ func (f *Phlare) Run() error {
 		if os.Getenv("PYROSCOPE_PRINT_ROUTES") != "" {
 			printRoutes(f.Server.HTTP)
 		}
-
-		// Start profiling when Pyroscope is ready
-		if !f.Cfg.SelfProfiling.DisablePush && f.Cfg.Target.String() == All {
-			_, err := pyroscope.Start(pyroscope.Config{
-				ApplicationName: "pyroscope",
-				ServerAddress:   fmt.Sprintf("http://%s:%d", "localhost", f.Cfg.Server.HTTPListenPort),
-				Tags: map[string]string{
-					"hostname": os.Getenv("HOSTNAME"),
-					"target":   "all",
-					"version":  version.Version,
-				},
-				ProfileTypes: []pyroscope.ProfileType{
-					pyroscope.ProfileCPU,
-					pyroscope.ProfileAllocObjects,
-					pyroscope.ProfileAllocSpace,
-					pyroscope.ProfileInuseObjects,
-					pyroscope.ProfileInuseSpace,
-					pyroscope.ProfileGoroutines,
-					pyroscope.ProfileMutexCount,
-					pyroscope.ProfileMutexDuration,
-					pyroscope.ProfileBlockCount,
-					pyroscope.ProfileBlockDuration,
-				},
-			})
-			if err != nil {
-				level.Warn(f.logger).Log("msg", "failed to start pyroscope", "err", err)
-			}
-		}
 	}
 
 	if err = f.API.RegisterCatchAll(); err != nil {
@@ -80,3 +80,18 @@ func TestConfigDiff(t *testing.T) {
 		require.Equal(t, "limits:\n    max_label_name_length: 123\n", string(result.Data))
 	})
 }
+
+func TestSelfProfiledComponent(t *testing.T) {
+	for _, tt := range []struct {
+		target   []string
+		expected string
+	}{
+		{target: []string{All}, expected: "all"},
+		{target: []string{Querier, SelfProfiling}, expected: "querier"},
+		{target: []string{Ingester, SelfProfiling, StoreGateway}, expected: "ingester,store-gateway"},
+		{target: []string{SelfProfiling}, expected: "self-profiling"},
+	} {
+		f := &Phlare{Cfg: Config{Target: tt.target}}
+		require.Equal(t, tt.expected, f.selfProfiledComponent())
+	}
+}
@@ -168,6 +168,15 @@ func (m *Meta) FileByRelPath(name string) *File {
 	return nil
 }
 
+// SizeBytes returns the total size of the block files.
+func (m *Meta) SizeBytes() uint64 {
+	var size uint64
+	for _, f := range m.Files {
+		size += f.SizeBytes
+	}
+	return size
+}
+
 func (m *Meta) InRange(start, end model.Time) bool {
 	return InRange(m.MinTime, m.MaxTime, start, end)
 }
@@ -0,0 +1,579 @@
+package phlaredb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/bloom"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
+	phlareparquet "github.com/grafana/pyroscope/pkg/parquet"
+	"github.com/grafana/pyroscope/pkg/phlaredb/block"
+	"github.com/grafana/pyroscope/pkg/phlaredb/query"
+	schemav1 "github.com/grafana/pyroscope/pkg/phlaredb/schemas/v1"
+	"github.com/grafana/pyroscope/pkg/phlaredb/symdb"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+// The block filters file holds bloom filters of the span IDs and function
+// names referenced by the samples of each profiles table row group.
+//
+// Layout (little endian):
+//
+//	magic [4]byte | version uint32 | row groups uint32 |
+//	  rows int64 | span IDs filter | function names filter | ...
+//
+// Each filter is prefixed with its size in bytes (uint32).
+// An empty filter matches nothing. Version 1 has no function names
+// filter: its blocks may contain any function.
+const (
+	BlockFiltersFilename = "filters.bloom"
+
+	blockFiltersMagic        = "PBF1"
+	blockFiltersFormatV1     = 1
+	blockFiltersFormatV2     = 2
+	blockFiltersBitsPerValue = 10
+
+	filterSpanID   = "span_id"
+	filterFunction = "function"
+)
+
+var errInvalidBlockFilters = errors.New("invalid block filters")
+
+// BlockFilter is implemented by queriers that maintain bloom filters of the
+// span IDs and function names found in the block profiles. The methods
+// return false only if the block definitely has no match. The filters are
+// loaded independently of the block files, so that the block doesn't have
+// to be opened.
+type BlockFilter interface {
+	MayContainSpans(ctx context.Context, spans phlaremodel.SpanSelector) (bool, error)
+	MayContainFunctions(ctx context.Context, names []string) (bool, error)
+}
+
+// ContextWithSpanSelector returns a context restricting the profiles selected
+// from the blocks to the row groups that may have samples of the spans.
+func ContextWithSpanSelector(ctx context.Context, spans phlaremodel.SpanSelector) context.Context {
+	return context.WithValue(ctx, spanSelectorContextKey, spans)
+}
+
+// SpanSelectorFromContext returns the span selector of the context, if any.
+func SpanSelectorFromContext(ctx context.Context) (phlaremodel.SpanSelector, bool) {
+	spans, ok := ctx.Value(spanSelectorContextKey).(phlaremodel.SpanSelector)
+	return spans, ok
+}
+
+// ContextWithFunctionSelector returns a context restricting the profiles
+// selected from the blocks to the row groups that may have samples with
+// any of the functions.
+func ContextWithFunctionSelector(ctx context.Context, names []string) context.Context {
+	return context.WithValue(ctx, functionSelectorContextKey, names)
+}
+
+// FunctionSelectorFromContext returns the function names of the context, if any.
+func FunctionSelectorFromContext(ctx context.Context) ([]string, bool) {
+	names, ok := ctx.Value(functionSelectorContextKey).([]string)
+	return names, ok && len(names) > 0
+}
+
+type blockFilters struct {
+	version   uint32
+	rowGroups []rowGroupFilters
+}
+
+type rowGroupFilters struct {
+	rows      int64
+	spans     bloom.SplitBlockFilter
+	functions bloom.SplitBlockFilter
+}
+
+func hashSpanID(id uint64) uint64 { return bloom.XXH64{}.Sum64Uint64(id) }
+
+func hashFunctionName(name string) uint64 { return bloom.XXH64{}.Sum64([]byte(name)) }
+
+func mayContain(f bloom.SplitBlockFilter, hashes []uint64) bool {
+	if len(f) == 0 {
+		return false
+	}
+	for _, h := range hashes {
+		if f.Check(h) {
+			return true
+		}
+	}
+	return false
+}
+
+func spanHashes(spans phlaremodel.SpanSelector) []uint64 {
+	hashes := make([]uint64, 0, len(spans))
+	for id := range spans {
+		hashes = append(hashes, hashSpanID(id))
+	}
+	return hashes
+}
+
+func functionHashes(names []string) []uint64 {
+	hashes := make([]uint64, len(names))
+	for i, name := range names {
+		hashes[i] = hashFunctionName(name)
+	}
+	return hashes
+}
+
+// filterSelector holds the hashes of the values a query looks for.
+type filterSelector struct {
+	name   string
+	hashes []uint64
+}
+
+// selectorsFromContext returns the selectors of the span IDs and function
+// names of the context.
+func selectorsFromContext(ctx context.Context) []filterSelector {
+	var selectors []filterSelector
+	if spans, ok := SpanSelectorFromContext(ctx); ok {
+		selectors = append(selectors, filterSelector{name: filterSpanID, hashes: spanHashes(spans)})
+	}
+	if names, ok := FunctionSelectorFromContext(ctx); ok {
+		selectors = append(selectors, filterSelector{name: filterFunction, hashes: functionHashes(names)})
+	}
+	return selectors
+}
+
+// mayContain returns false if the row group definitely has none of the
+// values of the selector.
+func (f *blockFilters) mayContain(rg int, s filterSelector) bool {
+	switch s.name {
+	case filterSpanID:
+		return mayContain(f.rowGroups[rg].spans, s.hashes)
+	case filterFunction:
+		return f.version < blockFiltersFormatV2 || mayContain(f.rowGroups[rg].functions, s.hashes)
+	}
+	return true
+}
+
+func (f *blockFilters) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString(blockFiltersMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(blockFiltersFormatV2))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(f.rowGroups)))
+	for _, rg := range f.rowGroups {
+		_ = binary.Write(&buf, binary.LittleEndian, rg.rows)
+		for _, filter := range []bloom.SplitBlockFilter{rg.spans, rg.functions} {
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(len(filter)*bloom.BlockSize))
+			buf.Write(filter.Bytes())
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+func (f *blockFilters) UnmarshalBinary(b []byte) error {
+	if len(b) < 12 || string(b[:4]) != blockFiltersMagic {
+		return errInvalidBlockFilters
+	}
+	f.version = binary.LittleEndian.Uint32(b[4:8])
+	if f.version != blockFiltersFormatV1 && f.version != blockFiltersFormatV2 {
+		return fmt.Errorf("%w: unsupported version %d", errInvalidBlockFilters, f.version)
+	}
+	n := binary.LittleEndian.Uint32(b[8:12])
+	b = b[12:]
+	readFilter := func() (bloom.SplitBlockFilter, error) {
+		if len(b) < 4 {
+			return nil, errInvalidBlockFilters
+		}
+		size := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		if uint32(len(b)) < size || size%bloom.BlockSize != 0 {
+			return nil, errInvalidBlockFilters
+		}
+		// The filter is copied to make sure it's properly aligned.
+		filter := make(bloom.SplitBlockFilter, size/bloom.BlockSize)
+		copy(filter.Bytes(), b[:size])
+		b = b[size:]
+		return filter, nil
+	}
+	f.rowGroups = make([]rowGroupFilters, 0, n)
+	for i := uint32(0); i < n; i++ {
+		if len(b) < 8 {
+			return errInvalidBlockFilters
+		}
+		rg := rowGroupFilters{rows: int64(binary.LittleEndian.Uint64(b))}
+		b = b[8:]
+		var err error
+		if rg.spans, err = readFilter(); err != nil {
+			return err
+		}
+		if f.version >= blockFiltersFormatV2 {
+			if rg.functions, err = readFilter(); err != nil {
+				return err
+			}
+		}
+		f.rowGroups = append(f.rowGroups, rg)
+	}
+	if len(b) != 0 {
+		return errInvalidBlockFilters
+	}
+	return nil
+}
+
+func openBlockFilters(ctx context.Context, bucket phlareobj.BucketReader) (*blockFilters, error) {
+	r, err := bucket.Get(ctx, BlockFiltersFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(util.Logger, r, "closing block filters")
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var f blockFilters
+	if err = f.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// writeBlockFilters creates the filters of the profiles table written to
+// the block directory. The function names of the samples are resolved
+// with the symbols of the block.
+func writeBlockFilters(ctx context.Context, dir string, symbols symdb.SymbolsReader) (block.File, error) {
+	profilesPath := filepath.Join(dir, (&schemav1.ProfilePersister{}).Name()+block.ParquetSuffix)
+	f, err := os.Open(profilesPath)
+	if err != nil {
+		return block.File{}, err
+	}
+	defer runutil.CloseWithLogOnErr(util.Logger, f, "closing profiles table")
+	stat, err := f.Stat()
+	if err != nil {
+		return block.File{}, err
+	}
+	pf, err := parquet.OpenFile(f, stat.Size(), parquet.SkipPageIndex(true), parquet.SkipBloomFilters(true))
+	if err != nil {
+		return block.File{}, fmt.Errorf("opening profiles table: %w", err)
+	}
+	spanIDColumn := -1
+	if c, ok := pf.Schema().Lookup("Samples", "list", "element", "SpanID"); ok {
+		spanIDColumn = c.ColumnIndex
+	}
+
+	var filters blockFilters
+	b := newRowGroupFiltersBuilder(symbols, spanIDColumn)
+	for _, rg := range pf.RowGroups() {
+		if err = b.addRows(rg.Rows()); err != nil {
+			return block.File{}, err
+		}
+		var rgf rowGroupFilters
+		if rgf, err = b.build(ctx); err != nil {
+			return block.File{}, err
+		}
+		rgf.rows = rg.NumRows()
+		filters.rowGroups = append(filters.rowGroups, rgf)
+	}
+
+	out, err := os.Create(filepath.Join(dir, BlockFiltersFilename))
+	if err != nil {
+		return block.File{}, err
+	}
+	n, err := filters.WriteTo(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return block.File{}, fmt.Errorf("writing block filters: %w", err)
+	}
+	return block.File{
+		RelPath:   BlockFiltersFilename,
+		SizeBytes: uint64(n),
+	}, nil
+}
+
+type rowGroupFiltersBuilder struct {
+	symbols      symdb.SymbolsReader
+	spanIDColumn int
+
+	spans       map[uint64]struct{}
+	stacktraces map[uint64][]uint32 // Stack trace IDs by partition.
+	locations   map[int32]struct{}
+	functions   map[string]struct{}
+}
+
+func newRowGroupFiltersBuilder(symbols symdb.SymbolsReader, spanIDColumn int) *rowGroupFiltersBuilder {
+	return &rowGroupFiltersBuilder{
+		symbols:      symbols,
+		spanIDColumn: spanIDColumn,
+		spans:        make(map[uint64]struct{}),
+		stacktraces:  make(map[uint64][]uint32),
+		locations:    make(map[int32]struct{}),
+		functions:    make(map[string]struct{}),
+	}
+}
+
+func (b *rowGroupFiltersBuilder) addRows(rows parquet.Rows) error {
+	defer runutil.CloseWithLogOnErr(util.Logger, rows, "closing row group rows")
+	it := phlareparquet.NewBufferedRowReaderIterator(rows, 32)
+	defer runutil.CloseWithLogOnErr(util.Logger, it, "closing row group iterator")
+	for it.Next() {
+		row := schemav1.ProfileRow(it.At())
+		partition := row.StacktracePartitionID()
+		row.ForStacktraceIDsValues(func(values []parquet.Value) {
+			for _, v := range values {
+				b.stacktraces[partition] = append(b.stacktraces[partition], v.Uint32())
+			}
+		})
+		if b.spanIDColumn < 0 {
+			continue
+		}
+		for _, v := range row {
+			if v.Column() == b.spanIDColumn && !v.IsNull() {
+				if id := v.Uint64(); id != 0 {
+					b.spans[id] = struct{}{}
+				}
+			}
+		}
+	}
+	return it.Err()
+}
+
+func (b *rowGroupFiltersBuilder) build(ctx context.Context) (rowGroupFilters, error) {
+	partitions := maps.Keys(b.stacktraces)
+	slices.Sort(partitions)
+	for _, p := range partitions {
+		if err := b.resolveFunctions(ctx, p, b.stacktraces[p]); err != nil {
+			return rowGroupFilters{}, err
+		}
+	}
+
+	var f rowGroupFilters
+	if len(b.spans) > 0 {
+		f.spans = make(bloom.SplitBlockFilter, bloom.NumSplitBlocksOf(int64(len(b.spans)), blockFiltersBitsPerValue))
+		for id := range b.spans {
+			f.spans.Insert(hashSpanID(id))
+		}
+	}
+	if len(b.functions) > 0 {
+		f.functions = make(bloom.SplitBlockFilter, bloom.NumSplitBlocksOf(int64(len(b.functions)), blockFiltersBitsPerValue))
+		for name := range b.functions {
+			f.functions.Insert(hashFunctionName(name))
+		}
+	}
+
+	for k := range b.spans {
+		delete(b.spans, k)
+	}
+	for k := range b.stacktraces {
+		delete(b.stacktraces, k)
+	}
+	for k := range b.functions {
+		delete(b.functions, k)
+	}
+	return f, nil
+}
+
+func (b *rowGroupFiltersBuilder) resolveFunctions(ctx context.Context, partition uint64, stacktraces []uint32) error {
+	pr, err := b.symbols.Partition(ctx, partition)
+	if err != nil {
+		return fmt.Errorf("reading symbols partition %d: %w", partition, err)
+	}
+	defer pr.Release()
+	// Stack traces must be resolved in the ascending order.
+	slices.Sort(stacktraces)
+	stacktraces = slices.Compact(stacktraces)
+	symbols := pr.Symbols()
+	if err = symbols.Stacktraces.ResolveStacktraceLocations(ctx, b, stacktraces); err != nil {
+		return fmt.Errorf("resolving stack traces of partition %d: %w", partition, err)
+	}
+	for loc := range b.locations {
+		delete(b.locations, loc)
+		if int(loc) >= len(symbols.Locations) {
+			continue
+		}
+		for _, line := range symbols.Locations[loc].Line {
+			if int(line.FunctionId) >= len(symbols.Functions) {
+				continue
+			}
+			if name := symbols.Functions[line.FunctionId].Name; int(name) < len(symbols.Strings) {
+				b.functions[symbols.Strings[name]] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *rowGroupFiltersBuilder) InsertStacktrace(_ uint32, locations []int32) {
+	for _, loc := range locations {
+		b.locations[loc] = struct{}{}
+	}
+}
+
+// loadFilters returns the block filters, or nil if the block has none.
+// The filters are loaded independently of the block files, so that the
+// block doesn't have to be opened to be skipped.
+func (b *singleBlockQuerier) loadFilters(ctx context.Context) (*blockFilters, error) {
+	b.filtersLock.Lock()
+	defer b.filtersLock.Unlock()
+	if b.filters != nil || b.meta.FileByRelPath(BlockFiltersFilename) == nil {
+		return b.filters, nil
+	}
+	f, err := openBlockFilters(ctx, b.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("loading block filters: %w", err)
+	}
+	b.filters = f
+	return f, nil
+}
+
+func (b *singleBlockQuerier) MayContainSpans(ctx context.Context, spans phlaremodel.SpanSelector) (bool, error) {
+	return b.mayContain(ctx, filterSelector{name: filterSpanID, hashes: spanHashes(spans)})
+}
+
+func (b *singleBlockQuerier) MayContainFunctions(ctx context.Context, names []string) (bool, error) {
+	return b.mayContain(ctx, filterSelector{name: filterFunction, hashes: functionHashes(names)})
+}
+
+func (b *singleBlockQuerier) mayContain(ctx context.Context, s filterSelector) (bool, error) {
+	f, err := b.loadFilters(ctx)
+	if err != nil || f == nil {
+		return true, err
+	}
+	for i := range f.rowGroups {
+		if f.mayContain(i, s) {
+			return true, nil
+		}
+	}
+	b.metrics.blocksSkipped.WithLabelValues(s.name).Inc()
+	return false, nil
+}
+
+// selectRowGroups returns the row groups of the profiles table to be read
+// by the query, or nil if all of them are. The row groups which can't have
+// the spans or the functions selected by the context are skipped.
+func (b *singleBlockQuerier) selectRowGroups(ctx context.Context) ([]parquet.RowGroup, error) {
+	selectors := selectorsFromContext(ctx)
+	if len(selectors) == 0 {
+		return nil, nil
+	}
+	f, err := b.loadFilters(ctx)
+	if err != nil || f == nil {
+		return nil, err
+	}
+	rowGroups := b.profiles.file.RowGroups()
+	if len(rowGroups) != len(f.rowGroups) {
+		return nil, nil
+	}
+	selected := make([]parquet.RowGroup, len(rowGroups))
+	var skipped bool
+	for i, rg := range rowGroups {
+		selected[i] = rg
+		for _, s := range selectors {
+			if !f.mayContain(i, s) {
+				selected[i] = skippedRowGroup{rg}
+				b.metrics.rowGroupsSkipped.WithLabelValues(s.name).Inc()
+				skipped = true
+				break
+			}
+		}
+	}
+	if !skipped {
+		return nil, nil
+	}
+	return selected, nil
+}
+
+// skippedRowGroup is a row group that is not read: it keeps its place among
+// the row groups, so that the row numbers are not shifted, but its column
+// chunks are rejected by rowGroupsPredicate.
+type skippedRowGroup struct{ parquet.RowGroup }
+
+type skippedColumnChunk struct{ parquet.ColumnChunk }
+
+func (rg skippedRowGroup) ColumnChunks() []parquet.ColumnChunk {
+	chunks := rg.RowGroup.ColumnChunks()
+	skipped := make([]parquet.ColumnChunk, len(chunks))
+	for i, c := range chunks {
+		skipped[i] = skippedColumnChunk{c}
+	}
+	return skipped
+}
+
+// rowGroupsPredicate rejects the column chunks of the skipped row groups,
+// and defers to the predicate, if any, otherwise.
+type rowGroupsPredicate struct{ predicate query.Predicate }
+
+func (p rowGroupsPredicate) KeepColumnChunk(c parquet.ColumnChunk) bool {
+	if _, ok := c.(skippedColumnChunk); ok {
+		return false
+	}
+	return p.predicate == nil || p.predicate.KeepColumnChunk(c)
+}
+
+func (p rowGroupsPredicate) KeepPage(page parquet.Page) bool {
+	return p.predicate == nil || p.predicate.KeepPage(page)
+}
+
+func (p rowGroupsPredicate) KeepValue(v parquet.Value) bool {
+	return p.predicate == nil || p.predicate.KeepValue(v)
+}
+
+// filterBySpans drops the queriers that can't have any of the spans.
+func (queriers Queriers) filterBySpans(ctx context.Context, spans phlaremodel.SpanSelector) (Queriers, error) {
+	result := make(Queriers, 0, len(queriers))
+	for _, q := range queriers {
+		if f, ok := q.(BlockFilter); ok {
+			match, err := f.MayContainSpans(ctx, spans)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		result = append(result, q)
+	}
+	return result, nil
+}
+
+// filterByFunctions drops the queriers that can't have any of the functions.
+func (queriers Queriers) filterByFunctions(ctx context.Context, names []string) (Queriers, error) {
+	result := make(Queriers, 0, len(queriers))
+	for _, q := range queriers {
+		if f, ok := q.(BlockFilter); ok {
+			match, err := f.MayContainFunctions(ctx, names)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		result = append(result, q)
+	}
+	return result, nil
+}
+
+// filterStacksByFunctions returns the tree of the stack traces that have
+// at least one of the functions.
+func filterStacksByFunctions(t *phlaremodel.Tree, names []string) *phlaremodel.Tree {
+	filtered := new(phlaremodel.Tree)
+	t.IterateStacks(func(_ string, self int64, stack []string) {
+		for _, name := range stack {
+			if slices.Contains(names, name) {
+				// The stack is ordered from the leaf to the root.
+				stack = slices.Clone(stack)
+				for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+					stack[i], stack[j] = stack[j], stack[i]
+				}
+				filtered.InsertStack(self, stack...)
+				return
+			}
+		}
+	})
+	return filtered
+}
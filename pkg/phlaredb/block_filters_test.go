@@ -0,0 +1,155 @@
+package phlaredb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go/bloom"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/pkg/iter"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/objstore/providers/filesystem"
+)
+
+func TestBlockFilters_Marshal(t *testing.T) {
+	spans := make(bloom.SplitBlockFilter, bloom.NumSplitBlocksOf(2, blockFiltersBitsPerValue))
+	spans.Insert(hashSpanID(1))
+	spans.Insert(hashSpanID(2))
+	functions := make(bloom.SplitBlockFilter, bloom.NumSplitBlocksOf(1, blockFiltersBitsPerValue))
+	functions.Insert(hashFunctionName("foo"))
+	f := blockFilters{rowGroups: []rowGroupFilters{
+		{rows: 10, spans: spans},
+		{rows: 5, functions: functions},
+	}}
+	var buf bytes.Buffer
+	_, err := f.WriteTo(&buf)
+	require.NoError(t, err)
+
+	var actual blockFilters
+	require.NoError(t, actual.UnmarshalBinary(buf.Bytes()))
+	require.Len(t, actual.rowGroups, 2)
+	require.Equal(t, int64(10), actual.rowGroups[0].rows)
+	require.True(t, mayContain(actual.rowGroups[0].spans, []uint64{hashSpanID(2)}))
+	require.False(t, mayContain(actual.rowGroups[1].spans, []uint64{hashSpanID(2)}))
+	foo := filterSelector{name: filterFunction, hashes: functionHashes([]string{"foo"})}
+	require.False(t, actual.mayContain(0, foo))
+	require.True(t, actual.mayContain(1, foo))
+
+	require.ErrorIs(t, actual.UnmarshalBinary(buf.Bytes()[:buf.Len()-1]), errInvalidBlockFilters)
+	require.ErrorIs(t, actual.UnmarshalBinary([]byte("invalid")), errInvalidBlockFilters)
+}
+
+func TestBlockFilters_UnmarshalV1(t *testing.T) {
+	// Version 1 has no function names filter.
+	var buf bytes.Buffer
+	buf.WriteString(blockFiltersMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(blockFiltersFormatV1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(1))
+	_ = binary.Write(&buf, binary.LittleEndian, int64(10))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0))
+
+	var f blockFilters
+	require.NoError(t, f.UnmarshalBinary(buf.Bytes()))
+	require.Len(t, f.rowGroups, 1)
+	require.False(t, f.mayContain(0, filterSelector{name: filterSpanID, hashes: []uint64{hashSpanID(1)}}))
+	require.True(t, f.mayContain(0, filterSelector{name: filterFunction, hashes: functionHashes([]string{"foo"})}))
+}
+
+func TestBlockFilters_SkipSpans(t *testing.T) {
+	ctx := testContext(t)
+	db, err := New(ctx, Config{
+		DataPath:         contextDataDir(ctx),
+		MaxBlockDuration: time.Duration(100000) * time.Minute, // we will manually flush
+	}, NoLimit, ctx.localBucketClient)
+	require.NoError(t, err)
+	require.NoError(t, db.Ingest(ctx, generateProfileWithSpans(t, 1000), uuid.New(), &typesv1.LabelPair{
+		Name:  model.MetricNameLabel,
+		Value: "process_cpu",
+	}))
+	require.NoError(t, db.Flush(context.Background(), true, ""))
+
+	b, err := filesystem.NewBucket(filepath.Join(contextDataDir(ctx), PathLocal))
+	require.NoError(t, err)
+	q := NewBlockQuerier(context.Background(), b)
+	require.NoError(t, q.Sync(context.Background()))
+	require.Len(t, q.queriers, 1)
+	bq := q.queriers[0]
+	require.NotNil(t, bq.meta.FileByRelPath(BlockFiltersFilename))
+
+	matching, err := phlaremodel.NewSpanSelector([]string{"badbadbadbadbadb"})
+	require.NoError(t, err)
+	other, err := phlaremodel.NewSpanSelector([]string{"0000000000000001"})
+	require.NoError(t, err)
+
+	match, err := bq.MayContainSpans(ctx, matching)
+	require.NoError(t, err)
+	require.True(t, match)
+	match, err = bq.MayContainSpans(ctx, other)
+	require.NoError(t, err)
+	require.False(t, match)
+	require.Equal(t, float64(1), testutil.ToFloat64(bq.metrics.blocksSkipped.WithLabelValues(filterSpanID)))
+
+	match, err = bq.MayContainFunctions(ctx, []string{"foo", "qux"})
+	require.NoError(t, err)
+	require.True(t, match)
+	match, err = bq.MayContainFunctions(ctx, []string{"qux"})
+	require.NoError(t, err)
+	require.False(t, match)
+	require.Equal(t, float64(1), testutil.ToFloat64(bq.metrics.blocksSkipped.WithLabelValues(filterFunction)))
+
+	// Queriers that can't match are dropped.
+	queriers, err := Queriers{bq}.filterBySpans(ctx, other)
+	require.NoError(t, err)
+	require.Empty(t, queriers)
+	queriers, err = Queriers{bq}.filterByFunctions(ctx, []string{"qux"})
+	require.NoError(t, err)
+	require.Empty(t, queriers)
+
+	// Row groups that can't match are not read.
+	req := &ingestv1.SelectProfilesRequest{
+		LabelSelector: `{}`,
+		Type:          mustParseProfileSelector(t, "process_cpu:cpu:nanoseconds:cpu:nanoseconds"),
+		Start:         0,
+		End:           int64(model.TimeFromUnixNano(int64(1 * time.Minute))),
+	}
+	for _, tc := range []struct {
+		ctx      context.Context
+		profiles int
+	}{
+		{ctx: ContextWithSpanSelector(ctx, matching), profiles: 1},
+		{ctx: ContextWithSpanSelector(ctx, other), profiles: 0},
+		{ctx: ContextWithFunctionSelector(ctx, []string{"bar"}), profiles: 1},
+		{ctx: ContextWithFunctionSelector(ctx, []string{"qux"}), profiles: 0},
+	} {
+		profileIt, err := bq.SelectMatchingProfiles(tc.ctx, req)
+		require.NoError(t, err)
+		profiles, err := iter.Slice(profileIt)
+		require.NoError(t, err)
+		require.Len(t, profiles, tc.profiles)
+	}
+	require.Equal(t, float64(1), testutil.ToFloat64(bq.metrics.rowGroupsSkipped.WithLabelValues(filterSpanID)))
+	require.Equal(t, float64(1), testutil.ToFloat64(bq.metrics.rowGroupsSkipped.WithLabelValues(filterFunction)))
+}
+
+func Test_filterStacksByFunctions(t *testing.T) {
+	tree := new(phlaremodel.Tree)
+	tree.InsertStack(1, "main", "foo", "bar")
+	tree.InsertStack(2, "main", "baz")
+	tree.InsertStack(3, "main", "foo")
+
+	expected := new(phlaremodel.Tree)
+	expected.InsertStack(1, "main", "foo", "bar")
+	expected.InsertStack(3, "main", "foo")
+	require.Equal(t, expected.String(), filterStacksByFunctions(tree, []string{"foo"}).String())
+	require.Zero(t, filterStacksByFunctions(tree, []string{"qux"}).Total())
+}
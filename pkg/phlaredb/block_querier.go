@@ -44,6 +44,7 @@ import (
 	"github.com/grafana/pyroscope/pkg/phlaredb/symdb"
 	"github.com/grafana/pyroscope/pkg/phlaredb/tsdb/index"
 	"github.com/grafana/pyroscope/pkg/util"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 const (
@@ -296,6 +297,9 @@ type singleBlockQuerier struct {
 	index    *index.Reader
 	profiles parquetReader[*schemav1.Profile, *schemav1.ProfilePersister]
 	symbols  symbolsResolver
+
+	filtersLock sync.Mutex
+	filters     *blockFilters
 }
 
 func NewSingleBlockQuerierFromMeta(phlarectx context.Context, bucketReader phlareobj.Bucket, meta *block.Meta) *singleBlockQuerier {
@@ -354,8 +358,11 @@ func (b *singleBlockQuerier) LabelNames(ctx context.Context, req *connect.Reques
 func (b *singleBlockQuerier) Close() error {
 	b.openLock.Lock()
 	defer func() {
+		if b.opened {
+			b.metrics.blockOpened.Dec()
+		}
+		b.opened = false
 		b.openLock.Unlock()
-		b.metrics.blockOpened.Dec()
 	}()
 	errs := multierror.New()
 	if b.index != nil {
@@ -376,7 +383,9 @@ func (b *singleBlockQuerier) Close() error {
 			errs.Add(err)
 		}
 	}
-	b.opened = false
+	b.filtersLock.Lock()
+	b.filters = nil
+	b.filtersLock.Unlock()
 	return errs.Err()
 }
 
@@ -683,10 +692,21 @@ func MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[in
 		otlog.String("profile_id", request.Type.ID),
 	)
 
+	functions := r.FunctionNames
+	if len(functions) > 0 {
+		// The blocks and the row groups that can't have the functions are skipped.
+		ctx = ContextWithFunctionSelector(ctx, functions)
+	}
+
 	queriers, err := blockGetter(ctx, model.Time(request.Start), model.Time(request.End))
 	if err != nil {
 		return err
 	}
+	if len(functions) > 0 {
+		if queriers, err = queriers.filterByFunctions(ctx, functions); err != nil {
+			return err
+		}
+	}
 
 	iters, err := SelectMatchingProfiles(ctx, request, queriers)
 	if err != nil {
@@ -704,6 +724,7 @@ func MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[in
 
 	var m sync.Mutex
 	t := new(phlaremodel.Tree)
+	ql := limiter.QueryLimiterFromContextWithFallback(ctx)
 	g, ctx := errgroup.WithContext(ctx)
 
 	for i, querier := range queriers {
@@ -719,10 +740,12 @@ func MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[in
 			if err != nil {
 				return err
 			}
+			if len(functions) > 0 {
+				merge = filterStacksByFunctions(merge, functions)
+			}
 			m.Lock()
-			t.Merge(merge)
-			m.Unlock()
-			return nil
+			defer m.Unlock()
+			return ql.AddTreeNodes(t.Merge(merge))
 		}))
 	}
 
@@ -787,11 +810,16 @@ func MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.M
 	if err != nil {
 		return err
 	}
+	// The blocks and the row groups that can't have the spans are skipped.
+	ctx = ContextWithSpanSelector(ctx, spanSelector)
 
 	queriers, err := blockGetter(ctx, model.Time(request.Start), model.Time(request.End))
 	if err != nil {
 		return err
 	}
+	if queriers, err = queriers.filterBySpans(ctx, spanSelector); err != nil {
+		return err
+	}
 
 	iters, err := SelectMatchingProfiles(ctx, &ingestv1.SelectProfilesRequest{
 		LabelSelector: request.LabelSelector,
@@ -814,6 +842,7 @@ func MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.M
 
 	var m sync.Mutex
 	t := new(phlaremodel.Tree)
+	ql := limiter.QueryLimiterFromContextWithFallback(ctx)
 	g, ctx := errgroup.WithContext(ctx)
 	for i, querier := range queriers {
 		querier := querier
@@ -829,9 +858,8 @@ func MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.M
 				return err
 			}
 			m.Lock()
-			t.Merge(merge)
-			m.Unlock()
-			return nil
+			defer m.Unlock()
+			return ql.AddTreeNodes(t.Merge(merge))
 		}))
 	}
 
@@ -1198,17 +1226,28 @@ func (b *singleBlockQuerier) SelectMatchingProfiles(ctx context.Context, params
 
 	var buf [][]parquet.Value
 
+	rowGroups, err := b.selectRowGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	columnIter := b.profiles.columnIter
+	if rowGroups != nil {
+		columnIter = func(ctx context.Context, columnName string, predicate query.Predicate, alias string) query.Iterator {
+			return b.profiles.rowGroupsColumnIter(ctx, rowGroups, columnName, rowGroupsPredicate{predicate}, alias)
+		}
+	}
+
 	pIt := query.NewBinaryJoinIterator(
 		0,
-		b.profiles.columnIter(ctx, "SeriesIndex", query.NewMapPredicate(lblsPerRef), "SeriesIndex"),
-		b.profiles.columnIter(ctx, "TimeNanos", query.NewIntBetweenPredicate(model.Time(params.Start).UnixNano(), model.Time(params.End).UnixNano()), "TimeNanos"),
+		columnIter(ctx, "SeriesIndex", query.NewMapPredicate(lblsPerRef), "SeriesIndex"),
+		columnIter(ctx, "TimeNanos", query.NewIntBetweenPredicate(model.Time(params.Start).UnixNano(), model.Time(params.End).UnixNano()), "TimeNanos"),
 	)
 
 	if b.meta.Version >= 2 {
 		pIt = query.NewBinaryJoinIterator(
 			0,
 			pIt,
-			b.profiles.columnIter(ctx, "StacktracePartition", nil, "StacktracePartition"),
+			columnIter(ctx, "StacktracePartition", nil, "StacktracePartition"),
 		)
 		buf = make([][]parquet.Value, 3)
 	} else {
@@ -1240,6 +1279,9 @@ func (b *singleBlockQuerier) SelectMatchingProfiles(ctx context.Context, params
 			RowNum:              res.RowNumber[0],
 		})
 	}
+	if err := pIt.Err(); err != nil {
+		return nil, err
+	}
 	if len(currentSeriesSlice) > 0 {
 		iters = append(iters, iter.NewSliceIterator(currentSeriesSlice))
 	}
@@ -1476,12 +1518,16 @@ func (r *parquetReader[M, P]) relPath() string {
 }
 
 func (r *parquetReader[M, P]) columnIter(ctx context.Context, columnName string, predicate query.Predicate, alias string) query.Iterator {
+	return r.rowGroupsColumnIter(ctx, r.file.RowGroups(), columnName, predicate, alias)
+}
+
+func (r *parquetReader[M, P]) rowGroupsColumnIter(ctx context.Context, rowGroups []parquet.RowGroup, columnName string, predicate query.Predicate, alias string) query.Iterator {
 	index, _ := query.GetColumnIndexByPath(r.file.File, columnName)
 	if index == -1 {
 		return query.NewErrIterator(fmt.Errorf("column '%s' not found in parquet file '%s'", columnName, r.relPath()))
 	}
 	ctx = query.AddMetricsToContext(ctx, r.metrics.query)
-	return query.NewSyncIterator(ctx, r.file.RowGroups(), index, columnName, 1000, predicate, alias)
+	return query.NewSyncIterator(ctx, rowGroups, index, columnName, 1000, predicate, alias)
 }
 
 func repeatedColumnIter[T any](ctx context.Context, source Source, columnName string, rows iter.Iterator[T]) iter.Iterator[*query.RepeatedRow[T]] {
@@ -0,0 +1,348 @@
+package phlaredb
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/common/model"
+	"golang.org/x/exp/slices"
+
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/objstore/client"
+	"github.com/grafana/pyroscope/pkg/objstore/providers/filesystem"
+	phlareparquet "github.com/grafana/pyroscope/pkg/parquet"
+	"github.com/grafana/pyroscope/pkg/phlaredb/block"
+	schemav1 "github.com/grafana/pyroscope/pkg/phlaredb/schemas/v1"
+	"github.com/grafana/pyroscope/pkg/phlaredb/symdb"
+	"github.com/grafana/pyroscope/pkg/phlaredb/tsdb/index"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+// BlockVerification is the result of a block verification.
+type BlockVerification struct {
+	Meta *block.Meta
+	// Issues found in the block files. Rows of the profiles
+	// table are only verified if the block can be opened.
+	Issues []string
+	// TotalRows is the number of rows in the profiles table.
+	TotalRows int64
+	// CorruptedRows maps the row numbers of the profiles
+	// table to the reason the row is considered corrupted.
+	CorruptedRows map[int64]string
+
+	readable bool
+}
+
+// OK returns true if no issues and no corrupted rows were found.
+func (v *BlockVerification) OK() bool {
+	return len(v.Issues) == 0 && len(v.CorruptedRows) == 0
+}
+
+func (v *BlockVerification) addIssue(format string, args ...any) {
+	v.Issues = append(v.Issues, fmt.Sprintf(format, args...))
+}
+
+// VerifyLocalBlock checks the consistency of the block in the given
+// directory: the files must match meta.json, the TSDB index must be
+// readable and sorted, the profiles must be ordered by series and time,
+// and all the stack traces referenced by profiles must exist in symdb.
+func VerifyLocalBlock(phlarectx context.Context, dir string) (*BlockVerification, error) {
+	v, err := newBlockVerifier(phlarectx, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer v.close()
+	return v.result, v.verify(phlarectx)
+}
+
+// RepairLocalBlock verifies the block in the given directory and, if any
+// corrupted rows are found, writes a new block without them to dst. The
+// source block is never modified. The returned meta is nil, if the block
+// does not need to be repaired.
+func RepairLocalBlock(phlarectx context.Context, dir string, dst string) (*BlockVerification, *block.Meta, error) {
+	v, err := newBlockVerifier(phlarectx, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer v.close()
+	if err = v.verify(phlarectx); err != nil {
+		return v.result, nil, err
+	}
+	if v.result.OK() {
+		return v.result, nil, nil
+	}
+	if !v.result.readable {
+		return v.result, nil, fmt.Errorf("block %s can't be repaired: it can't be read", v.result.Meta.ULID)
+	}
+	meta, err := v.repair(phlarectx, dst)
+	return v.result, meta, err
+}
+
+type blockVerifier struct {
+	dir     string
+	result  *BlockVerification
+	querier *singleBlockQuerier
+	// series maps the series index to its labels.
+	series []*labelsInfo
+}
+
+func newBlockVerifier(phlarectx context.Context, dir string) (*blockVerifier, error) {
+	meta, err := block.ReadMetaFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bkt, err := client.NewBucket(phlarectx, client.Config{
+		StorageBackendConfig: client.StorageBackendConfig{
+			Backend: client.Filesystem,
+			Filesystem: filesystem.Config{
+				Directory: path.Dir(dir),
+			},
+		},
+	}, "verify")
+	if err != nil {
+		return nil, err
+	}
+	return &blockVerifier{
+		dir: dir,
+		result: &BlockVerification{
+			Meta:          meta,
+			CorruptedRows: make(map[int64]string),
+		},
+		querier: NewSingleBlockQuerierFromMeta(phlarectx, bkt, meta),
+	}, nil
+}
+
+func (v *blockVerifier) close() {
+	if v.result.readable {
+		runutil.CloseWithLogOnErr(util.Logger, v.querier, "closing block querier")
+	}
+}
+
+func (v *blockVerifier) verify(ctx context.Context) error {
+	v.verifyFiles()
+	if !v.verifyIndex() {
+		return nil
+	}
+	if err := v.querier.Open(ctx); err != nil {
+		v.result.addIssue("opening block: %v", err)
+		return nil
+	}
+	v.result.readable = true
+	return v.verifyProfiles(ctx)
+}
+
+// verifyFiles checks that every file listed in meta.json
+// exists and its size matches the one recorded.
+func (v *blockVerifier) verifyFiles() {
+	for _, f := range v.result.Meta.Files {
+		info, err := os.Stat(filepath.Join(v.dir, f.RelPath))
+		if err != nil {
+			v.result.addIssue("%s: %v", f.RelPath, err)
+			continue
+		}
+		if f.SizeBytes > 0 && uint64(info.Size()) != f.SizeBytes {
+			v.result.addIssue("%s: size mismatch: expected %d bytes, got %d", f.RelPath, f.SizeBytes, info.Size())
+		}
+	}
+}
+
+// verifyIndex checks that the TSDB index is readable, series are sorted
+// by labels, and every series has a unique series index. It returns
+// false, if the profiles can't be mapped to the index series.
+func (v *blockVerifier) verifyIndex() bool {
+	idx, err := index.NewFileReader(filepath.Join(v.dir, block.IndexFilename))
+	if err != nil {
+		v.result.addIssue("%s: opening index: %v", block.IndexFilename, err)
+		return false
+	}
+	defer runutil.CloseWithLogOnErr(util.Logger, idx, "closing index reader")
+
+	k, val := index.AllPostingsKey()
+	postings, err := idx.Postings(k, nil, val)
+	if err != nil {
+		v.result.addIssue("%s: reading postings: %v", block.IndexFilename, err)
+		return false
+	}
+	var (
+		prev   phlaremodel.Labels
+		chunks = make([]index.ChunkMeta, 1)
+		ok     = true
+	)
+	for postings.Next() {
+		var lbls phlaremodel.Labels
+		fp, err := idx.Series(postings.At(), &lbls, &chunks)
+		if err != nil {
+			v.result.addIssue("%s: reading series: %v", block.IndexFilename, err)
+			return false
+		}
+		if !sort.IsSorted(lbls) {
+			v.result.addIssue("%s: series labels are not sorted: %s", block.IndexFilename, lbls)
+		}
+		if prev != nil && phlaremodel.CompareLabelPairs(prev, lbls) >= 0 {
+			v.result.addIssue("%s: series are not sorted: %s >= %s", block.IndexFilename, prev, lbls)
+		}
+		prev = lbls
+		if len(chunks) != 1 {
+			v.result.addIssue("%s: series %s: expected one chunk, got %d", block.IndexFilename, lbls, len(chunks))
+			ok = false
+			continue
+		}
+		i := int(chunks[0].SeriesIndex)
+		if i >= len(v.series) {
+			v.series = append(v.series, make([]*labelsInfo, i-len(v.series)+1)...)
+		}
+		if v.series[i] != nil {
+			v.result.addIssue("%s: series %s: duplicate series index %d", block.IndexFilename, lbls, i)
+			ok = false
+			continue
+		}
+		v.series[i] = &labelsInfo{fp: model.Fingerprint(fp), lbs: lbls}
+	}
+	if err = postings.Err(); err != nil {
+		v.result.addIssue("%s: reading postings: %v", block.IndexFilename, err)
+		return false
+	}
+	return ok
+}
+
+// verifyProfiles checks that the profiles are ordered by series and
+// time, and that all the stack traces they reference can be resolved.
+func (v *blockVerifier) verifyProfiles(ctx context.Context) error {
+	partitions := make(map[uint64]symdb.PartitionReader)
+	defer func() {
+		for _, p := range partitions {
+			p.Release()
+		}
+	}()
+
+	reader := v.querier.Profiles()
+	defer runutil.CloseWithLogOnErr(util.Logger, reader, "closing profiles reader")
+	rows := phlareparquet.NewBufferedRowReaderIterator(reader, 32)
+	defer runutil.CloseWithLogOnErr(util.Logger, rows, "closing profiles iterator")
+
+	var (
+		rowNum     int64
+		hasPrev    bool
+		prevSeries uint32
+		prevTime   int64
+		stacks     stacktracesVerifier
+	)
+	for ; rows.Next(); rowNum++ {
+		row := schemav1.ProfileRow(rows.At())
+		seriesIndex, timeNanos := row.SeriesIndex(), row.TimeNanos()
+		if int(seriesIndex) >= len(v.series) || v.series[seriesIndex] == nil {
+			v.result.CorruptedRows[rowNum] = fmt.Sprintf("series index %d not found in %s", seriesIndex, block.IndexFilename)
+			continue
+		}
+		if hasPrev && (seriesIndex < prevSeries || (seriesIndex == prevSeries && timeNanos < prevTime)) {
+			v.result.CorruptedRows[rowNum] = fmt.Sprintf("out of order: series %d at %d after series %d at %d",
+				seriesIndex, timeNanos, prevSeries, prevTime)
+			continue
+		}
+		partitionID := row.StacktracePartitionID()
+		p, ok := partitions[partitionID]
+		if !ok {
+			var err error
+			if p, err = v.querier.Symbols().Partition(ctx, partitionID); err != nil {
+				v.result.CorruptedRows[rowNum] = fmt.Sprintf("stacktrace partition %d: %v", partitionID, err)
+				continue
+			}
+			partitions[partitionID] = p
+		}
+		if err := stacks.verify(ctx, p, row); err != nil {
+			v.result.CorruptedRows[rowNum] = fmt.Sprintf("stacktrace partition %d: %v", partitionID, err)
+			continue
+		}
+		prevSeries, prevTime, hasPrev = seriesIndex, timeNanos, true
+	}
+	v.result.TotalRows = rowNum
+	return rows.Err()
+}
+
+func (v *blockVerifier) repair(ctx context.Context, dst string) (*block.Meta, error) {
+	meta := v.result.Meta.Clone()
+	meta.ULID = ulid.MustNew(meta.ULID.Time(), rand.Reader)
+	meta.Compaction.Parents = []block.BlockDesc{{
+		ULID:    v.result.Meta.ULID,
+		MinTime: v.result.Meta.MinTime,
+		MaxTime: v.result.Meta.MaxTime,
+	}}
+	meta.Compaction.Hints = append(meta.Compaction.Hints, "repaired")
+	w, err := newBlockWriter(dst, meta)
+	if err != nil {
+		return nil, fmt.Errorf("create block writer: %w", err)
+	}
+
+	reader := v.querier.Profiles()
+	defer runutil.CloseWithLogOnErr(util.Logger, reader, "closing profiles reader")
+	rows := phlareparquet.NewBufferedRowReaderIterator(reader, 32)
+	defer runutil.CloseWithLogOnErr(util.Logger, rows, "closing profiles iterator")
+
+	for rowNum := int64(0); rows.Next(); rowNum++ {
+		if _, corrupted := v.result.CorruptedRows[rowNum]; corrupted {
+			continue
+		}
+		row := schemav1.ProfileRow(rows.At())
+		s := v.series[row.SeriesIndex()]
+		if err = w.WriteRow(profileRow{
+			timeNanos:   row.TimeNanos(),
+			labels:      s.lbs,
+			fp:          s.fp,
+			row:         row,
+			blockReader: v.querier,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = w.Close(ctx); err != nil {
+		return nil, err
+	}
+	return w.meta, nil
+}
+
+// stacktracesVerifier checks that all the stack traces
+// of a profile can be resolved to non-empty locations.
+type stacktracesVerifier struct {
+	ids      []uint32
+	resolved int
+}
+
+func (s *stacktracesVerifier) verify(ctx context.Context, p symdb.PartitionReader, row schemav1.ProfileRow) error {
+	s.ids = s.ids[:0]
+	row.ForStacktraceIDsValues(func(values []parquet.Value) {
+		for _, v := range values {
+			s.ids = append(s.ids, v.Uint32())
+		}
+	})
+	if len(s.ids) == 0 {
+		return nil
+	}
+	slices.Sort(s.ids)
+	s.ids = slices.Compact(s.ids)
+	n := len(s.ids)
+	s.resolved = 0
+	if err := p.Symbols().Stacktraces.ResolveStacktraceLocations(ctx, s, s.ids); err != nil {
+		return err
+	}
+	if s.resolved != n {
+		return fmt.Errorf("%d of %d stack traces not found", n-s.resolved, n)
+	}
+	return nil
+}
+
+func (s *stacktracesVerifier) InsertStacktrace(_ uint32, locations []int32) {
+	if len(locations) > 0 {
+		s.resolved++
+	}
+}
@@ -0,0 +1,119 @@
+package phlaredb_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/pkg/phlaredb"
+	"github.com/grafana/pyroscope/pkg/phlaredb/block"
+	"github.com/grafana/pyroscope/pkg/phlaredb/block/testutil"
+	schemav1 "github.com/grafana/pyroscope/pkg/phlaredb/schemas/v1"
+	"github.com/grafana/pyroscope/pkg/pprof/testhelper"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+func Test_VerifyAndRepairBlock(t *testing.T) {
+	meta, dir := testutil.CreateBlock(t, func() []*testhelper.ProfileBuilder {
+		var builders []*testhelper.ProfileBuilder
+		for i := 0; i < 10; i++ {
+			builders = append(builders,
+				testhelper.NewProfileBuilder(int64(time.Second)*int64(i)).
+					CPUProfile().
+					WithLabels(
+						"job", "a",
+					).ForStacktraceString("foo", "bar", "baz").AddSamples(1))
+		}
+		return builders
+	})
+	ctx := context.Background()
+	blockDir := filepath.Join(dir, meta.ULID.String())
+
+	v, err := phlaredb.VerifyLocalBlock(ctx, blockDir)
+	require.NoError(t, err)
+	require.True(t, v.OK(), v.Issues)
+	require.Equal(t, int64(10), v.TotalRows)
+
+	t.Run("should report and repair rows referencing missing partitions", func(t *testing.T) {
+		corruptStacktracePartition(t, filepath.Join(blockDir, "profiles.parquet"), 3)
+
+		v, err = phlaredb.VerifyLocalBlock(ctx, blockDir)
+		require.NoError(t, err)
+		require.False(t, v.OK())
+		require.Len(t, v.CorruptedRows, 1)
+		require.Contains(t, v.CorruptedRows[3], "partition not found")
+
+		dst := t.TempDir()
+		_, repaired, err := phlaredb.RepairLocalBlock(ctx, blockDir, dst)
+		require.NoError(t, err)
+		require.NotNil(t, repaired)
+		require.Equal(t, uint64(9), repaired.Stats.NumProfiles)
+		require.Equal(t, meta.ULID, repaired.Compaction.Parents[0].ULID)
+
+		v, err = phlaredb.VerifyLocalBlock(ctx, filepath.Join(dst, repaired.ULID.String()))
+		require.NoError(t, err)
+		require.True(t, v.OK(), v.Issues)
+		require.Equal(t, int64(9), v.TotalRows)
+	})
+
+	t.Run("should report a missing index", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(blockDir, block.IndexFilename)))
+		v, err = phlaredb.VerifyLocalBlock(ctx, blockDir)
+		require.NoError(t, err)
+		require.False(t, v.OK())
+		require.Contains(t, v.Issues[0], "no such file")
+
+		_, _, err = phlaredb.RepairLocalBlock(ctx, blockDir, t.TempDir())
+		require.Error(t, err)
+	})
+}
+
+// corruptStacktracePartition rewrites the profiles table with the
+// given row referencing a stack trace partition that does not exist.
+func corruptStacktracePartition(t *testing.T, path string, rowNum int) {
+	t.Helper()
+	col, ok := schemav1.ProfilesSchema.Lookup("StacktracePartition")
+	require.True(t, ok)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	r := parquet.NewReader(f, schemav1.ProfilesSchema)
+	rows := make([]parquet.Row, r.NumRows())
+	n, err := r.ReadRows(rows)
+	if !errors.Is(err, io.EOF) {
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+	require.NoError(t, f.Close())
+	require.Equal(t, len(rows), n)
+	rows[rowNum][col.ColumnIndex] = parquet.Int64Value(1<<40).Level(0, 0, col.ColumnIndex)
+
+	f, err = os.Create(path)
+	require.NoError(t, err)
+	w := parquet.NewWriter(f, schemav1.ProfilesSchema)
+	_, err = w.WriteRows(rows)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	// Keep meta.json consistent with the new file size,
+	// otherwise the block can't be opened at all.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	meta, err := block.ReadMetaFromDir(filepath.Dir(path))
+	require.NoError(t, err)
+	for i := range meta.Files {
+		if meta.Files[i].RelPath == filepath.Base(path) {
+			meta.Files[i].SizeBytes = uint64(info.Size())
+		}
+	}
+	_, err = meta.WriteToFile(util.Logger, filepath.Dir(path))
+	require.NoError(t, err)
+}
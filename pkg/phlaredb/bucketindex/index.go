@@ -45,6 +45,16 @@ func (idx *Index) GetUpdatedAt() time.Time {
 	return time.Unix(idx.UpdatedAt, 0)
 }
 
+// SizeBytes returns the total size of the blocks in the index. The blocks indexed without
+// their size are not accounted.
+func (idx *Index) SizeBytes() uint64 {
+	var size uint64
+	for _, b := range idx.Blocks {
+		size += b.SizeBytes
+	}
+	return size
+}
+
 // RemoveBlock removes block and its deletion mark (if any) from index.
 func (idx *Index) RemoveBlock(id ulid.ULID) {
 	for i := 0; i < len(idx.Blocks); i++ {
@@ -78,6 +88,9 @@ type Block struct {
 
 	// Block's compactor shard ID, copied from tsdb.CompactorShardIDExternalLabel label.
 	CompactorShardID string `json:"compactor_shard_id,omitempty"`
+
+	// SizeBytes is the total size of the block files, zero if unknown.
+	SizeBytes uint64 `json:"size_bytes,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -122,6 +135,7 @@ func BlockFromMeta(meta block.Meta) *Block {
 		MinTime:          meta.MinTime,
 		MaxTime:          meta.MaxTime,
 		CompactorShardID: meta.Labels[sharding.CompactorShardIDLabel],
+		SizeBytes:        meta.SizeBytes(),
 	}
 }
 
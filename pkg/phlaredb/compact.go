@@ -187,6 +187,9 @@ func (bw *blockWriter) Close(ctx context.Context) error {
 	if err := bw.profilesWriter.Close(); err != nil {
 		return err
 	}
+	if _, err := writeBlockFilters(ctx, bw.path, bw.symbolsRewriter.w); err != nil {
+		return err
+	}
 	metaFiles, err := metaFilesFromDir(bw.path)
 	if err != nil {
 		return err
@@ -60,6 +60,15 @@ func TestCompact(t *testing.T) {
 	require.Equal(t, model.TimeFromUnix(3), compacted.MaxTime)
 	querier := blockQuerierFromMeta(t, dst, compacted)
 
+	// The compacted block has the function names filters.
+	require.NotNil(t, compacted.FileByRelPath(BlockFiltersFilename))
+	match, err := querier.(BlockFilter).MayContainFunctions(ctx, []string{"bar"})
+	require.NoError(t, err)
+	require.True(t, match)
+	match, err = querier.(BlockFilter).MayContainFunctions(ctx, []string{"qux"})
+	require.NoError(t, err)
+	require.False(t, match)
+
 	matchAll := &ingesterv1.SelectProfilesRequest{
 		LabelSelector: "{}",
 		Type:          mustParseProfileSelector(t, "process_cpu:cpu:nanoseconds:cpu:nanoseconds"),
@@ -533,15 +542,16 @@ func TestFlushMeta(t *testing.T) {
 	require.Equal(t, uint64(3), b.Meta().Stats.NumSeries)
 	require.Equal(t, uint64(3), b.Meta().Stats.NumSamples)
 	require.Equal(t, uint64(3), b.Meta().Stats.NumProfiles)
-	require.Len(t, b.Meta().Files, 8)
-	require.Equal(t, "index.tsdb", b.Meta().Files[0].RelPath)
-	require.Equal(t, "profiles.parquet", b.Meta().Files[1].RelPath)
-	require.Equal(t, "symbols/functions.parquet", b.Meta().Files[2].RelPath)
-	require.Equal(t, "symbols/index.symdb", b.Meta().Files[3].RelPath)
-	require.Equal(t, "symbols/locations.parquet", b.Meta().Files[4].RelPath)
-	require.Equal(t, "symbols/mappings.parquet", b.Meta().Files[5].RelPath)
-	require.Equal(t, "symbols/stacktraces.symdb", b.Meta().Files[6].RelPath)
-	require.Equal(t, "symbols/strings.parquet", b.Meta().Files[7].RelPath)
+	require.Len(t, b.Meta().Files, 9)
+	require.Equal(t, "filters.bloom", b.Meta().Files[0].RelPath)
+	require.Equal(t, "index.tsdb", b.Meta().Files[1].RelPath)
+	require.Equal(t, "profiles.parquet", b.Meta().Files[2].RelPath)
+	require.Equal(t, "symbols/functions.parquet", b.Meta().Files[3].RelPath)
+	require.Equal(t, "symbols/index.symdb", b.Meta().Files[4].RelPath)
+	require.Equal(t, "symbols/locations.parquet", b.Meta().Files[5].RelPath)
+	require.Equal(t, "symbols/mappings.parquet", b.Meta().Files[6].RelPath)
+	require.Equal(t, "symbols/stacktraces.symdb", b.Meta().Files[7].RelPath)
+	require.Equal(t, "symbols/strings.parquet", b.Meta().Files[8].RelPath)
 }
 
 func newBlock(t *testing.T, generator func() []*testhelper.ProfileBuilder) BlockReader {
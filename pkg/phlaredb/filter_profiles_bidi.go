@@ -14,6 +14,7 @@ import (
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	"github.com/grafana/pyroscope/pkg/iter"
 	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 type BidiServerMerge[Res any, Req any] interface {
@@ -66,6 +67,7 @@ func filterProfiles[B BidiServerMerge[Res, Req], Res filterResponse, Req filterR
 		Profiles:   make([]*ingestv1.SeriesProfile, 0, batchProfileSize),
 		LabelsSets: make([]*typesv1.Labels, 0, batchProfileSize),
 	}
+	ql := limiter.QueryLimiterFromContextWithFallback(ctx)
 	its := make([]iter.Iterator[ProfileWithIndex], len(profiles))
 	for i, iter := range profiles {
 		iter := iter
@@ -78,6 +80,9 @@ func filterProfiles[B BidiServerMerge[Res, Req], Res filterResponse, Req filterR
 		Profile: maxBlockProfile,
 		Index:   0,
 	}, true, its...), batchProfileSize, func(ctx context.Context, batch []ProfileWithIndex) error {
+		if err := ql.AddProfiles(len(batch)); err != nil {
+			return err
+		}
 		sp, _ := opentracing.StartSpanFromContext(ctx, "filterProfiles - Filtering batch")
 		sp.LogFields(
 			otlog.Int("batch_len", len(batch)),
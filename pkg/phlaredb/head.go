@@ -14,6 +14,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/gogo/status"
 	"github.com/google/uuid"
+	"github.com/grafana/dskit/multierror"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
@@ -62,6 +63,7 @@ type Head struct {
 	inFlightProfiles sync.WaitGroup // ongoing ingestion requests.
 	metaLock         sync.RWMutex
 	meta             *block.Meta
+	ingestedBytes    map[string]int64 // profile bytes per service name, guarded by metaLock.
 
 	parquetConfig *ParquetConfig
 	symdb         *symdb.SymDB
@@ -89,8 +91,9 @@ func NewHead(phlarectx context.Context, cfg Config, limiter TenantLimiter) (*Hea
 
 		stopCh: make(chan struct{}),
 
-		meta:         block.NewMeta(),
-		totalSamples: atomic.NewUint64(0),
+		meta:          block.NewMeta(),
+		ingestedBytes: make(map[string]int64),
+		totalSamples:  atomic.NewUint64(0),
 
 		parquetConfig: &parquetConfig,
 		limiter:       limiter,
@@ -178,11 +181,26 @@ func (h *Head) isStale(maxT int64, now time.Time) bool {
 }
 
 func (h *Head) Ingest(ctx context.Context, p *profilev1.Profile, id uuid.UUID, externalLabels ...*typesv1.LabelPair) error {
-	labels, seriesFingerprints := labelsForProfile(p, externalLabels...)
+	return h.ingest(ctx, p, id, false, externalLabels...)
+}
 
-	for i, fp := range seriesFingerprints {
-		if err := h.limiter.AllowProfile(fp, labels[i], p.TimeNanos); err != nil {
-			return err
+// IngestTransferred ingests a profile transferred from the head of another
+// ingester: its series were already admitted by the limiter and its samples
+// are already deltas.
+func (h *Head) IngestTransferred(ctx context.Context, p *profilev1.Profile, id uuid.UUID, externalLabels ...*typesv1.LabelPair) error {
+	return h.ingest(ctx, p, id, true, externalLabels...)
+}
+
+func (h *Head) ingest(ctx context.Context, p *profilev1.Profile, id uuid.UUID, transferred bool, externalLabels ...*typesv1.LabelPair) error {
+	labels, seriesFingerprints := labelsForProfile(p, externalLabels...)
+	// The profile is modified in place once symbols are written.
+	profileSize := int64(p.SizeVT())
+
+	if !transferred {
+		for i, fp := range seriesFingerprints {
+			if err := h.limiter.AllowProfile(fp, labels[i], p.TimeNanos); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -195,7 +213,11 @@ func (h *Head) Ingest(ctx context.Context, p *profilev1.Profile, id uuid.UUID, e
 	for idxType, profile := range h.symdb.WriteProfileSymbols(partition, p) {
 		profile.ID = id
 		profile.SeriesFingerprint = seriesFingerprints[idxType]
-		profile.Samples = h.delta.computeDelta(profile, labels[idxType])
+		if transferred {
+			profile.Samples = profile.Samples.Compact(false)
+		} else {
+			profile.Samples = h.delta.computeDelta(profile, labels[idxType])
+		}
 		profile.TotalValue = profile.Samples.Sum()
 
 		if profile.Samples.Len() == 0 {
@@ -225,6 +247,7 @@ func (h *Head) Ingest(ctx context.Context, p *profilev1.Profile, id uuid.UUID, e
 	if v > h.meta.MaxTime {
 		h.meta.MaxTime = v
 	}
+	h.ingestedBytes[labels[0].Get(phlaremodel.LabelNameServiceName)] += profileSize
 	h.metaLock.Unlock()
 
 	h.updatedAt.Store(time.Now())
@@ -330,6 +353,18 @@ func (h *Head) Bounds() (mint, maxt model.Time) {
 	return h.meta.MinTime, h.meta.MaxTime
 }
 
+// IngestedBytes returns the size of the profiles ingested into
+// the head, broken down by service name.
+func (h *Head) IngestedBytes() map[string]int64 {
+	h.metaLock.RLock()
+	defer h.metaLock.RUnlock()
+	res := make(map[string]int64, len(h.ingestedBytes))
+	for k, v := range h.ingestedBytes {
+		res[k] = v
+	}
+	return res
+}
+
 // Returns underlying queries, the queriers should be roughly ordered in TS increasing order
 func (h *Head) Queriers() Queriers {
 	h.profiles.rowsLock.RLock()
@@ -551,6 +586,15 @@ func (h *Head) flush(ctx context.Context) error {
 		h.metrics.flushedFileSizeBytes.WithLabelValues(file.RelPath).Observe(float64(file.SizeBytes))
 	}
 
+	// bloom filters
+	filters, err := writeBlockFilters(ctx, h.headPath, h.symdb)
+	if err != nil {
+		return errors.Wrap(err, "writing block filters")
+	}
+	files = append(files, filters)
+	blockSize += filters.SizeBytes
+	h.metrics.flushedFileSizeBytes.WithLabelValues("filters").Observe(float64(filters.SizeBytes))
+
 	// tsdb
 	h.meta.Stats.NumSeries = uint64(h.profiles.index.totalSeries.Load())
 	f := block.File{
@@ -587,6 +631,25 @@ func (h *Head) flush(ctx context.Context) error {
 	return nil
 }
 
+// Discard closes the head and removes its data without writing a block.
+// The call is not thread-safe: no concurrent reads and writes are allowed.
+func (h *Head) Discard() error {
+	close(h.stopCh)
+	h.wg.Wait()
+	h.inFlightProfiles.Wait()
+	errs := multierror.New()
+	for _, t := range h.tables {
+		if err := t.Close(); err != nil {
+			errs.Add(errors.Wrapf(err, "closing table %s", t.Name()))
+		}
+	}
+	if err := h.profiles.DeleteRowGroups(); err != nil {
+		errs.Add(err)
+	}
+	errs.Add(os.RemoveAll(h.headPath))
+	return errs.Err()
+}
+
 // Move moves the head directory to local blocks. The call is not thread-safe:
 // no concurrent reads and writes are allowed.
 //
@@ -249,6 +249,20 @@ func TestHeadProfileTypes(t *testing.T) {
 	}, res.Msg.ProfileTypes)
 }
 
+func TestHeadIngestedBytes(t *testing.T) {
+	head := newTestHead(t)
+	// Profiles are modified during ingestion.
+	fooSize, barSize := newProfileFoo().SizeVT(), newProfileBar().SizeVT()
+	require.NoError(t, head.Ingest(context.Background(), newProfileFoo(), uuid.New(), &typesv1.LabelPair{Name: "service_name", Value: "foo"}))
+	require.NoError(t, head.Ingest(context.Background(), newProfileFoo(), uuid.New(), &typesv1.LabelPair{Name: "service_name", Value: "foo"}))
+	require.NoError(t, head.Ingest(context.Background(), newProfileBar(), uuid.New(), &typesv1.LabelPair{Name: "service_name", Value: "bar"}))
+
+	require.Equal(t, map[string]int64{
+		"foo": 2 * int64(fooSize),
+		"bar": int64(barSize),
+	}, head.IngestedBytes())
+}
+
 func mustParseProfileSelector(t testing.TB, selector string) *typesv1.ProfileType {
 	ps, err := phlaremodel.ParseProfileTypeSelector(selector)
 	require.NoError(t, err)
@@ -293,6 +307,10 @@ func TestHeadFlush(t *testing.T) {
 			},
 			Labels: map[string]string{},
 			Files: []block.File{
+				{
+					RelPath:   "filters.bloom",
+					SizeBytes: 1820,
+				},
 				{
 					RelPath:   "index.tsdb",
 					SizeBytes: 2484,
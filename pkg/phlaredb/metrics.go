@@ -15,6 +15,8 @@ type contextKey uint8
 const (
 	headMetricsContextKey contextKey = iota
 	blockMetricsContextKey
+	spanSelectorContextKey
+	functionSelectorContextKey
 )
 
 type headMetrics struct {
@@ -198,6 +200,8 @@ type blocksMetrics struct {
 
 	blockOpeningLatency prometheus.Histogram
 	blockOpened         prometheus.Gauge
+	blocksSkipped       *prometheus.CounterVec
+	rowGroupsSkipped    *prometheus.CounterVec
 }
 
 func newBlocksMetrics(reg prometheus.Registerer) *blocksMetrics {
@@ -211,9 +215,19 @@ func newBlocksMetrics(reg prometheus.Registerer) *blocksMetrics {
 			Name: "pyroscopedb_blocks_currently_open",
 			Help: "Number of blocks opened",
 		}),
+		blocksSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscopedb_blocks_skipped_total",
+			Help: "Total number of blocks skipped by queries because their bloom filters ruled out a match.",
+		}, []string{"filter"}),
+		rowGroupsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscopedb_row_groups_skipped_total",
+			Help: "Total number of profiles row groups skipped by queries because their bloom filters ruled out a match.",
+		}, []string{"filter"}),
 	}
 	m.blockOpeningLatency = util.RegisterOrGet(reg, m.blockOpeningLatency)
 	m.blockOpened = util.RegisterOrGet(reg, m.blockOpened)
+	m.blocksSkipped = util.RegisterOrGet(reg, m.blocksSkipped)
+	m.rowGroupsSkipped = util.RegisterOrGet(reg, m.rowGroupsSkipped)
 	return m
 }
 
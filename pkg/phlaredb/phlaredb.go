@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -205,6 +206,12 @@ func (f *PhlareDB) Flush(ctx context.Context, force bool, reason string) (err er
 	}
 
 	f.headLock.Unlock()
+	return f.flushHeads(ctx, reason)
+}
+
+// flushHeads writes the flushing heads to disk and moves them to the blockQuerier.
+// The caller must hold the flushLock.
+func (f *PhlareDB) flushHeads(ctx context.Context, reason string) error {
 	// lock is release flushing heads are available for queries in the flushing array.
 	// New heads can be created and written to while the flushing heads are being flushed.
 	errs := multierror.New()
@@ -240,7 +247,7 @@ func (f *PhlareDB) Flush(ctx context.Context, force bool, reason string) (err er
 	}
 	f.flushing = nil
 	f.headLock.Unlock()
-	return err
+	return errs.Err()
 }
 
 func (f *PhlareDB) maxBlockDuration() time.Duration {
@@ -356,8 +363,9 @@ func (f *PhlareDB) headSize() uint64 {
 }
 
 const (
-	flushReasonMaxDuration   = "max-duration"
-	flushReasonMaxBlockBytes = "max-block-bytes"
+	flushReasonMaxDuration     = "max-duration"
+	flushReasonMaxBlockBytes   = "max-block-bytes"
+	flushReasonTransferAborted = "transfer-aborted"
 )
 
 // LabelValues returns the possible label values for a given label name.
@@ -450,3 +458,45 @@ func (f *PhlareDB) Evict(blockID ulid.ULID, fn func() error) (bool, error) {
 	<-e.done
 	return e.evicted, e.err
 }
+
+// IngestedBytes returns the size of the profiles held in the heads, broken
+// down by service name. Heads outside of the requested time range are skipped.
+func (f *PhlareDB) IngestedBytes(ctx context.Context, req *connect.Request[ingestv1.IngestedBytesRequest]) (*connect.Response[ingestv1.IngestedBytesResponse], error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "PhlareDB IngestedBytes")
+	defer sp.Finish()
+
+	f.headLock.RLock()
+	heads := make([]*Head, 0, len(f.heads)+len(f.flushing))
+	for _, h := range f.heads {
+		heads = append(heads, h)
+	}
+	heads = append(heads, f.flushing...)
+	f.headLock.RUnlock()
+
+	services := make(map[string]int64)
+	for _, h := range heads {
+		if req.Msg.Start != 0 && req.Msg.End != 0 {
+			mint, maxt := h.Bounds()
+			if maxt.Before(model.Time(req.Msg.Start)) || mint.After(model.Time(req.Msg.End)) {
+				continue
+			}
+		}
+		for name, size := range h.IngestedBytes() {
+			services[name] += size
+		}
+	}
+
+	res := &ingestv1.IngestedBytesResponse{
+		Services: make([]*ingestv1.ServiceIngestedBytes, 0, len(services)),
+	}
+	for name, size := range services {
+		res.Services = append(res.Services, &ingestv1.ServiceIngestedBytes{
+			ServiceName: name,
+			Bytes:       size,
+		})
+	}
+	sort.Slice(res.Services, func(i, j int) bool {
+		return res.Services[i].ServiceName < res.Services[j].ServiceName
+	})
+	return connect.NewResponse(res), nil
+}
@@ -23,6 +23,7 @@ import (
 	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
 	phlaremodel "github.com/grafana/pyroscope/pkg/model"
 	"github.com/grafana/pyroscope/pkg/testhelper"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 func TestCreateLocalDir(t *testing.T) {
@@ -136,6 +137,14 @@ func (i *ingesterHandlerPhlareDB) Flush(context.Context, *connect.Request[ingest
 	return nil, errors.New("not implemented")
 }
 
+func (i *ingesterHandlerPhlareDB) IngestedBytes(context.Context, *connect.Request[ingestv1.IngestedBytesRequest]) (*connect.Response[ingestv1.IngestedBytesResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (i *ingesterHandlerPhlareDB) Transfer(context.Context, *connect.ClientStream[ingestv1.TransferRequest]) (*connect.Response[ingestv1.TransferResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
 func TestMergeProfilesStacktraces(t *testing.T) {
 	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
 
@@ -267,6 +276,105 @@ func TestMergeProfilesStacktraces(t *testing.T) {
 	})
 }
 
+// limitedIngesterHandler enforces the query limits, as the ingesters and the store-gateways do.
+type limitedIngesterHandler struct {
+	*ingesterHandlerPhlareDB
+	maxProfiles, maxBytes, maxTreeNodes int
+}
+
+func (i *limitedIngesterHandler) MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesStacktracesRequest, ingestv1.MergeProfilesStacktracesResponse]) error {
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(i.maxProfiles, i.maxBytes, i.maxTreeNodes))
+	return i.ingesterHandlerPhlareDB.MergeProfilesStacktraces(ctx, stream)
+}
+
+func TestMergeProfilesStacktraces_QueryLimits(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var (
+		ctx     = testContext(t)
+		testDir = contextDataDir(ctx)
+		end     = time.Unix(0, int64(time.Hour))
+		start   = end.Add(-time.Minute)
+		step    = 15 * time.Second
+	)
+
+	db, err := New(ctx, Config{
+		DataPath:         testDir,
+		MaxBlockDuration: time.Duration(100000) * time.Minute, // we will manually flush
+	}, NoLimit, ctx.localBucketClient)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	ingestProfiles(t, db, cpuProfileGenerator, start.UnixNano(), end.UnixNano(), step,
+		&typesv1.LabelPair{Name: "pod", Value: "my-pod"},
+	)
+	// The bytes fetched are accounted when reading the blocks.
+	require.NoError(t, db.Flush(ctx, true, ""))
+
+	query := func(t *testing.T, handler *limitedIngesterHandler) error {
+		t.Helper()
+		mux := http.NewServeMux()
+		mux.Handle(ingesterv1connect.NewIngesterServiceHandler(handler))
+		serv := testhelper.NewInMemoryServer(mux)
+		defer serv.Close()
+		client := ingesterv1connect.NewIngesterServiceClient(serv.Client(), serv.URL())
+
+		bidi := client.MergeProfilesStacktraces(ctx)
+		require.NoError(t, bidi.Send(&ingestv1.MergeProfilesStacktracesRequest{
+			Request: &ingestv1.SelectProfilesRequest{
+				LabelSelector: `{pod="my-pod"}`,
+				Type:          mustParseProfileSelector(t, "process_cpu:cpu:nanoseconds:cpu:nanoseconds"),
+				Start:         start.UnixMilli(),
+				End:           end.UnixMilli(),
+			},
+		}))
+		resp, err := bidi.Receive()
+		if err != nil {
+			return err
+		}
+		keep := make([]bool, len(resp.SelectedProfiles.GetProfiles()))
+		for i := range keep {
+			keep[i] = true
+		}
+		require.NoError(t, bidi.Send(&ingestv1.MergeProfilesStacktracesRequest{Profiles: keep}))
+		for {
+			resp, err = bidi.Receive()
+			if err != nil || resp.Result != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		handler *limitedIngesterHandler
+		limit   string
+	}{
+		{name: "profiles selected", handler: &limitedIngesterHandler{maxProfiles: 2}, limit: "max_query_profiles_selected"},
+		{name: "bytes fetched", handler: &limitedIngesterHandler{maxBytes: 1}, limit: "max_query_bytes_fetched"},
+		{name: "flame graph nodes", handler: &limitedIngesterHandler{maxTreeNodes: 1}, limit: "max_query_flamegraph_nodes"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.handler.ingesterHandlerPhlareDB = &ingesterHandlerPhlareDB{db.queriers()}
+			err := query(t, tc.handler)
+			require.Error(t, err)
+			require.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+			require.Contains(t, err.Error(), tc.limit)
+		})
+	}
+
+	t.Run("within the limits", func(t *testing.T) {
+		handler := &limitedIngesterHandler{
+			ingesterHandlerPhlareDB: &ingesterHandlerPhlareDB{db.queriers()},
+			maxProfiles:             5,
+			maxBytes:                1 << 30,
+		}
+		require.NoError(t, query(t, handler))
+	})
+}
+
 func TestMergeProfilesPprof(t *testing.T) {
 	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
 
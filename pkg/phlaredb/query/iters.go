@@ -15,6 +15,7 @@ import (
 	"github.com/parquet-go/parquet-go"
 
 	"github.com/grafana/pyroscope/pkg/iter"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 const MaxDefinitionLevel = 5
@@ -832,12 +833,15 @@ type SyncIterator struct {
 	cancel          func()
 	span            opentracing.Span
 	metrics         *Metrics
+	limiter         *limiter.QueryLimiter
 	curr            RowNumber
 	currRowGroup    parquet.RowGroup
 	currRowGroupMin RowNumber
 	currRowGroupMax RowNumber
 	currChunk       parquet.ColumnChunk
+	currOffsets     parquet.OffsetIndex
 	currPages       parquet.Pages
+	currPageN       int
 	currPage        parquet.Page
 	currPageMax     RowNumber
 	currValues      parquet.ValueReader
@@ -899,6 +903,7 @@ func NewSyncIterator(ctx context.Context, rgs []parquet.RowGroup, column int, co
 		ctx:        ctx,
 		cancel:     cancel,
 		metrics:    getMetricsFromContext(ctx),
+		limiter:    limiter.QueryLimiterFromContextWithFallback(ctx),
 		span:       span,
 		column:     column,
 		columnName: columnName,
@@ -1069,6 +1074,10 @@ func (c *SyncIterator) seekPages(seekTo RowNumber, definitionLevel int) (done bo
 				log.Int64("page_num_values", pg.NumValues()),
 				log.Int64("page_size", pg.Size()),
 			)
+			if err = c.limiter.AddBytes(c.pageBytes(pg)); err != nil {
+				parquet.Release(pg)
+				return true, err
+			}
 
 			// Skip based on row number?
 			newRN := c.curr
@@ -1138,6 +1147,10 @@ func (c *SyncIterator) next() (RowNumber, *parquet.Value, error) {
 				log.Int64("page_num_values", pg.NumValues()),
 				log.Int64("page_size", pg.Size()),
 			)
+			if err = c.limiter.AddBytes(c.pageBytes(pg)); err != nil {
+				parquet.Release(pg)
+				return EmptyRowNumber(), nil, err
+			}
 
 			if c.filter != nil && !c.filter.KeepPage(pg) {
 				// This page filtered out
@@ -1192,7 +1205,21 @@ func (c *SyncIterator) setRowGroup(rg parquet.RowGroup, min, max RowNumber) {
 	c.currRowGroupMin = min
 	c.currRowGroupMax = max
 	c.currChunk = rg.ColumnChunks()[c.column]
+	c.currOffsets = c.currChunk.OffsetIndex()
 	c.currPages = c.currChunk.Pages()
+	c.currPageN = 0
+}
+
+// pageBytes returns the number of bytes of the page read from the storage: its
+// compressed size, if the column chunk has the offset index. The pages are read
+// sequentially, so the page number is the number of pages read in the chunk.
+func (c *SyncIterator) pageBytes(pg parquet.Page) int64 {
+	i := c.currPageN
+	c.currPageN++
+	if c.currOffsets != nil && i < c.currOffsets.NumPages() {
+		return c.currOffsets.CompressedPageSize(i)
+	}
+	return pg.Size()
 }
 
 func (c *SyncIterator) setPage(pg parquet.Page) {
@@ -1235,7 +1262,9 @@ func (c *SyncIterator) closeCurrRowGroup() {
 	c.currRowGroupMin = EmptyRowNumber()
 	c.currRowGroupMax = EmptyRowNumber()
 	c.currChunk = nil
+	c.currOffsets = nil
 	c.currPages = nil
+	c.currPageN = 0
 	c.setPage(nil)
 }
 
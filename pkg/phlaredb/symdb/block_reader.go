@@ -21,6 +21,7 @@ import (
 	parquetobj "github.com/grafana/pyroscope/pkg/objstore/parquet"
 	"github.com/grafana/pyroscope/pkg/phlaredb/block"
 	schemav1 "github.com/grafana/pyroscope/pkg/phlaredb/schemas/v1"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 	"github.com/grafana/pyroscope/pkg/util/refctr"
 )
 
@@ -351,6 +352,9 @@ func (c *stacktraceChunkReader) fetch(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if err = limiter.QueryLimiterFromContextWithFallback(ctx).AddBytes(c.header.Size); err != nil {
+			return err
+		}
 		rc, err := c.reader.bucket.GetRange(ctx, f.RelPath, c.header.Offset, c.header.Size)
 		if err != nil {
 			return err
@@ -415,8 +419,13 @@ func (t *parquetTableRange[M, P]) fetch(ctx context.Context) (err error) {
 	defer span.Finish()
 	return t.r.Inc(func() error {
 		var s uint32
+		var size int64
 		for _, h := range t.headers {
 			s += h.Rows
+			size += t.rowGroupSize(h.RowGroup)
+		}
+		if err := limiter.QueryLimiterFromContextWithFallback(ctx).AddBytes(size); err != nil {
+			return err
 		}
 		buf := make([]parquet.Row, inMemoryReaderRowsBufSize)
 		t.s = make([]M, s)
@@ -444,6 +453,15 @@ func (t *parquetTableRange[M, P]) fetch(ctx context.Context) (err error) {
 	})
 }
 
+// rowGroupSize returns the compressed size of the row group, read from the storage.
+func (t *parquetTableRange[M, P]) rowGroupSize(i uint32) int64 {
+	var size int64
+	for _, c := range t.file.Metadata().RowGroups[i].Columns {
+		size += c.MetaData.TotalCompressedSize
+	}
+	return size
+}
+
 func (t *parquetTableRange[M, P]) readRows(dst []M, buf []parquet.Row, rows parquet.Rows) (err error) {
 	defer func() {
 		err = multierror.New(err, rows.Close()).Err()
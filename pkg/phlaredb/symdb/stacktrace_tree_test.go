@@ -165,6 +165,23 @@ func Test_stacktrace_tree_pprof_locations(t *testing.T) {
 	}
 }
 
+func Test_parent_pointer_tree_resolve_unknown_id(t *testing.T) {
+	x := newStacktraceTree(10)
+	sid := x.insert([]uint64{3, 2, 1})
+
+	var b bytes.Buffer
+	_, err := x.WriteTo(&b)
+	require.NoError(t, err)
+	ppt := newParentPointerTree(x.len())
+	_, err = ppt.ReadFrom(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	dst := ppt.resolve(nil, sid)
+	require.Equal(t, []int32{3, 2, 1}, dst)
+	// The stack resolved previously must not be returned for an unknown ID.
+	require.Empty(t, ppt.resolve(dst, x.len()+1))
+}
+
 func Benchmark_stacktrace_tree_insert(b *testing.B) {
 	p, err := pprof.OpenFile("testdata/profile.pb.gz")
 	require.NoError(b, err)
@@ -0,0 +1,153 @@
+package phlaredb
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/pkg/iter"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+)
+
+// HeadTransfer is the transfer of the heads of the database to another
+// ingester. Once started, the transfer must be either committed, or aborted.
+//
+// Ingestion requests handled during the transfer are written to new heads,
+// while the transferred heads are still available for queries.
+type HeadTransfer struct {
+	db    *PhlareDB
+	heads []*Head
+}
+
+// StartHeadTransfer starts the transfer of the heads. No flush happens
+// until the transfer is committed or aborted.
+func (f *PhlareDB) StartHeadTransfer() *HeadTransfer {
+	f.flushLock.Lock()
+	f.headLock.Lock()
+	defer f.headLock.Unlock()
+	t := &HeadTransfer{db: f, heads: make([]*Head, 0, len(f.heads))}
+	for maxT, h := range f.heads {
+		t.heads = append(t.heads, h)
+		delete(f.heads, maxT)
+	}
+	f.flushing = t.heads
+	return t
+}
+
+// Send calls fn with the profiles of the transferred heads, in batches of
+// at most batchSize profiles. Each profile is sent as a pprof profile with
+// the labels of its series.
+func (t *HeadTransfer) Send(ctx context.Context, batchSize int, fn func([]*pushv1.RawProfileSeries) error) error {
+	batch := make([]*pushv1.RawProfileSeries, 0, batchSize)
+	for _, h := range t.heads {
+		// Ensure all the in-flight ingestion requests have finished.
+		h.inFlightProfiles.Wait()
+		err := h.forEachProfile(ctx, func(lbs phlaremodel.Labels, raw []byte) error {
+			batch = append(batch, &pushv1.RawProfileSeries{
+				Labels: lbs,
+				Samples: []*pushv1.RawSample{{
+					ID:         uuid.NewString(),
+					RawProfile: raw,
+				}},
+			})
+			if len(batch) < batchSize {
+				return nil
+			}
+			err := fn(batch)
+			batch = make([]*pushv1.RawProfileSeries, 0, batchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// Commit discards the transferred heads: their profiles are now owned by
+// another ingester.
+func (t *HeadTransfer) Commit() error {
+	defer t.db.flushLock.Unlock()
+	t.db.headLock.Lock()
+	defer t.db.headLock.Unlock()
+	var err error
+	for _, h := range t.heads {
+		if discardErr := h.Discard(); discardErr != nil && err == nil {
+			err = discardErr
+		}
+	}
+	t.db.flushing = nil
+	return err
+}
+
+// Abort writes the transferred heads to disk, as a flush would.
+func (t *HeadTransfer) Abort(ctx context.Context) error {
+	defer t.db.flushLock.Unlock()
+	return t.db.flushHeads(ctx, flushReasonTransferAborted)
+}
+
+// forEachProfile calls fn with each profile of the head, with the labels of its series.
+func (h *Head) forEachProfile(ctx context.Context, fn func(phlaremodel.Labels, []byte) error) error {
+	types, err := h.ProfileTypes(ctx, connect.NewRequest(&ingestv1.ProfileTypesRequest{}))
+	if err != nil {
+		return err
+	}
+	mint, maxt := h.Bounds()
+	for _, profileType := range types.Msg.ProfileTypes {
+		for _, q := range h.Queriers() {
+			if err = forEachQuerierProfile(ctx, q, &ingestv1.SelectProfilesRequest{
+				LabelSelector: "{}",
+				Type:          profileType,
+				Start:         int64(mint),
+				End:           int64(maxt) + 1,
+			}, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func forEachQuerierProfile(ctx context.Context, q Querier, params *ingestv1.SelectProfilesRequest, fn func(phlaremodel.Labels, []byte) error) error {
+	it, err := q.SelectMatchingProfiles(ctx, params)
+	if err != nil {
+		return err
+	}
+	profiles, err := iter.Slice(it)
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		pprof, err := q.MergePprof(ctx, iter.NewSliceIterator([]Profile{p}))
+		if err != nil {
+			return errors.Wrap(err, "reading profile")
+		}
+		phlaremodel.SetProfileMetadata(pprof, params.Type)
+		pprof.TimeNanos = p.Timestamp().UnixNano()
+		var buf bytes.Buffer
+		if err = pprof.WriteUncompressed(&buf); err != nil {
+			return err
+		}
+		if err = fn(p.Labels(), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IngestTransferred ingests a profile transferred from the head of another ingester.
+func (f *PhlareDB) IngestTransferred(ctx context.Context, p *profilev1.Profile, id uuid.UUID, externalLabels ...*typesv1.LabelPair) error {
+	return f.headForIngest(p.TimeNanos, func(head *Head) error {
+		return head.IngestTransferred(ctx, p, id, externalLabels...)
+	})
+}
@@ -0,0 +1,120 @@
+package phlaredb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/grafana/pyroscope/pkg/iter"
+	"github.com/grafana/pyroscope/pkg/pprof"
+)
+
+func newTransferTestDB(t *testing.T) (*PhlareDB, testCtx) {
+	t.Helper()
+	ctx := testContext(t)
+	db, err := New(ctx, Config{
+		DataPath:         contextDataDir(ctx),
+		MaxBlockDuration: time.Duration(100000) * time.Minute,
+	}, NoLimit, ctx.localBucketClient)
+	require.NoError(t, err)
+	return db, ctx
+}
+
+// headTotal returns the number of profiles and the total value of the CPU profiles in the heads.
+func headTotal(t *testing.T, db *PhlareDB) (profiles int, total int64) {
+	t.Helper()
+	ctx := context.Background()
+	for _, q := range db.headQueriers() {
+		it, err := q.SelectMatchingProfiles(ctx, &ingestv1.SelectProfilesRequest{
+			LabelSelector: `{pod="my-pod"}`,
+			Type:          mustParseProfileSelector(t, "process_cpu:cpu:nanoseconds:cpu:nanoseconds"),
+			Start:         0,
+			End:           time.Hour.Milliseconds() + 1,
+		})
+		require.NoError(t, err)
+		selected, err := iter.Slice(it)
+		require.NoError(t, err)
+		profiles += len(selected)
+		tree, err := q.MergeByStacktraces(ctx, iter.NewSliceIterator(selected))
+		require.NoError(t, err)
+		total += tree.Total()
+	}
+	return profiles, total
+}
+
+func TestHeadTransfer(t *testing.T) {
+	src, srcCtx := newTransferTestDB(t)
+	defer func() {
+		require.NoError(t, src.Close())
+	}()
+	dst, _ := newTransferTestDB(t)
+	defer func() {
+		require.NoError(t, dst.Close())
+	}()
+
+	end := time.Unix(0, int64(time.Hour))
+	ingestProfiles(t, src, cpuProfileGenerator, end.Add(-time.Minute).UnixNano(), end.UnixNano(), 15*time.Second,
+		&typesv1.LabelPair{Name: "pod", Value: "my-pod"},
+	)
+	profiles, total := headTotal(t, src)
+	require.Equal(t, 5, profiles)
+
+	transfer := src.StartHeadTransfer()
+	// The heads are readable until the transfer is committed.
+	profilesTransferring, _ := headTotal(t, src)
+	require.Equal(t, profiles, profilesTransferring)
+
+	var batches int
+	require.NoError(t, transfer.Send(context.Background(), 2, func(series []*pushv1.RawProfileSeries) error {
+		batches++
+		for _, s := range series {
+			for _, sample := range s.Samples {
+				require.NoError(t, pprof.FromBytes(sample.RawProfile, func(p *profilev1.Profile, _ int) error {
+					return dst.IngestTransferred(context.Background(), p, uuid.MustParse(sample.ID), s.Labels...)
+				}))
+			}
+		}
+		return nil
+	}))
+	// 5 CPU profiles with 2 sample types.
+	require.Equal(t, 5, batches)
+	require.NoError(t, transfer.Commit())
+
+	transferredProfiles, transferredTotal := headTotal(t, dst)
+	require.Equal(t, profiles, transferredProfiles)
+	require.Equal(t, total, transferredTotal)
+
+	// The source heads are discarded, without writing a block.
+	srcProfiles, _ := headTotal(t, src)
+	require.Zero(t, srcProfiles)
+	metas, err := src.BlockMetas(srcCtx)
+	require.NoError(t, err)
+	require.Empty(t, metas)
+}
+
+func TestHeadTransfer_Abort(t *testing.T) {
+	db, ctx := newTransferTestDB(t)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+	end := time.Unix(0, int64(time.Hour))
+	ingestProfiles(t, db, cpuProfileGenerator, end.Add(-time.Minute).UnixNano(), end.UnixNano(), 15*time.Second,
+		&typesv1.LabelPair{Name: "pod", Value: "my-pod"},
+	)
+
+	transfer := db.StartHeadTransfer()
+	require.NoError(t, transfer.Abort(context.Background()))
+
+	// The heads are flushed.
+	metas, err := db.BlockMetas(ctx)
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Equal(t, uint64(10), metas[0].Stats.NumProfiles)
+}
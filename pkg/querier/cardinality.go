@@ -0,0 +1,167 @@
+package querier
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+)
+
+const defaultCardinalityLimit = 10
+
+// Cardinality returns the number of series per label name, the top values of
+// each label and the bytes held by the ingesters per service. Series are
+// collected from both the ingesters and the store-gateways, according to the
+// query time range.
+func (q *Querier) Cardinality(ctx context.Context, req *connect.Request[querierv1.CardinalityRequest]) (*connect.Response[querierv1.CardinalityResponse], error) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "Cardinality")
+	defer sp.Finish()
+	sp.LogFields(
+		otlog.String("matchers", strings.Join(req.Msg.Matchers, ",")),
+		otlog.Int64("start", req.Msg.Start),
+		otlog.Int64("end", req.Msg.End),
+		otlog.Int64("limit", req.Msg.Limit),
+	)
+
+	series, err := q.Series(ctx, connect.NewRequest(&querierv1.SeriesRequest{
+		Matchers: req.Msg.Matchers,
+		Start:    req.Msg.Start,
+		End:      req.Msg.End,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	limit := int(req.Msg.Limit)
+	if limit <= 0 {
+		limit = defaultCardinalityLimit
+	}
+	res := &querierv1.CardinalityResponse{
+		SeriesCount: int64(len(series.Msg.LabelsSet)),
+		Labels:      labelsCardinality(series.Msg.LabelsSet, limit),
+	}
+
+	services, err := q.ingestedBytes(ctx, &ingestv1.IngestedBytesRequest{
+		Start: req.Msg.Start,
+		End:   req.Msg.End,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Only report services that match the request.
+	matched := make(map[string]struct{})
+	for _, s := range series.Msg.LabelsSet {
+		matched[phlaremodel.Labels(s.Labels).Get(phlaremodel.LabelNameServiceName)] = struct{}{}
+	}
+	for _, s := range services {
+		if _, ok := matched[s.ServiceName]; ok {
+			res.Services = append(res.Services, s)
+		}
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+// labelsCardinality counts series and distinct values for each label name.
+// Labels are ordered by the number of distinct values, top values by the
+// number of series.
+func labelsCardinality(series []*typesv1.Labels, limit int) []*querierv1.LabelCardinality {
+	type labelStats struct {
+		series int64
+		values map[string]int64
+	}
+	stats := make(map[string]*labelStats)
+	for _, s := range series {
+		for _, l := range s.Labels {
+			ls, ok := stats[l.Name]
+			if !ok {
+				ls = &labelStats{values: make(map[string]int64)}
+				stats[l.Name] = ls
+			}
+			ls.series++
+			ls.values[l.Value]++
+		}
+	}
+
+	result := make([]*querierv1.LabelCardinality, 0, len(stats))
+	for name, ls := range stats {
+		values := make([]*querierv1.LabelValueCardinality, 0, len(ls.values))
+		for v, n := range ls.values {
+			values = append(values, &querierv1.LabelValueCardinality{Value: v, SeriesCount: n})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].SeriesCount != values[j].SeriesCount {
+				return values[i].SeriesCount > values[j].SeriesCount
+			}
+			return values[i].Value < values[j].Value
+		})
+		if len(values) > limit {
+			values = values[:limit]
+		}
+		result = append(result, &querierv1.LabelCardinality{
+			Name:        name,
+			SeriesCount: ls.series,
+			ValuesCount: int64(len(ls.values)),
+			TopValues:   values,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ValuesCount != result[j].ValuesCount {
+			return result[i].ValuesCount > result[j].ValuesCount
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ingestedBytes returns the bytes held by the ingesters per service. Only a
+// quorum of ingesters is queried, and every profile is written to all the
+// replicas: the sum is therefore extrapolated to the whole ring and divided
+// by the replication factor, which makes the result an estimate.
+func (q *Querier) ingestedBytes(ctx context.Context, req *ingestv1.IngestedBytesRequest) ([]*querierv1.ServiceIngestedBytes, error) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "IngestedBytes Ingesters")
+	defer sp.Finish()
+
+	responses, err := forAllIngesters(ctx, q.ingesterQuerier, func(childCtx context.Context, ic IngesterQueryClient) ([]*ingestv1.ServiceIngestedBytes, error) {
+		res, err := ic.IngestedBytes(childCtx, connect.NewRequest(req))
+		if err != nil {
+			return nil, err
+		}
+		return res.Msg.Services, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]int64)
+	for _, r := range responses {
+		for _, s := range r.response {
+			totals[s.ServiceName] += s.Bytes
+		}
+	}
+	instances := int64(q.ingesterQuerier.ring.InstancesCount())
+	divisor := int64(len(responses)) * int64(q.ingesterQuerier.ring.ReplicationFactor())
+	if instances == 0 || divisor == 0 {
+		instances, divisor = 1, 1
+	}
+	services := make([]*querierv1.ServiceIngestedBytes, 0, len(totals))
+	for name, size := range totals {
+		services = append(services, &querierv1.ServiceIngestedBytes{
+			ServiceName: name,
+			Bytes:       size * instances / divisor,
+		})
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Bytes != services[j].Bytes {
+			return services[i].Bytes > services[j].Bytes
+		}
+		return services[i].ServiceName < services[j].ServiceName
+	})
+	return services, nil
+}
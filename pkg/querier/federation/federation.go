@@ -0,0 +1,570 @@
+// Package federation implements the queries spanning multiple tenants.
+//
+// A federated query lists the tenants in the X-Scope-OrgID header separated by '|', e.g. "a|b|c".
+// It is fanned out per tenant and the results are merged. The synthetic label __tenant_id__ can be
+// used in the label selectors to select the tenants and in group_by to split the series per tenant.
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/google/pprof/profile"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"golang.org/x/sync/errgroup"
+
+	profilev1 "github.com/grafana/pyroscope/api/gen/proto/go/google/v1"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/pprof"
+	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/util/math"
+)
+
+type Limits interface {
+	TenantFederationEnabled(tenantID string) bool
+}
+
+// Querier fans the federated queries out per tenant to the next handler and merges the results.
+// The queries of a single tenant are passed through.
+type Querier struct {
+	next   querierv1connect.QuerierServiceHandler
+	limits Limits
+}
+
+var (
+	_ querierv1connect.QuerierServiceHandler = (*Querier)(nil)
+	_ querierv1connect.QuerierServiceClient  = (*Querier)(nil)
+)
+
+func NewQuerier(next querierv1connect.QuerierServiceHandler, limits Limits) *Querier {
+	return &Querier{next: next, limits: limits}
+}
+
+// tenants returns the tenants of a federated query, or nil if the query is not federated.
+func (q *Querier) tenants(ctx context.Context) ([]string, error) {
+	tenantIDs, err := tenant.FederatedTenantIDs(ctx)
+	if err != nil || len(tenantIDs) < 2 {
+		// The next handler reports the missing or invalid tenant.
+		return nil, nil
+	}
+	for _, tenantID := range tenantIDs {
+		if !q.limits.TenantFederationEnabled(tenantID) {
+			return nil, connect.NewError(connect.CodePermissionDenied,
+				fmt.Errorf("tenant federation is not enabled for tenant %s", tenantID))
+		}
+	}
+	return tenantIDs, nil
+}
+
+// selector is a label selector with the tenant matchers split out.
+type selector struct {
+	tenantMatchers []*labels.Matcher
+	// selector is the label selector without the tenant matchers.
+	selector string
+}
+
+func parseSelector(s string) (selector, error) {
+	matchers, err := parser.ParseMetricSelector(s)
+	if err != nil {
+		return selector{}, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	res := selector{selector: s}
+	rest := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Name == phlaremodel.LabelNameTenantID {
+			res.tenantMatchers = append(res.tenantMatchers, m)
+			continue
+		}
+		rest = append(rest, m.String())
+	}
+	if len(res.tenantMatchers) > 0 {
+		res.selector = "{" + strings.Join(rest, ",") + "}"
+	}
+	return res, nil
+}
+
+func (s selector) matches(tenantID string) bool {
+	for _, m := range s.tenantMatchers {
+		if !m.Matches(tenantID) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectTenants returns the tenants matched by the label selector and the selector to query them with.
+func selectTenants(tenantIDs []string, labelSelector string) ([]string, string, error) {
+	s, err := parseSelector(labelSelector)
+	if err != nil {
+		return nil, "", err
+	}
+	selected := make([]string, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		if s.matches(tenantID) {
+			selected = append(selected, tenantID)
+		}
+	}
+	return selected, s.selector, nil
+}
+
+// selectTenantsMatchers is selectTenants for a list of selectors, a tenant is queried with the
+// selectors matching it. An empty list selects all the tenants.
+func selectTenantsMatchers(tenantIDs []string, matchers []string) (map[string][]string, error) {
+	res := make(map[string][]string, len(tenantIDs))
+	if len(matchers) == 0 {
+		for _, tenantID := range tenantIDs {
+			res[tenantID] = nil
+		}
+		return res, nil
+	}
+	for _, m := range matchers {
+		s, err := parseSelector(m)
+		if err != nil {
+			return nil, err
+		}
+		for _, tenantID := range tenantIDs {
+			if s.matches(tenantID) {
+				res[tenantID] = append(res[tenantID], s.selector)
+			}
+		}
+	}
+	return res, nil
+}
+
+// forEachTenant calls fn concurrently with the context of each tenant.
+func forEachTenant(ctx context.Context, tenantIDs []string, fn func(ctx context.Context, tenantID string) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, tenantID := range tenantIDs {
+		tenantID := tenantID
+		g.Go(func() error {
+			return fn(user.InjectOrgID(ctx, tenantID), tenantID)
+		})
+	}
+	return g.Wait()
+}
+
+// newRequest returns a request with the headers of the federated request.
+func newRequest[Req any](header http.Header, msg *Req) *connect.Request[Req] {
+	req := connect.NewRequest(msg)
+	for k, v := range header {
+		req.Header()[k] = v
+	}
+	return req
+}
+
+// tenantRequest returns a request of the tenant with the headers of the federated request.
+func tenantRequest[Req any](c connect.AnyRequest, tenantID string, msg *Req) *connect.Request[Req] {
+	req := newRequest(c.Header(), msg)
+	req.Header().Set(user.OrgIDHeaderName, tenantID)
+	return req
+}
+
+func (q *Querier) ProfileTypes(ctx context.Context, c *connect.Request[querierv1.ProfileTypesRequest]) (*connect.Response[querierv1.ProfileTypesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.ProfileTypes(ctx, c)
+	}
+	var mu sync.Mutex
+	profileTypes := make(map[string]*typesv1.ProfileType)
+	err = forEachTenant(ctx, tenantIDs, func(ctx context.Context, tenantID string) error {
+		resp, err := q.next.ProfileTypes(ctx, tenantRequest(c, tenantID, c.Msg.CloneVT()))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, t := range resp.Msg.ProfileTypes {
+			profileTypes[t.ID] = t
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &querierv1.ProfileTypesResponse{ProfileTypes: make([]*typesv1.ProfileType, 0, len(profileTypes))}
+	for _, t := range profileTypes {
+		res.ProfileTypes = append(res.ProfileTypes, t)
+	}
+	sort.Slice(res.ProfileTypes, func(i, j int) bool {
+		return res.ProfileTypes[i].ID < res.ProfileTypes[j].ID
+	})
+	return connect.NewResponse(res), nil
+}
+
+func (q *Querier) LabelValues(ctx context.Context, c *connect.Request[typesv1.LabelValuesRequest]) (*connect.Response[typesv1.LabelValuesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.LabelValues(ctx, c)
+	}
+	tenantMatchers, err := selectTenantsMatchers(tenantIDs, c.Msg.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	if c.Msg.Name == phlaremodel.LabelNameTenantID {
+		return connect.NewResponse(&typesv1.LabelValuesResponse{Names: keys(tenantMatchers)}), nil
+	}
+	var mu sync.Mutex
+	values := make(map[string]struct{})
+	err = forEachTenant(ctx, keys(tenantMatchers), func(ctx context.Context, tenantID string) error {
+		resp, err := q.next.LabelValues(ctx, tenantRequest(c, tenantID, &typesv1.LabelValuesRequest{
+			Name:     c.Msg.Name,
+			Matchers: tenantMatchers[tenantID],
+		}))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, v := range resp.Msg.Names {
+			values[v] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&typesv1.LabelValuesResponse{Names: sortedKeys(values)}), nil
+}
+
+func (q *Querier) LabelNames(ctx context.Context, c *connect.Request[typesv1.LabelNamesRequest]) (*connect.Response[typesv1.LabelNamesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.LabelNames(ctx, c)
+	}
+	tenantMatchers, err := selectTenantsMatchers(tenantIDs, c.Msg.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	names := make(map[string]struct{})
+	if len(tenantMatchers) > 0 {
+		names[phlaremodel.LabelNameTenantID] = struct{}{}
+	}
+	err = forEachTenant(ctx, keys(tenantMatchers), func(ctx context.Context, tenantID string) error {
+		resp, err := q.next.LabelNames(ctx, tenantRequest(c, tenantID, &typesv1.LabelNamesRequest{
+			Matchers: tenantMatchers[tenantID],
+		}))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, n := range resp.Msg.Names {
+			names[n] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&typesv1.LabelNamesResponse{Names: sortedKeys(names)}), nil
+}
+
+func (q *Querier) Series(ctx context.Context, c *connect.Request[querierv1.SeriesRequest]) (*connect.Response[querierv1.SeriesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.Series(ctx, c)
+	}
+	tenantMatchers, err := selectTenantsMatchers(tenantIDs, c.Msg.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	labelNames, withTenant := withoutTenantLabel(c.Msg.LabelNames)
+	withTenant = withTenant || len(c.Msg.LabelNames) == 0
+	if len(labelNames) == 0 && len(c.Msg.LabelNames) > 0 {
+		// Only the tenant is requested.
+		res := &querierv1.SeriesResponse{}
+		for _, tenantID := range keys(tenantMatchers) {
+			res.LabelsSet = append(res.LabelsSet, &typesv1.Labels{Labels: tenantLabels(nil, tenantID)})
+		}
+		return connect.NewResponse(res), nil
+	}
+	var mu sync.Mutex
+	series := make(map[uint64]*typesv1.Labels)
+	err = forEachTenant(ctx, keys(tenantMatchers), func(ctx context.Context, tenantID string) error {
+		resp, err := q.next.Series(ctx, tenantRequest(c, tenantID, &querierv1.SeriesRequest{
+			Matchers:   tenantMatchers[tenantID],
+			LabelNames: labelNames,
+			Start:      c.Msg.Start,
+			End:        c.Msg.End,
+		}))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, ls := range resp.Msg.LabelsSet {
+			if withTenant {
+				ls.Labels = tenantLabels(ls.Labels, tenantID)
+			}
+			series[phlaremodel.Labels(ls.Labels).Hash()] = ls
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &querierv1.SeriesResponse{LabelsSet: make([]*typesv1.Labels, 0, len(series))}
+	for _, ls := range series {
+		res.LabelsSet = append(res.LabelsSet, ls)
+	}
+	sort.Slice(res.LabelsSet, func(i, j int) bool {
+		return phlaremodel.CompareLabelPairs(res.LabelsSet[i].Labels, res.LabelsSet[j].Labels) < 0
+	})
+	return connect.NewResponse(res), nil
+}
+
+func (q *Querier) SelectMergeStacktraces(ctx context.Context, c *connect.Request[querierv1.SelectMergeStacktracesRequest]) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.SelectMergeStacktraces(ctx, c)
+	}
+	selected, labelSelector, err := selectTenants(tenantIDs, c.Msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	m := phlaremodel.NewFlameGraphMerger()
+	err = forEachTenant(ctx, selected, func(ctx context.Context, tenantID string) error {
+		msg := c.Msg.CloneVT()
+		msg.LabelSelector = labelSelector
+		resp, err := q.next.SelectMergeStacktraces(ctx, tenantRequest(c, tenantID, msg))
+		if err != nil {
+			return err
+		}
+		m.MergeFlameGraph(resp.Msg.Flamegraph)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&querierv1.SelectMergeStacktracesResponse{
+		Flamegraph: m.FlameGraph(c.Msg.GetMaxNodes()),
+	}), nil
+}
+
+func (q *Querier) SelectMergeSpanProfile(ctx context.Context, c *connect.Request[querierv1.SelectMergeSpanProfileRequest]) (*connect.Response[querierv1.SelectMergeSpanProfileResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.SelectMergeSpanProfile(ctx, c)
+	}
+	selected, labelSelector, err := selectTenants(tenantIDs, c.Msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	m := phlaremodel.NewFlameGraphMerger()
+	err = forEachTenant(ctx, selected, func(ctx context.Context, tenantID string) error {
+		msg := c.Msg.CloneVT()
+		msg.LabelSelector = labelSelector
+		resp, err := q.next.SelectMergeSpanProfile(ctx, tenantRequest(c, tenantID, msg))
+		if err != nil {
+			return err
+		}
+		m.MergeFlameGraph(resp.Msg.Flamegraph)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&querierv1.SelectMergeSpanProfileResponse{
+		Flamegraph: m.FlameGraph(c.Msg.GetMaxNodes()),
+	}), nil
+}
+
+func (q *Querier) SelectMergeProfile(ctx context.Context, c *connect.Request[querierv1.SelectMergeProfileRequest]) (*connect.Response[profilev1.Profile], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.SelectMergeProfile(ctx, c)
+	}
+	selected, labelSelector, err := selectTenants(tenantIDs, c.Msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	profiles := make([]*profile.Profile, 0, len(selected))
+	err = forEachTenant(ctx, selected, func(ctx context.Context, tenantID string) error {
+		msg := c.Msg.CloneVT()
+		msg.LabelSelector = labelSelector
+		resp, err := q.next.SelectMergeProfile(ctx, tenantRequest(c, tenantID, msg))
+		if err != nil {
+			return err
+		}
+		b, err := resp.Msg.MarshalVT()
+		if err != nil {
+			return err
+		}
+		p, err := profile.ParseUncompressed(b)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		profiles = append(profiles, p)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return connect.NewResponse(&profilev1.Profile{}), nil
+	}
+	p, err := profile.Merge(profiles)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	res, err := pprof.FromProfile(p)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (q *Querier) SelectSeries(ctx context.Context, c *connect.Request[querierv1.SelectSeriesRequest]) (*connect.Response[querierv1.SelectSeriesResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.SelectSeries(ctx, c)
+	}
+	selected, labelSelector, err := selectTenants(tenantIDs, c.Msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	groupBy, byTenant := withoutTenantLabel(c.Msg.GroupBy)
+	// The series of the tenants are summed up unless grouped by tenant.
+	m := phlaremodel.NewSeriesMerger(true)
+	err = forEachTenant(ctx, selected, func(ctx context.Context, tenantID string) error {
+		msg := c.Msg.CloneVT()
+		msg.LabelSelector = labelSelector
+		msg.GroupBy = groupBy
+		resp, err := q.next.SelectSeries(ctx, tenantRequest(c, tenantID, msg))
+		if err != nil {
+			return err
+		}
+		if byTenant {
+			for _, s := range resp.Msg.Series {
+				s.Labels = tenantLabels(s.Labels, tenantID)
+			}
+		}
+		m.MergeSeries(resp.Msg.Series)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&querierv1.SelectSeriesResponse{Series: m.Series()}), nil
+}
+
+func (q *Querier) Cardinality(ctx context.Context, c *connect.Request[querierv1.CardinalityRequest]) (*connect.Response[querierv1.CardinalityResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.Cardinality(ctx, c)
+	}
+	return nil, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("cardinality is not supported for federated queries"))
+}
+
+func (q *Querier) Diff(ctx context.Context, c *connect.Request[querierv1.DiffRequest]) (*connect.Response[querierv1.DiffResponse], error) {
+	tenantIDs, err := q.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs == nil {
+		return q.next.Diff(ctx, c)
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	var left, right *phlaremodel.Tree
+	selectTree := func(req *querierv1.SelectMergeStacktracesRequest, t **phlaremodel.Tree) func() error {
+		return func() error {
+			resp, err := q.SelectMergeStacktraces(ctx, newRequest(c.Header(), req))
+			if err != nil {
+				return err
+			}
+			m := phlaremodel.NewFlameGraphMerger()
+			m.MergeFlameGraph(resp.Msg.Flamegraph)
+			*t = m.Tree()
+			return nil
+		}
+	}
+	g.Go(selectTree(c.Msg.Left, &left))
+	g.Go(selectTree(c.Msg.Right, &right))
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+
+	maxNodes := int(math.Max(c.Msg.Left.GetMaxNodes(), c.Msg.Right.GetMaxNodes()))
+	diff, err := phlaremodel.NewFlamegraphDiff(left, right, maxNodes)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return connect.NewResponse(&querierv1.DiffResponse{Flamegraph: diff}), nil
+}
+
+// withoutTenantLabel removes the tenant label from the label names and reports whether it was present.
+func withoutTenantLabel(names []string) ([]string, bool) {
+	res := make([]string, 0, len(names))
+	found := false
+	for _, n := range names {
+		if n == phlaremodel.LabelNameTenantID {
+			found = true
+			continue
+		}
+		res = append(res, n)
+	}
+	return res, found
+}
+
+func tenantLabels(ls []*typesv1.LabelPair, tenantID string) []*typesv1.LabelPair {
+	return phlaremodel.NewLabelsBuilder(ls).Set(phlaremodel.LabelNameTenantID, tenantID).Labels()
+}
+
+func keys(m map[string][]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,159 @@
+package federation
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/validation"
+)
+
+// fakeQuerier answers the queries of a single tenant, with the tenant name as the only service.
+type fakeQuerier struct {
+	querierv1connect.UnimplementedQuerierServiceHandler
+
+	mu        sync.Mutex
+	selectors map[string]string
+}
+
+func (f *fakeQuerier) tenant(ctx context.Context, selector string) (string, error) {
+	tenantID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.selectors == nil {
+		f.selectors = make(map[string]string)
+	}
+	f.selectors[tenantID] = selector
+	return tenantID, nil
+}
+
+func (f *fakeQuerier) LabelValues(ctx context.Context, c *connect.Request[typesv1.LabelValuesRequest]) (*connect.Response[typesv1.LabelValuesResponse], error) {
+	tenantID, err := f.tenant(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&typesv1.LabelValuesResponse{Names: []string{tenantID}}), nil
+}
+
+func (f *fakeQuerier) SelectMergeStacktraces(ctx context.Context, c *connect.Request[querierv1.SelectMergeStacktracesRequest]) (*connect.Response[querierv1.SelectMergeStacktracesResponse], error) {
+	tenantID, err := f.tenant(ctx, c.Msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	t := new(phlaremodel.Tree)
+	t.InsertStack(1, "main", tenantID)
+	return connect.NewResponse(&querierv1.SelectMergeStacktracesResponse{
+		Flamegraph: phlaremodel.NewFlameGraph(t, c.Msg.GetMaxNodes()),
+	}), nil
+}
+
+func (f *fakeQuerier) SelectSeries(ctx context.Context, c *connect.Request[querierv1.SelectSeriesRequest]) (*connect.Response[querierv1.SelectSeriesResponse], error) {
+	if _, err := f.tenant(ctx, c.Msg.LabelSelector); err != nil {
+		return nil, err
+	}
+	var ls phlaremodel.Labels
+	for _, name := range c.Msg.GroupBy {
+		ls = append(ls, &typesv1.LabelPair{Name: name, Value: "api"})
+	}
+	return connect.NewResponse(&querierv1.SelectSeriesResponse{
+		Series: []*typesv1.Series{{Labels: ls, Points: []*typesv1.Point{{Timestamp: 1000, Value: 1}}}},
+	}), nil
+}
+
+func newTestQuerier(t *testing.T, enabled ...string) (*Querier, *fakeQuerier) {
+	t.Helper()
+	overrides := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		for _, tenantID := range enabled {
+			l := *defaults
+			l.TenantFederationEnabled = true
+			tenantLimits[tenantID] = &l
+		}
+	})
+	next := &fakeQuerier{}
+	return NewQuerier(next, overrides), next
+}
+
+func Test_SingleTenantPassThrough(t *testing.T) {
+	q, next := newTestQuerier(t)
+	resp, err := q.LabelValues(user.InjectOrgID(context.Background(), "a"), connect.NewRequest(&typesv1.LabelValuesRequest{Name: "service_name"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, resp.Msg.Names)
+	require.Len(t, next.selectors, 1)
+}
+
+func Test_FederationDisabled(t *testing.T) {
+	q, _ := newTestQuerier(t, "a", "b")
+	_, err := q.LabelValues(user.InjectOrgID(context.Background(), "a|b|c"), connect.NewRequest(&typesv1.LabelValuesRequest{Name: "service_name"}))
+	require.Error(t, err)
+	require.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+}
+
+func Test_LabelValues(t *testing.T) {
+	q, _ := newTestQuerier(t, "a", "b", "c")
+	ctx := user.InjectOrgID(context.Background(), "a|b|c")
+
+	resp, err := q.LabelValues(ctx, connect.NewRequest(&typesv1.LabelValuesRequest{Name: "service_name"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, resp.Msg.Names)
+
+	resp, err = q.LabelValues(ctx, connect.NewRequest(&typesv1.LabelValuesRequest{
+		Name:     phlaremodel.LabelNameTenantID,
+		Matchers: []string{`{__tenant_id__=~"a|c"}`},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "c"}, resp.Msg.Names)
+}
+
+func Test_SelectMergeStacktraces(t *testing.T) {
+	q, next := newTestQuerier(t, "a", "b", "c")
+	ctx := user.InjectOrgID(context.Background(), "a|b|c")
+
+	resp, err := q.SelectMergeStacktraces(ctx, connect.NewRequest(&querierv1.SelectMergeStacktracesRequest{
+		LabelSelector: `{service_name="api", __tenant_id__!="b"}`,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"a": `{service_name="api"}`,
+		"c": `{service_name="api"}`,
+	}, next.selectors)
+	require.Equal(t, int64(2), resp.Msg.Flamegraph.Total)
+	require.ElementsMatch(t, []string{"total", "main", "a", "c"}, resp.Msg.Flamegraph.Names)
+}
+
+func Test_SelectSeries(t *testing.T) {
+	q, _ := newTestQuerier(t, "a", "b")
+	ctx := user.InjectOrgID(context.Background(), "a|b")
+
+	resp, err := q.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
+		LabelSelector: `{}`,
+		GroupBy:       []string{"service_name"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Series, 1)
+	require.Equal(t, float64(2), resp.Msg.Series[0].Points[0].Value)
+
+	resp, err = q.SelectSeries(ctx, connect.NewRequest(&querierv1.SelectSeriesRequest{
+		LabelSelector: `{}`,
+		GroupBy:       []string{phlaremodel.LabelNameTenantID, "service_name"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Series, 2)
+	for i, tenantID := range []string{"a", "b"} {
+		require.Equal(t, phlaremodel.LabelsFromStrings(
+			phlaremodel.LabelNameTenantID, tenantID,
+			"service_name", "api",
+		), phlaremodel.Labels(resp.Msg.Series[i].Labels))
+		require.Equal(t, float64(1), resp.Msg.Series[i].Points[0].Value)
+	}
+}
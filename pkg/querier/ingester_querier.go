@@ -24,6 +24,7 @@ type IngesterQueryClient interface {
 	LabelNames(context.Context, *connect.Request[typesv1.LabelNamesRequest]) (*connect.Response[typesv1.LabelNamesResponse], error)
 	ProfileTypes(context.Context, *connect.Request[ingestv1.ProfileTypesRequest]) (*connect.Response[ingestv1.ProfileTypesResponse], error)
 	Series(ctx context.Context, req *connect.Request[ingestv1.SeriesRequest]) (*connect.Response[ingestv1.SeriesResponse], error)
+	IngestedBytes(ctx context.Context, req *connect.Request[ingestv1.IngestedBytesRequest]) (*connect.Response[ingestv1.IngestedBytesResponse], error)
 	MergeProfilesStacktraces(context.Context) clientpool.BidiClientMergeProfilesStacktraces
 	MergeProfilesLabels(ctx context.Context) clientpool.BidiClientMergeProfilesLabels
 	MergeProfilesPprof(ctx context.Context) clientpool.BidiClientMergeProfilesPprof
@@ -34,12 +35,15 @@ type IngesterQueryClient interface {
 type IngesterQuerier struct {
 	ring ring.ReadRing
 	pool *ring_client.Pool
+
+	minimizeZones bool
 }
 
-func NewIngesterQuerier(pool *ring_client.Pool, ring ring.ReadRing) *IngesterQuerier {
+func NewIngesterQuerier(cfg Config, pool *ring_client.Pool, ring ring.ReadRing) *IngesterQuerier {
 	return &IngesterQuerier{
-		ring: ring,
-		pool: pool,
+		ring:          ring,
+		pool:          pool,
+		minimizeZones: cfg.MinimizeZones,
 	}
 }
 
@@ -53,6 +57,9 @@ func forAllIngesters[T any](ctx context.Context, ingesterQuerier *IngesterQuerie
 	if err != nil {
 		return nil, err
 	}
+	if ingesterQuerier.minimizeZones {
+		replicationSet = minimizeZones(replicationSet)
+	}
 	return forGivenReplicationSet(ctx, func(addr string) (IngesterQueryClient, error) {
 		client, err := ingesterQuerier.pool.GetClientFor(addr)
 		if err != nil {
@@ -94,7 +101,8 @@ func (q *Querier) selectTreeFromIngesters(ctx context.Context, req *querierv1.Se
 					End:           req.End,
 					Type:          profileType,
 				},
-				MaxNodes: req.MaxNodes,
+				MaxNodes:      req.MaxNodes,
+				FunctionNames: req.FunctionNames,
 				// TODO(kolesnikovae): Max stacks.
 			})
 		}))
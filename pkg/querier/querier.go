@@ -39,12 +39,14 @@ import (
 type Config struct {
 	PoolConfig      clientpool.PoolConfig `yaml:"pool_config,omitempty"`
 	QueryStoreAfter time.Duration         `yaml:"query_store_after" category:"advanced"`
+	MinimizeZones   bool                  `yaml:"minimize_zones" category:"experimental"`
 }
 
 // RegisterFlags registers distributor-related flags.
 func (cfg *Config) RegisterFlags(fs *flag.FlagSet) {
 	cfg.PoolConfig.RegisterFlagsWithPrefix("querier", fs)
 	fs.DurationVar(&cfg.QueryStoreAfter, "querier.query-store-after", 4*time.Hour, "The time after which a metric should be queried from storage and not just ingesters. 0 means all queries are sent to store. If this option is enabled, the time range of the query sent to the store-gateway will be manipulated to ensure the query end is not more recent than 'now - query-store-after'.")
+	fs.BoolVar(&cfg.MinimizeZones, "querier.minimize-zones", false, "Query the ingesters and store-gateways of a single availability zone, when zone-awareness is enabled, and other zones only if instances of that zone fail. This lowers the cost of queries, but profiles written to a quorum of ingesters may be missing from the zone queried.")
 }
 
 type Querier struct {
@@ -74,6 +76,7 @@ func New(cfg Config, ingestersRing ring.ReadRing, factory ring_client.PoolFactor
 		cfg:    cfg,
 		logger: logger,
 		ingesterQuerier: NewIngesterQuerier(
+			cfg,
 			clientpool.NewIngesterPool(cfg.PoolConfig, ingestersRing, factory, clientsMetrics, logger, clientsOptions...),
 			ingestersRing,
 		),
@@ -456,6 +459,7 @@ func (sq storeQuery) MergeStacktracesRequest(req *querierv1.SelectMergeStacktrac
 		LabelSelector: req.LabelSelector,
 		ProfileTypeID: req.ProfileTypeID,
 		MaxNodes:      req.MaxNodes,
+		FunctionNames: req.FunctionNames,
 	}
 }
 
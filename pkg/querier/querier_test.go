@@ -174,6 +174,63 @@ func Test_Series(t *testing.T) {
 	}, out.Msg.LabelsSet)
 }
 
+func Test_Cardinality(t *testing.T) {
+	seriesResponse := connect.NewResponse(&ingestv1.SeriesResponse{LabelsSet: []*typesv1.Labels{
+		{Labels: phlaremodel.LabelsFromStrings("service_name", "foo", "pod", "a")},
+		{Labels: phlaremodel.LabelsFromStrings("service_name", "foo", "pod", "b")},
+		{Labels: phlaremodel.LabelsFromStrings("service_name", "foo", "pod", "c")},
+		{Labels: phlaremodel.LabelsFromStrings("service_name", "bar", "pod", "a")},
+	}})
+	bytesResponse := connect.NewResponse(&ingestv1.IngestedBytesResponse{Services: []*ingestv1.ServiceIngestedBytes{
+		{ServiceName: "foo", Bytes: 300},
+		{ServiceName: "bar", Bytes: 30},
+		{ServiceName: "unmatched", Bytes: 3000},
+	}})
+	querier, err := New(Config{
+		PoolConfig: clientpool.PoolConfig{ClientCleanupPeriod: 1 * time.Millisecond},
+	}, testhelper.NewMockRing([]ring.InstanceDesc{
+		{Addr: "1"},
+		{Addr: "2"},
+		{Addr: "3"},
+	}, 3), &poolFactory{func(addr string) (client.PoolClient, error) {
+		q := newFakeQuerier()
+		q.On("Series", mock.Anything, mock.Anything).Return(seriesResponse, nil)
+		q.On("IngestedBytes", mock.Anything, mock.Anything).Return(bytesResponse, nil)
+		return q, nil
+	}}, nil, nil, log.NewLogfmtLogger(os.Stdout))
+	require.NoError(t, err)
+
+	out, err := querier.Cardinality(context.Background(), connect.NewRequest(&querierv1.CardinalityRequest{Limit: 2}))
+	require.NoError(t, err)
+	require.Equal(t, &querierv1.CardinalityResponse{
+		SeriesCount: 4,
+		Labels: []*querierv1.LabelCardinality{
+			{
+				Name:        "pod",
+				SeriesCount: 4,
+				ValuesCount: 3,
+				TopValues: []*querierv1.LabelValueCardinality{
+					{Value: "a", SeriesCount: 2},
+					{Value: "b", SeriesCount: 1},
+				},
+			},
+			{
+				Name:        "service_name",
+				SeriesCount: 4,
+				ValuesCount: 2,
+				TopValues: []*querierv1.LabelValueCardinality{
+					{Value: "foo", SeriesCount: 3},
+					{Value: "bar", SeriesCount: 1},
+				},
+			},
+		},
+		Services: []*querierv1.ServiceIngestedBytes{
+			{ServiceName: "foo", Bytes: 300},
+			{ServiceName: "bar", Bytes: 30},
+		},
+	}, out.Msg)
+}
+
 func Test_SelectMergeStacktraces(t *testing.T) {
 	req := connect.NewRequest(&querierv1.SelectMergeStacktracesRequest{
 		LabelSelector: `{app="foo"}`,
@@ -567,6 +624,22 @@ func (f *fakeQuerierIngester) Series(ctx context.Context, req *connect.Request[i
 	return res, err
 }
 
+func (f *fakeQuerierIngester) IngestedBytes(ctx context.Context, req *connect.Request[ingestv1.IngestedBytesRequest]) (*connect.Response[ingestv1.IngestedBytesResponse], error) {
+	var (
+		args = f.Called(ctx, req)
+		res  *connect.Response[ingestv1.IngestedBytesResponse]
+		err  error
+	)
+	if args[0] != nil {
+		res = args[0].(*connect.Response[ingestv1.IngestedBytesResponse])
+	}
+	if args[1] != nil {
+		err = args.Get(1).(error)
+	}
+
+	return res, err
+}
+
 type testProfile struct {
 	Ts     int64
 	Labels *typesv1.Labels
@@ -69,3 +69,22 @@ func forGivenReplicationSet[Result any, Querier any](ctx context.Context, client
 
 	return results, err
 }
+
+// minimizeZones returns a replication set for which a single zone is queried,
+// unless instances of that zone fail: the other zones are then queried in turn.
+// It only applies to zone-aware replication sets, with at least one zone that
+// can be unavailable.
+//
+// Profiles written to a quorum of ingesters may be missing from the queried zone,
+// which is the cost of querying fewer instances.
+func minimizeZones(rs ring.ReplicationSet) ring.ReplicationSet {
+	if rs.MaxUnavailableZones == 0 {
+		return rs
+	}
+	zones := make(map[string]struct{})
+	for _, instance := range rs.Instances {
+		zones[instance.Zone] = struct{}{}
+	}
+	rs.MaxUnavailableZones = len(zones) - 1
+	return rs
+}
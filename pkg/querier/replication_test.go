@@ -0,0 +1,95 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_minimizeZones(t *testing.T) {
+	instances := []ring.InstanceDesc{
+		{Addr: "a-1", Zone: "a"},
+		{Addr: "a-2", Zone: "a"},
+		{Addr: "b-1", Zone: "b"},
+		{Addr: "b-2", Zone: "b"},
+		{Addr: "c-1", Zone: "c"},
+		{Addr: "c-2", Zone: "c"},
+	}
+	for _, tc := range []struct {
+		name     string
+		in       ring.ReplicationSet
+		expected ring.ReplicationSet
+	}{
+		{
+			name:     "zone-aware",
+			in:       ring.ReplicationSet{Instances: instances, MaxUnavailableZones: 1},
+			expected: ring.ReplicationSet{Instances: instances, MaxUnavailableZones: 2},
+		},
+		{
+			name:     "zone-aware with a failed zone",
+			in:       ring.ReplicationSet{Instances: instances[:4], MaxUnavailableZones: 0},
+			expected: ring.ReplicationSet{Instances: instances[:4], MaxUnavailableZones: 0},
+		},
+		{
+			name:     "not zone-aware",
+			in:       ring.ReplicationSet{Instances: instances, MaxErrors: 2},
+			expected: ring.ReplicationSet{Instances: instances, MaxErrors: 2},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, minimizeZones(tc.in))
+		})
+	}
+}
+
+func Test_forGivenReplicationSet_MinimizeZones(t *testing.T) {
+	rs := minimizeZones(ring.ReplicationSet{
+		Instances: []ring.InstanceDesc{
+			{Addr: "a-1", Zone: "a"},
+			{Addr: "a-2", Zone: "a"},
+			{Addr: "b-1", Zone: "b"},
+			{Addr: "b-2", Zone: "b"},
+			{Addr: "c-1", Zone: "c"},
+			{Addr: "c-2", Zone: "c"},
+		},
+		MaxUnavailableZones: 1,
+	})
+
+	var (
+		mtx     sync.Mutex
+		queried = make(map[string]int)
+	)
+	query := func(failing string) []ResponseFromReplica[string] {
+		queried = make(map[string]int)
+		res, err := forGivenReplicationSet(context.Background(), func(addr string) (string, error) {
+			return addr, nil
+		}, rs, func(_ context.Context, addr string) (string, error) {
+			mtx.Lock()
+			queried[addr[:1]]++
+			mtx.Unlock()
+			if addr[:1] == failing {
+				return "", errors.New("zone unavailable")
+			}
+			return addr, nil
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	// A single zone is queried.
+	res := query("")
+	require.Len(t, res, 2)
+	require.Len(t, queried, 1)
+	require.Equal(t, res[0].addr[:1], res[1].addr[:1])
+
+	// Another zone is queried when instances of the first fail.
+	res = query("a")
+	require.Len(t, res, 2)
+	require.NotEqual(t, "a", res[0].addr[:1])
+	require.Equal(t, res[0].addr[:1], res[1].addr[:1])
+}
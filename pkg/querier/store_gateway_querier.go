@@ -44,6 +44,8 @@ type StoreGatewayQuerier struct {
 	pool   *ring_client.Pool
 	limits StoreGatewayLimits
 
+	minimizeZones bool
+
 	services.Service
 	// Subservices manager.
 	subservices        *services.Manager
@@ -52,13 +54,14 @@ type StoreGatewayQuerier struct {
 
 func NewStoreGatewayQuerier(
 	gatewayCfg storegateway.Config,
+	querierCfg Config,
 	factory ring_client.PoolFactory,
 	limits StoreGatewayLimits,
 	logger log.Logger,
 	reg prometheus.Registerer,
 	clientsOptions ...connect.ClientOption,
 ) (*StoreGatewayQuerier, error) {
-	storesRingCfg := gatewayCfg.ShardingRing.Ring.ToRingConfig()
+	storesRingCfg := gatewayCfg.ShardingRing.ToRingConfig()
 	storesRingBackend, err := kv.NewClient(
 		storesRingCfg.KVStore,
 		ring.GetCodec(),
@@ -86,6 +89,7 @@ func NewStoreGatewayQuerier(
 		ring:               storesRing,
 		pool:               pool,
 		limits:             limits,
+		minimizeZones:      querierCfg.MinimizeZones,
 		subservicesWatcher: services.NewFailureWatcher(),
 	}
 	s.subservices, err = services.NewManager(storesRing, pool)
@@ -129,6 +133,9 @@ func forAllStoreGateways[T any](ctx context.Context, tenantID string, storegatew
 	if err != nil {
 		return nil, err
 	}
+	if storegatewayQuerier.minimizeZones {
+		replicationSet = minimizeZones(replicationSet)
+	}
 
 	return forGivenReplicationSet(ctx, func(addr string) (StoreGatewayQueryClient, error) {
 		client, err := storegatewayQuerier.pool.GetClientFor(addr)
@@ -189,7 +196,8 @@ func (q *Querier) selectTreeFromStoreGateway(ctx context.Context, req *querierv1
 					End:           req.End,
 					Type:          profileType,
 				},
-				MaxNodes: req.MaxNodes,
+				MaxNodes:      req.MaxNodes,
+				FunctionNames: req.FunctionNames,
 				// TODO(kolesnikovae): Max stacks.
 			})
 		}))
@@ -66,6 +66,7 @@ func newSchedulerProcessor(cfg Config, handler RequestHandler, log log.Logger, r
 			Help:    "Time spend doing requests to frontend.",
 			Buckets: prometheus.ExponentialBuckets(0.001, 4, 6),
 		}, []string{"operation", "status_code"}),
+		frontendClientInterceptors: cfg.FrontendClientInterceptors,
 	}
 
 	frontendClientsGauge := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
@@ -94,6 +95,7 @@ type schedulerProcessor struct {
 
 	frontendPool                  *client.Pool
 	frontendClientRequestDuration *prometheus.HistogramVec
+	frontendClientInterceptors    []grpc.UnaryClientInterceptor
 
 	schedulerClientFactory func(conn *grpc.ClientConn) schedulerpb.SchedulerForQuerierClient
 }
@@ -298,11 +300,11 @@ func (f *frontendClientFactory) FromInstance(inst ring.InstanceDesc) (client.Poo
 
 func (sp *schedulerProcessor) frontendClientFactory() client.PoolFactory {
 	return newFrontendClientFactory(func() ([]grpc.DialOption, error) {
-		return sp.grpcConfig.DialOption([]grpc.UnaryClientInterceptor{
+		return sp.grpcConfig.DialOption(append([]grpc.UnaryClientInterceptor{
 			otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 			middleware.ClientUserHeaderInterceptor,
 			middleware.UnaryClientInstrumentInterceptor(sp.frontendClientRequestDuration),
-		}, nil)
+		}, sp.frontendClientInterceptors...), nil)
 	})
 }
 
@@ -0,0 +1,49 @@
+package queue
+
+import "strings"
+
+// Priority is the class of a request. The requests of a tenant are dequeued by weighted round-robin
+// over the priority classes, so that expensive queries can't starve the others of the same tenant.
+type Priority int
+
+const (
+	PriorityHigh Priority = iota
+	PriorityNormal
+	PriorityLow
+
+	numPriorities = 3
+)
+
+// PriorityHeader is the request header setting the priority class of a query: high, normal or low.
+const PriorityHeader = "X-Query-Priority"
+
+// priorityWeights are the dequeue weights of the priority classes: when a tenant has requests
+// of all the classes pending, 4 out of 7 dequeued requests are high, 2 are normal and 1 is low.
+var priorityWeights = [numPriorities]int{
+	PriorityHigh:   4,
+	PriorityNormal: 2,
+	PriorityLow:    1,
+}
+
+var priorityNames = [numPriorities]string{
+	PriorityHigh:   "high",
+	PriorityNormal: "normal",
+	PriorityLow:    "low",
+}
+
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return "unknown"
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority returns the priority class of the header value. Unknown values are normal priority.
+func ParsePriority(s string) Priority {
+	for p, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return Priority(p)
+		}
+	}
+	return PriorityNormal
+}
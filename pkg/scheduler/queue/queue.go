@@ -61,15 +61,18 @@ type RequestQueue struct {
 	queues  *queues
 	stopped bool
 
-	queueLength       *prometheus.GaugeVec   // Per user and reason.
-	discardedRequests *prometheus.CounterVec // Per user.
+	queueLength         *prometheus.GaugeVec   // Per user and reason.
+	priorityQueueLength *prometheus.GaugeVec   // Per user and priority.
+	discardedRequests   *prometheus.CounterVec // Per user.
 }
 
-func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec) *RequestQueue {
+// NewRequestQueue creates a RequestQueue. The highPriorityQueriersShare of the queriers only handle high priority requests.
+func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, highPriorityQueriersShare float64, queueLength, priorityQueueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec) *RequestQueue {
 	q := &RequestQueue{
-		queues:                  newUserQueues(maxOutstandingPerTenant, forgetDelay),
+		queues:                  newUserQueues(maxOutstandingPerTenant, forgetDelay, highPriorityQueriersShare),
 		connectedQuerierWorkers: atomic.NewInt32(0),
 		queueLength:             queueLength,
+		priorityQueueLength:     priorityQueueLength,
 		discardedRequests:       discardedRequests,
 	}
 
@@ -79,12 +82,12 @@ func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, que
 	return q
 }
 
-// EnqueueRequest puts the request into the queue. MaxQueries is user-specific value that specifies how many queriers can
+// EnqueueRequest puts the request into the queue of its priority class. MaxQueries is user-specific value that specifies how many queriers can
 // this user use (zero or negative = all queriers). It is passed to each EnqueueRequest, because it can change
 // between calls.
 //
 // If request is successfully enqueued, successFn is called with the lock held, before any querier can receive the request.
-func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers int, successFn func()) error {
+func (q *RequestQueue) EnqueueRequest(userID string, req Request, priority Priority, maxQueriers int, successFn func()) error {
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
 
@@ -98,19 +101,18 @@ func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers in
 		return errors.New("no queue found")
 	}
 
-	select {
-	case queue <- req:
-		q.queueLength.WithLabelValues(userID).Inc()
-		q.cond.Broadcast()
-		// Call this function while holding a lock. This guarantees that no querier can fetch the request before function returns.
-		if successFn != nil {
-			successFn()
-		}
-		return nil
-	default:
+	if !queue.enqueue(req, priority, q.queues.maxUserQueueSize) {
 		q.discardedRequests.WithLabelValues(userID).Inc()
 		return ErrTooManyRequests
 	}
+	q.queueLength.WithLabelValues(userID).Inc()
+	q.priorityQueueLength.WithLabelValues(userID, priority.String()).Inc()
+	q.cond.Broadcast()
+	// Call this function while holding a lock. This guarantees that no querier can fetch the request before function returns.
+	if successFn != nil {
+		successFn()
+	}
+	return nil
 }
 
 // GetNextRequestForQuerier find next user queue and takes the next request off of it. Will block if there are no requests.
@@ -145,19 +147,18 @@ FindQueue:
 		}
 
 		// Pick next request from the queue.
-		for {
-			request := <-queue
-			if len(queue) == 0 {
-				q.queues.deleteQueue(userID)
-			}
+		request, priority := queue.dequeue(q.queues.highPriorityOnly(queue, querierID))
+		if queue.len() == 0 {
+			q.queues.deleteQueue(userID)
+		}
 
-			q.queueLength.WithLabelValues(userID).Dec()
+		q.queueLength.WithLabelValues(userID).Dec()
+		q.priorityQueueLength.WithLabelValues(userID, priority.String()).Dec()
 
-			// Tell close() we've processed a request.
-			q.cond.Broadcast()
+		// Tell close() we've processed a request.
+		q.cond.Broadcast()
 
-			return request, last, nil
-		}
+		return request, last, nil
 	}
 
 	// There are no unexpired requests, so we can get back
@@ -28,8 +28,9 @@ func BenchmarkGetNextRequest(b *testing.B) {
 	queues := make([]*RequestQueue, 0, b.N)
 
 	for n := 0; n < b.N; n++ {
-		queue := NewRequestQueue(maxOutstandingPerTenant, 0,
+		queue := NewRequestQueue(maxOutstandingPerTenant, 0, 0,
 			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"tenant"}),
+			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"tenant", "priority"}),
 			promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"tenant"}),
 		)
 		queues = append(queues, queue)
@@ -42,7 +43,7 @@ func BenchmarkGetNextRequest(b *testing.B) {
 			for j := 0; j < numTenants; j++ {
 				tenantID := strconv.Itoa(j)
 
-				err := queue.EnqueueRequest(tenantID, "request", 0, nil)
+				err := queue.EnqueueRequest(tenantID, "request", PriorityNormal, 0, nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -85,8 +86,9 @@ func BenchmarkQueueRequest(b *testing.B) {
 	requests := make([]string, 0, numTenants)
 
 	for n := 0; n < b.N; n++ {
-		q := NewRequestQueue(maxOutstandingPerTenant, 0,
+		q := NewRequestQueue(maxOutstandingPerTenant, 0, 0,
 			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"tenant"}),
+			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"tenant", "priority"}),
 			promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		)
 
@@ -106,7 +108,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		for i := 0; i < maxOutstandingPerTenant; i++ {
 			for j := 0; j < numTenants; j++ {
-				err := queues[n].EnqueueRequest(users[j], requests[j], 0, nil)
+				err := queues[n].EnqueueRequest(users[j], requests[j], PriorityNormal, 0, nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -118,8 +120,9 @@ func BenchmarkQueueRequest(b *testing.B) {
 func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBecauseQuerierHasBeenForgotten(t *testing.T) {
 	const forgetDelay = 3 * time.Second
 
-	queue := NewRequestQueue(1, forgetDelay,
+	queue := NewRequestQueue(1, forgetDelay, 0,
 		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "priority"}),
 		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}))
 
 	// Start the queue service.
@@ -147,7 +150,7 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 
 	// Enqueue a request from an user which would be assigned to querier-1.
 	// NOTE: "user-1" hash falls in the querier-1 shard.
-	require.NoError(t, queue.EnqueueRequest("user-1", "request", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", PriorityNormal, 1, nil))
 
 	startTime := time.Now()
 	querier2wg.Wait()
@@ -157,6 +160,53 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 	assert.GreaterOrEqual(t, waitTime.Milliseconds(), forgetDelay.Milliseconds())
 }
 
+func TestRequestQueue_GetNextRequestForQuerier_Priorities(t *testing.T) {
+	queue := NewRequestQueue(100, 0, 0.5,
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "priority"}),
+		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}))
+	ctx := context.Background()
+	require.NoError(t, services.StartAndAwaitRunning(ctx, queue))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, queue))
+	})
+
+	// querier-2 is reserved for the high priority requests.
+	queue.RegisterQuerierConnection("querier-1")
+	queue.RegisterQuerierConnection("querier-2")
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, queue.EnqueueRequest("user-1", fmt.Sprintf("low-%d", i), PriorityLow, 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-1", fmt.Sprintf("normal-%d", i), PriorityNormal, 0, nil))
+	}
+	require.NoError(t, queue.EnqueueRequest("user-1", "high-0", PriorityHigh, 0, nil))
+
+	req, _, err := queue.GetNextRequestForQuerier(ctx, FirstUser(), "querier-2")
+	require.NoError(t, err)
+	require.Equal(t, "high-0", req)
+
+	// The normal priority requests are dequeued twice as often as the low priority ones.
+	var dequeued []Request
+	for i := 0; i < 6; i++ {
+		req, _, err = queue.GetNextRequestForQuerier(ctx, FirstUser(), "querier-1")
+		require.NoError(t, err)
+		dequeued = append(dequeued, req)
+	}
+	require.Equal(t, []Request{"normal-0", "low-0", "normal-1", "normal-2", "low-1", "normal-3"}, dequeued)
+
+	// The reserved querier doesn't get the low priority requests.
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, _, err = queue.GetNextRequestForQuerier(waitCtx, FirstUser(), "querier-2")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	for i := 2; i < 4; i++ {
+		req, _, err = queue.GetNextRequestForQuerier(ctx, FirstUser(), "querier-1")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("low-%d", i), req)
+	}
+}
+
 func TestContextCond(t *testing.T) {
 	t.Run("wait until broadcast", func(t *testing.T) {
 		t.Parallel()
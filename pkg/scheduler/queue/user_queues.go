@@ -48,10 +48,17 @@ type queues struct {
 
 	// Sorted list of querier names, used when creating per-user shard.
 	sortedQueriers []string
+
+	// Share of the queriers reserved for high priority requests, and the reserved queriers.
+	highPriorityQueriersShare float64
+	highPriorityQueriers      map[string]struct{}
 }
 
 type userQueue struct {
-	ch chan Request
+	// Pending requests per priority class.
+	requests [numPriorities][]Request
+	// Smooth weighted round-robin state of the priority classes.
+	currentWeights [numPriorities]int
 
 	// If not nil, only these queriers can handle user requests. If nil, all queriers can.
 	// We set this to nil if number of available queriers <= maxQueriers.
@@ -66,15 +73,58 @@ type userQueue struct {
 	index int
 }
 
-func newUserQueues(maxUserQueueSize int, forgetDelay time.Duration) *queues {
+func newUserQueues(maxUserQueueSize int, forgetDelay time.Duration, highPriorityQueriersShare float64) *queues {
 	return &queues{
-		userQueues:       map[string]*userQueue{},
-		users:            nil,
-		maxUserQueueSize: maxUserQueueSize,
-		forgetDelay:      forgetDelay,
-		queriers:         map[string]*querier{},
-		sortedQueriers:   nil,
+		userQueues:                map[string]*userQueue{},
+		users:                     nil,
+		maxUserQueueSize:          maxUserQueueSize,
+		forgetDelay:               forgetDelay,
+		queriers:                  map[string]*querier{},
+		sortedQueriers:            nil,
+		highPriorityQueriersShare: highPriorityQueriersShare,
+	}
+}
+
+func (uq *userQueue) len() int {
+	n := 0
+	for _, r := range uq.requests {
+		n += len(r)
 	}
+	return n
+}
+
+// enqueue adds the request to its priority class, unless the queue already holds maxSize requests.
+func (uq *userQueue) enqueue(req Request, p Priority, maxSize int) bool {
+	if uq.len() >= maxSize {
+		return false
+	}
+	uq.requests[p] = append(uq.requests[p], req)
+	return true
+}
+
+// dequeue takes the next request by smooth weighted round-robin over the non-empty priority classes.
+// If highOnly is set, only high priority requests are taken. The queue must not be empty.
+func (uq *userQueue) dequeue(highOnly bool) (Request, Priority) {
+	next := PriorityHigh
+	if !highOnly {
+		total := 0
+		next = -1
+		for p := range uq.requests {
+			if len(uq.requests[p]) == 0 {
+				continue
+			}
+			uq.currentWeights[p] += priorityWeights[p]
+			total += priorityWeights[p]
+			if next < 0 || uq.currentWeights[p] > uq.currentWeights[next] {
+				next = Priority(p)
+			}
+		}
+		uq.currentWeights[next] -= total
+	}
+	req := uq.requests[next][0]
+	uq.requests[next][0] = nil
+	uq.requests[next] = uq.requests[next][1:]
+	return req, next
 }
 
 func (q *queues) len() int {
@@ -100,7 +150,7 @@ func (q *queues) deleteQueue(userID string) {
 // MaxQueriers is used to compute which queriers should handle requests for this user.
 // If maxQueriers is <= 0, all queriers can handle this user's requests.
 // If maxQueriers has changed since the last call, queriers for this are recomputed.
-func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
+func (q *queues) getOrAddQueue(userID string, maxQueriers int) *userQueue {
 	// Empty user is not allowed, as that would break our users list ("" is used for free spot).
 	if userID == "" {
 		return nil
@@ -114,7 +164,6 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 
 	if uq == nil {
 		uq = &userQueue{
-			ch:    make(chan Request, q.maxUserQueueSize),
 			seed:  util.ShuffleShardSeed(userID, ""),
 			index: -1,
 		}
@@ -141,13 +190,14 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 		uq.queriers = shuffleQueriersForUser(uq.seed, maxQueriers, q.sortedQueriers, nil)
 	}
 
-	return uq.ch
+	return uq
 }
 
 // Finds next queue for the querier. To support fair scheduling between users, client is expected
 // to pass last user index returned by this function as argument. Is there was no previous
-// last user index, use -1.
-func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (chan Request, string, int) {
+// last user index, use -1. The queriers reserved for high priority requests only get the queues
+// with pending high priority requests, unless they are the only queriers of the user (see highPriorityOnly).
+func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (*userQueue, string, int) {
 	uid := lastUserIndex
 
 	// Ensure the querier is not shutting down. If the querier is shutting down, we shouldn't forward
@@ -155,7 +205,6 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 	if info := q.queriers[querierID]; info == nil || info.shuttingDown {
 		return nil, "", uid
 	}
-
 	for iters := 0; iters < len(q.users); iters++ {
 		uid = uid + 1
 
@@ -170,16 +219,19 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 			continue
 		}
 
-		q := q.userQueues[u]
+		uq := q.userQueues[u]
 
-		if q.queriers != nil {
-			if _, ok := q.queriers[querierID]; !ok {
+		if uq.queriers != nil {
+			if _, ok := uq.queriers[querierID]; !ok {
 				// This querier is not handling the user.
 				continue
 			}
 		}
+		if len(uq.requests[PriorityHigh]) == 0 && q.highPriorityOnly(uq, querierID) {
+			continue
+		}
 
-		return q.ch, u, uid
+		return uq, u, uid
 	}
 	return nil, "", uid
 }
@@ -202,6 +254,7 @@ func (q *queues) addQuerierConnection(querierID string) {
 	slices.Sort(q.sortedQueriers)
 
 	q.recomputeUserQueriers()
+	q.recomputeHighPriorityQueriers()
 }
 
 func (q *queues) removeQuerierConnection(querierID string, now time.Time) {
@@ -240,6 +293,7 @@ func (q *queues) removeQuerier(querierID string) {
 	q.sortedQueriers = append(q.sortedQueriers[:ix], q.sortedQueriers[ix+1:]...)
 
 	q.recomputeUserQueriers()
+	q.recomputeHighPriorityQueriers()
 }
 
 // notifyQuerierShutdown records that a querier has sent notification about a graceful shutdown.
@@ -291,6 +345,45 @@ func (q *queues) recomputeUserQueriers() {
 	}
 }
 
+// recomputeHighPriorityQueriers reserves the last queriers of the sorted list for high priority requests,
+// so that all the schedulers reserve the same queriers. The share is rounded down: at least one querier
+// is left for the other requests.
+func (q *queues) recomputeHighPriorityQueriers() {
+	n := int(q.highPriorityQueriersShare * float64(len(q.sortedQueriers)))
+	if n == 0 {
+		q.highPriorityQueriers = nil
+		return
+	}
+	q.highPriorityQueriers = make(map[string]struct{}, n)
+	for _, querierID := range q.sortedQueriers[len(q.sortedQueriers)-n:] {
+		q.highPriorityQueriers[querierID] = struct{}{}
+	}
+}
+
+func (q *queues) isHighPriorityQuerier(querierID string) bool {
+	_, ok := q.highPriorityQueriers[querierID]
+	return ok
+}
+
+// highPriorityOnly returns true if the querier only handles the high priority requests of the user.
+// A querier reserved for high priority requests handles all the requests of the users whose shard
+// has no other querier, otherwise their normal and low priority requests would never be handled.
+func (q *queues) highPriorityOnly(uq *userQueue, querierID string) bool {
+	if !q.isHighPriorityQuerier(querierID) {
+		return false
+	}
+	if uq.queriers == nil {
+		// All the queriers handle the user, and at least one of them is not reserved.
+		return true
+	}
+	for id := range uq.queriers {
+		if !q.isHighPriorityQuerier(id) {
+			return true
+		}
+	}
+	return false
+}
+
 // shuffleQueriersForUser returns nil if queriersToSelect is 0 or there are not enough queriers to select from.
 // In that case *all* queriers should be used.
 // Scratchpad is used for shuffling, to avoid new allocations. If nil, new slice is allocated.
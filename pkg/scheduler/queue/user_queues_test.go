@@ -19,7 +19,7 @@ import (
 )
 
 func TestQueues(t *testing.T) {
-	uq := newUserQueues(0, 0)
+	uq := newUserQueues(0, 0, 0)
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
@@ -77,7 +77,7 @@ func TestQueues(t *testing.T) {
 }
 
 func TestQueuesOnTerminatingQuerier(t *testing.T) {
-	uq := newUserQueues(0, 0)
+	uq := newUserQueues(0, 0, 0)
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
@@ -107,7 +107,7 @@ func TestQueuesOnTerminatingQuerier(t *testing.T) {
 }
 
 func TestQueuesWithQueriers(t *testing.T) {
-	uq := newUserQueues(0, 0)
+	uq := newUserQueues(0, 0, 0)
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
@@ -174,6 +174,52 @@ func TestQueuesWithQueriers(t *testing.T) {
 	assert.InDelta(t, stdDev, 0, mean*0.2)
 }
 
+func TestQueuesWithHighPriorityQueriers(t *testing.T) {
+	// querier-2 and querier-3 are reserved for the high priority requests.
+	uq := newUserQueues(0, 0, 0.5)
+	for ix := 0; ix < 4; ix++ {
+		uq.addQuerierConnection(fmt.Sprintf("querier-%d", ix))
+	}
+	assert.NoError(t, isConsistent(uq))
+
+	// Find a user whose only querier is reserved, and a user whose only querier is not.
+	var reservedOnly, unreserved string
+	var reservedQuerier, unreservedQuerier string
+	for u := 0; reservedOnly == "" || unreserved == ""; u++ {
+		userID := fmt.Sprintf("user-%d", u)
+		var querierID string
+		for querierID = range getOrAdd(t, uq, userID, 1).queriers {
+		}
+		switch {
+		case uq.isHighPriorityQuerier(querierID) && reservedOnly == "":
+			reservedOnly, reservedQuerier = userID, querierID
+		case !uq.isHighPriorityQuerier(querierID) && unreserved == "":
+			unreserved, unreservedQuerier = userID, querierID
+		default:
+			uq.deleteQueue(userID)
+		}
+	}
+	require.True(t, uq.userQueues[reservedOnly].enqueue("normal", PriorityNormal, 10))
+	require.True(t, uq.userQueues[reservedOnly].enqueue("low", PriorityLow, 10))
+	require.True(t, uq.userQueues[unreserved].enqueue("normal", PriorityNormal, 10))
+
+	// The reserved querier handles all the requests of the user it is the only querier of.
+	for _, expected := range []Request{"normal", "low"} {
+		q, u, _ := uq.getNextQueueForQuerier(-1, reservedQuerier)
+		require.Equal(t, reservedOnly, u)
+		req, _ := q.dequeue(uq.highPriorityOnly(q, reservedQuerier))
+		require.Equal(t, expected, req)
+	}
+	uq.deleteQueue(reservedOnly)
+
+	// It only handles the high priority requests of the users with an unreserved querier.
+	uq.userQueues[unreserved].queriers[reservedQuerier] = struct{}{}
+	q, _, _ := uq.getNextQueueForQuerier(-1, reservedQuerier)
+	require.Nil(t, q)
+	_, u, _ := uq.getNextQueueForQuerier(-1, unreservedQuerier)
+	require.Equal(t, unreserved, u)
+}
+
 func TestQueuesConsistency(t *testing.T) {
 	tests := map[string]struct {
 		forgetDelay time.Duration
@@ -184,7 +230,7 @@ func TestQueuesConsistency(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			uq := newUserQueues(0, testData.forgetDelay)
+			uq := newUserQueues(0, testData.forgetDelay, 0)
 			assert.NotNil(t, uq)
 			assert.NoError(t, isConsistent(uq))
 
@@ -233,7 +279,7 @@ func TestQueues_ForgetDelay(t *testing.T) {
 	)
 
 	now := time.Now()
-	uq := newUserQueues(0, forgetDelay)
+	uq := newUserQueues(0, forgetDelay, 0)
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
@@ -325,7 +371,7 @@ func TestQueues_ForgetDelay_ShouldCorrectlyHandleQuerierReconnectingBeforeForget
 	)
 
 	now := time.Now()
-	uq := newUserQueues(0, forgetDelay)
+	uq := newUserQueues(0, forgetDelay, 0)
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
@@ -398,7 +444,7 @@ func generateQuerier(r *rand.Rand) string {
 	return fmt.Sprint("querier-", r.Int()%5)
 }
 
-func getOrAdd(t *testing.T, uq *queues, tenant string, maxQueriers int) chan Request {
+func getOrAdd(t *testing.T, uq *queues, tenant string, maxQueriers int) *userQueue {
 	q := uq.getOrAddQueue(tenant, maxQueriers)
 	assert.NotNil(t, q)
 	assert.NoError(t, isConsistent(uq))
@@ -406,8 +452,8 @@ func getOrAdd(t *testing.T, uq *queues, tenant string, maxQueriers int) chan Req
 	return q
 }
 
-func confirmOrderForQuerier(t *testing.T, uq *queues, querier string, lastUserIndex int, qs ...chan Request) int {
-	var n chan Request
+func confirmOrderForQuerier(t *testing.T, uq *queues, querier string, lastUserIndex int, qs ...*userQueue) int {
+	var n *userQueue
 	for _, q := range qs {
 		n, _, lastUserIndex = uq.getNextQueueForQuerier(lastUserIndex, querier)
 		assert.Equal(t, q, n)
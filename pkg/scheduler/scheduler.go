@@ -67,6 +67,7 @@ type Scheduler struct {
 
 	// Metrics.
 	queueLength              *prometheus.GaugeVec
+	priorityQueueLength      *prometheus.GaugeVec
 	discardedRequests        *prometheus.CounterVec
 	cancelledRequests        *prometheus.CounterVec
 	connectedQuerierClients  prometheus.GaugeFunc
@@ -93,20 +94,28 @@ type connectedFrontend struct {
 }
 
 type Config struct {
-	MaxOutstandingPerTenant int                       `yaml:"max_outstanding_requests_per_tenant"`
-	QuerierForgetDelay      time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
-	GRPCClientConfig        grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
-	ServiceDiscovery        schedulerdiscovery.Config `yaml:",inline"`
+	MaxOutstandingPerTenant   int                       `yaml:"max_outstanding_requests_per_tenant"`
+	QuerierForgetDelay        time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
+	HighPriorityQueriersShare float64                   `yaml:"high_priority_queriers_share" category:"experimental"`
+	GRPCClientConfig          grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
+	ServiceDiscovery          schedulerdiscovery.Config `yaml:",inline"`
+
+	// This configuration is injected internally.
+	FrontendClientInterceptors []grpc.UnaryClientInterceptor `yaml:"-"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per query-scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
+	f.Float64Var(&cfg.HighPriorityQueriersShare, "query-scheduler.high-priority-queriers-share", 0, "Share of the queriers, between 0 and 1, that only handle the high priority queries. The share is rounded down, at least one querier always handles the other queries. 0 to disable.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	cfg.ServiceDiscovery.RegisterFlags(f, logger)
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.HighPriorityQueriersShare < 0 || cfg.HighPriorityQueriersShare >= 1 {
+		return errors.New("the high priority queriers share must be greater than or equal to 0 and less than 1")
+	}
 	return cfg.ServiceDiscovery.Validate()
 }
 
@@ -128,6 +137,10 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "pyroscope_query_scheduler_queue_length",
 		Help: "Number of queries in the queue.",
 	}, []string{"tenant"})
+	s.priorityQueueLength = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pyroscope_query_scheduler_priority_queue_length",
+		Help: "Number of queries in the queue per priority class.",
+	}, []string{"tenant", "priority"})
 
 	s.cancelledRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 		Name: "pyroscope_query_scheduler_cancelled_requests_total",
@@ -137,7 +150,7 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "pyroscope_query_scheduler_discarded_requests_total",
 		Help: "Total number of query requests discarded.",
 	}, []string{"tenant"})
-	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests)
+	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, cfg.HighPriorityQueriersShare, s.queueLength, s.priorityQueueLength, s.discardedRequests)
 
 	s.queueDuration = promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
 		Name:    "pyroscope_query_scheduler_queue_duration_seconds",
@@ -351,7 +364,7 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerTenant)
 
 	s.activeUsers.UpdateUserTimestamp(userID, now)
-	return s.requestQueue.EnqueueRequest(userID, req, maxQueriers, func() {
+	return s.requestQueue.EnqueueRequest(userID, req, requestPriority(msg.HttpRequest), maxQueriers, func() {
 		shouldCancel = false
 
 		s.pendingRequestsMu.Lock()
@@ -360,6 +373,16 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	})
 }
 
+// requestPriority returns the priority class set by the query-frontend or the client.
+func requestPriority(req *httpgrpc.HTTPRequest) queue.Priority {
+	for _, h := range req.GetHeaders() {
+		if http.CanonicalHeaderKey(h.Key) == queue.PriorityHeader && len(h.Values) > 0 {
+			return queue.ParsePriority(h.Values[0])
+		}
+	}
+	return queue.PriorityNormal
+}
+
 // This method doesn't do removal from the queue.
 func (s *Scheduler) cancelRequestAndRemoveFromPending(frontendAddr string, queryID uint64) {
 	s.pendingRequestsMu.Lock()
@@ -526,10 +549,10 @@ func (s *Scheduler) forwardRequestToQuerier(querier *BidiStreamCloser[schedulerp
 }
 
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
-	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
+	opts, err := s.cfg.GRPCClientConfig.DialOption(append([]grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor,
-	},
+	}, s.cfg.FrontendClientInterceptors...),
 		nil)
 	if err != nil {
 		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
@@ -615,6 +638,7 @@ func (s *Scheduler) stopping(_ error) error {
 
 func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
 	s.queueLength.DeleteLabelValues(user)
+	s.priorityQueueLength.DeletePartialMatch(prometheus.Labels{"tenant": user})
 	s.discardedRequests.DeleteLabelValues(user)
 	s.cancelledRequests.DeleteLabelValues(user)
 }
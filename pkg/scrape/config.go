@@ -0,0 +1,154 @@
+package scrape
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v2"
+
+	// Register the file and the kubernetes service discoveries, besides the static one.
+	_ "github.com/prometheus/prometheus/discovery/file"
+	_ "github.com/prometheus/prometheus/discovery/kubernetes"
+
+	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+type Config struct {
+	ConfigFile string                `yaml:"config_file" category:"experimental"`
+	Ring       util.CommonRingConfig `yaml:"ring" doc:"hidden"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
+	f.StringVar(&cfg.ConfigFile, "scrape.config-file", "", "Path of the YAML file with the scrape configs. Pyroscope periodically fetches the pprof endpoints of the targets they discover, and pushes the profiles through the local distributor. The targets are sharded between the replicas running the scrape module. The scraping is disabled if empty.")
+	cfg.Ring.RegisterFlags("scrape.ring.", "collectors/", "scrapers", f, logger)
+}
+
+// ScrapeConfig configures the scraping of the targets of a job. The targets
+// are discovered and relabeled as by Prometheus: static_configs,
+// file_sd_configs and kubernetes_sd_configs are supported.
+//
+// For instance, to scrape the pods with the pyroscope.io/scrape annotation:
+//
+//	scrape_configs:
+//	  - job_name: kubernetes-pods
+//	    scrape_interval: 15s
+//	    profiles: [process_cpu, memory, goroutine]
+//	    kubernetes_sd_configs:
+//	      - role: pod
+//	    relabel_configs:
+//	      - source_labels: [__meta_kubernetes_pod_annotation_pyroscope_io_scrape]
+//	        action: keep
+//	        regex: true
+//	      - source_labels: [__meta_kubernetes_pod_label_app]
+//	        target_label: service_name
+//
+// The profiles are pushed with the labels of the target, and the service_name
+// label defaults to the job name.
+type ScrapeConfig struct {
+	JobName string `yaml:"job_name"`
+	// ScrapeInterval is the interval between the scrapes of a target, and
+	// the duration of its CPU profile, minus a second.
+	ScrapeInterval model.Duration `yaml:"scrape_interval,omitempty"`
+	// ScrapeTimeout is the timeout of the requests, in addition to the
+	// duration of the CPU profile.
+	ScrapeTimeout model.Duration `yaml:"scrape_timeout,omitempty"`
+	Scheme        string         `yaml:"scheme,omitempty"`
+	// Tenant is the tenant of the profiles, the default tenant if empty.
+	Tenant string `yaml:"tenant,omitempty"`
+	// Profiles are the profiles scraped, all of them if empty.
+	Profiles []string `yaml:"profiles,omitempty"`
+
+	HTTPClientConfig        config.HTTPClientConfig `yaml:",inline"`
+	ServiceDiscoveryConfigs discovery.Configs       `yaml:"-"`
+	RelabelConfigs          []*relabel.Config       `yaml:"relabel_configs,omitempty"`
+}
+
+var DefaultScrapeConfig = ScrapeConfig{
+	ScrapeInterval:   model.Duration(15 * time.Second),
+	ScrapeTimeout:    model.Duration(10 * time.Second),
+	Scheme:           "http",
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+}
+
+func (c *ScrapeConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultScrapeConfig
+	if err := discovery.UnmarshalYAMLWithInlineConfigs(c, unmarshal); err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+func (c *ScrapeConfig) validate() error {
+	if c.JobName == "" {
+		return errors.New("job_name is empty")
+	}
+	if c.ScrapeInterval <= 0 || c.ScrapeTimeout <= 0 {
+		return fmt.Errorf("the scrape interval and timeout of job %q must be positive", c.JobName)
+	}
+	if c.ScrapeTimeout > c.ScrapeInterval {
+		return fmt.Errorf("the scrape timeout of job %q is greater than its interval", c.JobName)
+	}
+	if c.Scheme != "http" && c.Scheme != "https" {
+		return fmt.Errorf("invalid scheme %q of job %q", c.Scheme, c.JobName)
+	}
+	if c.Tenant == "" {
+		c.Tenant = tenant.DefaultTenantID
+	}
+	if err := tenant.ValidTenantID(c.Tenant); err != nil {
+		return fmt.Errorf("job %q: %w", c.JobName, err)
+	}
+	if len(c.Profiles) == 0 {
+		c.Profiles = []string{profileCPU, profileMemory, profileGoroutine, profileMutex, profileBlock}
+	}
+	seen := make(map[string]struct{}, len(c.Profiles))
+	for _, p := range c.Profiles {
+		if _, ok := profileTypes[p]; !ok {
+			return fmt.Errorf("unknown profile %q of job %q", p, c.JobName)
+		}
+		if _, ok := seen[p]; ok {
+			return fmt.Errorf("duplicate profile %q of job %q", p, c.JobName)
+		}
+		seen[p] = struct{}{}
+	}
+	return c.HTTPClientConfig.Validate()
+}
+
+type configFile struct {
+	ScrapeConfigs []*ScrapeConfig `yaml:"scrape_configs"`
+}
+
+// loadScrapeConfigs returns the scrape configs of the file.
+func loadScrapeConfigs(filename string) ([]*ScrapeConfig, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading the scrape config file: %w", err)
+	}
+	var file configFile
+	if err = yaml.UnmarshalStrict(b, &file); err != nil {
+		return nil, fmt.Errorf("parsing the scrape config file %s: %w", filename, err)
+	}
+	jobs := make(map[string]struct{}, len(file.ScrapeConfigs))
+	for _, c := range file.ScrapeConfigs {
+		if c == nil {
+			return nil, fmt.Errorf("empty scrape config in %s", filename)
+		}
+		if _, ok := jobs[c.JobName]; ok {
+			return nil, fmt.Errorf("duplicate job %q in %s", c.JobName, filename)
+		}
+		jobs[c.JobName] = struct{}{}
+		// The file_sd_configs and the credential files are relative to the file.
+		c.ServiceDiscoveryConfigs.SetDirectory(filepath.Dir(filename))
+		c.HTTPClientConfig.SetDirectory(filepath.Dir(filename))
+	}
+	return file.ScrapeConfigs, nil
+}
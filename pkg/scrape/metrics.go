@@ -0,0 +1,47 @@
+package scrape
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	targets        *prometheus.GaugeVec
+	scrapeDuration *prometheus.HistogramVec
+	scrapeFailures *prometheus.CounterVec
+	pushFailures   *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		targets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "pyroscope",
+			Name:      "scrape_targets",
+			Help:      "The number of targets scraped per job.",
+		}, []string{"job"}),
+		scrapeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "pyroscope",
+			Name:      "scrape_duration_seconds",
+			Help:      "The duration of the scrapes of the profiles, including the duration of the CPU profiles.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 13),
+		}, []string{"job", "profile"}),
+		scrapeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Name:      "scrape_failures_total",
+			Help:      "The number of failed scrapes of the profiles.",
+		}, []string{"job", "profile"}),
+		pushFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pyroscope",
+			Name:      "scrape_push_failures_total",
+			Help:      "The number of failed pushes of the scraped profiles.",
+		}, []string{"job"}),
+	}
+	if reg != nil {
+		reg.MustRegister(
+			m.targets,
+			m.scrapeDuration,
+			m.scrapeFailures,
+			m.pushFailures,
+		)
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+package scrape
+
+import (
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/ring"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+const (
+	// ringKey is the key under which we store the scrapers ring in the KVStore.
+	ringKey = "scrape"
+
+	// ringNumTokens is how many tokens each scraper has in the ring. The
+	// targets are sharded by the hash of their labels, so enough tokens are
+	// needed to spread them evenly.
+	ringNumTokens = 128
+
+	// ringAutoForgetUnhealthyPeriods is how many consecutive timeout periods an
+	// unhealthy instance in the ring will be automatically removed after.
+	ringAutoForgetUnhealthyPeriods = 4
+)
+
+// ringOp selects the scraper of a target. Only the ACTIVE scrapers are
+// selected, so that the targets of a leaving scraper move to the others.
+var ringOp = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
+
+// toBasicLifecyclerConfig returns a ring.BasicLifecyclerConfig based on the scrapers ring config.
+func toBasicLifecyclerConfig(cfg util.CommonRingConfig, logger log.Logger) (ring.BasicLifecyclerConfig, error) {
+	instanceAddr, err := ring.GetInstanceAddr(cfg.InstanceAddr, cfg.InstanceInterfaceNames, logger, cfg.EnableIPv6)
+	if err != nil {
+		return ring.BasicLifecyclerConfig{}, err
+	}
+
+	instancePort := ring.GetInstancePort(cfg.InstancePort, cfg.ListenPort)
+
+	return ring.BasicLifecyclerConfig{
+		ID:                              cfg.InstanceID,
+		Addr:                            fmt.Sprintf("%s:%d", instanceAddr, instancePort),
+		HeartbeatPeriod:                 cfg.HeartbeatPeriod,
+		HeartbeatTimeout:                cfg.HeartbeatTimeout,
+		TokensObservePeriod:             0,
+		NumTokens:                       ringNumTokens,
+		KeepInstanceInTheRingOnShutdown: false,
+	}, nil
+}
+
+// newRingAndLifecycler creates the client and the lifecycler of the scrapers ring.
+func newRingAndLifecycler(cfg util.CommonRingConfig, logger log.Logger, reg prometheus.Registerer) (*ring.Ring, *ring.BasicLifecycler, error) {
+	reg = prometheus.WrapRegistererWithPrefix("pyroscope_", reg)
+	kvStore, err := kv.NewClient(cfg.KVStore, ring.GetCodec(), kv.RegistererWithKVName(reg, "scrape-lifecycler"), logger)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize scrapers' KV store")
+	}
+
+	lifecyclerCfg, err := toBasicLifecyclerConfig(cfg, logger)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to build scrapers' lifecycler config")
+	}
+
+	var delegate ring.BasicLifecyclerDelegate
+	delegate = ring.NewInstanceRegisterDelegate(ring.ACTIVE, ringNumTokens)
+	delegate = ring.NewLeaveOnStoppingDelegate(delegate, logger)
+	delegate = ring.NewAutoForgetDelegate(ringAutoForgetUnhealthyPeriods*cfg.HeartbeatTimeout, delegate, logger)
+
+	lifecycler, err := ring.NewBasicLifecycler(lifecyclerCfg, "scrape", ringKey, kvStore, delegate, logger, reg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize scrapers' lifecycler")
+	}
+
+	ringCfg := cfg.ToRingConfig()
+	ringCfg.ReplicationFactor = 1
+	client, err := ring.New(ringCfg, "scrape", ringKey, logger, reg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize scrapers' ring client")
+	}
+
+	return client, lifecycler, nil
+}
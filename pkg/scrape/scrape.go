@@ -0,0 +1,260 @@
+// Package scrape implements the pull mode: it discovers the targets of the
+// scrape configs, periodically fetches their pprof endpoints, and pushes the
+// profiles through the local distributor. The targets are sharded between
+// the replicas running the scrape module with a ring, by the hash of their
+// labels: each target is scraped by a single replica.
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+)
+
+// ringCheckPeriod is the period at which the targets are re-sharded, to
+// follow the changes of the ring.
+const ringCheckPeriod = 5 * time.Second
+
+// Scraper scrapes the targets of the jobs of its config file it owns in the ring.
+type Scraper struct {
+	services.Service
+
+	configs []*ScrapeConfig
+	pusher  pushv1connect.PusherServiceHandler
+	metrics *metrics
+	logger  log.Logger
+
+	ring               *ring.Ring
+	lifecycler         *ring.BasicLifecycler
+	subservices        *services.Manager
+	subservicesWatcher *services.FailureWatcher
+
+	// mtx protects the pools from the concurrent syncs of the discovered
+	// targets and of the ring.
+	mtx sync.Mutex
+	// pools are the scrape pools, by job name.
+	pools map[string]*pool
+}
+
+func New(cfg Config, pusher pushv1connect.PusherServiceHandler, reg prometheus.Registerer, logger log.Logger) (*Scraper, error) {
+	configs, err := loadScrapeConfigs(cfg.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scraper{
+		configs: configs,
+		pusher:  pusher,
+		metrics: newMetrics(reg),
+		logger:  logger,
+		pools:   make(map[string]*pool, len(configs)),
+	}
+	for _, c := range configs {
+		client, err := config.NewClientFromConfig(c.HTTPClientConfig, c.JobName)
+		if err != nil {
+			return nil, err
+		}
+		s.pools[c.JobName] = &pool{
+			cfg:     c,
+			client:  client,
+			pusher:  pusher,
+			metrics: s.metrics,
+			logger:  log.With(logger, "job", c.JobName),
+			loops:   make(map[uint64]*loop),
+		}
+	}
+	if s.ring, s.lifecycler, err = newRingAndLifecycler(cfg.Ring, logger, reg); err != nil {
+		return nil, err
+	}
+	if s.subservices, err = services.NewManager(s.lifecycler, s.ring); err != nil {
+		return nil, errors.Wrap(err, "failed to create scrape subservices manager")
+	}
+	s.subservicesWatcher = services.NewFailureWatcher()
+	s.subservicesWatcher.WatchManager(s.subservices)
+	s.Service = services.NewBasicService(s.starting, s.running, s.stopping)
+	return s, nil
+}
+
+func (s *Scraper) starting(ctx context.Context) error {
+	if err := services.StartManagerAndAwaitHealthy(ctx, s.subservices); err != nil {
+		return errors.Wrap(err, "unable to start scrape subservices")
+	}
+	level.Info(s.logger).Log("msg", "waiting until the scraper is ACTIVE in the ring")
+	if err := ring.WaitInstanceState(ctx, s.ring, s.lifecycler.GetInstanceID(), ring.ACTIVE); err != nil {
+		return errors.Wrap(err, "scraper failed to become ACTIVE in the ring")
+	}
+	return nil
+}
+
+func (s *Scraper) running(ctx context.Context) error {
+	m := discovery.NewManager(ctx, log.With(s.logger, "component", "discovery"), discovery.Name("scrape"))
+	sdConfigs := make(map[string]discovery.Configs, len(s.configs))
+	for _, c := range s.configs {
+		sdConfigs[c.JobName] = c.ServiceDiscoveryConfigs
+	}
+	if err := m.ApplyConfig(sdConfigs); err != nil {
+		return err
+	}
+	go func() {
+		_ = m.Run()
+	}()
+
+	ticker := time.NewTicker(ringCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-s.subservicesWatcher.Chan():
+			return errors.Wrap(err, "scrape subservice failed")
+		case groups := <-m.SyncCh():
+			s.sync(groups)
+		case <-ticker.C:
+			s.resync()
+		}
+	}
+}
+
+func (s *Scraper) stopping(_ error) error {
+	s.stop()
+	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
+}
+
+// RingHandler serves the status page of the scrapers ring.
+func (s *Scraper) RingHandler(w http.ResponseWriter, req *http.Request) {
+	s.lifecycler.ServeHTTP(w, req)
+}
+
+// sync updates the targets of the jobs with the discovered target groups.
+func (s *Scraper) sync(groups map[string][]*targetgroup.Group) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for job, g := range groups {
+		if p, ok := s.pools[job]; ok {
+			p.groups = g
+			p.sync(s.owns)
+		}
+	}
+}
+
+// resync updates the targets of the jobs with the current ring.
+func (s *Scraper) resync() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, p := range s.pools {
+		p.sync(s.owns)
+	}
+}
+
+// owns returns true if the target is scraped by this replica.
+func (s *Scraper) owns(t *target) (bool, error) {
+	rs, err := s.ring.Get(uint32(t.hash), ringOp, nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	return rs.Includes(s.lifecycler.GetInstanceAddr()), nil
+}
+
+func (s *Scraper) stop() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var wg sync.WaitGroup
+	for _, p := range s.pools {
+		wg.Add(1)
+		go func(p *pool) {
+			defer wg.Done()
+			p.stop()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// pool scrapes the targets of a job.
+type pool struct {
+	cfg     *ScrapeConfig
+	client  *http.Client
+	pusher  pushv1connect.PusherServiceHandler
+	metrics *metrics
+	logger  log.Logger
+
+	// groups are the last discovered target groups.
+	groups []*targetgroup.Group
+	// loops are the scrape loops of the targets, by target hash.
+	loops map[uint64]*loop
+}
+
+// sync starts the loops of the new targets owned by the replica, and stops
+// the loops of the targets no longer discovered or owned. The loops are left
+// untouched if the ring can't be read.
+func (p *pool) sync(owns func(*target) (bool, error)) {
+	targets := make(map[uint64]*target)
+	for _, g := range p.groups {
+		if g == nil {
+			continue
+		}
+		for _, lset := range g.Targets {
+			t, err := newTarget(lset, g.Labels, p.cfg)
+			if err != nil {
+				level.Warn(p.logger).Log("msg", "invalid target", "source", g.Source, "labels", lset.String(), "err", err)
+				continue
+			}
+			if t == nil {
+				continue
+			}
+			owned, err := owns(t)
+			if err != nil {
+				level.Warn(p.logger).Log("msg", "failed to find the owner of the target in the ring", "err", err)
+				return
+			}
+			if owned {
+				targets[t.hash] = t
+			}
+		}
+	}
+
+	for hash, l := range p.loops {
+		if _, ok := targets[hash]; !ok {
+			l.stop()
+			delete(p.loops, hash)
+		}
+	}
+	for hash, t := range targets {
+		if _, ok := p.loops[hash]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		l := &loop{
+			target:  t,
+			cfg:     p.cfg,
+			client:  p.client,
+			pusher:  p.pusher,
+			metrics: p.metrics,
+			logger:  log.With(p.logger, "instance", t.address),
+			cancel:  cancel,
+			done:    make(chan struct{}),
+		}
+		p.loops[hash] = l
+		go l.run(ctx)
+	}
+	p.metrics.targets.WithLabelValues(p.cfg.JobName).Set(float64(len(p.loops)))
+}
+
+func (p *pool) stop() {
+	for hash, l := range p.loops {
+		l.stop()
+		delete(p.loops, hash)
+	}
+	p.metrics.targets.WithLabelValues(p.cfg.JobName).Set(0)
+}
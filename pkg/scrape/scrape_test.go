@@ -0,0 +1,311 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/pprof"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/util"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "scrape.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return filename
+}
+
+func TestLoadScrapeConfigs(t *testing.T) {
+	configs, err := loadScrapeConfigs(writeConfigFile(t, `
+scrape_configs:
+  - job_name: default
+    static_configs:
+      - targets: [localhost:6060]
+  - job_name: custom
+    scrape_interval: 30s
+    scrape_timeout: 5s
+    scheme: https
+    tenant: team-a
+    profiles: [process_cpu, mutex]
+    basic_auth:
+      username: pyroscope
+      password: secret
+    file_sd_configs:
+      - files: [targets.json]
+    kubernetes_sd_configs:
+      - role: pod
+    relabel_configs:
+      - source_labels: [__meta_kubernetes_pod_label_app]
+        target_label: service_name
+`))
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	c := configs[0]
+	require.Equal(t, "default", c.JobName)
+	require.Equal(t, model.Duration(15*time.Second), c.ScrapeInterval)
+	require.Equal(t, model.Duration(10*time.Second), c.ScrapeTimeout)
+	require.Equal(t, "http", c.Scheme)
+	require.Equal(t, tenant.DefaultTenantID, c.Tenant)
+	require.Equal(t, []string{"process_cpu", "memory", "goroutine", "mutex", "block"}, c.Profiles)
+	require.Len(t, c.ServiceDiscoveryConfigs, 1)
+	require.Equal(t, "static", c.ServiceDiscoveryConfigs[0].Name())
+
+	c = configs[1]
+	require.Equal(t, model.Duration(30*time.Second), c.ScrapeInterval)
+	require.Equal(t, "https", c.Scheme)
+	require.Equal(t, "team-a", c.Tenant)
+	require.Equal(t, []string{"process_cpu", "mutex"}, c.Profiles)
+	require.Equal(t, "pyroscope", c.HTTPClientConfig.BasicAuth.Username)
+	require.Len(t, c.ServiceDiscoveryConfigs, 2)
+	require.Equal(t, "file", c.ServiceDiscoveryConfigs[0].Name())
+	require.Equal(t, "kubernetes", c.ServiceDiscoveryConfigs[1].Name())
+	require.Len(t, c.RelabelConfigs, 1)
+
+	for name, content := range map[string]string{
+		"no job name":       "scrape_configs: [{static_configs: [{targets: [a:1]}]}]",
+		"duplicate job":     "scrape_configs: [{job_name: a}, {job_name: a}]",
+		"unknown field":     "scrape_configs: [{job_name: a, metrics_path: /metrics}]",
+		"unknown profile":   "scrape_configs: [{job_name: a, profiles: [threadcreate]}]",
+		"duplicate profile": "scrape_configs: [{job_name: a, profiles: [memory, memory]}]",
+		"timeout":           "scrape_configs: [{job_name: a, scrape_interval: 5s, scrape_timeout: 10s}]",
+		"scheme":            "scrape_configs: [{job_name: a, scheme: ftp}]",
+		"tenant":            "scrape_configs: [{job_name: a, tenant: ..}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadScrapeConfigs(writeConfigFile(t, content))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewTarget(t *testing.T) {
+	configs, err := loadScrapeConfigs(writeConfigFile(t, `
+scrape_configs:
+  - job_name: pods
+    relabel_configs:
+      - source_labels: [__meta_pod_annotation_scrape]
+        action: keep
+        regex: true
+      - source_labels: [__meta_pod_label_app]
+        target_label: service_name
+      - source_labels: [__meta_pod_ip]
+        target_label: __address__
+        replacement: $1:6060
+`))
+	require.NoError(t, err)
+	cfg := configs[0]
+
+	tgt, err := newTarget(model.LabelSet{
+		"__meta_pod_annotation_scrape": "true",
+		"__meta_pod_label_app":         "checkout",
+		"__meta_pod_ip":                "10.0.0.1",
+	}, model.LabelSet{"env": "prod"}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "http", tgt.scheme)
+	require.Equal(t, "10.0.0.1:6060", tgt.address)
+	require.Equal(t, labels.FromStrings(
+		"env", "prod",
+		"instance", "10.0.0.1:6060",
+		"job", "pods",
+		"service_name", "checkout",
+	), tgt.labels)
+
+	// Dropped by the relabeling.
+	tgt, err = newTarget(model.LabelSet{"__meta_pod_ip": "10.0.0.2"}, nil, cfg)
+	require.NoError(t, err)
+	require.Nil(t, tgt)
+
+	// The service name defaults to the job name.
+	static := DefaultScrapeConfig
+	static.JobName = "static"
+	tgt, err = newTarget(model.LabelSet{"__address__": "localhost:6060"}, nil, &static)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:6060", tgt.labels.Get("instance"))
+	require.Equal(t, "static", tgt.labels.Get("service_name"))
+
+	_, err = newTarget(model.LabelSet{"env": "prod"}, nil, &static)
+	require.Error(t, err)
+}
+
+type fakePusher struct {
+	pushv1connect.UnimplementedPusherServiceHandler
+
+	mtx      sync.Mutex
+	tenants  []string
+	requests []*pushv1.PushRequest
+}
+
+func (f *fakePusher) Push(ctx context.Context, req *connect.Request[pushv1.PushRequest]) (*connect.Response[pushv1.PushResponse], error) {
+	tenantID, err := tenant.ExtractTenantIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.tenants = append(f.tenants, tenantID)
+	f.requests = append(f.requests, req.Msg)
+	return connect.NewResponse(&pushv1.PushResponse{}), nil
+}
+
+func (f *fakePusher) pushed() ([]string, []*pushv1.PushRequest) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.tenants...), append([]*pushv1.PushRequest(nil), f.requests...)
+}
+
+// newTestScraper starts a scraper registered in the ring of the KV store.
+func newTestScraper(t *testing.T, id int, configFile string, kvStore kv.Client, pusher pushv1connect.PusherServiceHandler, reg prometheus.Registerer) *Scraper {
+	t.Helper()
+	s, err := New(Config{
+		ConfigFile: configFile,
+		Ring: util.CommonRingConfig{
+			KVStore:          kv.Config{Mock: kvStore},
+			HeartbeatPeriod:  100 * time.Millisecond,
+			HeartbeatTimeout: time.Minute,
+			InstanceID:       fmt.Sprintf("scraper-%d", id),
+			InstanceAddr:     "127.0.0.1",
+			InstancePort:     9000 + id,
+		},
+	}, pusher, reg, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), s))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), s)
+	})
+	return s
+}
+
+func (s *Scraper) loopCount(job string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.pools[job].loops)
+}
+
+func TestScraper(t *testing.T) {
+	// The target serves the CPU and heap profiles only.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.Handle("/debug/pprof/heap", pprof.Handler("heap"))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	pusher := new(fakePusher)
+	kvStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { _ = closer.Close() })
+	s := newTestScraper(t, 1, writeConfigFile(t, `
+scrape_configs:
+  - job_name: test
+    scrape_interval: 1s
+    scrape_timeout: 1s
+    tenant: team-a
+    profiles: [process_cpu, memory, mutex]
+`), kvStore, pusher, prometheus.NewRegistry())
+
+	// The targets are synced as by the discovery manager.
+	s.sync(map[string][]*targetgroup.Group{
+		"test": {{
+			Source:  "static",
+			Targets: []model.LabelSet{{model.AddressLabel: model.LabelValue(u.Host)}},
+			Labels:  model.LabelSet{"env": "test"},
+		}},
+		"unknown": {{Targets: []model.LabelSet{{model.AddressLabel: "localhost:1"}}}},
+	})
+	require.Equal(t, 1.0, testutil.ToFloat64(s.metrics.targets.WithLabelValues("test")))
+
+	require.Eventually(t, func() bool {
+		_, requests := pusher.pushed()
+		return len(requests) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), s))
+	require.Equal(t, 0.0, testutil.ToFloat64(s.metrics.targets.WithLabelValues("test")))
+
+	tenants, requests := pusher.pushed()
+	require.Equal(t, "team-a", tenants[0])
+	names := map[string]bool{}
+	for _, series := range requests[0].Series {
+		ls := phlaremodel.Labels(series.Labels)
+		names[ls.Get(phlaremodel.LabelNameProfileName)] = true
+		require.Equal(t, "test", ls.Get("env"))
+		require.Equal(t, "test", ls.Get("job"))
+		require.Equal(t, "test", ls.Get(phlaremodel.LabelNameServiceName))
+		require.Equal(t, u.Host, ls.Get("instance"))
+		require.Empty(t, ls.Get(model.AddressLabel))
+		require.NotEmpty(t, series.Samples[0].RawProfile)
+	}
+	require.Equal(t, map[string]bool{"process_cpu": true, "memory": true}, names)
+	require.GreaterOrEqual(t, testutil.ToFloat64(s.metrics.scrapeFailures.WithLabelValues("test", "mutex")), 1.0)
+	require.Equal(t, 0.0, testutil.ToFloat64(s.metrics.scrapeFailures.WithLabelValues("test", "memory")))
+}
+
+func TestScraperSharding(t *testing.T) {
+	kvStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { _ = closer.Close() })
+	configFile := writeConfigFile(t, `
+scrape_configs:
+  - job_name: test
+    scrape_interval: 1h
+`)
+	s1 := newTestScraper(t, 1, configFile, kvStore, new(fakePusher), nil)
+	s2 := newTestScraper(t, 2, configFile, kvStore, new(fakePusher), nil)
+
+	group := &targetgroup.Group{Source: "static"}
+	for i := 0; i < 32; i++ {
+		group.Targets = append(group.Targets, model.LabelSet{model.AddressLabel: model.LabelValue(fmt.Sprintf("localhost:%d", 10000+i))})
+	}
+	groups := map[string][]*targetgroup.Group{"test": {group}}
+
+	// Each target is scraped by a single scraper, once both see the whole ring.
+	require.Eventually(t, func() bool {
+		s1.sync(groups)
+		s2.sync(groups)
+		return s1.loopCount("test")+s2.loopCount("test") == 32 &&
+			s1.loopCount("test") > 0 && s2.loopCount("test") > 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// The targets of a scraper leaving the ring move to the others.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), s2))
+	require.Eventually(t, func() bool {
+		s1.resync()
+		return s1.loopCount("test") == 32
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestSeriesDeltaLabel(t *testing.T) {
+	l := &loop{target: &target{labels: labels.FromStrings("job", "test")}}
+	for name, p := range profileTypes {
+		ls := phlaremodel.Labels(l.series(name, nil).Labels)
+		if p.delta {
+			require.Equal(t, "true", ls.Get(phlaremodel.LabelNameDelta), name)
+		} else {
+			require.Empty(t, ls.Get(phlaremodel.LabelNameDelta), name)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+const (
+	profileCPU       = "process_cpu"
+	profileMemory    = "memory"
+	profileGoroutine = "goroutine"
+	profileMutex     = "mutex"
+	profileBlock     = "block"
+)
+
+type profileType struct {
+	path string
+	// delta is true if the samples of the profile are cumulative: the
+	// ingesters then ingest the difference with the previous profile.
+	delta bool
+}
+
+// profileTypes are the profiles which can be scraped, by profile name.
+// The delta of the alloc samples of the memory profile is computed
+// without the __delta__ label.
+var profileTypes = map[string]profileType{
+	profileCPU:       {path: "/debug/pprof/profile"},
+	profileMemory:    {path: "/debug/pprof/heap"},
+	profileGoroutine: {path: "/debug/pprof/goroutine"},
+	profileMutex:     {path: "/debug/pprof/mutex", delta: true},
+	profileBlock:     {path: "/debug/pprof/block", delta: true},
+}
+
+// target is a pprof server discovered by a job.
+type target struct {
+	scheme  string
+	address string
+	// labels are the labels of the profiles of the target.
+	labels labels.Labels
+	// hash identifies the target in its job.
+	hash uint64
+}
+
+// newTarget returns the target of the discovered labels, relabeled with the
+// config of the job, or nil if it is dropped.
+func newTarget(lset, groupLabels model.LabelSet, cfg *ScrapeConfig) (*target, error) {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	for ln, lv := range groupLabels {
+		lb.Set(string(ln), string(lv))
+	}
+	for ln, lv := range lset {
+		lb.Set(string(ln), string(lv))
+	}
+	for _, l := range []labels.Label{
+		{Name: model.JobLabel, Value: cfg.JobName},
+		{Name: model.SchemeLabel, Value: cfg.Scheme},
+	} {
+		if lb.Get(l.Name) == "" {
+			lb.Set(l.Name, l.Value)
+		}
+	}
+	if !relabel.ProcessBuilder(lb, cfg.RelabelConfigs...) {
+		return nil, nil
+	}
+
+	t := &target{
+		scheme:  lb.Get(model.SchemeLabel),
+		address: lb.Get(model.AddressLabel),
+	}
+	if t.address == "" {
+		return nil, errors.New("no address")
+	}
+	if t.scheme != "http" && t.scheme != "https" {
+		return nil, fmt.Errorf("invalid scheme %q", t.scheme)
+	}
+	if lb.Get(model.InstanceLabel) == "" {
+		lb.Set(model.InstanceLabel, t.address)
+	}
+	if lb.Get(phlaremodel.LabelNameServiceName) == "" {
+		lb.Set(phlaremodel.LabelNameServiceName, lb.Get(model.JobLabel))
+	}
+	t.hash = lb.Labels().Hash()
+	// The meta and the internal labels are not pushed.
+	lb.Range(func(l labels.Label) {
+		if strings.HasPrefix(l.Name, model.ReservedLabelPrefix) {
+			lb.Del(l.Name)
+		}
+	})
+	t.labels = lb.Labels()
+	return t, nil
+}
+
+func (t *target) url(path string) *url.URL {
+	return &url.URL{Scheme: t.scheme, Host: t.address, Path: path}
+}
+
+// cpuSeconds returns the duration of the CPU profiles, for an interval between the scrapes.
+func cpuSeconds(interval time.Duration) int {
+	seconds := int(interval/time.Second) - 1
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// loop scrapes a target, until it is stopped.
+type loop struct {
+	target  *target
+	cfg     *ScrapeConfig
+	client  *http.Client
+	pusher  pushv1connect.PusherServiceHandler
+	metrics *metrics
+	logger  log.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (l *loop) run(ctx context.Context) {
+	defer close(l.done)
+	interval := time.Duration(l.cfg.ScrapeInterval)
+	// The scrapes of the targets are spread over the interval.
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Duration(l.target.hash % uint64(interval))):
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		l.scrape(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *loop) stop() {
+	l.cancel()
+	<-l.done
+}
+
+// scrape fetches the profiles of the target concurrently, and pushes them.
+func (l *loop) scrape(ctx context.Context) {
+	series := make([]*pushv1.RawProfileSeries, len(l.cfg.Profiles))
+	var wg sync.WaitGroup
+	for i, name := range l.cfg.Profiles {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			start := time.Now()
+			raw, err := l.fetch(ctx, name)
+			l.metrics.scrapeDuration.WithLabelValues(l.cfg.JobName, name).Observe(time.Since(start).Seconds())
+			if err != nil {
+				if ctx.Err() == nil {
+					l.metrics.scrapeFailures.WithLabelValues(l.cfg.JobName, name).Inc()
+					level.Warn(l.logger).Log("msg", "failed to scrape profile", "profile", name, "err", err)
+				}
+				return
+			}
+			series[i] = l.series(name, raw)
+		}(i, name)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	req := &pushv1.PushRequest{Series: make([]*pushv1.RawProfileSeries, 0, len(series))}
+	for _, s := range series {
+		if s != nil {
+			req.Series = append(req.Series, s)
+		}
+	}
+	if len(req.Series) == 0 {
+		return
+	}
+	if _, err := l.pusher.Push(tenant.InjectTenantID(ctx, l.cfg.Tenant), connect.NewRequest(req)); err != nil {
+		l.metrics.pushFailures.WithLabelValues(l.cfg.JobName).Inc()
+		level.Warn(l.logger).Log("msg", "failed to push scraped profiles", "err", err)
+	}
+}
+
+func (l *loop) fetch(ctx context.Context, name string) ([]byte, error) {
+	u := l.target.url(profileTypes[name].path)
+	timeout := time.Duration(l.cfg.ScrapeTimeout)
+	if name == profileCPU {
+		seconds := cpuSeconds(time.Duration(l.cfg.ScrapeInterval))
+		u.RawQuery = url.Values{"seconds": []string{strconv.Itoa(seconds)}}.Encode()
+		timeout += time.Duration(seconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (l *loop) series(name string, raw []byte) *pushv1.RawProfileSeries {
+	lbls := make([]*typesv1.LabelPair, 0, l.target.labels.Len()+2)
+	lbls = append(lbls, &typesv1.LabelPair{Name: phlaremodel.LabelNameProfileName, Value: name})
+	l.target.labels.Range(func(lbl labels.Label) {
+		lbls = append(lbls, &typesv1.LabelPair{Name: lbl.Name, Value: lbl.Value})
+	})
+	if profileTypes[name].delta {
+		lbls = append(lbls, &typesv1.LabelPair{Name: phlaremodel.LabelNameDelta, Value: "true"})
+	}
+	return &pushv1.RawProfileSeries{
+		Labels: lbls,
+		Samples: []*pushv1.RawSample{{
+			ID:         uuid.NewString(),
+			RawProfile: raw,
+		}},
+	}
+}
@@ -0,0 +1,157 @@
+// Package selfprofiling profiles the running process: it periodically collects its CPU, heap,
+// goroutine, mutex and block profiles, and pushes them through the local distributor. Outside
+// of the single binary, the self-profiling target thus runs a full distributor, with its push
+// API, in every component it is added to.
+package selfprofiling
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"runtime/pprof"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/grafana/dskit/services"
+
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+// ServiceName is the service name of the self profiles.
+const ServiceName = "pyroscope"
+
+type Config struct {
+	DisablePush          bool          `yaml:"disable_push,omitempty"`
+	MutexProfileFraction int           `yaml:"mutex_profile_fraction,omitempty"`
+	BlockProfileRate     int           `yaml:"block_profile_rate,omitempty"`
+	Tenant               string        `yaml:"tenant,omitempty"`
+	Interval             time.Duration `yaml:"interval,omitempty" category:"advanced"`
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	// these are values that worked well in OG Pyroscope Cloud without adding much overhead
+	f.IntVar(&c.MutexProfileFraction, "self-profiling.mutex-profile-fraction", 5, "")
+	f.IntVar(&c.BlockProfileRate, "self-profiling.block-profile-rate", 5, "")
+	f.BoolVar(&c.DisablePush, "self-profiling.disable-push", false, "When running in single binary (--target=all), or with the self-profiling target, Pyroscope pushes its own profiles through the local distributor. The self-profiling target adds a full distributor, serving the push API, to the other targets of the process. Set to true to disable self-profiling.")
+	f.StringVar(&c.Tenant, "self-profiling.tenant", tenant.DefaultTenantID, "Tenant of the self profiles. With multi-tenancy, a dedicated tenant keeps them apart from the profiles of the other services.")
+	f.DurationVar(&c.Interval, "self-profiling.interval", 15*time.Second, "Interval at which the profiles are collected and pushed. The CPU profile covers the whole interval.")
+}
+
+func (c *Config) Validate() error {
+	if c.DisablePush {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("the self-profiling interval must be positive")
+	}
+	return tenant.ValidTenantID(c.Tenant)
+}
+
+type profile struct {
+	name  string
+	delta bool
+}
+
+// profiles are the profiles collected besides the CPU one, with their profile name.
+// The samples of the mutex and block profiles are cumulative: their delta is ingested.
+var profiles = map[string]profile{
+	"heap":      {name: "memory"},
+	"goroutine": {name: "goroutine"},
+	"mutex":     {name: "mutex", delta: true},
+	"block":     {name: "block", delta: true},
+}
+
+// Profiler pushes the profiles of the process, with the labels of the process.
+type Profiler struct {
+	services.Service
+
+	cfg    Config
+	pusher pushv1connect.PusherServiceHandler
+	labels []*typesv1.LabelPair
+	logger log.Logger
+}
+
+// New returns a profiler, which labels the profiles with the component, the instance and the
+// version of the process.
+func New(cfg Config, pusher pushv1connect.PusherServiceHandler, component, instance, version string, logger log.Logger) *Profiler {
+	p := &Profiler{
+		cfg:    cfg,
+		pusher: pusher,
+		labels: []*typesv1.LabelPair{
+			{Name: phlaremodel.LabelNameServiceName, Value: ServiceName},
+			{Name: "component", Value: component},
+			{Name: "instance", Value: instance},
+			{Name: "version", Value: version},
+		},
+		logger: logger,
+	}
+	p.Service = services.NewBasicService(nil, p.running, nil)
+	return p
+}
+
+func (p *Profiler) running(ctx context.Context) error {
+	for ctx.Err() == nil {
+		req, err := p.collect(ctx)
+		if err != nil {
+			level.Warn(p.logger).Log("msg", "failed to collect self profiles", "err", err)
+			continue
+		}
+		// The profiles collected when the service stops are pushed too.
+		if _, err = p.pusher.Push(tenant.InjectTenantID(context.Background(), p.cfg.Tenant), connect.NewRequest(req)); err != nil {
+			level.Warn(p.logger).Log("msg", "failed to push self profiles", "err", err)
+		}
+	}
+	return nil
+}
+
+// collect profiles the CPU during the interval, or until the context is done,
+// then returns all the profiles.
+func (p *Profiler) collect(ctx context.Context) (*pushv1.PushRequest, error) {
+	var cpu bytes.Buffer
+	// The CPU profile may be collected already, e.g. by /debug/pprof/profile.
+	cpuErr := pprof.StartCPUProfile(&cpu)
+	if cpuErr != nil {
+		level.Debug(p.logger).Log("msg", "skipping the self CPU profile", "err", cpuErr)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(p.cfg.Interval):
+	}
+	req := &pushv1.PushRequest{Series: make([]*pushv1.RawProfileSeries, 0, len(profiles)+1)}
+	if cpuErr == nil {
+		pprof.StopCPUProfile()
+		req.Series = append(req.Series, p.series(profile{name: "process_cpu"}, cpu.Bytes()))
+	}
+	for name, prof := range profiles {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			return nil, fmt.Errorf("collecting the %s profile: %w", name, err)
+		}
+		req.Series = append(req.Series, p.series(prof, buf.Bytes()))
+	}
+	return req, nil
+}
+
+func (p *Profiler) series(prof profile, raw []byte) *pushv1.RawProfileSeries {
+	labels := make([]*typesv1.LabelPair, 0, len(p.labels)+2)
+	labels = append(labels, &typesv1.LabelPair{Name: phlaremodel.LabelNameProfileName, Value: prof.name})
+	labels = append(labels, p.labels...)
+	if prof.delta {
+		labels = append(labels, &typesv1.LabelPair{Name: phlaremodel.LabelNameDelta, Value: "true"})
+	}
+	return &pushv1.RawProfileSeries{
+		Labels: labels,
+		Samples: []*pushv1.RawSample{{
+			ID:         uuid.NewString(),
+			RawProfile: raw,
+		}},
+	}
+}
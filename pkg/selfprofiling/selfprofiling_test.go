@@ -0,0 +1,93 @@
+package selfprofiling
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+
+	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+type fakePusher struct {
+	pushv1connect.UnimplementedPusherServiceHandler
+
+	mtx      sync.Mutex
+	tenants  []string
+	requests []*pushv1.PushRequest
+}
+
+func (f *fakePusher) Push(ctx context.Context, req *connect.Request[pushv1.PushRequest]) (*connect.Response[pushv1.PushResponse], error) {
+	tenantID, err := tenant.ExtractTenantIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.tenants = append(f.tenants, tenantID)
+	f.requests = append(f.requests, req.Msg)
+	return connect.NewResponse(&pushv1.PushResponse{}), nil
+}
+
+func (f *fakePusher) pushed() ([]string, []*pushv1.PushRequest) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.tenants...), append([]*pushv1.PushRequest(nil), f.requests...)
+}
+
+func TestProfiler(t *testing.T) {
+	pusher := new(fakePusher)
+	cfg := Config{Tenant: "self", Interval: 50 * time.Millisecond}
+	require.NoError(t, cfg.Validate())
+	p := New(cfg, pusher, "all", "host-1", "v1.2.3", log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), p))
+
+	require.Eventually(t, func() bool {
+		_, requests := pusher.pushed()
+		return len(requests) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), p))
+
+	tenants, requests := pusher.pushed()
+	for _, tenantID := range tenants {
+		require.Equal(t, "self", tenantID)
+	}
+	names := map[string]bool{}
+	for _, s := range requests[0].Series {
+		ls := phlaremodel.Labels(s.Labels)
+		name := ls.Get(phlaremodel.LabelNameProfileName)
+		names[name] = true
+		require.Equal(t, ServiceName, ls.Get(phlaremodel.LabelNameServiceName))
+		require.Equal(t, "all", ls.Get("component"))
+		require.Equal(t, "host-1", ls.Get("instance"))
+		require.Equal(t, "v1.2.3", ls.Get("version"))
+		if name == "mutex" || name == "block" {
+			require.Equal(t, "true", ls.Get(phlaremodel.LabelNameDelta))
+		} else {
+			require.Empty(t, ls.Get(phlaremodel.LabelNameDelta))
+		}
+		require.Len(t, s.Samples, 1)
+		require.NotEmpty(t, s.Samples[0].RawProfile)
+	}
+	require.Equal(t, map[string]bool{
+		"process_cpu": true,
+		"memory":      true,
+		"goroutine":   true,
+		"mutex":       true,
+		"block":       true,
+	}, names)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.Error(t, (&Config{Tenant: "self"}).Validate())
+	require.Error(t, (&Config{Tenant: "..", Interval: time.Second}).Validate())
+	require.NoError(t, (&Config{DisablePush: true}).Validate())
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
@@ -15,6 +16,7 @@ import (
 
 type BlockCloser interface {
 	phlaredb.Querier
+	phlaredb.BlockFilter
 	Close() error
 }
 
@@ -22,6 +24,22 @@ type Block struct {
 	BlockCloser
 	meta   *block.Meta
 	logger log.Logger
+
+	// The fields below are guarded by BucketStore.openMtx.
+	open     bool
+	opening  *blockOpening // The last attempt to open the block.
+	refs     int
+	lastUsed time.Time
+	// removed is set once the block is removed from the store: it is
+	// closed when the last query using it releases it.
+	removed bool
+}
+
+// blockOpening is an attempt to open a block. done is closed once
+// the attempt is over, and err is its result.
+type blockOpening struct {
+	done chan struct{}
+	err  error
 }
 
 func (bs *BucketStore) createBlock(ctx context.Context, meta *block.Meta) (*Block, error) {
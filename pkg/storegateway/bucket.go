@@ -14,9 +14,9 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
 
 	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
-	"github.com/grafana/pyroscope/pkg/phlaredb"
 	"github.com/grafana/pyroscope/pkg/phlaredb/block"
 )
 
@@ -40,20 +40,29 @@ type BucketStore struct {
 	blocks   map[ulid.ULID]*Block
 	blockSet *bucketBlockSet
 
+	// Blocks are opened on demand, when queried. The number of
+	// blocks open at the same time is bounded by maxOpenBlocks.
+	maxOpenBlocks int
+	openMtx       sync.Mutex // Must be acquired before blocksMx.
+	openBlocks    int
+	released      chan struct{} // Closed and replaced each time blocks are released.
+
 	metrics *Metrics
 	stats   BucketStoreStats
 }
 
-func NewBucketStore(bucket phlareobj.Bucket, fetcher block.MetadataFetcher, tenantID string, syncDir string, logger log.Logger, Metrics *Metrics) (*BucketStore, error) {
+func NewBucketStore(bucket phlareobj.Bucket, fetcher block.MetadataFetcher, tenantID string, syncDir string, maxOpenBlocks int, logger log.Logger, Metrics *Metrics) (*BucketStore, error) {
 	s := &BucketStore{
-		fetcher:  fetcher,
-		bucket:   phlareobj.NewTenantBucketClient(tenantID, bucket, nil),
-		tenantID: tenantID,
-		syncDir:  syncDir,
-		logger:   logger,
-		blockSet: newBucketBlockSet(),
-		blocks:   map[ulid.ULID]*Block{},
-		metrics:  Metrics,
+		fetcher:       fetcher,
+		bucket:        phlareobj.NewTenantBucketClient(tenantID, bucket, nil),
+		tenantID:      tenantID,
+		syncDir:       syncDir,
+		logger:        logger,
+		blockSet:      newBucketBlockSet(),
+		blocks:        map[ulid.ULID]*Block{},
+		maxOpenBlocks: maxOpenBlocks,
+		released:      make(chan struct{}),
+		metrics:       Metrics,
 	}
 
 	if err := os.MkdirAll(syncDir, 0o750); err != nil {
@@ -173,38 +182,16 @@ func (bs *BucketStore) addBlock(ctx context.Context, meta *block.Meta) (err erro
 
 	bs.metrics.blockLoads.Inc()
 
-	b, err := func() (*Block, error) {
-		bs.blocksMx.Lock()
-		defer bs.blocksMx.Unlock()
+	bs.blocksMx.Lock()
+	defer bs.blocksMx.Unlock()
 
-		b, err := bs.createBlock(ctx, meta)
-		if err != nil {
-			return nil, errors.Wrap(err, "load block from disk")
-		}
-		bs.blockSet.add(b)
-		bs.blocks[meta.ULID] = b
-		return b, nil
-	}()
+	b, err := bs.createBlock(ctx, meta)
 	if err != nil {
-		return err
-	}
-	// Load the block into memory if it's within the last 24 hours.
-	// Todo make this configurable
-	if phlaredb.InRange(b, model.Now().Add(-24*time.Hour), model.Now()) {
-		level.Debug(bs.logger).Log("msg", "opening block",
-			"id", meta.ULID.String(),
-			"min", b.meta.MinTime.Time().Format(time.RFC3339),
-			"max", b.meta.MaxTime.Time().Format(time.RFC3339),
-		)
-
-		start := time.Now()
-		defer func() {
-			level.Info(bs.logger).Log("msg", "block opened", "duration", time.Since(start), "id", meta.ULID.String())
-		}()
-		if err := b.Open(ctx); err != nil {
-			level.Error(bs.logger).Log("msg", "open block", "err", err)
-		}
+		return errors.Wrap(err, "load block from disk")
 	}
+	// The block is opened once queried.
+	bs.blockSet.add(b)
+	bs.blocks[meta.ULID] = b
 	return nil
 }
 
@@ -235,10 +222,26 @@ func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	// // even if releasing its resources could fail below.
 	s.metrics.blockDrops.Inc()
 
+	s.openMtx.Lock()
+	b.removed = true
+	inUse := b.refs > 0
+	if !inUse {
+		s.markClosed(b)
+	}
+	s.openMtx.Unlock()
+	if inUse {
+		// The block is closed once released by the queries using it.
+		return nil
+	}
+	return s.dropBlock(b)
+}
+
+// dropBlock closes a removed block and deletes its local files.
+func (s *BucketStore) dropBlock(b *Block) error {
 	if err := b.Close(); err != nil {
 		return errors.Wrap(err, "close block")
 	}
-	if err := os.RemoveAll(s.localPath(id.String())); err != nil {
+	if err := os.RemoveAll(s.localPath(b.meta.ULID.String())); err != nil {
 		return errors.Wrap(err, "delete block")
 	}
 	return nil
@@ -250,12 +253,234 @@ func (s *BucketStore) localPath(id string) string {
 
 // RemoveBlocksAndClose remove all blocks from local disk and releases all resources associated with the BucketStore.
 func (s *BucketStore) RemoveBlocksAndClose() error {
+	s.openMtx.Lock()
+	s.blocksMx.RLock()
+	for _, b := range s.blocks {
+		if b.open {
+			s.closeBlock(b, "")
+		}
+	}
+	s.blocksMx.RUnlock()
+	s.openMtx.Unlock()
 	if err := os.RemoveAll(s.syncDir); err != nil {
 		return errors.Wrap(err, "delete block")
 	}
 	return nil
 }
 
+// acquireBlocks opens the given blocks, if they are not open yet, and
+// prevents them from being closed until released with releaseBlocks.
+// If the number of open blocks would exceed the limit, the least recently
+// used blocks that are not in use are closed. If that's not enough, the
+// call waits until other queries release their blocks. A query that needs
+// more blocks than the limit is not delayed: it opens them all, and the
+// blocks are closed when released, until the limit is met again.
+func (s *BucketStore) acquireBlocks(ctx context.Context, blks []*Block) error {
+	var (
+		opening  map[*Block]struct{}
+		attempts = make([]*blockOpening, len(blks))
+	)
+	for {
+		var ok bool
+		s.openMtx.Lock()
+		if opening, ok = s.reserveBlocks(blks); ok {
+			for i, b := range blks {
+				attempts[i] = b.opening
+			}
+			s.openMtx.Unlock()
+			break
+		}
+		released := s.released
+		s.openMtx.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, b := range blks {
+		b, o := b, attempts[i]
+		if _, ok := opening[b]; ok {
+			g.Go(func() error { return s.openBlock(gctx, b, o) })
+			continue
+		}
+		// The block might have been reserved by a concurrent query
+		// and still be opening: wait for the outcome of the attempt.
+		g.Go(func() error {
+			select {
+			case <-gctx.Done():
+				return gctx.Err()
+			case <-o.done:
+				return o.err
+			}
+		})
+	}
+	if err := g.Wait(); err != nil {
+		s.releaseBlocks(blks)
+		return err
+	}
+	return nil
+}
+
+// reserveBlocks marks the blocks as open and in use, and returns the ones
+// that need to be opened. It returns false if the blocks can't be opened
+// without exceeding the limit. Must be called with openMtx held.
+func (s *BucketStore) reserveBlocks(blks []*Block) (map[*Block]struct{}, bool) {
+	opening := make(map[*Block]struct{})
+	for _, b := range blks {
+		if !b.open {
+			opening[b] = struct{}{}
+		}
+	}
+	if s.maxOpenBlocks > 0 {
+		n := s.openBlocks + len(opening) - s.maxOpenBlocks
+		if n > 0 && s.evictBlocks(n, blks) < n && len(blks) <= s.maxOpenBlocks {
+			return nil, false
+		}
+	}
+	now := time.Now()
+	for _, b := range blks {
+		if !b.open {
+			b.open = true
+			b.opening = &blockOpening{done: make(chan struct{})}
+			s.openBlocks++
+			s.metrics.blocksOpen.Inc()
+		}
+		b.refs++
+		b.lastUsed = now
+	}
+	return opening, true
+}
+
+// evictBlocks closes up to n least recently used blocks that are not in
+// use, except the ones to keep, and returns the number of blocks closed.
+// Must be called with openMtx held.
+func (s *BucketStore) evictBlocks(n int, keep []*Block) int {
+	skip := make(map[*Block]struct{}, len(keep))
+	for _, b := range keep {
+		skip[b] = struct{}{}
+	}
+	s.blocksMx.RLock()
+	candidates := make([]*Block, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		if _, ok := skip[b]; !ok && b.open && b.refs == 0 {
+			candidates = append(candidates, b)
+		}
+	}
+	s.blocksMx.RUnlock()
+	if len(candidates) < n {
+		n = len(candidates)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+	for _, b := range candidates[:n] {
+		s.closeBlock(b, blockCloseReasonEvicted)
+	}
+	return n
+}
+
+// openBlock opens a block reserved with reserveBlocks, and reports the
+// outcome to the queries waiting for the attempt.
+func (s *BucketStore) openBlock(ctx context.Context, b *Block, o *blockOpening) error {
+	start := time.Now()
+	s.metrics.blockOpens.Inc()
+	err := b.Open(ctx)
+	if err != nil {
+		s.metrics.blockOpenFailures.Inc()
+		level.Warn(s.logger).Log("msg", "failed to open block", "id", b.meta.ULID, "err", err)
+		// Release whatever has been opened, before the block can be
+		// reserved again: it is opened the next time it is queried.
+		if closeErr := b.Close(); closeErr != nil {
+			level.Warn(s.logger).Log("msg", "failed to close block", "id", b.meta.ULID, "err", closeErr)
+		}
+		err = errors.Wrapf(err, "open block %s", b.meta.ULID)
+	} else {
+		elapsed := time.Since(start)
+		s.metrics.blockOpenDuration.Observe(elapsed.Seconds())
+		level.Debug(s.logger).Log("msg", "block opened", "id", b.meta.ULID, "elapsed", elapsed)
+	}
+
+	s.openMtx.Lock()
+	defer s.openMtx.Unlock()
+	o.err = err
+	close(o.done)
+	if err != nil {
+		s.markClosed(b)
+		close(s.released)
+		s.released = make(chan struct{})
+	}
+	return err
+}
+
+// releaseBlocks allows the blocks acquired with acquireBlocks to be closed.
+// The blocks removed while in use are closed, and the blocks open above the
+// limit are evicted.
+func (s *BucketStore) releaseBlocks(blks []*Block) {
+	var removed []*Block
+	s.openMtx.Lock()
+	now := time.Now()
+	for _, b := range blks {
+		b.refs--
+		b.lastUsed = now
+		if b.removed && b.refs == 0 {
+			s.markClosed(b)
+			removed = append(removed, b)
+		}
+	}
+	if s.maxOpenBlocks > 0 && s.openBlocks > s.maxOpenBlocks {
+		s.evictBlocks(s.openBlocks-s.maxOpenBlocks, nil)
+	}
+	close(s.released)
+	s.released = make(chan struct{})
+	s.openMtx.Unlock()
+
+	for _, b := range removed {
+		if err := s.dropBlock(b); err != nil {
+			s.metrics.blockDropFailures.Inc()
+			level.Warn(s.logger).Log("msg", "failed to drop block", "id", b.meta.ULID, "err", err)
+		}
+	}
+}
+
+// CloseIdleBlocks closes the blocks that have not been used for longer
+// than the idle timeout.
+func (s *BucketStore) CloseIdleBlocks(idleTimeout time.Duration) {
+	s.openMtx.Lock()
+	defer s.openMtx.Unlock()
+	s.blocksMx.RLock()
+	defer s.blocksMx.RUnlock()
+	for _, b := range s.blocks {
+		if b.open && b.refs == 0 && time.Since(b.lastUsed) > idleTimeout {
+			s.closeBlock(b, blockCloseReasonIdle)
+		}
+	}
+}
+
+// closeBlock closes the block files. Must be called with openMtx held.
+func (s *BucketStore) closeBlock(b *Block, reason string) {
+	s.markClosed(b)
+	if reason != "" {
+		s.metrics.blockCloses.WithLabelValues(reason).Inc()
+	}
+	if err := b.Close(); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to close block", "id", b.meta.ULID, "err", err)
+	}
+	level.Debug(s.logger).Log("msg", "block closed", "id", b.meta.ULID, "reason", reason)
+}
+
+// markClosed updates the open blocks accounting.
+// Must be called with openMtx held.
+func (s *BucketStore) markClosed(b *Block) {
+	if b.open {
+		b.open = false
+		s.openBlocks--
+		s.metrics.blocksOpen.Dec()
+	}
+}
+
 // bucketBlockSet holds all blocks.
 type bucketBlockSet struct {
 	mtx    sync.RWMutex
@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
+	"github.com/grafana/pyroscope/pkg/objstore/bucketcache"
 	"github.com/grafana/pyroscope/pkg/phlaredb/block"
 	"github.com/grafana/pyroscope/pkg/phlaredb/bucket"
 	"github.com/grafana/pyroscope/pkg/util"
@@ -25,18 +26,22 @@ import (
 var errBucketStoreNotFound = errors.New("bucket store not found")
 
 type BucketStoreConfig struct {
-	SyncDir                  string        `yaml:"sync_dir"`
-	SyncInterval             time.Duration `yaml:"sync_interval" category:"advanced"`
-	TenantSyncConcurrency    int           `yaml:"tenant_sync_concurrency" category:"advanced"`
-	IgnoreBlocksWithin       time.Duration `yaml:"ignore_blocks_within" category:"advanced"`
-	MetaSyncConcurrency      int           `yaml:"meta_sync_concurrency" category:"advanced"`
-	IgnoreDeletionMarksDelay time.Duration `yaml:"ignore_deletion_mark_delay" category:"advanced"`
+	SyncDir                  string             `yaml:"sync_dir"`
+	SyncInterval             time.Duration      `yaml:"sync_interval" category:"advanced"`
+	TenantSyncConcurrency    int                `yaml:"tenant_sync_concurrency" category:"advanced"`
+	IgnoreBlocksWithin       time.Duration      `yaml:"ignore_blocks_within" category:"advanced"`
+	MetaSyncConcurrency      int                `yaml:"meta_sync_concurrency" category:"advanced"`
+	IgnoreDeletionMarksDelay time.Duration      `yaml:"ignore_deletion_mark_delay" category:"advanced"`
+	BlockIdleTimeout         time.Duration      `yaml:"block_idle_timeout" category:"advanced"`
+	MaxOpenBlocksPerTenant   int                `yaml:"max_open_blocks_per_tenant" category:"advanced"`
+	Cache                    bucketcache.Config `yaml:"cache"`
 }
 
 // RegisterFlags registers the BucketStore flags
 func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	// cfg.IndexCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-cache.")
 	// cfg.ChunksCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.chunks-cache.", logger)
+	cfg.Cache.RegisterFlagsWithPrefix("blocks-storage.bucket-store.cache.", f)
 	// cfg.MetadataCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.metadata-cache.")
 	// cfg.BucketIndex.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.bucket-index.")
 	// cfg.IndexHeader.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-header.")
@@ -56,6 +61,8 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger)
 	// f.DurationVar(&cfg.DeprecatedConsistencyDelay, consistencyDelayFlag, 0, "Minimum age of a block before it's being read. Set it to safe value (e.g 30m) if your object storage is eventually consistent. GCS and S3 are (roughly) strongly consistent.")
 	f.DurationVar(&cfg.IgnoreDeletionMarksDelay, "blocks-storage.bucket-store.ignore-deletion-marks-delay", time.Hour*1, "Duration after which the blocks marked for deletion will be filtered out while fetching blocks. "+
 		"The idea of ignore-deletion-marks-delay is to ignore blocks that are marked for deletion with some delay. This ensures store can still serve blocks that are meant to be deleted but do not have a replacement yet.")
+	f.DurationVar(&cfg.BlockIdleTimeout, "blocks-storage.bucket-store.block-idle-timeout", time.Hour, "Blocks are opened when first queried, and closed once not queried for this duration. 0 disables closing idle blocks.")
+	f.IntVar(&cfg.MaxOpenBlocksPerTenant, "blocks-storage.bucket-store.max-open-blocks-per-tenant", 0, "Maximum number of blocks open at the same time per tenant. Least recently used blocks are closed to open new ones, queries that need more blocks wait for the ones in use to be released. A query that needs more blocks than the limit opens them all, and they are closed once released. 0 to disable the limit.")
 	// f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
 	// f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", true, "If enabled, store-gateway will lazy load an index-header only once required by a query.")
 	// f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 60*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will offload unused index-headers after 'idle timeout' inactivity.")
@@ -76,6 +83,15 @@ func (cfg *BucketStoreConfig) Validate(logger log.Logger) error {
 	// if err := cfg.ChunksCache.Validate(); err != nil {
 	// 	return errors.Wrap(err, "chunks-cache configuration")
 	// }
+	if err := cfg.Cache.Validate(); err != nil {
+		return errors.Wrap(err, "cache configuration")
+	}
+	if cfg.BlockIdleTimeout < 0 {
+		return errors.New("block idle timeout must not be negative")
+	}
+	if cfg.MaxOpenBlocksPerTenant < 0 {
+		return errors.New("max open blocks per tenant must not be negative")
+	}
 	// if err := cfg.MetadataCache.Validate(); err != nil {
 	// 	return errors.Wrap(err, "metadata-cache configuration")
 	// }
@@ -102,6 +118,7 @@ func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 
 type BucketStores struct {
 	storageBucket     phlareobj.Bucket
+	cache             bucketcache.Cache
 	cfg               BucketStoreConfig
 	logger            log.Logger
 	syncBackoffConfig backoff.Config
@@ -122,8 +139,15 @@ type BucketStores struct {
 }
 
 func NewBucketStores(cfg BucketStoreConfig, shardingStrategy ShardingStrategy, storageBucket phlareobj.Bucket, limits Limits, logger log.Logger, reg prometheus.Registerer) (*BucketStores, error) {
+	c, err := bucketcache.NewCache("bucket", cfg.Cache, logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create bucket cache")
+	}
+	storageBucket = bucketcache.NewCachingBucket(storageBucket, c, cfg.Cache, reg)
+
 	bs := &BucketStores{
 		storageBucket: storageBucket,
+		cache:         c,
 		logger:        logger,
 		cfg:           cfg,
 		syncBackoffConfig: backoff.Config{
@@ -343,6 +367,7 @@ func (bs *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		fetcher,
 		userID,
 		bs.syncDirForUser(userID),
+		bs.cfg.MaxOpenBlocksPerTenant,
 		userLogger,
 		bs.metrics,
 	)
@@ -423,6 +448,27 @@ func (bs *BucketStores) closeBucketStore(userID string) error {
 	return s.RemoveBlocksAndClose()
 }
 
+// CloseIdleBlocks closes the blocks not queried for longer than the idle timeout.
+func (bs *BucketStores) CloseIdleBlocks() {
+	bs.storesMu.RLock()
+	stores := make([]*BucketStore, 0, len(bs.stores))
+	for _, s := range bs.stores {
+		stores = append(stores, s)
+	}
+	bs.storesMu.RUnlock()
+
+	for _, s := range stores {
+		s.CloseIdleBlocks(bs.cfg.BlockIdleTimeout)
+	}
+}
+
+// Stop releases the bucket cache.
+func (bs *BucketStores) Stop() {
+	if bs.cache != nil {
+		bs.cache.Stop()
+	}
+}
+
 // getBlocksLoadedMetric returns the number of blocks currently loaded across all bucket stores.
 func (u *BucketStores) getBlocksLoadedMetric() float64 {
 	count := 0
@@ -0,0 +1,288 @@
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	phlaremodel "github.com/grafana/pyroscope/pkg/model"
+	"github.com/grafana/pyroscope/pkg/phlaredb"
+	"github.com/grafana/pyroscope/pkg/phlaredb/block"
+)
+
+type fakeBlockQuerier struct {
+	BlockCloser
+
+	mtx     sync.Mutex
+	opened  bool
+	opens   int
+	openErr error
+	// If not nil, Open waits for the channel to be closed.
+	wait chan struct{}
+	// noSpans is true if the block has no span.
+	noSpans bool
+	// noFunctions is true if the block has no function.
+	noFunctions bool
+}
+
+func (q *fakeBlockQuerier) MayContainSpans(context.Context, phlaremodel.SpanSelector) (bool, error) {
+	return !q.noSpans, nil
+}
+
+func (q *fakeBlockQuerier) MayContainFunctions(context.Context, []string) (bool, error) {
+	return !q.noFunctions, nil
+}
+
+func (q *fakeBlockQuerier) Open(context.Context) error {
+	if q.wait != nil {
+		<-q.wait
+	}
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.opened {
+		return nil
+	}
+	q.opens++
+	if q.openErr != nil {
+		return q.openErr
+	}
+	q.opened = true
+	return nil
+}
+
+func (q *fakeBlockQuerier) Close() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.opened = false
+	return nil
+}
+
+func (q *fakeBlockQuerier) isOpen() bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.opened
+}
+
+func newTestBucketStore(t *testing.T, maxOpenBlocks int, blocks int) (*BucketStore, []*fakeBlockQuerier) {
+	t.Helper()
+	s, err := NewBucketStore(nil, nil, "tenant", t.TempDir(), maxOpenBlocks, log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	queriers := make([]*fakeBlockQuerier, blocks)
+	for i := range queriers {
+		queriers[i] = new(fakeBlockQuerier)
+		meta := &block.Meta{
+			ULID:    ulid.MustNew(uint64(i), nil),
+			MinTime: model.Time(i * 10),
+			MaxTime: model.Time(i*10 + 9),
+		}
+		b := &Block{BlockCloser: queriers[i], meta: meta, logger: s.logger}
+		s.blocks[meta.ULID] = b
+		s.blockSet.add(b)
+	}
+	return s, queriers
+}
+
+func queryBlocks(t *testing.T, s *BucketStore, minT, maxT model.Time) {
+	t.Helper()
+	require.NoError(t, s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		_, err := blockGetter(context.Background(), minT, maxT)
+		return err
+	}))
+}
+
+func Test_BucketStore_OpenBlocksOnDemand(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 0, 3)
+	for _, q := range queriers {
+		require.False(t, q.isOpen())
+	}
+
+	queryBlocks(t, s, 0, 15)
+	queryBlocks(t, s, 0, 15)
+	require.True(t, queriers[0].isOpen())
+	require.True(t, queriers[1].isOpen())
+	require.False(t, queriers[2].isOpen())
+	require.Equal(t, 1, queriers[0].opens)
+	require.Equal(t, float64(2), testutil.ToFloat64(s.metrics.blocksOpen))
+	require.Equal(t, float64(2), testutil.ToFloat64(s.metrics.blockOpens))
+
+	// Blocks in use are never closed.
+	blockGetterCalled := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+			_, err := blockGetter(context.Background(), 20, 25)
+			close(blockGetterCalled)
+			time.Sleep(10 * time.Millisecond)
+			return err
+		})
+	}()
+	<-blockGetterCalled
+	s.CloseIdleBlocks(0)
+	require.False(t, queriers[0].isOpen())
+	require.False(t, queriers[1].isOpen())
+	require.True(t, queriers[2].isOpen())
+	<-done
+
+	s.CloseIdleBlocks(time.Hour)
+	require.True(t, queriers[2].isOpen())
+	s.CloseIdleBlocks(0)
+	require.False(t, queriers[2].isOpen())
+	require.Equal(t, float64(0), testutil.ToFloat64(s.metrics.blocksOpen))
+	require.Equal(t, float64(3), testutil.ToFloat64(s.metrics.blockCloses.WithLabelValues(blockCloseReasonIdle)))
+}
+
+func Test_BucketStore_MaxOpenBlocks(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 2, 3)
+
+	queryBlocks(t, s, 0, 5)
+	queryBlocks(t, s, 10, 15)
+	// The least recently used block is closed.
+	queryBlocks(t, s, 20, 25)
+	require.False(t, queriers[0].isOpen())
+	require.True(t, queriers[1].isOpen())
+	require.True(t, queriers[2].isOpen())
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.blockCloses.WithLabelValues(blockCloseReasonEvicted)))
+
+	// Queries needing more blocks than the limit open them all, and
+	// the blocks above the limit are closed once released.
+	require.NoError(t, s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		queriers, err := blockGetter(context.Background(), 0, 25)
+		require.Len(t, queriers, 3)
+		require.Equal(t, float64(3), testutil.ToFloat64(s.metrics.blocksOpen))
+		return err
+	}))
+	require.Equal(t, float64(2), testutil.ToFloat64(s.metrics.blocksOpen))
+	s.CloseIdleBlocks(0)
+
+	// Queries wait for the blocks in use to be released.
+	release := make(chan struct{})
+	acquired := make(chan struct{})
+	go func() {
+		_ = s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+			_, err := blockGetter(context.Background(), 10, 25)
+			close(acquired)
+			<-release
+			return err
+		})
+	}()
+	<-acquired
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		_, err := blockGetter(ctx, 0, 5)
+		return err
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	queryBlocks(t, s, 0, 5)
+	require.True(t, queriers[0].isOpen())
+	require.Equal(t, float64(2), testutil.ToFloat64(s.metrics.blocksOpen))
+}
+
+func Test_BucketStore_RemoveBlockInUse(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 0, 1)
+	id := ulid.MustNew(0, nil)
+	require.NoError(t, s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		_, err := blockGetter(context.Background(), 0, 5)
+		require.NoError(t, err)
+		// The block is closed once released.
+		require.NoError(t, s.removeBlock(id))
+		require.True(t, queriers[0].isOpen())
+		return nil
+	}))
+	require.False(t, queriers[0].isOpen())
+	require.Nil(t, s.getBlock(id))
+	require.Equal(t, float64(0), testutil.ToFloat64(s.metrics.blocksOpen))
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.blockDrops))
+}
+
+func Test_BucketStore_OpenBlockFailure(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 0, 1)
+	queriers[0].openErr = errors.New("unavailable")
+	err := s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		_, err := blockGetter(context.Background(), 0, 5)
+		return err
+	})
+	require.ErrorContains(t, err, "unavailable")
+	require.Equal(t, float64(0), testutil.ToFloat64(s.metrics.blocksOpen))
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.blockOpenFailures))
+
+	queriers[0].openErr = nil
+	queryBlocks(t, s, 0, 5)
+	require.True(t, queriers[0].isOpen())
+	require.Equal(t, 2, queriers[0].opens)
+}
+
+func Test_BucketStore_ConcurrentOpenBlockFailure(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 1, 1)
+	queriers[0].openErr = errors.New("unavailable")
+	queriers[0].wait = make(chan struct{})
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+				_, err := blockGetter(context.Background(), 0, 5)
+				return err
+			})
+		}()
+	}
+	// Both queries reserve the block before the attempt fails.
+	require.Eventually(t, func() bool {
+		s.openMtx.Lock()
+		defer s.openMtx.Unlock()
+		return s.blocks[ulid.MustNew(0, nil)].refs == 2
+	}, 5*time.Second, time.Millisecond)
+	close(queriers[0].wait)
+	for i := 0; i < 2; i++ {
+		require.ErrorContains(t, <-errs, "unavailable")
+	}
+	require.Equal(t, 1, queriers[0].opens)
+	require.Equal(t, float64(0), testutil.ToFloat64(s.metrics.blocksOpen))
+
+	queriers[0].openErr = nil
+	queryBlocks(t, s, 0, 5)
+	require.True(t, queriers[0].isOpen())
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.blocksOpen))
+}
+
+func Test_BucketStore_SkipBlocksBySpans(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 0, 2)
+	queriers[1].noSpans = true
+	spans, err := phlaremodel.NewSpanSelector([]string{"badbadbadbadbadb"})
+	require.NoError(t, err)
+	ctx := phlaredb.ContextWithSpanSelector(context.Background(), spans)
+	require.NoError(t, s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		queriers, err := blockGetter(ctx, 0, 15)
+		require.Len(t, queriers, 1)
+		return err
+	}))
+	require.True(t, queriers[0].isOpen())
+	require.False(t, queriers[1].isOpen())
+	require.Equal(t, 0, queriers[1].opens)
+}
+
+func Test_BucketStore_SkipBlocksByFunctions(t *testing.T) {
+	s, queriers := newTestBucketStore(t, 0, 2)
+	queriers[0].noFunctions = true
+	ctx := phlaredb.ContextWithFunctionSelector(context.Background(), []string{"foo"})
+	require.NoError(t, s.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		queriers, err := blockGetter(ctx, 0, 15)
+		require.Len(t, queriers, 1)
+		return err
+	}))
+	require.False(t, queriers[0].isOpen())
+	require.Equal(t, 0, queriers[0].opens)
+	require.True(t, queriers[1].isOpen())
+}
@@ -16,6 +16,7 @@ import (
 
 	phlareobj "github.com/grafana/pyroscope/pkg/objstore"
 	"github.com/grafana/pyroscope/pkg/util"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 	"github.com/grafana/pyroscope/pkg/validation"
 )
 
@@ -35,6 +36,7 @@ var errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value
 type Limits interface {
 	ShardingLimits
 	phlareobj.TenantConfigProvider
+	limiter.QueryLimits
 }
 
 // ShardingLimits is the interface that should be implemented by the limits provider,
@@ -49,6 +51,7 @@ type StoreGateway struct {
 
 	gatewayCfg Config
 	stores     *BucketStores
+	limits     Limits
 
 	// Ring used for sharding blocks.
 	ringLifecycler *ring.BasicLifecycler
@@ -103,6 +106,7 @@ func newStoreGateway(gatewayCfg Config, storageBucket phlareobj.Bucket, ringStor
 	g := &StoreGateway{
 		gatewayCfg: gatewayCfg,
 		logger:     logger,
+		limits:     limits,
 		bucketSync: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "pyroscope_storegateway_bucket_sync_total",
 			Help: "Total number of times the bucket sync operation triggered.",
@@ -134,7 +138,7 @@ func newStoreGateway(gatewayCfg Config, storageBucket phlareobj.Bucket, ringStor
 		return nil, errors.Wrap(err, "create ring lifecycler")
 	}
 
-	ringCfg := gatewayCfg.ShardingRing.Ring.ToRingConfig()
+	ringCfg := gatewayCfg.ShardingRing.ToRingConfig()
 	g.ring, err = ring.NewWithStoreClientAndStrategy(ringCfg, RingNameForServer, RingKey, ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), prometheus.WrapRegistererWithPrefix("cortex_", reg), logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "create ring client")
@@ -240,6 +244,17 @@ func (g *StoreGateway) running(ctx context.Context) error {
 	ringTicker := time.NewTicker(util.DurationWithJitter(g.gatewayCfg.ShardingRing.RingCheckPeriod, 0.2))
 	defer ringTicker.Stop()
 
+	var idleTickerChan <-chan time.Time
+	if idleTimeout := g.gatewayCfg.BucketStoreConfig.BlockIdleTimeout; idleTimeout > 0 {
+		interval := idleTimeout / 10
+		if interval < time.Second {
+			interval = time.Second
+		}
+		idleTicker := time.NewTicker(interval)
+		defer idleTicker.Stop()
+		idleTickerChan = idleTicker.C
+	}
+
 	for {
 		select {
 		case <-syncTicker.C:
@@ -253,6 +268,8 @@ func (g *StoreGateway) running(ctx context.Context) error {
 				ringLastState = currRingState
 				g.syncStores(ctx, syncReasonRingChange)
 			}
+		case <-idleTickerChan:
+			g.stores.CloseIdleBlocks()
 		case <-ctx.Done():
 			return nil
 		case err := <-g.subservicesWatcher.Chan():
@@ -267,6 +284,7 @@ func (g *StoreGateway) stopping(_ error) error {
 			level.Warn(g.logger).Log("msg", "failed to stop store-gateway subservices", "err", err)
 		}
 	}
+	g.stores.Stop()
 
 	return nil
 }
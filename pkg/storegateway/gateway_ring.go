@@ -112,3 +112,10 @@ func (cfg *RingConfig) ToLifecyclerConfig(logger log.Logger) (ring.BasicLifecycl
 		KeepInstanceInTheRingOnShutdown: !cfg.UnregisterOnShutdown,
 	}, nil
 }
+
+func (cfg *RingConfig) ToRingConfig() ring.Config {
+	rc := cfg.Ring.ToRingConfig()
+	rc.ReplicationFactor = cfg.ReplicationFactor
+	rc.ZoneAwarenessEnabled = cfg.ZoneAwarenessEnabled
+	return rc
+}
@@ -11,8 +11,19 @@ type Metrics struct {
 	blockLoadFailures prometheus.Counter
 	blockDrops        prometheus.Counter
 	blockDropFailures prometheus.Counter
+
+	blocksOpen        prometheus.Gauge
+	blockOpens        prometheus.Counter
+	blockOpenFailures prometheus.Counter
+	blockOpenDuration prometheus.Histogram
+	blockCloses       *prometheus.CounterVec
 }
 
+const (
+	blockCloseReasonIdle    = "idle"
+	blockCloseReasonEvicted = "evicted"
+)
+
 func NewMetrics(reg prometheus.Registerer) *Metrics {
 	var m Metrics
 	m.Synced = prometheus.NewGaugeVec(
@@ -39,6 +50,28 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		Name: "pyroscope_bucket_store_block_drop_failures_total",
 		Help: "Total number of local blocks that failed to be dropped.",
 	})
-	reg.MustRegister(m.Synced, m.blockDropFailures, m.blockDrops, m.blockLoadFailures, m.blockLoads)
+	m.blocksOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "pyroscope_bucket_store_blocks_open",
+		Help: "Number of blocks currently open for querying.",
+	})
+	m.blockOpens = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pyroscope_bucket_store_block_opens_total",
+		Help: "Total number of blocks opened on demand.",
+	})
+	m.blockOpenFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pyroscope_bucket_store_block_open_failures_total",
+		Help: "Total number of blocks that failed to be opened on demand.",
+	})
+	m.blockOpenDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pyroscope_bucket_store_block_open_duration_seconds",
+		Help:    "Time it takes to open a block on demand.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	})
+	m.blockCloses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pyroscope_bucket_store_block_closes_total",
+		Help: "Total number of open blocks closed to release resources.",
+	}, []string{"reason"})
+	reg.MustRegister(m.Synced, m.blockDropFailures, m.blockDrops, m.blockLoadFailures, m.blockLoads,
+		m.blocksOpen, m.blockOpens, m.blockOpenFailures, m.blockOpenDuration, m.blockCloses)
 	return &m
 }
@@ -3,6 +3,7 @@ package storegateway
 import (
 	"context"
 	"io"
+	"sync"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/pkg/errors"
@@ -11,9 +12,11 @@ import (
 	ingestv1 "github.com/grafana/pyroscope/api/gen/proto/go/ingester/v1"
 	"github.com/grafana/pyroscope/pkg/phlaredb"
 	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/util/limiter"
 )
 
 func (s *StoreGateway) MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesStacktracesRequest, ingestv1.MergeProfilesStacktracesResponse]) error {
+	ctx = limiter.WithTenantQueryLimiter(ctx, s.limits)
 	found, err := s.forBucketStore(ctx, func(bs *BucketStore) error {
 		return bs.MergeProfilesStacktraces(ctx, stream)
 	})
@@ -24,6 +27,7 @@ func (s *StoreGateway) MergeProfilesStacktraces(ctx context.Context, stream *con
 }
 
 func (s *StoreGateway) MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesLabelsRequest, ingestv1.MergeProfilesLabelsResponse]) error {
+	ctx = limiter.WithTenantQueryLimiter(ctx, s.limits)
 	found, err := s.forBucketStore(ctx, func(bs *BucketStore) error {
 		return bs.MergeProfilesLabels(ctx, stream)
 	})
@@ -34,6 +38,7 @@ func (s *StoreGateway) MergeProfilesLabels(ctx context.Context, stream *connect.
 }
 
 func (s *StoreGateway) MergeProfilesPprof(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesPprofRequest, ingestv1.MergeProfilesPprofResponse]) error {
+	ctx = limiter.WithTenantQueryLimiter(ctx, s.limits)
 	found, err := s.forBucketStore(ctx, func(bs *BucketStore) error {
 		return bs.MergeProfilesPprof(ctx, stream)
 	})
@@ -47,7 +52,7 @@ func (s *StoreGateway) Series(ctx context.Context, req *connect.Request[ingestv1
 	var res *ingestv1.SeriesResponse
 	_, err := s.forBucketStore(ctx, func(bs *BucketStore) error {
 		var err error
-		res, err = phlaredb.Series(ctx, req.Msg, bs.openBlocksForReading)
+		res, err = bs.Series(ctx, req.Msg)
 		if err != nil {
 			return err
 		}
@@ -61,6 +66,7 @@ func (s *StoreGateway) Series(ctx context.Context, req *connect.Request[ingestv1
 }
 
 func (s *StoreGateway) MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeSpanProfileRequest, ingestv1.MergeSpanProfileResponse]) error {
+	ctx = limiter.WithTenantQueryLimiter(ctx, s.limits)
 	found, err := s.forBucketStore(ctx, func(bs *BucketStore) error {
 		return bs.MergeSpanProfile(ctx, stream)
 	})
@@ -96,30 +102,98 @@ func (s *StoreGateway) forBucketStore(ctx context.Context, f func(*BucketStore)
 	return false, nil
 }
 
-func (s *BucketStore) openBlocksForReading(ctx context.Context, minT, maxT model.Time) (phlaredb.Queriers, error) {
-	blks := s.blockSet.getFor(minT, maxT)
-	querier := make(phlaredb.Queriers, 0, len(blks))
+// withBlocks calls f with a BlockGetter that opens the blocks queried.
+// The blocks can't be closed until f returns.
+func (s *BucketStore) withBlocks(f func(phlaredb.BlockGetter) error) error {
+	var (
+		mtx      sync.Mutex
+		acquired []*Block
+	)
+	defer func() {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if len(acquired) > 0 {
+			s.releaseBlocks(acquired)
+		}
+	}()
+	return f(func(ctx context.Context, minT, maxT model.Time) (phlaredb.Queriers, error) {
+		blks := s.blockSet.getFor(minT, maxT)
+		if spans, ok := phlaredb.SpanSelectorFromContext(ctx); ok {
+			// The blocks that can't have the spans are not opened.
+			var err error
+			if blks, err = filterBlocks(blks, func(b *Block) (bool, error) {
+				return b.MayContainSpans(ctx, spans)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if names, ok := phlaredb.FunctionSelectorFromContext(ctx); ok {
+			// The blocks that can't have the functions are not opened.
+			var err error
+			if blks, err = filterBlocks(blks, func(b *Block) (bool, error) {
+				return b.MayContainFunctions(ctx, names)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.acquireBlocks(ctx, blks); err != nil {
+			return nil, err
+		}
+		mtx.Lock()
+		acquired = append(acquired, blks...)
+		mtx.Unlock()
+		querier := make(phlaredb.Queriers, 0, len(blks))
+		for _, b := range blks {
+			querier = append(querier, b)
+		}
+		return querier, nil
+	})
+}
+
+// filterBlocks drops the blocks that can't match, according to their
+// bloom filters.
+func filterBlocks(blks []*Block, mayContain func(*Block) (bool, error)) ([]*Block, error) {
+	result := make([]*Block, 0, len(blks))
 	for _, b := range blks {
-		querier = append(querier, b)
-	}
-	if err := querier.Open(ctx); err != nil {
-		return nil, err
+		match, err := mayContain(b)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			result = append(result, b)
+		}
 	}
-	return querier, nil
+	return result, nil
+}
+
+func (store *BucketStore) Series(ctx context.Context, req *ingestv1.SeriesRequest) (res *ingestv1.SeriesResponse, err error) {
+	err = store.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		res, err = phlaredb.Series(ctx, req, blockGetter)
+		return err
+	})
+	return res, err
 }
 
 func (store *BucketStore) MergeProfilesStacktraces(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesStacktracesRequest, ingestv1.MergeProfilesStacktracesResponse]) error {
-	return phlaredb.MergeProfilesStacktraces(ctx, stream, store.openBlocksForReading)
+	return store.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		return phlaredb.MergeProfilesStacktraces(ctx, stream, blockGetter)
+	})
 }
 
 func (store *BucketStore) MergeProfilesLabels(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesLabelsRequest, ingestv1.MergeProfilesLabelsResponse]) error {
-	return phlaredb.MergeProfilesLabels(ctx, stream, store.openBlocksForReading)
+	return store.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		return phlaredb.MergeProfilesLabels(ctx, stream, blockGetter)
+	})
 }
 
 func (store *BucketStore) MergeProfilesPprof(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeProfilesPprofRequest, ingestv1.MergeProfilesPprofResponse]) error {
-	return phlaredb.MergeProfilesPprof(ctx, stream, store.openBlocksForReading)
+	return store.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		return phlaredb.MergeProfilesPprof(ctx, stream, blockGetter)
+	})
 }
 
 func (store *BucketStore) MergeSpanProfile(ctx context.Context, stream *connect.BidiStream[ingestv1.MergeSpanProfileRequest, ingestv1.MergeSpanProfileResponse]) error {
-	return phlaredb.MergeSpanProfile(ctx, stream, store.openBlocksForReading)
+	return store.withBlocks(func(blockGetter phlaredb.BlockGetter) error {
+		return phlaredb.MergeSpanProfile(ctx, stream, blockGetter)
+	})
 }
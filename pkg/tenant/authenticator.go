@@ -0,0 +1,214 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grafana/dskit/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("the credentials do not allow this operation")
+)
+
+// Scope is the set of operations allowed with a credential.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	// scopeInternal is the scope of the requests between the components
+	// of the process, which are allowed for any tenant.
+	scopeInternal Scope = "internal"
+)
+
+type AuthenticatorConfig struct {
+	CredentialsFile string `yaml:"credentials_file" category:"experimental"`
+}
+
+func (cfg *AuthenticatorConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.CredentialsFile, "auth.credentials-file", "", "Path of the YAML file with the credentials of the built-in authenticator, for the single binary only. Each credential, either a bearer token or a basic auth username and password, grants the read or write scope on a tenant: the X-Scope-OrgId header of the requests is ignored. The authenticator is disabled if empty.")
+}
+
+// Credential grants a scope on the data of a tenant, to the requests with
+// either its bearer token, or its basic auth username and password.
+//
+// For instance, with a write-only token for the agents, and a read-only
+// username and password for Grafana:
+//
+//	credentials:
+//	  - tenant: team-a
+//	    scopes: [write]
+//	    token: <token>
+//	  - tenant: team-a
+//	    scopes: [read]
+//	    username: grafana
+//	    password: <password>
+type Credential struct {
+	Tenant   string  `yaml:"tenant"`
+	Scopes   []Scope `yaml:"scopes"`
+	Token    string  `yaml:"token"`
+	Username string  `yaml:"username"`
+	Password string  `yaml:"password"`
+}
+
+type credentialsFile struct {
+	Credentials []Credential `yaml:"credentials"`
+}
+
+// Authenticator authenticates the requests with the credentials of a file.
+//
+// It also issues an internal token, for the clients between the components of
+// the process: requests with the token are allowed for the tenant of the
+// X-Scope-OrgId header.
+type Authenticator struct {
+	credentials   []Credential
+	internalToken string
+}
+
+// NewAuthenticator returns the authenticator of the configuration, or nil if
+// it is disabled.
+func NewAuthenticator(cfg AuthenticatorConfig) (*Authenticator, error) {
+	if cfg.CredentialsFile == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading the credentials file: %w", err)
+	}
+	var file credentialsFile
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parsing the credentials file %s: %w", cfg.CredentialsFile, err)
+	}
+	for i, c := range file.Credentials {
+		if err = c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid credential %d in %s: %w", i, cfg.CredentialsFile, err)
+		}
+	}
+	token := make([]byte, 32)
+	if _, err = rand.Read(token); err != nil {
+		return nil, err
+	}
+	return &Authenticator{
+		credentials:   file.Credentials,
+		internalToken: hex.EncodeToString(token),
+	}, nil
+}
+
+func (c *Credential) validate() error {
+	if err := ValidTenantID(c.Tenant); err != nil {
+		return err
+	}
+	if len(c.Scopes) == 0 {
+		return errors.New("no scopes")
+	}
+	for _, s := range c.Scopes {
+		if s != ScopeRead && s != ScopeWrite {
+			return fmt.Errorf("unknown scope %q, it must be %q or %q", s, ScopeRead, ScopeWrite)
+		}
+	}
+	switch {
+	case c.Token != "" && c.Username == "" && c.Password == "":
+	case c.Token == "" && c.Username != "" && c.Password != "":
+	default:
+		return errors.New("either a token, or a username and a password must be set")
+	}
+	return nil
+}
+
+func (c *Credential) allows(s Scope) bool {
+	for _, scope := range c.Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// InternalToken returns the bearer token of the requests between the
+// components of the process.
+func (a *Authenticator) InternalToken() string {
+	return a.internalToken
+}
+
+// UnaryClientInterceptor returns a gRPC interceptor which injects the internal token.
+func (a *Authenticator) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.internalToken)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Authenticate returns the tenant of the credentials of the request, if they
+// grant the scope, or else ErrUnauthenticated or ErrForbidden. Requests with
+// the internal token are allowed with an empty tenant: it is then set by the
+// X-Scope-OrgId header, as without authenticator.
+func (a *Authenticator) Authenticate(h http.Header, s Scope) (string, error) {
+	c, internal := a.credential(h)
+	switch {
+	case internal:
+		return "", nil
+	case c == nil:
+		return "", ErrUnauthenticated
+	case s == scopeInternal || !c.allows(s):
+		return "", ErrForbidden
+	}
+	return c.Tenant, nil
+}
+
+func (a *Authenticator) credential(h http.Header) (c *Credential, internal bool) {
+	r := http.Request{Header: h}
+	if username, password, ok := r.BasicAuth(); ok {
+		for i := range a.credentials {
+			if c := &a.credentials[i]; c.Username != "" && equal(c.Username, username) && equal(c.Password, password) {
+				return c, false
+			}
+		}
+		return nil, false
+	}
+	authorization := h.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, false
+	}
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == "" {
+		return nil, false
+	}
+	if equal(token, a.internalToken) {
+		return nil, true
+	}
+	for i := range a.credentials {
+		if c := &a.credentials[i]; c.Token != "" && equal(c.Token, token) {
+			return c, false
+		}
+	}
+	return nil, false
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// ValidTenantID returns an error if the tenant ID is not valid.
+func ValidTenantID(tenantID string) error {
+	switch tenantID {
+	case "", ".", "..":
+		return fmt.Errorf("invalid tenant ID %q", tenantID)
+	}
+	return tenant.ValidTenantID(tenantID)
+}
@@ -0,0 +1,168 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/require"
+)
+
+const testCredentials = `
+credentials:
+  - tenant: team-a
+    scopes: [write]
+    token: agent-token
+  - tenant: team-a
+    scopes: [read]
+    username: grafana
+    password: secret
+  - tenant: team-b
+    scopes: [read, write]
+    token: admin-token
+`
+
+func newTestAuthenticator(t *testing.T, credentials string) (*Authenticator, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(credentials), 0o600))
+	return NewAuthenticator(AuthenticatorConfig{CredentialsFile: path})
+}
+
+func Test_Authenticator(t *testing.T) {
+	a, err := newTestAuthenticator(t, testCredentials)
+	require.NoError(t, err)
+
+	bearer := func(token string) http.Header {
+		return http.Header{"Authorization": []string{"Bearer " + token}}
+	}
+	basic := func(username, password string) http.Header {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.SetBasicAuth(username, password)
+		return r.Header
+	}
+
+	for _, tc := range []struct {
+		name     string
+		header   http.Header
+		scope    Scope
+		expected string
+		err      error
+	}{
+		{name: "write token", header: bearer("agent-token"), scope: ScopeWrite, expected: "team-a"},
+		{name: "write token reads", header: bearer("agent-token"), scope: ScopeRead, err: ErrForbidden},
+		{name: "read basic auth", header: basic("grafana", "secret"), scope: ScopeRead, expected: "team-a"},
+		{name: "read basic auth writes", header: basic("grafana", "secret"), scope: ScopeWrite, err: ErrForbidden},
+		{name: "read-write token", header: bearer("admin-token"), scope: ScopeRead, expected: "team-b"},
+		{name: "credentials of internal APIs", header: bearer("admin-token"), scope: scopeInternal, err: ErrForbidden},
+		{name: "internal token", header: bearer(a.InternalToken()), scope: scopeInternal},
+		{name: "wrong password", header: basic("grafana", "agent-token"), scope: ScopeRead, err: ErrUnauthenticated},
+		{name: "unknown token", header: bearer("secret"), scope: ScopeRead, err: ErrUnauthenticated},
+		{name: "no credentials", header: http.Header{"X-Scope-Orgid": []string{"team-a"}}, scope: ScopeRead, err: ErrUnauthenticated},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tenantID, err := a.Authenticate(tc.header, tc.scope)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.expected, tenantID)
+		})
+	}
+}
+
+func Test_NewAuthenticator(t *testing.T) {
+	a, err := NewAuthenticator(AuthenticatorConfig{})
+	require.NoError(t, err)
+	require.Nil(t, a)
+
+	for name, credentials := range map[string]string{
+		"unknown field":     "credentials:\n  - tenant: a\n    scopes: [read]\n    token: t\n    role: admin\n",
+		"invalid tenant":    "credentials:\n  - tenant: ..\n    scopes: [read]\n    token: t\n",
+		"no scopes":         "credentials:\n  - tenant: a\n    token: t\n",
+		"unknown scope":     "credentials:\n  - tenant: a\n    scopes: [admin]\n    token: t\n",
+		"token and user":    "credentials:\n  - tenant: a\n    scopes: [read]\n    token: t\n    username: u\n    password: p\n",
+		"no password":       "credentials:\n  - tenant: a\n    scopes: [read]\n    username: u\n",
+		"no authentication": "credentials:\n  - tenant: a\n    scopes: [read]\n",
+	} {
+		credentials := credentials
+		t.Run(name, func(t *testing.T) {
+			_, err := newTestAuthenticator(t, credentials)
+			require.Error(t, err)
+		})
+	}
+}
+
+func Test_AuthInterceptorWithAuthenticator(t *testing.T) {
+	for testName, testCase := range map[string]func(t *testing.T){
+		"server: authenticator, tenant of the credentials": func(t *testing.T) {
+			a, err := newTestAuthenticator(t, testCredentials)
+			require.NoError(t, err)
+			i := NewAuthInterceptorWithAuthenticator(true, a)
+			req := newFakeProcedureReq(false)
+			req.procedure = "/push.v1.PusherService/Push"
+			req.Header().Set("X-Scope-OrgID", "foo")
+			req.Header().Set("Authorization", "Bearer agent-token")
+			_, err = i.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				tenantID, err := ExtractTenantIDFromContext(ctx)
+				require.NoError(t, err)
+				require.Equal(t, "team-a", tenantID)
+				return nil, nil
+			})(context.Background(), req)
+			require.NoError(t, err)
+
+			req.procedure = "/querier.v1.QuerierService/LabelNames"
+			_, err = i.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				t.Fatal("unexpected call")
+				return nil, nil
+			})(context.Background(), req)
+			require.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+		},
+		"server: authenticator, internal token": func(t *testing.T) {
+			a, err := newTestAuthenticator(t, testCredentials)
+			require.NoError(t, err)
+			i := NewAuthInterceptorWithAuthenticator(false, a)
+			req := newFakeProcedureReq(true)
+			_, err = i.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				serverReq := newFakeProcedureReq(false)
+				serverReq.procedure = "/ingester.v1.IngesterService/LabelNames"
+				serverReq.headers = ar.Header()
+				return i.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+					tenantID, err := ExtractTenantIDFromContext(ctx)
+					require.NoError(t, err)
+					require.Equal(t, "foo", tenantID)
+					return nil, nil
+				})(context.Background(), serverReq)
+			})(InjectTenantID(context.Background(), "foo"), req)
+			require.NoError(t, err)
+
+			req = newFakeProcedureReq(false)
+			req.procedure = "/ingester.v1.IngesterService/LabelNames"
+			req.Header().Set("X-Scope-OrgID", "foo")
+			_, err = i.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				t.Fatal("unexpected call")
+				return nil, nil
+			})(context.Background(), req)
+			require.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+		},
+	} {
+		t.Run(testName, testCase)
+	}
+}
+
+// fakeProcedureReq is a fakeReq of a procedure.
+type fakeProcedureReq struct {
+	fakeReq
+	procedure string
+}
+
+func newFakeProcedureReq(isClient bool) *fakeProcedureReq {
+	return &fakeProcedureReq{fakeReq: newFakeReq(isClient)}
+}
+
+func (f *fakeProcedureReq) Spec() connect.Spec {
+	spec := f.fakeReq.Spec()
+	spec.Procedure = f.procedure
+	return spec
+}
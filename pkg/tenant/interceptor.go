@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/user"
+
+	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
 )
 
 // DefaultTenantID is the default tenant ID used when the interceptor is disabled.
@@ -24,30 +28,33 @@ const DefaultTenantID = "anonymous"
 //
 // The interceptor will inject the tenant ID from the context into the request header no matter if the interceptor is enabled or not.
 func NewAuthInterceptor(enabled bool) connect.Interceptor {
-	return &authInterceptor{enabled: enabled}
+	return NewAuthInterceptorWithAuthenticator(enabled, nil)
+}
+
+// NewAuthInterceptorWithAuthenticator is like NewAuthInterceptor, but if an authenticator is given, the tenant ID is
+// the one of the credentials of the request instead: the push API requires the write scope, the query API the read
+// scope, and the other APIs the internal token of the authenticator, with which the tenant ID in the request header
+// is used. The client injects the internal token too.
+func NewAuthInterceptorWithAuthenticator(enabled bool, authenticator *Authenticator) connect.Interceptor {
+	return &authInterceptor{enabled: enabled, authenticator: authenticator}
 }
 
 type authInterceptor struct {
-	enabled bool
+	enabled       bool
+	authenticator *Authenticator
 }
 
 func (i *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
 	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 		// client side we extract the tenantID from the context and inject it into the request header
 		if req.Spec().IsClient {
-			tenantID, _ := ExtractTenantIDFromContext(ctx)
-			if tenantID != "" {
-				req.Header().Set("X-Scope-OrgID", tenantID)
-			}
+			i.injectHeaders(ctx, req.Header())
 			return next(ctx, req)
 		}
-		// Server side if the interceptor is enabled, we extract the tenantID from the request header and inject it into the context
-		// If the interceptor is disabled, we inject the default tenant ID into the context.
-		if !i.enabled {
-			return next(InjectTenantID(ctx, DefaultTenantID), req)
+		ctx, err := i.authenticate(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
 		}
-		_, ctx, _ = ExtractTenantIDFromHeaders(ctx, req.Header())
-
 		resp, err := next(ctx, req)
 		if err != nil && errors.Is(err, ErrNoTenantID) {
 			return resp, connect.NewError(connect.CodeUnauthenticated, err)
@@ -56,24 +63,68 @@ func (i *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
 	}
 }
 
+func (i *authInterceptor) injectHeaders(ctx context.Context, h http.Header) {
+	tenantID, _ := ExtractTenantIDFromContext(ctx)
+	if tenantID != "" {
+		h.Set("X-Scope-OrgID", tenantID)
+	}
+	if i.authenticator != nil {
+		h.Set("Authorization", "Bearer "+i.authenticator.InternalToken())
+	}
+}
+
+// authenticate injects the tenant ID of the request into the context.
+func (i *authInterceptor) authenticate(ctx context.Context, procedure string, h http.Header) (context.Context, error) {
+	if i.authenticator != nil {
+		tenantID, err := i.authenticator.Authenticate(h, procedureScope(procedure))
+		switch {
+		case errors.Is(err, ErrUnauthenticated):
+			return ctx, connect.NewError(connect.CodeUnauthenticated, err)
+		case err != nil:
+			return ctx, connect.NewError(connect.CodePermissionDenied, err)
+		case tenantID != "":
+			return InjectTenantID(ctx, tenantID), nil
+		}
+		// The internal token: the tenant ID is the one of the client, if any.
+		if _, tenantCtx, err := ExtractTenantIDFromHeaders(ctx, h); err == nil {
+			return tenantCtx, nil
+		}
+	}
+	// If the interceptor is enabled, we extract the tenantID from the request header and inject it into the context
+	// If the interceptor is disabled, we inject the default tenant ID into the context.
+	if !i.enabled {
+		return InjectTenantID(ctx, DefaultTenantID), nil
+	}
+	_, ctx, _ = ExtractTenantIDFromHeaders(ctx, h)
+	return ctx, nil
+}
+
+// procedureScope returns the scope the credentials must grant to call the procedure.
+func procedureScope(procedure string) Scope {
+	switch {
+	case strings.HasPrefix(procedure, "/"+pushv1connect.PusherServiceName+"/"):
+		return ScopeWrite
+	case strings.HasPrefix(procedure, "/"+querierv1connect.QuerierServiceName+"/"):
+		return ScopeRead
+	}
+	return scopeInternal
+}
+
 func (i *authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
 	return func(ctx context.Context, s connect.Spec) connect.StreamingClientConn {
 		conn := next(ctx, s)
-		tenantID, _ := ExtractTenantIDFromContext(ctx)
-		if tenantID != "" {
-			conn.RequestHeader().Set("X-Scope-OrgID", tenantID)
-		}
+		i.injectHeaders(ctx, conn.RequestHeader())
 		return conn
 	}
 }
 
 func (i *authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
-		if !i.enabled {
-			return next(InjectTenantID(ctx, DefaultTenantID), conn)
+		ctx, err := i.authenticate(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
 		}
-		_, ctx, _ = ExtractTenantIDFromHeaders(ctx, conn.RequestHeader())
-		if err := next(ctx, conn); err != nil {
+		if err = next(ctx, conn); err != nil {
 			if errors.Is(err, ErrNoTenantID) {
 				return connect.NewError(connect.CodeUnauthenticated, err)
 			}
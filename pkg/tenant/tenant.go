@@ -3,6 +3,7 @@ package tenant
 import (
 	"context"
 
+	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/user"
 )
 
@@ -11,3 +12,11 @@ var ErrNoTenantID = user.ErrNoOrgID
 func InjectTenantID(ctx context.Context, tenantID string) context.Context {
 	return user.InjectOrgID(ctx, tenantID)
 }
+
+var federatedResolver = tenant.NewMultiResolver()
+
+// FederatedTenantIDs returns the tenants of the context, the tenants of a federated
+// query are separated by '|'. The other resolvers handle them as a single tenant.
+func FederatedTenantIDs(ctx context.Context) ([]string, error) {
+	return federatedResolver.TenantIDs(ctx)
+}
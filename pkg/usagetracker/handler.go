@@ -0,0 +1,173 @@
+package usagetracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/pyroscope/pkg/tenant"
+	"github.com/grafana/pyroscope/pkg/util"
+	httputil "github.com/grafana/pyroscope/pkg/util/http"
+)
+
+// defaultUsagePeriod is the period of the usage served when the start is not set.
+const defaultUsagePeriod = 24 * time.Hour
+
+// UsageResponse is the response of the usage endpoint.
+type UsageResponse struct {
+	Usage []Usage `json:"usage"`
+}
+
+// Handler serves the usage of the tenant of the request, aggregated from the reports
+// written to the bucket by all the instances: the ingestion is summed over the reports
+// which end between the start and end query parameters, the storage is the one of the
+// latest of these reports. The start defaults to 24 hours before the end, the end to now.
+// The usage accounted by the instances since their last report is not included.
+func Handler(bucket objstore.BucketReader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := tenant.ExtractTenantIDFromContext(r.Context())
+		if err != nil {
+			httputil.ErrorWithStatus(w, err, http.StatusBadRequest)
+			return
+		}
+		start, end, err := parsePeriod(r, time.Now())
+		if err != nil {
+			httputil.Error(w, err)
+			return
+		}
+		reports, err := ReadReports(r.Context(), bucket, start, end)
+		if err != nil {
+			httputil.Error(w, err)
+			return
+		}
+		util.WriteJSONResponse(w, UsageResponse{Usage: Aggregate(reports, tenantID)})
+	})
+}
+
+func parsePeriod(r *http.Request, now time.Time) (start, end time.Time, err error) {
+	end = now
+	if s := r.URL.Query().Get("end"); s != "" {
+		ms, err := util.ParseTime(s)
+		if err != nil {
+			return start, end, err
+		}
+		end = time.UnixMilli(ms)
+	}
+	start = end.Add(-defaultUsagePeriod)
+	if s := r.URL.Query().Get("start"); s != "" {
+		ms, err := util.ParseTime(s)
+		if err != nil {
+			return start, end, err
+		}
+		start = time.UnixMilli(ms)
+	}
+	if !start.Before(end) {
+		return start, end, httpgrpc.Errorf(http.StatusBadRequest, "the start must be before the end")
+	}
+	return start, end, nil
+}
+
+// ReadReports reads the reports of all the instances which end in (start, end]. Only the
+// reports of the days of the period are listed.
+func ReadReports(ctx context.Context, bucket objstore.BucketReader, start, end time.Time) ([]Report, error) {
+	var days []string
+	err := bucket.Iter(ctx, ReportsPrefix+"/", func(dir string) error {
+		day, err := time.Parse(dayLayout, path.Base(dir))
+		if err != nil {
+			return nil
+		}
+		if day.Add(24*time.Hour).After(start) && !day.After(end) {
+			days = append(days, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, dir := range days {
+		err = bucket.Iter(ctx, dir, func(name string) error {
+			// The reports are named after their end.
+			ts, err := strconv.ParseInt(strings.TrimSuffix(path.Base(name), ".json"), 10, 64)
+			if err != nil || !strings.HasSuffix(name, ".json") {
+				return nil
+			}
+			if t := time.Unix(ts, 0); t.After(start) && !t.After(end) {
+				names = append(names, name)
+			}
+			return nil
+		}, objstore.WithRecursiveIter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	reports := make([]Report, 0, len(names))
+	for _, name := range names {
+		rc, err := bucket.Get(ctx, name)
+		if bucket.IsObjNotFoundErr(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var report Report
+		err = json.NewDecoder(rc).Decode(&report)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Aggregate returns the usage of the tenant over the reports, of all the instances,
+// sorted by service.
+func Aggregate(reports []Report, tenantID string) []Usage {
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].End.Before(reports[j].End)
+	})
+	// The storage entries are aggregated apart from the ingestion of the service without name.
+	type aggregateKey struct {
+		serviceName string
+		storage     bool
+	}
+	usage := make(map[aggregateKey]*Usage)
+	for _, report := range reports {
+		for _, u := range report.Usage {
+			if u.TenantID != tenantID {
+				continue
+			}
+			k := aggregateKey{serviceName: u.ServiceName, storage: u.BytesStored != 0}
+			a, ok := usage[k]
+			if !ok {
+				a = &Usage{TenantID: u.TenantID, ServiceName: u.ServiceName}
+				usage[k] = a
+			}
+			a.BytesIngested += u.BytesIngested
+			a.ProfilesReceived += u.ProfilesReceived
+			if u.BytesStored != 0 {
+				a.BytesStored = u.BytesStored
+			}
+		}
+	}
+	result := make([]Usage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ServiceName != result[j].ServiceName {
+			return result[i].ServiceName < result[j].ServiceName
+		}
+		return result[i].BytesStored > result[j].BytesStored
+	})
+	return result
+}
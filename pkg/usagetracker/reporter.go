@@ -0,0 +1,167 @@
+package usagetracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/pyroscope/pkg/phlaredb/bucket"
+)
+
+// ReportsPrefix is the bucket prefix under which the usage reports are written.
+var ReportsPrefix = path.Join(bucket.PyroscopeInternalsPrefix, "usage")
+
+// dayLayout is the layout of the directories of the reports of a day, in UTC.
+const dayLayout = "2006-01-02"
+
+type Config struct {
+	ReportInterval  time.Duration `yaml:"report_interval" category:"experimental"`
+	ReportRetention time.Duration `yaml:"report_retention" category:"experimental"`
+	InstanceID      string        `yaml:"instance_id" category:"experimental"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.ReportInterval, "usage.report-interval", time.Hour, "Interval at which the usage tracked by the instance is written as a JSON report to the object storage. 0 to disable.")
+	f.DurationVar(&cfg.ReportRetention, "usage.report-retention", 90*24*time.Hour, "Period after which the usage reports of all the instances are deleted from the object storage, by whole days. 0 to keep them forever.")
+	f.StringVar(&cfg.InstanceID, "usage.instance-id", "", "Instance ID the usage reports are written under. Defaults to the hostname.")
+}
+
+// reportName returns the name of the report of the instance which ends at the time.
+func reportName(instanceID string, end time.Time) string {
+	return path.Join(ReportsPrefix, end.UTC().Format(dayLayout), instanceID, fmt.Sprintf("%d.json", end.Unix()))
+}
+
+// Report is the usage of the tenants over the report period: the ingestion is accounted
+// during the period, the storage is the one at the end of the period.
+type Report struct {
+	InstanceID string    `json:"instance_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Usage      []Usage   `json:"usage"`
+}
+
+// Reporter periodically writes the usage tracked by the instance to the object storage,
+// under <ReportsPrefix>/<report end day>/<instance ID>/<report end>.json, and deletes
+// the reports past the retention.
+type Reporter struct {
+	services.Service
+
+	cfg     Config
+	bucket  objstore.Bucket
+	tracker *Tracker
+	logger  log.Logger
+
+	start    time.Time
+	previous map[key]Usage
+}
+
+func NewReporter(cfg Config, bucket objstore.Bucket, tracker *Tracker, logger log.Logger) *Reporter {
+	if cfg.InstanceID == "" {
+		cfg.InstanceID, _ = os.Hostname()
+	}
+	r := &Reporter{
+		cfg:      cfg,
+		bucket:   bucket,
+		tracker:  tracker,
+		logger:   logger,
+		start:    time.Now(),
+		previous: make(map[key]Usage),
+	}
+	r.Service = services.NewTimerService(cfg.ReportInterval, nil, r.iteration, r.stopping)
+	return r
+}
+
+func (r *Reporter) iteration(ctx context.Context) error {
+	now := time.Now()
+	if err := r.report(ctx, now); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to write usage report", "err", err)
+	}
+	if err := r.prune(ctx, now); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to delete expired usage reports", "err", err)
+	}
+	return nil
+}
+
+func (r *Reporter) stopping(_ error) error {
+	// Write the usage accounted since the last report, so that it's not lost on shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.report(ctx, time.Now()); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to write usage report", "err", err)
+	}
+	return nil
+}
+
+func (r *Reporter) report(ctx context.Context, now time.Time) error {
+	report := Report{
+		InstanceID: r.cfg.InstanceID,
+		Start:      r.start,
+		End:        now,
+	}
+	ingested, stored := r.tracker.snapshot("")
+	report.Usage = stored
+	for _, u := range ingested {
+		k := key{tenantID: u.TenantID, serviceName: u.ServiceName}
+		delta := u
+		delta.BytesIngested -= r.previous[k].BytesIngested
+		delta.ProfilesReceived -= r.previous[k].ProfilesReceived
+		if delta.BytesIngested == 0 && delta.ProfilesReceived == 0 {
+			continue
+		}
+		report.Usage = append(report.Usage, delta)
+	}
+	if len(report.Usage) == 0 {
+		return nil
+	}
+	sortUsage(report.Usage)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	name := reportName(r.cfg.InstanceID, now)
+	if err = r.bucket.Upload(ctx, name, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	level.Debug(r.logger).Log("msg", "usage report written", "path", name, "entries", len(report.Usage))
+	r.start = now
+	for _, u := range ingested {
+		r.previous[key{tenantID: u.TenantID, serviceName: u.ServiceName}] = u
+	}
+	return nil
+}
+
+// prune deletes the reports of all the instances of the days which ended before the retention.
+func (r *Reporter) prune(ctx context.Context, now time.Time) error {
+	if r.cfg.ReportRetention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-r.cfg.ReportRetention)
+	return r.bucket.Iter(ctx, ReportsPrefix+"/", func(dir string) error {
+		day, err := time.Parse(dayLayout, path.Base(dir))
+		if err != nil || !day.Add(24*time.Hour).Before(cutoff) {
+			return nil
+		}
+		err = r.bucket.Iter(ctx, dir, func(name string) error {
+			if err := r.bucket.Delete(ctx, name); err != nil && !r.bucket.IsObjNotFoundErr(err) {
+				return err
+			}
+			return nil
+		}, objstore.WithRecursiveIter)
+		if err != nil {
+			return err
+		}
+		level.Debug(r.logger).Log("msg", "expired usage reports deleted", "day", path.Base(dir))
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+// Package usagetracker tracks the usage of the tenants for chargeback: the bytes ingested and the
+// profiles received per tenant and service, as accounted by the distributors, and the bytes
+// stored per tenant, as accounted by the compactors.
+package usagetracker
+
+import (
+	"sort"
+	"sync"
+)
+
+var defaultTracker = NewTracker()
+
+// Usage is the usage of a tenant service. The stored bytes are accounted per tenant only,
+// as the blocks mix the profiles of all the services: they have their own entries, with
+// an empty service name and no ingestion.
+type Usage struct {
+	TenantID         string `json:"tenant_id"`
+	ServiceName      string `json:"service_name,omitempty"`
+	BytesIngested    int64  `json:"bytes_ingested"`
+	ProfilesReceived int64  `json:"profiles_received"`
+	BytesStored      int64  `json:"bytes_stored"`
+}
+
+type key struct {
+	tenantID    string
+	serviceName string
+}
+
+// Tracker aggregates the usage of the tenants since the process start.
+type Tracker struct {
+	mu       sync.Mutex
+	ingested map[key]*Usage
+	// stored is the bytes stored per tenant.
+	stored map[string]int64
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		ingested: make(map[key]*Usage),
+		stored:   make(map[string]int64),
+	}
+}
+
+// Ingested accounts the profiles and the uncompressed bytes ingested for the tenant service.
+func (t *Tracker) Ingested(tenantID, serviceName string, profiles, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := key{tenantID: tenantID, serviceName: serviceName}
+	u, ok := t.ingested[k]
+	if !ok {
+		u = &Usage{TenantID: tenantID, ServiceName: serviceName}
+		t.ingested[k] = u
+	}
+	u.ProfilesReceived += profiles
+	u.BytesIngested += bytes
+}
+
+// Stored sets the bytes stored in the tenant blocks. Setting zero stops tracking the
+// storage of the tenant, e.g. when it's deleted or owned by another compactor.
+func (t *Tracker) Stored(tenantID string, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if bytes == 0 {
+		delete(t.stored, tenantID)
+		return
+	}
+	t.stored[tenantID] = bytes
+}
+
+// Usage returns the usage of the tenant, or of all the tenants if the tenant ID is empty,
+// sorted by tenant and service, the storage of a tenant first.
+func (t *Tracker) Usage(tenantID string) []Usage {
+	ingested, stored := t.snapshot(tenantID)
+	result := make([]Usage, 0, len(stored)+len(ingested))
+	result = append(append(result, stored...), ingested...)
+	sortUsage(result)
+	return result
+}
+
+// snapshot returns the ingestion and the storage of the tenant, or of all the tenants if the
+// tenant ID is empty.
+func (t *Tracker) snapshot(tenantID string) (ingested, stored []Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, u := range t.ingested {
+		if tenantID == "" || k.tenantID == tenantID {
+			ingested = append(ingested, *u)
+		}
+	}
+	for id, bytes := range t.stored {
+		if tenantID == "" || id == tenantID {
+			stored = append(stored, Usage{TenantID: id, BytesStored: bytes})
+		}
+	}
+	return ingested, stored
+}
+
+func sortUsage(usage []Usage) {
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].TenantID != usage[j].TenantID {
+			return usage[i].TenantID < usage[j].TenantID
+		}
+		return usage[i].ServiceName < usage[j].ServiceName
+	})
+}
+
+// Ingested accounts the profiles and the bytes ingested for the tenant service in the default tracker.
+func Ingested(tenantID, serviceName string, profiles, bytes int64) {
+	defaultTracker.Ingested(tenantID, serviceName, profiles, bytes)
+}
+
+// Stored sets the bytes stored in the tenant blocks in the default tracker.
+func Stored(tenantID string, bytes int64) {
+	defaultTracker.Stored(tenantID, bytes)
+}
+
+// DefaultTracker returns the tracker the distributors and the compactors of the process account the usage to.
+func DefaultTracker() *Tracker {
+	return defaultTracker
+}
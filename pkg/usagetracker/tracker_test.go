@@ -0,0 +1,167 @@
+package usagetracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+func Test_Tracker(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Ingested("b", "api", 1, 100)
+	tracker.Ingested("a", "web", 2, 200)
+	tracker.Ingested("a", "api", 1, 50)
+	tracker.Ingested("a", "api", 3, 150)
+	tracker.Ingested("b", "", 1, 10)
+	tracker.Stored("a", 1000)
+	tracker.Stored("b", 2000)
+	tracker.Stored("b", 0)
+
+	// The storage doesn't share the entry of the ingestion without service name.
+	require.Equal(t, []Usage{
+		{TenantID: "a", BytesStored: 1000},
+		{TenantID: "a", ServiceName: "api", ProfilesReceived: 4, BytesIngested: 200},
+		{TenantID: "a", ServiceName: "web", ProfilesReceived: 2, BytesIngested: 200},
+		{TenantID: "b", ProfilesReceived: 1, BytesIngested: 10},
+		{TenantID: "b", ServiceName: "api", ProfilesReceived: 1, BytesIngested: 100},
+	}, tracker.Usage(""))
+	tracker.Stored("b", 3000)
+	require.Equal(t, []Usage{
+		{TenantID: "b", BytesStored: 3000},
+		{TenantID: "b", ProfilesReceived: 1, BytesIngested: 10},
+		{TenantID: "b", ServiceName: "api", ProfilesReceived: 1, BytesIngested: 100},
+	}, tracker.Usage("b"))
+}
+
+func Test_Reporter(t *testing.T) {
+	ctx := context.Background()
+	bucket := objstore.NewInMemBucket()
+	tracker := NewTracker()
+	reporter := NewReporter(Config{ReportInterval: time.Hour, ReportRetention: 48 * time.Hour, InstanceID: "distributor-1"}, bucket, tracker, log.NewNopLogger())
+
+	readReport := func(ts time.Time) Report {
+		t.Helper()
+		r, err := bucket.Get(ctx, fmt.Sprintf("%s/1970-01-01/distributor-1/%d.json", ReportsPrefix, ts.Unix()))
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		var report Report
+		require.NoError(t, json.Unmarshal(data, &report))
+		return report
+	}
+
+	tracker.Ingested("a", "api", 2, 200)
+	tracker.Stored("a", 1000)
+	first := time.Unix(3600, 0)
+	require.NoError(t, reporter.report(ctx, first))
+	require.Equal(t, []Usage{
+		{TenantID: "a", BytesStored: 1000},
+		{TenantID: "a", ServiceName: "api", ProfilesReceived: 2, BytesIngested: 200},
+	}, readReport(first).Usage)
+
+	// Only the ingestion of the period is reported.
+	tracker.Ingested("a", "api", 1, 50)
+	second := time.Unix(7200, 0)
+	require.NoError(t, reporter.report(ctx, second))
+	report := readReport(second)
+	require.Equal(t, first.UTC(), report.Start.UTC())
+	require.Equal(t, []Usage{
+		{TenantID: "a", BytesStored: 1000},
+		{TenantID: "a", ServiceName: "api", ProfilesReceived: 1, BytesIngested: 50},
+	}, report.Usage)
+
+	// The days of the reports of all the instances are deleted once past the retention.
+	require.NoError(t, bucket.Upload(ctx, reportName("distributor-2", time.Unix(86400+3600, 0)), bytes.NewReader([]byte("{}"))))
+	require.NoError(t, reporter.prune(ctx, time.Unix(3*86400, 0)))
+	require.Len(t, bucket.Objects(), 3)
+	require.NoError(t, reporter.prune(ctx, time.Unix(3*86400+1, 0)))
+	require.Len(t, bucket.Objects(), 1)
+	require.NoError(t, reporter.prune(ctx, time.Unix(4*86400+1, 0)))
+	require.Empty(t, bucket.Objects())
+}
+
+func Test_Handler(t *testing.T) {
+	ctx := context.Background()
+	bucket := objstore.NewInMemBucket()
+	for _, r := range []Report{
+		{InstanceID: "distributor-1", End: time.Unix(3600, 0), Usage: []Usage{
+			{TenantID: "a", ServiceName: "api", ProfilesReceived: 2, BytesIngested: 200},
+			{TenantID: "b", ServiceName: "api", ProfilesReceived: 1, BytesIngested: 100},
+		}},
+		{InstanceID: "distributor-2", End: time.Unix(3600, 0), Usage: []Usage{
+			{TenantID: "a", ServiceName: "api", ProfilesReceived: 1, BytesIngested: 50},
+			{TenantID: "a", ServiceName: "web", ProfilesReceived: 3, BytesIngested: 300},
+		}},
+		{InstanceID: "compactor-1", End: time.Unix(3600, 0), Usage: []Usage{{TenantID: "a", BytesStored: 1000}}},
+		{InstanceID: "compactor-1", End: time.Unix(7200, 0), Usage: []Usage{{TenantID: "a", BytesStored: 1500}}},
+		{InstanceID: "distributor-1", End: time.Unix(7200, 0), Usage: []Usage{
+			{TenantID: "a", ProfilesReceived: 1, BytesIngested: 10},
+		}},
+		// Out of the period.
+		{InstanceID: "distributor-1", End: time.Unix(10800, 0), Usage: []Usage{
+			{TenantID: "a", ServiceName: "api", ProfilesReceived: 5, BytesIngested: 500},
+		}},
+		{InstanceID: "distributor-1", End: time.Unix(86400+3600, 0), Usage: []Usage{
+			{TenantID: "a", ServiceName: "api", ProfilesReceived: 7, BytesIngested: 700},
+		}},
+	} {
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.NoError(t, bucket.Upload(ctx, reportName(r.InstanceID, r.End), bytes.NewReader(data)))
+	}
+
+	request := func(tenantID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/usage?"+query, nil)
+		if tenantID != "" {
+			req = req.WithContext(tenant.InjectTenantID(req.Context(), tenantID))
+		}
+		rec := httptest.NewRecorder()
+		Handler(bucket).ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The usage of the tenant of the request is aggregated over the instances.
+	rec := request("a", "start=0&end=7200")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp UsageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, []Usage{
+		{TenantID: "a", BytesStored: 1500},
+		{TenantID: "a", ProfilesReceived: 1, BytesIngested: 10},
+		{TenantID: "a", ServiceName: "api", ProfilesReceived: 3, BytesIngested: 250},
+		{TenantID: "a", ServiceName: "web", ProfilesReceived: 3, BytesIngested: 300},
+	}, resp.Usage)
+
+	// Only the reports of the days of the period are listed.
+	recorder := &iterRecorder{Bucket: bucket}
+	reports, err := ReadReports(ctx, recorder, time.Unix(86400, 0), time.Unix(2*86400, 0))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, int64(700), reports[0].Usage[0].BytesIngested)
+	require.Equal(t, []string{ReportsPrefix + "/", ReportsPrefix + "/1970-01-02/"}, recorder.dirs)
+
+	require.Equal(t, http.StatusBadRequest, request("", "start=0&end=7200").Code)
+	require.Equal(t, http.StatusBadRequest, request("a", "start=7200&end=3600").Code)
+}
+
+type iterRecorder struct {
+	objstore.Bucket
+	dirs []string
+}
+
+func (b *iterRecorder) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	b.dirs = append(b.dirs, dir)
+	return b.Bucket.Iter(ctx, dir, f, options...)
+}
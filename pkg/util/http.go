@@ -379,8 +379,38 @@ func WriteJSONResponse(w http.ResponseWriter, v interface{}) {
 // AuthenticateUser propagates the user ID from HTTP headers back to the request's context.
 // If on is false, it will inject the default tenant ID.
 func AuthenticateUser(on bool) middleware.Interface {
+	return AuthenticateUserWithAuthenticator(on, nil)
+}
+
+// AuthenticateUserWithAuthenticator is like AuthenticateUser, but if an authenticator is given,
+// the user ID is the tenant of the credentials of the request instead: GET and HEAD requests
+// require the read scope, the others the write scope.
+func AuthenticateUserWithAuthenticator(on bool, authenticator *tenant.Authenticator) middleware.Interface {
 	return middleware.Func(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authenticator != nil {
+				scope := tenant.ScopeWrite
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					scope = tenant.ScopeRead
+				}
+				tenantID, err := authenticator.Authenticate(r.Header, scope)
+				switch {
+				case errors.Is(err, tenant.ErrUnauthenticated):
+					httputil.ErrorWithStatus(w, err, http.StatusUnauthorized)
+					return
+				case err != nil:
+					httputil.ErrorWithStatus(w, err, http.StatusForbidden)
+					return
+				case tenantID != "":
+					next.ServeHTTP(w, r.WithContext(user.InjectOrgID(r.Context(), tenantID)))
+					return
+				}
+				// The internal token: the user ID is the one of the client, if any.
+				if _, ctx, err := user.ExtractOrgIDFromHTTPRequest(r); err == nil {
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
 			if !on {
 				next.ServeHTTP(w, r.WithContext(user.InjectOrgID(r.Context(), tenant.DefaultTenantID)))
 				return
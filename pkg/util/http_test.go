@@ -3,6 +3,8 @@ package util_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,3 +52,41 @@ func TestMultitenantMiddleware(t *testing.T) {
 	m.ServeHTTP(w, r)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+credentials:
+  - tenant: team-a
+    scopes: [write]
+    token: agent-token
+`), 0o600))
+	authenticator, err := tenant.NewAuthenticator(tenant.AuthenticatorConfig{CredentialsFile: path})
+	require.NoError(t, err)
+
+	m := util.AuthenticateUserWithAuthenticator(true, authenticator).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := tenant.ExtractTenantIDFromContext(r.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", id)
+	}))
+
+	for _, tc := range []struct {
+		method        string
+		authorization string
+		expected      int
+	}{
+		{method: http.MethodPost, authorization: "Bearer agent-token", expected: http.StatusOK},
+		{method: http.MethodGet, authorization: "Bearer agent-token", expected: http.StatusForbidden},
+		{method: http.MethodPost, authorization: "Bearer unknown", expected: http.StatusUnauthorized},
+		{method: http.MethodPost, expected: http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tc.method, "http://localhost:8080/ingest", nil)
+		r.Header.Set("X-Scope-OrgID", "team-b")
+		if tc.authorization != "" {
+			r.Header.Set("Authorization", tc.authorization)
+		}
+		m.ServeHTTP(w, r)
+		assert.Equal(t, tc.expected, w.Code, tc.method+" "+tc.authorization)
+	}
+}
@@ -0,0 +1,110 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/dustin/go-humanize"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+type queryLimiterCtxKey struct{}
+
+var ctxKey = &queryLimiterCtxKey{}
+
+// QueryLimiter limits the data a query may touch in an ingester or a store-gateway.
+// A zero limit disables the corresponding check.
+type QueryLimiter struct {
+	profiles  atomic.Int64
+	bytes     atomic.Int64
+	treeNodes atomic.Int64
+
+	maxProfiles  int64
+	maxBytes     int64
+	maxTreeNodes int64
+}
+
+// NewQueryLimiter makes a new per-query limiter.
+func NewQueryLimiter(maxProfiles, maxBytes, maxTreeNodes int) *QueryLimiter {
+	return &QueryLimiter{
+		maxProfiles:  int64(maxProfiles),
+		maxBytes:     int64(maxBytes),
+		maxTreeNodes: int64(maxTreeNodes),
+	}
+}
+
+// AddQueryLimiterToContext returns a context carrying the query limiter.
+func AddQueryLimiterToContext(ctx context.Context, limiter *QueryLimiter) context.Context {
+	return context.WithValue(ctx, ctxKey, limiter)
+}
+
+// QueryLimiterFromContextWithFallback returns the query limiter of the context,
+// or a limiter without any limit if there is none.
+func QueryLimiterFromContextWithFallback(ctx context.Context) *QueryLimiter {
+	ql, ok := ctx.Value(ctxKey).(*QueryLimiter)
+	if !ok {
+		return NewQueryLimiter(0, 0, 0)
+	}
+	return ql
+}
+
+// AddProfiles accounts profiles selected by the query and returns an error if
+// the limit of the profiles selected is exceeded.
+func (ql *QueryLimiter) AddProfiles(n int) error {
+	if ql.maxProfiles == 0 {
+		return nil
+	}
+	if ql.profiles.Add(int64(n)) > ql.maxProfiles {
+		return limitError(fmt.Sprintf("the query exceeded the maximum number of profiles selected (limit: %d profiles)", ql.maxProfiles), "max_query_profiles_selected")
+	}
+	return nil
+}
+
+// AddBytes accounts bytes fetched from the storage by the query and returns an
+// error if the limit of the bytes fetched is exceeded.
+func (ql *QueryLimiter) AddBytes(n int64) error {
+	if ql.maxBytes == 0 {
+		return nil
+	}
+	if ql.bytes.Add(n) > ql.maxBytes {
+		return limitError(fmt.Sprintf("the query exceeded the maximum number of bytes fetched (limit: %s)", humanize.IBytes(uint64(ql.maxBytes))), "max_query_bytes_fetched")
+	}
+	return nil
+}
+
+// AddTreeNodes accounts nodes added to the tree merged by the query and returns
+// an error if the limit of the nodes, before the tree truncation, is exceeded.
+func (ql *QueryLimiter) AddTreeNodes(n int64) error {
+	if ql.maxTreeNodes == 0 || ql.treeNodes.Add(n) <= ql.maxTreeNodes {
+		return nil
+	}
+	return limitError(fmt.Sprintf("the query exceeded the maximum number of flame graph nodes (limit: %d nodes)", ql.maxTreeNodes), "max_query_flamegraph_nodes")
+}
+
+func limitError(msg, limit string) error {
+	return connect.NewError(connect.CodeResourceExhausted, fmt.Errorf("%s, consider narrowing the query selector or time range, or adjusting the %s limit", msg, limit))
+}
+
+// QueryLimits are the per-tenant limits of the data a query may touch.
+type QueryLimits interface {
+	MaxQueryProfilesSelected(tenantID string) int
+	MaxQueryBytesFetched(tenantID string) int
+	MaxQueryFlameGraphNodes(tenantID string) int
+}
+
+// WithTenantQueryLimiter returns a context carrying a new query limiter, with the
+// limits of the tenant of the context.
+func WithTenantQueryLimiter(ctx context.Context, limits QueryLimits) context.Context {
+	tenantID, err := tenant.ExtractTenantIDFromContext(ctx)
+	if err != nil {
+		return ctx
+	}
+	return AddQueryLimiterToContext(ctx, NewQueryLimiter(
+		limits.MaxQueryProfilesSelected(tenantID),
+		limits.MaxQueryBytesFetched(tenantID),
+		limits.MaxQueryFlameGraphNodes(tenantID),
+	))
+}
@@ -0,0 +1,106 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/pyroscope/pkg/tenant"
+)
+
+func Test_QueryLimiter(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+
+		limiter *QueryLimiter
+		add     func(*QueryLimiter, int) error
+		// allowed is the number of units accounted before the limit is exceeded.
+		allowed int
+		msg     string
+	}{
+		{
+			name:    "profiles",
+			limiter: NewQueryLimiter(10, 0, 0),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddProfiles(n) },
+			allowed: 10,
+			msg:     "resource_exhausted: the query exceeded the maximum number of profiles selected (limit: 10 profiles), consider narrowing the query selector or time range, or adjusting the max_query_profiles_selected limit",
+		},
+		{
+			name:    "bytes",
+			limiter: NewQueryLimiter(0, 2048, 0),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddBytes(int64(n)) },
+			allowed: 2048,
+			msg:     "resource_exhausted: the query exceeded the maximum number of bytes fetched (limit: 2.0 KiB), consider narrowing the query selector or time range, or adjusting the max_query_bytes_fetched limit",
+		},
+		{
+			name:    "tree nodes",
+			limiter: NewQueryLimiter(0, 0, 100),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddTreeNodes(int64(n)) },
+			allowed: 100,
+			msg:     "resource_exhausted: the query exceeded the maximum number of flame graph nodes (limit: 100 nodes), consider narrowing the query selector or time range, or adjusting the max_query_flamegraph_nodes limit",
+		},
+		{
+			name:    "profiles disabled",
+			limiter: NewQueryLimiter(0, 1, 1),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddProfiles(n) },
+			allowed: -1,
+		},
+		{
+			name:    "bytes disabled",
+			limiter: NewQueryLimiter(1, 0, 1),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddBytes(int64(n)) },
+			allowed: -1,
+		},
+		{
+			name:    "tree nodes disabled",
+			limiter: NewQueryLimiter(1, 1, 0),
+			add:     func(ql *QueryLimiter, n int) error { return ql.AddTreeNodes(int64(n)) },
+			allowed: -1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.allowed < 0 {
+				// A zero limit disables the check.
+				for i := 0; i < 10; i++ {
+					require.NoError(t, tt.add(tt.limiter, 1<<20))
+				}
+				return
+			}
+			require.NoError(t, tt.add(tt.limiter, tt.allowed-1))
+			require.NoError(t, tt.add(tt.limiter, 1))
+			err := tt.add(tt.limiter, 1)
+			require.Error(t, err)
+			require.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+			require.Equal(t, tt.msg, err.Error())
+			// The limit stays exceeded.
+			require.Error(t, tt.add(tt.limiter, 0))
+		})
+	}
+}
+
+func Test_QueryLimiterFromContext(t *testing.T) {
+	// Without limiter in the context, there is no limit.
+	ql := QueryLimiterFromContextWithFallback(context.Background())
+	require.NoError(t, ql.AddProfiles(1<<20))
+	require.NoError(t, ql.AddBytes(1<<40))
+	require.NoError(t, ql.AddTreeNodes(1<<20))
+
+	// The limits are the ones of the tenant of the context.
+	ctx := WithTenantQueryLimiter(tenant.InjectTenantID(context.Background(), "tenant-a"), fakeLimits{"tenant-a": 5})
+	ql = QueryLimiterFromContextWithFallback(ctx)
+	require.NoError(t, ql.AddProfiles(5))
+	require.Error(t, ql.AddProfiles(1))
+	require.Same(t, ql, QueryLimiterFromContextWithFallback(ctx))
+
+	ctx = WithTenantQueryLimiter(tenant.InjectTenantID(context.Background(), "tenant-b"), fakeLimits{"tenant-a": 5})
+	require.NoError(t, QueryLimiterFromContextWithFallback(ctx).AddProfiles(1<<20))
+}
+
+// fakeLimits are the limits of the profiles selected by the queries, by tenant.
+type fakeLimits map[string]int
+
+func (l fakeLimits) MaxQueryProfilesSelected(tenantID string) int { return l[tenantID] }
+func (l fakeLimits) MaxQueryBytesFetched(string) int              { return 0 }
+func (l fakeLimits) MaxQueryFlameGraphNodes(string) int           { return 0 }
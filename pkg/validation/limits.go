@@ -55,6 +55,13 @@ type Limits struct {
 	MaxQueryLength      model.Duration `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryParallelism int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
 
+	// Ingester and store-gateway enforced query limits.
+	MaxQueryProfilesSelected int `yaml:"max_query_profiles_selected" json:"max_query_profiles_selected"`
+	MaxQueryBytesFetched     int `yaml:"max_query_bytes_fetched" json:"max_query_bytes_fetched"`
+	MaxQueryFlameGraphNodes  int `yaml:"max_query_flamegraph_nodes" json:"max_query_flamegraph_nodes"`
+
+	TenantFederationEnabled bool `yaml:"tenant_federation_enabled" json:"tenant_federation_enabled"`
+
 	// Store-gateway.
 	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
 
@@ -113,6 +120,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.QuerySplitDuration, "querier.split-queries-by-interval", "Split queries by a time interval and execute in parallel. The value 0 disables splitting by time")
 
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 0, "Maximum number of queries that will be scheduled in parallel by the frontend.")
+	f.IntVar(&l.MaxQueryProfilesSelected, "querier.max-query-profiles-selected", 0, "Maximum number of profiles a query may select, per ingester and store-gateway. 0 to disable.")
+	f.IntVar(&l.MaxQueryBytesFetched, "querier.max-query-bytes-fetched", 0, "Maximum number of bytes a query may fetch from the storage, per ingester and store-gateway. 0 to disable.")
+	f.IntVar(&l.MaxQueryFlameGraphNodes, "querier.max-query-flamegraph-nodes", 0, "Maximum number of nodes of the flame graph merged by a query before its truncation, per ingester and store-gateway. 0 to disable.")
+	f.BoolVar(&l.TenantFederationEnabled, "querier.tenant-federation-enabled", false, "Allow the queries spanning multiple tenants, listed in the X-Scope-OrgID header separated by '|'. A federated query is only allowed if all its tenants have it enabled.")
 
 	f.IntVar(&l.MaxProfileSizeBytes, "validation.max-profile-size-bytes", 4*1024*1024, "Maximum size of a profile in bytes. This is based off the uncompressed size. 0 to disable.")
 	f.IntVar(&l.MaxProfileStacktraceSamples, "validation.max-profile-stacktrace-samples", 16000, "Maximum number of samples in a profile. 0 to disable.")
@@ -156,6 +167,43 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Validate validates that this limits config is valid.
 func (l *Limits) Validate() error {
+	if l.IngestionRateMB < 0 {
+		return errors.New("ingestion_rate_mb must be positive or zero")
+	}
+	if l.IngestionBurstSizeMB < 0 {
+		return errors.New("ingestion_burst_size_mb must be positive or zero")
+	}
+	for name, v := range map[string]int{
+		"ingestion_tenant_shard_size":      l.IngestionTenantShardSize,
+		"max_local_series_per_tenant":      l.MaxLocalSeriesPerTenant,
+		"max_global_series_per_tenant":     l.MaxGlobalSeriesPerTenant,
+		"max_query_parallelism":            l.MaxQueryParallelism,
+		"max_query_profiles_selected":      l.MaxQueryProfilesSelected,
+		"max_query_bytes_fetched":          l.MaxQueryBytesFetched,
+		"max_query_flamegraph_nodes":       l.MaxQueryFlameGraphNodes,
+		"store_gateway_tenant_shard_size":  l.StoreGatewayTenantShardSize,
+		"compactor_split_and_merge_shards": l.CompactorSplitAndMergeShards,
+		"compactor_tenant_shard_size":      l.CompactorTenantShardSize,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s must be positive or zero", name)
+		}
+	}
+	if l.CompactorSplitGroups <= 0 {
+		return errors.New("compactor_split_groups must be positive")
+	}
+	for name, d := range map[string]model.Duration{
+		"max_query_lookback":                l.MaxQueryLookback,
+		"max_query_length":                  l.MaxQueryLength,
+		"split_queries_by_interval":         l.QuerySplitDuration,
+		"compactor_blocks_retention_period": l.CompactorBlocksRetentionPeriod,
+		"reject_older_than":                 l.RejectOlderThan,
+		"reject_newer_than":                 l.RejectNewerThan,
+	} {
+		if d < 0 {
+			return fmt.Errorf("%s must be positive or zero", name)
+		}
+	}
 	return nil
 }
 
@@ -286,6 +334,26 @@ func (o *Overrides) MaxQueryParallelism(tenantID string) int {
 	return o.getOverridesForTenant(tenantID).MaxQueryParallelism
 }
 
+// TenantFederationEnabled returns whether the tenant can be queried together with other tenants.
+func (o *Overrides) TenantFederationEnabled(tenantID string) bool {
+	return o.getOverridesForTenant(tenantID).TenantFederationEnabled
+}
+
+// MaxQueryProfilesSelected returns the limit to the number of profiles a query may select.
+func (o *Overrides) MaxQueryProfilesSelected(tenantID string) int {
+	return o.getOverridesForTenant(tenantID).MaxQueryProfilesSelected
+}
+
+// MaxQueryBytesFetched returns the limit to the number of bytes a query may fetch from the storage.
+func (o *Overrides) MaxQueryBytesFetched(tenantID string) int {
+	return o.getOverridesForTenant(tenantID).MaxQueryBytesFetched
+}
+
+// MaxQueryFlameGraphNodes returns the limit to the number of nodes of the flame graph merged by a query.
+func (o *Overrides) MaxQueryFlameGraphNodes(tenantID string) int {
+	return o.getOverridesForTenant(tenantID).MaxQueryFlameGraphNodes
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(tenantID string) time.Duration {
 	return time.Duration(o.getOverridesForTenant(tenantID).MaxQueryLookback)
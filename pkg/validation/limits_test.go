@@ -158,3 +158,19 @@ func TestOverwriteMarshalingStringMapYAML(t *testing.T) {
 	require.Nil(t, yaml.Unmarshal(out, &back))
 	require.Equal(t, m, back)
 }
+
+func TestLimitsValidate(t *testing.T) {
+	require.NoError(t, MockDefaultLimits().Validate())
+
+	l := MockDefaultLimits()
+	l.IngestionRateMB = -1
+	require.EqualError(t, l.Validate(), "ingestion_rate_mb must be positive or zero")
+
+	l = MockDefaultLimits()
+	l.MaxQueryBytesFetched = -1
+	require.EqualError(t, l.Validate(), "max_query_bytes_fetched must be positive or zero")
+
+	l = MockDefaultLimits()
+	l.CompactorSplitGroups = 0
+	require.EqualError(t, l.Validate(), "compactor_split_groups must be positive")
+}
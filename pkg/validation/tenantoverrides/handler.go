@@ -0,0 +1,195 @@
+package tenantoverrides
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/pyroscope/pkg/util"
+	httputil "github.com/grafana/pyroscope/pkg/util/http"
+	"github.com/grafana/pyroscope/pkg/validation"
+)
+
+// TenantsResponse lists the tenants with overrides set with the admin API.
+type TenantsResponse struct {
+	Tenants []string `json:"tenants"`
+}
+
+// OverridesResponse is the overrides of a tenant.
+type OverridesResponse struct {
+	TenantID string `json:"tenant_id"`
+	Overrides
+}
+
+// AuditLogResponse is the audit log of the overrides of a tenant.
+type AuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// TenantsHandler lists the tenants with overrides. It requires the admin credentials.
+func (s *Store) TenantsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := s.authenticate(r); err != nil {
+			writeError(w, err)
+			return
+		}
+		util.WriteJSONResponse(w, TenantsResponse{Tenants: s.Tenants()})
+	})
+}
+
+// Handler gets, sets and deletes the overrides of the tenant of the path. It requires the
+// admin credentials.
+//
+// The generation of the overrides is returned in the ETag header. A change requires the
+// If-Match header, and is only applied if it matches the generation of the overrides in the
+// object storage: 0 if the tenant has no overrides. The limits are set in YAML or JSON, the
+// missing ones take the default values.
+func (s *Store) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := mux.Vars(r)["tenant"]
+		switch r.Method {
+		case http.MethodGet:
+			if _, err := s.authenticate(r); err != nil {
+				writeError(w, err)
+				return
+			}
+			o, err := s.Get(r.Context(), tenantID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeOverrides(w, tenantID, o)
+
+		case http.MethodPut:
+			author, err := s.authenticate(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			generation, err := ifMatch(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			limits, err := decodeLimits(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			o, err := s.Set(r.Context(), tenantID, limits, generation, author)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeOverrides(w, tenantID, o)
+
+		case http.MethodDelete:
+			author, err := s.authenticate(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			generation, err := ifMatch(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if err = s.Delete(r.Context(), tenantID, generation, author); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// AuditLogHandler returns the audit log of the overrides of the tenant of the path. It requires
+// the admin credentials.
+func (s *Store) AuditLogHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := s.authenticate(r); err != nil {
+			writeError(w, err)
+			return
+		}
+		entries, err := s.AuditLog(r.Context(), mux.Vars(r)["tenant"])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		util.WriteJSONResponse(w, AuditLogResponse{Entries: entries})
+	})
+}
+
+func writeOverrides(w http.ResponseWriter, tenantID string, o *Overrides) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(o.Generation, 10)))
+	util.WriteJSONResponse(w, OverridesResponse{TenantID: tenantID, Overrides: *o})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		httputil.ErrorWithStatus(w, err, http.StatusBadRequest)
+	case errors.Is(err, ErrNotFound):
+		httputil.ErrorWithStatus(w, err, http.StatusNotFound)
+	case errors.Is(err, ErrConflict):
+		httputil.ErrorWithStatus(w, err, http.StatusPreconditionFailed)
+	case errors.Is(err, ErrPreconditionRequired):
+		httputil.ErrorWithStatus(w, err, http.StatusPreconditionRequired)
+	case errors.Is(err, ErrUnauthenticated):
+		w.Header().Set("WWW-Authenticate", `Basic realm="tenant overrides"`)
+		httputil.ErrorWithStatus(w, err, http.StatusUnauthorized)
+	case errors.Is(err, ErrAdminDisabled):
+		httputil.ErrorWithStatus(w, err, http.StatusForbidden)
+	default:
+		httputil.Error(w, err)
+	}
+}
+
+// ifMatch returns the generation of the If-Match header.
+func ifMatch(r *http.Request) (int64, error) {
+	v := r.Header.Get("If-Match")
+	if v == "" {
+		return 0, ErrPreconditionRequired
+	}
+	generation, err := strconv.ParseInt(strings.Trim(v, `"`), 10, 64)
+	if err != nil || generation < 0 {
+		return 0, fmt.Errorf("%w: If-Match header %q is not a generation", ErrInvalid, v)
+	}
+	return generation, nil
+}
+
+func decodeLimits(r *http.Request) (*validation.Limits, error) {
+	var limits validation.Limits
+	decoder := yaml.NewDecoder(r.Body)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&limits); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	return &limits, nil
+}
+
+// authenticate returns the author of the request, if it has the admin credentials.
+func (s *Store) authenticate(r *http.Request) (Author, error) {
+	password := s.cfg.AdminPassword.String()
+	if password == "" {
+		return Author{}, ErrAdminDisabled
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok || !equal(user, s.cfg.AdminUsername) || !equal(pass, password) {
+		return Author{}, ErrUnauthenticated
+	}
+	return Author{User: user, RemoteAddr: r.RemoteAddr}, nil
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,403 @@
+// Package tenantoverrides implements the tenant overrides set at runtime with the admin API.
+// The overrides are persisted to the object storage, and every component periodically reloads
+// them, so that a change propagates to the whole cluster without restarts.
+package tenantoverrides
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/thanos-io/objstore"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/pyroscope/pkg/phlaredb/bucket"
+	"github.com/grafana/pyroscope/pkg/util"
+	"github.com/grafana/pyroscope/pkg/validation"
+)
+
+var (
+	// OverridesPrefix is the bucket prefix under which the overrides of each tenant are written.
+	OverridesPrefix = path.Join(bucket.PyroscopeInternalsPrefix, "tenant-overrides")
+	// AuditPrefix is the bucket prefix under which the audit log of the changes is written.
+	AuditPrefix = path.Join(bucket.PyroscopeInternalsPrefix, "tenant-overrides-audit")
+
+	ErrNotFound             = errors.New("tenant has no overrides")
+	ErrConflict             = errors.New("tenant overrides have been changed concurrently")
+	ErrPreconditionRequired = errors.New("the If-Match header with the generation of the tenant overrides is required")
+	ErrInvalid              = errors.New("invalid tenant overrides")
+	ErrUnauthenticated      = errors.New("missing or invalid admin credentials")
+	ErrAdminDisabled        = errors.New("the admin API is disabled on this replica: no admin password is set")
+)
+
+type Config struct {
+	PollInterval  time.Duration  `yaml:"poll_interval" category:"experimental"`
+	AdminUsername string         `yaml:"admin_username" category:"experimental"`
+	AdminPassword flagext.Secret `yaml:"admin_password" category:"experimental"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.PollInterval, "tenant-overrides.poll-interval", 10*time.Second, "How often the tenant overrides set with the admin API are reloaded from the object storage.")
+	f.StringVar(&cfg.AdminUsername, "tenant-overrides.admin-username", "admin", "Basic auth username of the admin API reading and changing the tenant overrides and reading their audit log.")
+	f.Var(&cfg.AdminPassword, "tenant-overrides.admin-password", "Basic auth password of the admin API reading and changing the tenant overrides and reading their audit log. The object storage has no conditional writes: set it on a single replica, the only writer of the overrides. The admin API is disabled if empty.")
+}
+
+// Overrides are the limits of a tenant set with the admin API. The generation is incremented
+// by every change, and is used to detect concurrent changes.
+type Overrides struct {
+	Generation int64              `yaml:"generation" json:"generation"`
+	Limits     *validation.Limits `yaml:"limits" json:"limits"`
+}
+
+// AuditEntry records a change of the overrides of a tenant.
+type AuditEntry struct {
+	Time       time.Time          `json:"time"`
+	User       string             `json:"user"`
+	RemoteAddr string             `json:"remote_addr,omitempty"`
+	TenantID   string             `json:"tenant_id"`
+	Action     string             `json:"action"`
+	Generation int64              `json:"generation"`
+	Changes    []Change           `json:"changes"`
+	Before     *validation.Limits `json:"before,omitempty"`
+	After      *validation.Limits `json:"after,omitempty"`
+}
+
+// Change is a limit changed by an audited action.
+type Change struct {
+	Limit  string      `json:"limit"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Author is the authenticated admin user changing the overrides.
+type Author struct {
+	User       string
+	RemoteAddr string
+}
+
+// Store holds the tenant overrides set with the admin API. It implements validation.TenantLimits:
+// the overrides of a tenant take precedence over the ones of the runtime config.
+//
+// Changes are only accepted by the replica with the admin password, the single writer of the
+// overrides, as the object storage has no conditional writes. They are serialized, and check
+// the generation of the overrides against the one of the bucket.
+type Store struct {
+	services.Service
+
+	cfg            Config
+	bucket         objstore.Bucket
+	runtimeLimits  validation.TenantLimits
+	defaultsLimits validation.Limits
+	logger         log.Logger
+
+	writeMu sync.Mutex
+
+	mu        sync.RWMutex
+	overrides map[string]*Overrides
+}
+
+// NewStore makes a new Store. runtimeLimits are the overrides of the runtime config, and may be nil.
+func NewStore(cfg Config, bucket objstore.Bucket, defaults validation.Limits, runtimeLimits validation.TenantLimits, logger log.Logger) *Store {
+	s := &Store{
+		cfg:            cfg,
+		bucket:         bucket,
+		runtimeLimits:  runtimeLimits,
+		defaultsLimits: defaults,
+		logger:         logger,
+		overrides:      make(map[string]*Overrides),
+	}
+	s.Service = services.NewTimerService(cfg.PollInterval, s.reload, s.iteration, nil)
+	return s
+}
+
+func (s *Store) iteration(ctx context.Context) error {
+	if err := s.reload(ctx); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to reload tenant overrides", "err", err)
+	}
+	return nil
+}
+
+// reload reads the overrides of all the tenants from the bucket.
+func (s *Store) reload(ctx context.Context) error {
+	overrides := make(map[string]*Overrides)
+	err := s.bucket.Iter(ctx, OverridesPrefix+"/", func(name string) error {
+		tenantID, ok := tenantFromObjectName(name)
+		if !ok {
+			return nil
+		}
+		o, err := s.read(ctx, tenantID)
+		if errors.Is(err, ErrNotFound) {
+			// Deleted since listed.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		overrides[tenantID] = o
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+func objectName(tenantID string) string {
+	return path.Join(OverridesPrefix, tenantID+".yaml")
+}
+
+func tenantFromObjectName(name string) (string, bool) {
+	name = strings.TrimPrefix(name, OverridesPrefix+"/")
+	if !strings.HasSuffix(name, ".yaml") || strings.Contains(name, "/") {
+		return "", false
+	}
+	return strings.TrimSuffix(name, ".yaml"), true
+}
+
+func (s *Store) read(ctx context.Context, tenantID string) (*Overrides, error) {
+	r, err := s.bucket.Get(ctx, objectName(tenantID))
+	if s.bucket.IsObjNotFoundErr(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var o Overrides
+	if err = yaml.NewDecoder(r).Decode(&o); err != nil {
+		return nil, fmt.Errorf("decoding overrides of tenant %s: %w", tenantID, err)
+	}
+	if o.Limits == nil {
+		return nil, fmt.Errorf("decoding overrides of tenant %s: no limits", tenantID)
+	}
+	return &o, nil
+}
+
+// TenantLimits implements validation.TenantLimits.
+func (s *Store) TenantLimits(tenantID string) *validation.Limits {
+	s.mu.RLock()
+	o, ok := s.overrides[tenantID]
+	s.mu.RUnlock()
+	if ok {
+		return o.Limits
+	}
+	if s.runtimeLimits == nil {
+		return nil
+	}
+	return s.runtimeLimits.TenantLimits(tenantID)
+}
+
+// AllByTenantID implements validation.TenantLimits.
+func (s *Store) AllByTenantID() map[string]*validation.Limits {
+	all := make(map[string]*validation.Limits)
+	if s.runtimeLimits != nil {
+		for tenantID, l := range s.runtimeLimits.AllByTenantID() {
+			all[tenantID] = l
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for tenantID, o := range s.overrides {
+		all[tenantID] = o.Limits
+	}
+	return all
+}
+
+// Tenants returns the tenants with overrides set with the admin API.
+func (s *Store) Tenants() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tenants := make([]string, 0, len(s.overrides))
+	for tenantID := range s.overrides {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Get returns the current overrides of the tenant from the bucket.
+func (s *Store) Get(ctx context.Context, tenantID string) (*Overrides, error) {
+	if err := validTenantID(tenantID); err != nil {
+		return nil, err
+	}
+	return s.read(ctx, tenantID)
+}
+
+// Set validates and writes the overrides of the tenant. The generation must match the
+// current generation of the overrides in the bucket, 0 if the tenant has none.
+func (s *Store) Set(ctx context.Context, tenantID string, limits *validation.Limits, generation int64, author Author) (*Overrides, error) {
+	if err := limits.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	return s.update(ctx, tenantID, "set", limits, generation, author)
+}
+
+// Delete deletes the overrides of the tenant. The generation must match the current
+// generation of the overrides in the bucket.
+func (s *Store) Delete(ctx context.Context, tenantID string, generation int64, author Author) error {
+	_, err := s.update(ctx, tenantID, "delete", nil, generation, author)
+	return err
+}
+
+func (s *Store) update(ctx context.Context, tenantID, action string, limits *validation.Limits, generation int64, author Author) (*Overrides, error) {
+	if err := validTenantID(tenantID); err != nil {
+		return nil, err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	current, err := s.read(ctx, tenantID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		if limits == nil {
+			return nil, err
+		}
+		current = &Overrides{}
+	case err != nil:
+		return nil, err
+	}
+	if generation != current.Generation {
+		return nil, ErrConflict
+	}
+
+	var next *Overrides
+	if limits != nil {
+		next = &Overrides{Generation: current.Generation + 1, Limits: limits}
+		data, err := yaml.Marshal(next)
+		if err != nil {
+			return nil, err
+		}
+		if err = s.bucket.Upload(ctx, objectName(tenantID), bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	} else if err = s.bucket.Delete(ctx, objectName(tenantID)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if next != nil {
+		s.overrides[tenantID] = next
+	} else {
+		delete(s.overrides, tenantID)
+	}
+	s.mu.Unlock()
+
+	entry := AuditEntry{
+		Time:       time.Now().UTC(),
+		User:       author.User,
+		RemoteAddr: author.RemoteAddr,
+		TenantID:   tenantID,
+		Action:     action,
+		Generation: current.Generation,
+		Before:     current.Limits,
+	}
+	if next != nil {
+		entry.Generation = next.Generation
+		entry.After = next.Limits
+	}
+	if entry.Changes, err = s.changes(entry.Before, entry.After); err != nil {
+		return nil, err
+	}
+	level.Info(s.logger).Log("msg", "tenant overrides changed", "tenant", tenantID, "action", action, "user", author.User, "generation", entry.Generation, "changes", len(entry.Changes))
+	if err = s.writeAuditEntry(ctx, entry); err != nil {
+		// The change is applied: it is not worth failing the request.
+		level.Error(s.logger).Log("msg", "failed to write tenant overrides audit entry", "tenant", tenantID, "err", err)
+	}
+	return next, nil
+}
+
+// changes returns the limits that differ, a missing side being the default limits.
+func (s *Store) changes(before, after *validation.Limits) ([]Change, error) {
+	if before == nil {
+		before = &s.defaultsLimits
+	}
+	if after == nil {
+		after = &s.defaultsLimits
+	}
+	b, err := util.YAMLMarshalUnmarshal(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := util.YAMLMarshalUnmarshal(after)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for k, v := range a {
+		if !reflect.DeepEqual(b[k], v) {
+			changes = append(changes, Change{Limit: fmt.Sprint(k), Before: b[k], After: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Limit < changes[j].Limit })
+	return changes, nil
+}
+
+func (s *Store) writeAuditEntry(ctx context.Context, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	name := path.Join(AuditPrefix, entry.TenantID, strconv.FormatInt(entry.Time.UnixNano(), 10)+".json")
+	return s.bucket.Upload(ctx, name, bytes.NewReader(data))
+}
+
+// AuditLog returns the audited changes of the overrides of the tenant, oldest first.
+func (s *Store) AuditLog(ctx context.Context, tenantID string) ([]AuditEntry, error) {
+	if err := validTenantID(tenantID); err != nil {
+		return nil, err
+	}
+	entries := []AuditEntry{}
+	err := s.bucket.Iter(ctx, path.Join(AuditPrefix, tenantID)+"/", func(name string) error {
+		r, err := s.bucket.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		var entry AuditEntry
+		if err = json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("decoding audit entry %s: %w", name, err)
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+func validTenantID(tenantID string) error {
+	switch tenantID {
+	case "", ".", "..":
+		return fmt.Errorf("%w: tenant ID %q", ErrInvalid, tenantID)
+	}
+	if err := tenant.ValidTenantID(tenantID); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package tenantoverrides
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/pyroscope/pkg/validation"
+)
+
+func newTestStore(t *testing.T, bucket objstore.Bucket, next validation.TenantLimits) (*Store, *validation.Overrides) {
+	t.Helper()
+	defaults := validation.MockDefaultLimits()
+	validation.SetDefaultLimitsForYAMLUnmarshalling(*defaults)
+	cfg := Config{PollInterval: time.Hour, AdminUsername: "alice"}
+	require.NoError(t, cfg.AdminPassword.Set("secret"))
+	s := NewStore(cfg, bucket, *defaults, next, log.NewNopLogger())
+	overrides, err := validation.NewOverrides(*defaults, s)
+	require.NoError(t, err)
+	return s, overrides
+}
+
+func Test_Store(t *testing.T) {
+	ctx := context.Background()
+	bucket := objstore.NewInMemBucket()
+	runtime := validation.NewMockTenantLimits(map[string]*validation.Limits{"b": {IngestionRateMB: 2}})
+	s, overrides := newTestStore(t, bucket, runtime)
+	require.Equal(t, float64(2), overrides.IngestionRateBytes("b")/(1<<20))
+
+	limits := *validation.MockDefaultLimits()
+	limits.IngestionRateMB = 10
+	o, err := s.Set(ctx, "a", &limits, 0, Author{User: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), o.Generation)
+	require.Equal(t, float64(10), overrides.IngestionRateBytes("a")/(1<<20))
+
+	// The generation must match the current one.
+	_, err = s.Set(ctx, "a", &limits, 0, Author{User: "bob"})
+	require.ErrorIs(t, err, ErrConflict)
+	limits.IngestionRateMB = 20
+	o, err = s.Set(ctx, "a", &limits, 1, Author{User: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), o.Generation)
+
+	// Invalid limits are rejected.
+	invalid := *validation.MockDefaultLimits()
+	invalid.MaxQueryParallelism = -1
+	_, err = s.Set(ctx, "a", &invalid, 2, Author{})
+	require.ErrorIs(t, err, ErrInvalid)
+	_, err = s.Set(ctx, "../a", &limits, 0, Author{})
+	require.ErrorIs(t, err, ErrInvalid)
+	// A negative generation never matches.
+	_, err = s.Set(ctx, "a", &limits, -1, Author{})
+	require.ErrorIs(t, err, ErrConflict)
+
+	// The admin API overrides take precedence over the runtime config.
+	o, err = s.Set(ctx, "b", &limits, 0, Author{User: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, float64(20), overrides.IngestionRateBytes("b")/(1<<20))
+	require.NoError(t, s.Delete(ctx, "b", o.Generation, Author{User: "bob"}))
+	require.Equal(t, float64(2), overrides.IngestionRateBytes("b")/(1<<20))
+	require.ErrorIs(t, s.Delete(ctx, "b", 0, Author{}), ErrNotFound)
+
+	// The changes propagate to the other stores on reload.
+	other, otherOverrides := newTestStore(t, bucket, nil)
+	require.NoError(t, other.reload(ctx))
+	require.Equal(t, []string{"a"}, other.Tenants())
+	require.Equal(t, float64(20), otherOverrides.IngestionRateBytes("a")/(1<<20))
+
+	entries, err := s.AuditLog(ctx, "a")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "alice", entries[0].User)
+	require.Equal(t, []Change{{Limit: "ingestion_rate_mb", Before: float64(4), After: float64(10)}}, entries[0].Changes)
+	require.Equal(t, "bob", entries[1].User)
+	require.Equal(t, []Change{{Limit: "ingestion_rate_mb", Before: float64(10), After: float64(20)}}, entries[1].Changes)
+
+	entries, err = s.AuditLog(ctx, "b")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "delete", entries[1].Action)
+	require.Nil(t, entries[1].After)
+}
+
+func Test_Handler(t *testing.T) {
+	s, _ := newTestStore(t, objstore.NewInMemBucket(), nil)
+	router := mux.NewRouter()
+	router.Path("/api/v1/tenant_overrides").Handler(s.TenantsHandler())
+	router.Path("/api/v1/tenant_overrides/{tenant}").Handler(s.Handler())
+	router.Path("/api/v1/tenant_overrides/{tenant}/audit").Handler(s.AuditLogHandler())
+
+	doAs := func(user, password, method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		return doAs("alice", "secret", method, path, ifMatch, body)
+	}
+
+	// The overrides and the audit log require the admin credentials.
+	require.Equal(t, http.StatusUnauthorized, doAs("", "", "PUT", "/api/v1/tenant_overrides/a", `"0"`, "ingestion_rate_mb: 10").Code)
+	require.Equal(t, http.StatusUnauthorized, doAs("alice", "wrong", "PUT", "/api/v1/tenant_overrides/a", `"0"`, "ingestion_rate_mb: 10").Code)
+	require.Equal(t, http.StatusUnauthorized, doAs("bob", "secret", "DELETE", "/api/v1/tenant_overrides/a", `"0"`, "").Code)
+	require.Equal(t, http.StatusUnauthorized, doAs("", "", "GET", "/api/v1/tenant_overrides/a", "", "").Code)
+	require.Equal(t, http.StatusUnauthorized, doAs("", "", "GET", "/api/v1/tenant_overrides", "", "").Code)
+	require.Equal(t, http.StatusUnauthorized, doAs("", "", "GET", "/api/v1/tenant_overrides/a/audit", "", "").Code)
+
+	require.Equal(t, http.StatusNotFound, do("GET", "/api/v1/tenant_overrides/a", "", "").Code)
+	require.Equal(t, http.StatusBadRequest, do("PUT", "/api/v1/tenant_overrides/a", `"0"`, "unknown_limit: 1").Code)
+	require.Equal(t, http.StatusBadRequest, do("PUT", "/api/v1/tenant_overrides/a", `"0"`, `{"ingestion_rate_mb": -1}`).Code)
+	require.Equal(t, http.StatusBadRequest, do("PUT", "/api/v1/tenant_overrides/a", `"-1"`, "ingestion_rate_mb: 10").Code)
+	// The changes require the generation they apply to.
+	require.Equal(t, http.StatusPreconditionRequired, do("PUT", "/api/v1/tenant_overrides/a", "", "ingestion_rate_mb: 10").Code)
+
+	rec := do("PUT", "/api/v1/tenant_overrides/a", `"0"`, `{"ingestion_rate_mb": 10}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	require.Equal(t, http.StatusPreconditionFailed, do("PUT", "/api/v1/tenant_overrides/a", `"0"`, "ingestion_rate_mb: 20").Code)
+
+	rec = do("GET", "/api/v1/tenant_overrides/a", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp OverridesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "a", resp.TenantID)
+	require.Equal(t, int64(1), resp.Generation)
+	require.Equal(t, float64(10), resp.Limits.IngestionRateMB)
+	// The limits not set take the default values.
+	require.Equal(t, validation.MockDefaultLimits().MaxLabelNamesPerSeries, resp.Limits.MaxLabelNamesPerSeries)
+
+	rec = do("GET", "/api/v1/tenant_overrides", "", "")
+	require.JSONEq(t, `{"tenants":["a"]}`, rec.Body.String())
+
+	rec = do("GET", "/api/v1/tenant_overrides/a/audit", "", "")
+	var audit AuditLogResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &audit))
+	require.Len(t, audit.Entries, 1)
+	require.Equal(t, "alice", audit.Entries[0].User)
+
+	require.Equal(t, http.StatusPreconditionRequired, do("DELETE", "/api/v1/tenant_overrides/a", "", "").Code)
+	require.Equal(t, http.StatusPreconditionFailed, do("DELETE", "/api/v1/tenant_overrides/a", `"0"`, "").Code)
+	require.Equal(t, http.StatusNoContent, do("DELETE", "/api/v1/tenant_overrides/a", `"1"`, "").Code)
+	require.Equal(t, http.StatusNotFound, do("GET", "/api/v1/tenant_overrides/a", "", "").Code)
+
+	// Without admin password, the replica does not serve the admin API.
+	readOnly := NewStore(Config{PollInterval: time.Hour, AdminUsername: "alice"}, objstore.NewInMemBucket(), *validation.MockDefaultLimits(), nil, log.NewNopLogger())
+	router = mux.NewRouter()
+	router.Path("/api/v1/tenant_overrides/{tenant}").Handler(readOnly.Handler())
+	require.Equal(t, http.StatusForbidden, do("PUT", "/api/v1/tenant_overrides/a", `"0"`, "ingestion_rate_mb: 10").Code)
+	require.Equal(t, http.StatusForbidden, do("GET", "/api/v1/tenant_overrides/a", "", "").Code)
+}